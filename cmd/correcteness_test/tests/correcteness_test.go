@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"matching-engine/internal/adapter/replay"
 	"matching-engine/internal/adapter/routing"
 	"matching-engine/internal/adapter/valhalla"
 	"matching-engine/internal/app/config"
@@ -24,13 +25,24 @@ func setupTestingEnvironment() (routing.Engine, error) {
 		return nil, err
 	}
 	// Create a mock routing engine
-	engine, err := valhalla.NewValhalla()
+	engine, err := newRoutingEngine()
 	if err != nil {
 		return nil, err
 	}
 	return engine, nil
 }
 
+// routingFixturesPath holds the Valhalla calls of the suite, record them against a live Valhalla
+// with ROUTING_FIXTURES_MODE=record
+const routingFixturesPath = "testdata/routing_fixtures.jsonl"
+
+// newRoutingEngine replays the recorded Valhalla calls when the fixtures exist, otherwise it needs a live Valhalla
+func newRoutingEngine() (routing.Engine, error) {
+	return replay.WrapWithFixtures(routingFixturesPath, func() (routing.Engine, error) {
+		return valhalla.NewValhalla()
+	})
+}
+
 func TestCorrecteness(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -189,7 +201,7 @@ func runMatcher(offers []*model.Offer, requests []*model.Request) ([]*model.Matc
 	di.RegisterPathServices(c)
 	di.RegisterCheckers(c)
 	di.RegisterMatchingServices(c)
-	utils.Must(c.Provide(valhalla.NewValhalla))
+	utils.Must(c.Provide(newRoutingEngine))
 
 	var matches []*model.MatchingResult
 	var matchErr error
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/adapter/roadgraph"
+	"matching-engine/internal/app/config"
+	"os"
+	"time"
+)
+
+// Preprocesses an OSM PBF extract into a road graph file that the roadgraph routing engine loads at startup:
+//
+//	go run ./cmd/roadgraph -in city-latest.osm.pbf -out road_graph.bin
+func main() {
+	in := flag.String("in", "", "OSM PBF extract to preprocess")
+	out := flag.String("out", "road_graph.bin", "preprocessed road graph file")
+	flag.Parse()
+
+	config.ConfigureLogging()
+	if *in == "" {
+		log.Fatal().Msg("The -in OSM PBF extract is required")
+	}
+
+	input, err := os.Open(*in)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open the OSM extract")
+	}
+	defer input.Close()
+
+	output, err := os.Create(*out)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create the road graph file")
+	}
+	defer output.Close()
+
+	start := time.Now()
+	writer := bufio.NewWriter(output)
+	if err := roadgraph.Preprocess(bufio.NewReaderSize(input, 1<<20), writer); err != nil {
+		log.Fatal().Err(err).Msg("Failed to preprocess the OSM extract")
+	}
+	if err := writer.Flush(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to write the road graph file")
+	}
+	log.Info().Str("out", *out).Dur("duration", time.Since(start)).Msg("Road graph preprocessed")
+}
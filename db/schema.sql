@@ -2,6 +2,7 @@
 -- The schema below assumes user UUIDs will be provided from that external source
 CREATE TYPE point_type AS ENUM ('pickup', 'dropoff');
 CREATE TYPE gender_type AS ENUM ('male', 'female');
+CREATE TYPE block_reason_type AS ENUM ('block', 'report');
 
 
 -- Rider requests table
@@ -24,11 +25,24 @@ CREATE TABLE rider_requests (
     latest_arrival_time TIMESTAMP WITH TIME ZONE NOT NULL,
     max_walking_duration_minutes INTEGER DEFAULT 5,
     number_of_riders INTEGER NOT NULL DEFAULT 1 CHECK (number_of_riders > 0),
+    luggage_count INTEGER NOT NULL DEFAULT 0 CHECK (luggage_count >= 0),
+    wheelchair_count INTEGER NOT NULL DEFAULT 0 CHECK (wheelchair_count >= 0),
+    child_seat_count INTEGER NOT NULL DEFAULT 0 CHECK (child_seat_count >= 0),
 
     -- Boolean preferences
     same_gender BOOLEAN NOT NULL DEFAULT FALSE,
     user_gender gender_type NOT NULL,
 
+    -- Other leg of a round trip, both legs reference each other
+    linked_request_id VARCHAR(50) REFERENCES rider_requests(id) ON DELETE SET NULL,
+
+    -- Recurrence, a NULL recurrence_weekdays means a one-off trip.
+    -- Bit i of recurrence_weekdays is set when the trip repeats on day i of the week, Sunday being day 0
+    recurrence_weekdays SMALLINT CHECK (recurrence_weekdays BETWEEN 0 AND 127),
+    recurrence_start_date DATE,
+    recurrence_end_date DATE,
+    recurrence_exceptions TEXT, -- comma separated dates skipped by the recurrence
+
     is_matched BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
@@ -44,6 +58,11 @@ CREATE TABLE driver_offers (
     source_latitude DECIMAL(10, 8) NOT NULL,
     source_longitude DECIMAL(11, 8) NOT NULL,
     source_address TEXT,
+    -- the driver accepts to start the trip anywhere within this driving time from the source, such as a park-and-ride
+    flexible_source_minutes INTEGER NOT NULL DEFAULT 0 CHECK (flexible_source_minutes >= 0),
+    -- start chosen by the matching for a flexible source, NULL while the trip starts at the source
+    start_latitude DECIMAL(10, 8),
+    start_longitude DECIMAL(11, 8),
 
     --destination
     destination_latitude DECIMAL(10, 8) NOT NULL,
@@ -56,17 +75,37 @@ CREATE TABLE driver_offers (
 
     detour_duration_minutes INTEGER DEFAULT 0,
     capacity INTEGER NOT NULL CHECK (capacity > 0),
+    luggage_capacity INTEGER NOT NULL DEFAULT 0 CHECK (luggage_capacity >= 0),
+    wheelchair_capacity INTEGER NOT NULL DEFAULT 0 CHECK (wheelchair_capacity >= 0),
+    child_seat_capacity INTEGER NOT NULL DEFAULT 0 CHECK (child_seat_capacity >= 0),
 
     current_number_of_requests INTEGER NOT NULL DEFAULT 0,
 
+    -- encoded driving route through the path points, reused while the path matches route_path_signature.
+    -- The engine only reads them: the consumer of the matching results writes back their routePolyline and
+    -- routePathSignature with the new path, and clears both when the path changes in any other way
+    route_polyline TEXT,
+    route_path_signature VARCHAR(16),
+
     -- Boolean preferences
     same_gender BOOLEAN NOT NULL DEFAULT FALSE,
     user_gender gender_type NOT NULL,
 
+    -- Recurrence, a NULL recurrence_weekdays means a one-off trip.
+    -- Bit i of recurrence_weekdays is set when the trip repeats on day i of the week, Sunday being day 0
+    recurrence_weekdays SMALLINT CHECK (recurrence_weekdays BETWEEN 0 AND 127),
+    recurrence_start_date DATE,
+    recurrence_end_date DATE,
+    recurrence_exceptions TEXT, -- comma separated dates skipped by the recurrence
+
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Pickup and dropoff points of the riders matched with an offer.
+-- A recurring offer is a template shared by all its occurrences, each matched occurrence keeps its own points
+-- tagged with occurrence_date, and a recurring rider occurrence is tagged with rider_occurrence_date.
+-- Points of a recurring offer without an occurrence_date are ignored
 CREATE TABLE path_point (
     id VARCHAR(50) PRIMARY KEY,
     driver_offer_id VARCHAR(50) NOT NULL REFERENCES driver_offers(id) ON DELETE CASCADE,
@@ -76,35 +115,86 @@ CREATE TABLE path_point (
     latitude DECIMAL(10, 8) NOT NULL,
     longitude DECIMAL(11, 8) NOT NULL,
     walking_duration_minutes INTEGER DEFAULT 0,
+    transit_duration_minutes INTEGER DEFAULT 0,
     address TEXT,
 
     -- pickup time or dropoff time depending on the type
     expected_arrival_time TIMESTAMP WITH TIME ZONE NOT NULL,
     rider_request_id VARCHAR(50) NOT NULL REFERENCES rider_requests(id) ON DELETE CASCADE,
 
+    -- day of the occurrence of a recurring offer or rider request, NULL for a one-off trip
+    occurrence_date DATE,
+    rider_occurrence_date DATE,
+
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE (driver_offer_id, path_order)
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
--- Table to store matching information between driver offers and rider requests
+-- Table to store matching information between driver offers and rider requests.
+-- A recurring offer or request is matched per occurrence: the row carries the day of each recurring side,
+-- and a recurring request stays is_matched = false as its other occurrences remain to be matched.
+-- Likewise a recurring offer keeps current_number_of_requests at 0, each occurrence counting the riders of its points
 CREATE TABLE ride_matches (
+    id BIGSERIAL PRIMARY KEY,
     driver_offer_id VARCHAR(50) NOT NULL REFERENCES driver_offers(id) ON DELETE CASCADE,
     rider_request_id VARCHAR(50) NOT NULL REFERENCES rider_requests(id) ON DELETE CASCADE,
 
+    -- day of the occurrence of a recurring offer or rider request, NULL for a one-off trip
+    offer_occurrence_date DATE,
+    request_occurrence_date DATE,
+
     pickup_point_id VARCHAR(50) NOT NULL REFERENCES path_point(id) ON DELETE CASCADE,
     dropoff_point_id VARCHAR(50) NOT NULL REFERENCES path_point(id) ON DELETE CASCADE,
 
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Blocks and reports between users, a row excludes both users from sharing a car in either direction
+CREATE TABLE user_blocks (
+    blocker_user_id VARCHAR(50) NOT NULL,
+    blocked_user_id VARCHAR(50) NOT NULL,
+    reason block_reason_type NOT NULL DEFAULT 'block',
+
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    PRIMARY KEY (driver_offer_id, rider_request_id)
+    PRIMARY KEY (blocker_user_id, blocked_user_id)
+);
+
+-- Ratings of riders and the minimum rider rating a driver accepts
+CREATE TABLE user_trust_profiles (
+    user_id VARCHAR(50) PRIMARY KEY,
+    rider_rating DECIMAL(3, 2) CHECK (rider_rating BETWEEN 0 AND 5),
+    minimum_rider_rating DECIMAL(3, 2) CHECK (minimum_rider_rating BETWEEN 0 AND 5),
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Fixed meeting points (metro stations, parking lots, campus gates...) allowed for pickups and dropoffs
+CREATE TABLE meeting_hubs (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    hub_type VARCHAR(50) NOT NULL,
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Indexes for performance optimization
 CREATE INDEX idx_rider_requests_matching ON rider_requests(is_matched, earliest_departure_time);
-CREATE INDEX idx_path_point_driver_path ON path_point(driver_offer_id, path_order);
+CREATE UNIQUE INDEX idx_path_point_driver_path ON path_point(driver_offer_id, COALESCE(occurrence_date, '-infinity'::DATE), path_order);
 CREATE INDEX idx_driver_offers_availability ON driver_offers(departure_time, current_number_of_requests);
 CREATE INDEX idx_path_point_rider_request ON path_point(rider_request_id);
+CREATE UNIQUE INDEX idx_ride_matches_occurrence ON ride_matches(
+    driver_offer_id, COALESCE(offer_occurrence_date, '-infinity'::DATE),
+    rider_request_id, COALESCE(request_occurrence_date, '-infinity'::DATE)
+);
+CREATE INDEX idx_ride_matches_request_occurrence ON ride_matches(rider_request_id, request_occurrence_date);
+CREATE INDEX idx_user_blocks_blocked_user ON user_blocks(blocked_user_id);
 
 -- Create a function to update the updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -130,4 +220,16 @@ FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
 CREATE TRIGGER update_path_point_updated_at
 BEFORE UPDATE ON path_point
-FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
\ No newline at end of file
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_user_blocks_updated_at
+BEFORE UPDATE ON user_blocks
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_user_trust_profiles_updated_at
+BEFORE UPDATE ON user_trust_profiles
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_meeting_hubs_updated_at
+BEFORE UPDATE ON meeting_hubs
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
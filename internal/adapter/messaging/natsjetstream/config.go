@@ -18,6 +18,9 @@ type Config struct {
 	ConnectionName string
 	NatsUsername   string
 	NatsPassword   string
+	GraphSubject   string        // Subject of the graph tasks dispatched by a coordinator to the graph workers
+	GraphQueue     string        // Queue group sharing the graph tasks between the workers
+	GraphTimeout   time.Duration // Maximum time a coordinator waits for the edges of a graph task
 }
 
 func DefaultConfig() Config {
@@ -31,6 +34,9 @@ func DefaultConfig() Config {
 		ConnectionName: "matching-engine-publisher",
 		NatsUsername:   "publisher",
 		NatsPassword:   "publisherpass",
+		GraphSubject:   "matching.graph.tasks",
+		GraphQueue:     "matching-graph-workers",
+		GraphTimeout:   5 * time.Minute,
 	}
 }
 
@@ -48,11 +54,14 @@ func LoadConfig() Config {
 	override("NATS_CONNECTION_NAME", &cfg.ConnectionName)
 	override("NATS_USER", &cfg.NatsUsername)
 	override("NATS_PASSWORD", &cfg.NatsPassword)
+	override("NATS_GRAPH_SUBJECT", &cfg.GraphSubject)
+	override("NATS_GRAPH_QUEUE", &cfg.GraphQueue)
 
 	cfg.ConnectTimeout = getEnvDuration("NATS_CONNECT_TIMEOUT", cfg.ConnectTimeout)
 	cfg.PublishTimeout = getEnvDuration("NATS_PUBLISH_TIMEOUT", cfg.PublishTimeout)
 	cfg.ReconnectWait = getEnvDuration("NATS_RECONNECT_WAIT", cfg.ReconnectWait)
 	cfg.MaxReconnects = getEnvInt("NATS_MAX_RECONNECTS", cfg.MaxReconnects)
+	cfg.GraphTimeout = getEnvDuration("NATS_GRAPH_TIMEOUT", cfg.GraphTimeout)
 
 	logConfig(cfg)
 	return cfg
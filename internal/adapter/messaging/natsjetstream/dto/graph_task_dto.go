@@ -0,0 +1,41 @@
+package dto
+
+// GraphTaskDTO is a Data Transfer Object for the graph task sent by a coordinator to a graph worker
+type GraphTaskDTO struct {
+	BatchID string              `json:"batchId"`
+	Offers  []GraphTaskOfferDTO `json:"offers"`
+}
+
+// GraphTaskOfferDTO is a Data Transfer Object for an offer of a graph task
+type GraphTaskOfferDTO struct {
+	OfferID                 string              `json:"offerId"`
+	Path                    []GraphPathPointDTO `json:"path"`
+	MatchedRequestIDs       []string            `json:"matchedRequestIds,omitempty"`
+	NewlyAssignedRequestIDs []string            `json:"newlyAssignedRequestIds,omitempty"`
+	RequestIDs              []string            `json:"requestIds"`
+}
+
+// GraphEdgesDTO is a Data Transfer Object for the reply of a graph worker, holding the edges or the error of the task
+type GraphEdgesDTO struct {
+	Edges []GraphEdgeDTO `json:"edges"`
+	Error string         `json:"error,omitempty"`
+}
+
+// GraphEdgeDTO is a Data Transfer Object for an edge found by a graph worker
+type GraphEdgeDTO struct {
+	OfferID   string              `json:"offerId"`
+	RequestID string              `json:"requestId"`
+	Path      []GraphPathPointDTO `json:"path"`
+}
+
+// GraphPathPointDTO is a Data Transfer Object for a path point exchanged with a graph worker.
+// Unlike PointDTO it keeps the exact times and durations, as the worker keeps evaluating the path.
+type GraphPathPointDTO struct {
+	OwnerType       string        `json:"ownerType,omitempty"`
+	OwnerID         string        `json:"ownerId,omitempty"`
+	Point           CoordinateDTO `json:"point"`
+	Time            string        `json:"time"`
+	PointType       string        `json:"pointType"`
+	WalkingDuration int64         `json:"walkingDurationNs,omitempty"`
+	TransitDuration int64         `json:"transitDurationNs,omitempty"`
+}
@@ -2,6 +2,7 @@ package dto
 
 // MatchedRequestDTO is a Data Transfer Object for MatchedRequest
 type MatchedRequestDTO struct {
-	UserID    string `json:"userId"`
-	RequestID string `json:"requestId"`
+	UserID         string `json:"userId"`
+	RequestID      string `json:"requestId"`
+	OccurrenceDate string `json:"occurrenceDate,omitempty"`
 }
@@ -1,10 +1,21 @@
 package dto
 
-// MatchingResultDTO is a Data Transfer Object for JSON serialization
+// MatchingResultDTO is a Data Transfer Object for JSON serialization.
+//
+// The engine only reads the database, the consumer of the results writes them back. Along with the path and the
+// matched requests, it stores RoutePolyline and RoutePathSignature in the route_polyline and route_path_signature
+// columns of the offer, so the next runs reuse the route instead of planning it again. Both are written together,
+// and cleared whenever the path is changed by anything else than a matching result. They are left unchanged for an
+// occurrence of a recurring offer, whose row is shared by all the occurrences.
 type MatchingResultDTO struct {
 	UserID                  string              `json:"userId"`
 	OfferID                 string              `json:"offerId"`
+	OccurrenceDate          string              `json:"occurrenceDate,omitempty"`
 	AssignedMatchedRequests []MatchedRequestDTO `json:"assignedMatchedRequests"`
 	Path                    []PointDTO          `json:"path"`
 	CurrentNumberOfRequests int                 `json:"currentNumberOfRequests"`
+	TrafficProfile          string              `json:"trafficProfile,omitempty"`
+	Mode                    string              `json:"mode,omitempty"`
+	RoutePolyline           string              `json:"routePolyline,omitempty"`      // Encoded driving route through Path, empty when not planned
+	RoutePathSignature      string              `json:"routePathSignature,omitempty"` // Signature of Path the route was planned for
 }
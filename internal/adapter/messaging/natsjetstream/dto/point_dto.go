@@ -4,8 +4,10 @@ package dto
 type PointDTO struct {
 	OwnerType              string        `json:"ownerType"`
 	OwnerID                string        `json:"ownerID"`
+	OwnerOccurrenceDate    string        `json:"ownerOccurrenceDate,omitempty"`
 	Point                  CoordinateDTO `json:"point"`
 	Time                   string        `json:"time"`
 	PointType              string        `json:"pointType"`
 	WalkingDurationMinutes int           `json:"walkingDurationMinutes"`
+	TransitDurationMinutes int           `json:"transitDurationMinutes,omitempty"`
 }
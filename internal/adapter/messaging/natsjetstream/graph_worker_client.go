@@ -0,0 +1,62 @@
+package natsjetstream
+
+import (
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/adapter/messaging/natsjetstream/mappers"
+	"matching-engine/internal/service/matcher"
+	"time"
+)
+
+// Requester sends a request and waits for its reply, it is implemented by *nats.Conn
+type Requester interface {
+	Request(subject string, data []byte, timeout time.Duration) (*nats.Msg, error)
+}
+
+// GraphWorkerClient implements the matcher.GraphWorkerPool interface using NATS request/reply.
+// The graph workers subscribe to the graph subject in a queue group, so every task is built by a single worker.
+type GraphWorkerClient struct {
+	requester Requester
+	mapper    *mappers.GraphTaskMapper
+	config    Config
+}
+
+// NewGraphWorkerClient creates a client dispatching the graph tasks to the workers with the default configuration
+func NewGraphWorkerClient() (matcher.GraphWorkerPool, error) {
+	config := LoadConfig()
+	nc, err := connect(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewGraphWorkerClientWithRequester(nc, config), nil
+}
+
+// NewGraphWorkerClientWithRequester creates a client dispatching the graph tasks through the requester
+func NewGraphWorkerClientWithRequester(requester Requester, config Config) matcher.GraphWorkerPool {
+	return &GraphWorkerClient{
+		requester: requester,
+		mapper:    mappers.NewGraphTaskMapper(),
+		config:    config,
+	}
+}
+
+// BuildEdges sends the task to a graph worker and returns the edges it found
+func (c *GraphWorkerClient) BuildEdges(task *matcher.GraphTask) ([]matcher.GraphEdge, error) {
+	data, err := c.mapper.MarshalTask(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal graph task: %w", err)
+	}
+
+	log.Debug().
+		Str("subject", c.config.GraphSubject).
+		Int("offers", len(task.Offers)).
+		Int("dataSize", len(data)).
+		Msg("Sending graph task")
+
+	msg, err := c.requester.Request(c.config.GraphSubject, data, c.config.GraphTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("graph task request failed: %w", err)
+	}
+	return c.mapper.UnmarshalEdges(msg.Data)
+}
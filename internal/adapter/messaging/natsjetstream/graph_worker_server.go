@@ -0,0 +1,105 @@
+package natsjetstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/adapter/messaging/natsjetstream/mappers"
+	"matching-engine/internal/service/matcher"
+)
+
+// GraphWorkerServer replies to the graph tasks of a coordinator with the edges built by a graph worker.
+// The batch is loaded again when a task belongs to another batch, as the coordinator matched a new one.
+type GraphWorkerServer struct {
+	worker         *matcher.GraphWorker
+	mapper         *mappers.GraphTaskMapper
+	config         Config
+	missedBatchIDs map[string]bool // Batches of the tasks the reloaded batch didn't match, they aren't loaded again
+}
+
+// NewGraphWorkerServer creates a server replying with the edges built by the worker, with the default configuration
+func NewGraphWorkerServer(worker *matcher.GraphWorker) *GraphWorkerServer {
+	return &GraphWorkerServer{
+		worker:         worker,
+		mapper:         mappers.NewGraphTaskMapper(),
+		config:         LoadConfig(),
+		missedBatchIDs: make(map[string]bool),
+	}
+}
+
+// Serve loads the batch into the worker and replies to the graph tasks until the context is done.
+// The tasks are handled one at a time, the batch being loaded again for the tasks of another batch.
+func (s *GraphWorkerServer) Serve(ctx context.Context, load matcher.BatchLoader) error {
+	offers, requests, err := load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load the batch: %w", err)
+	}
+	s.worker.Load(offers, requests)
+
+	nc, err := connect(s.config)
+	if err != nil {
+		return err
+	}
+
+	_, err = nc.QueueSubscribe(s.config.GraphSubject, s.config.GraphQueue, func(msg *nats.Msg) {
+		if err := msg.Respond(s.reply(ctx, load, msg.Data)); err != nil {
+			log.Error().Err(err).Msg("Failed to reply to graph task")
+		}
+	})
+	if err != nil {
+		nc.Close()
+		return fmt.Errorf("failed to subscribe to graph tasks: %w", err)
+	}
+
+	log.Info().
+		Str("subject", s.config.GraphSubject).
+		Str("queue", s.config.GraphQueue).
+		Msg("Graph worker waiting for tasks")
+
+	<-ctx.Done()
+
+	log.Info().Msg("Draining NATS connection")
+	if err = nc.Drain(); err != nil {
+		return fmt.Errorf("failed to drain NATS connection: %w", err)
+	}
+	return nil
+}
+
+// reply builds the edges of a serialized graph task and returns the serialized reply.
+// The failures are sent back to the coordinator, which fails the matching round.
+func (s *GraphWorkerServer) reply(ctx context.Context, load matcher.BatchLoader, data []byte) []byte {
+	edges, err := s.handle(ctx, load, data)
+	if err != nil {
+		log.Error().Err(err).Msg("Graph task failed")
+	}
+	reply, marshalErr := s.mapper.MarshalEdges(edges, err)
+	if marshalErr != nil {
+		log.Error().Err(marshalErr).Msg("Failed to marshal graph edges")
+		reply, _ = s.mapper.MarshalEdges(nil, marshalErr)
+	}
+	return reply
+}
+
+func (s *GraphWorkerServer) handle(ctx context.Context, load matcher.BatchLoader, data []byte) ([]matcher.GraphEdge, error) {
+	task, err := s.mapper.UnmarshalTask(data)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := s.worker.BuildEdges(task)
+	if errors.Is(err, matcher.ErrUnknownBatch) && !s.missedBatchIDs[task.BatchID] {
+		log.Info().Str("batch", task.BatchID).Msg("Graph task of another batch, loading the batch again")
+		offers, requests, loadErr := load(ctx)
+		if loadErr != nil {
+			return nil, fmt.Errorf("failed to load the batch again: %w", loadErr)
+		}
+		s.worker.Load(offers, requests)
+		edges, err = s.worker.BuildEdges(task)
+	}
+	if errors.Is(err, matcher.ErrUnknownBatch) {
+		s.missedBatchIDs[task.BatchID] = true
+		return nil, fmt.Errorf("the worker must read the same batch as the coordinator: %w", err)
+	}
+	return edges, err
+}
@@ -0,0 +1,125 @@
+package converters
+
+import (
+	"fmt"
+	"matching-engine/internal/adapter/messaging/natsjetstream/dto"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/matcher"
+	"time"
+)
+
+// GraphTaskConverter handles conversion between the graph tasks and edges of the matcher and their DTOs
+type GraphTaskConverter struct{}
+
+// NewGraphTaskConverter creates a new GraphTaskConverter
+func NewGraphTaskConverter() *GraphTaskConverter {
+	return &GraphTaskConverter{}
+}
+
+// TaskToDTO converts a graph task to a GraphTaskDTO
+func (c *GraphTaskConverter) TaskToDTO(task *matcher.GraphTask) dto.GraphTaskDTO {
+	offers := make([]dto.GraphTaskOfferDTO, len(task.Offers))
+	for i, offer := range task.Offers {
+		offers[i] = dto.GraphTaskOfferDTO{
+			OfferID:                 offer.OfferID,
+			Path:                    c.pathToDTO(offer.Path),
+			MatchedRequestIDs:       offer.MatchedRequestIDs,
+			NewlyAssignedRequestIDs: offer.NewlyAssignedRequestIDs,
+			RequestIDs:              offer.RequestIDs,
+		}
+	}
+	return dto.GraphTaskDTO{BatchID: task.BatchID, Offers: offers}
+}
+
+// TaskFromDTO converts a GraphTaskDTO to a graph task
+func (c *GraphTaskConverter) TaskFromDTO(taskDTO dto.GraphTaskDTO) (*matcher.GraphTask, error) {
+	task := &matcher.GraphTask{BatchID: taskDTO.BatchID, Offers: make([]matcher.GraphTaskOffer, len(taskDTO.Offers))}
+	for i, offerDTO := range taskDTO.Offers {
+		path, err := c.pathFromDTO(offerDTO.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path for offer %s: %w", offerDTO.OfferID, err)
+		}
+		task.Offers[i] = matcher.GraphTaskOffer{
+			OfferID:                 offerDTO.OfferID,
+			Path:                    path,
+			MatchedRequestIDs:       offerDTO.MatchedRequestIDs,
+			NewlyAssignedRequestIDs: offerDTO.NewlyAssignedRequestIDs,
+			RequestIDs:              offerDTO.RequestIDs,
+		}
+	}
+	return task, nil
+}
+
+// EdgesToDTO converts the edges found by a graph worker to a GraphEdgesDTO
+func (c *GraphTaskConverter) EdgesToDTO(edges []matcher.GraphEdge) dto.GraphEdgesDTO {
+	edgesDTO := dto.GraphEdgesDTO{Edges: make([]dto.GraphEdgeDTO, len(edges))}
+	for i, edge := range edges {
+		edgesDTO.Edges[i] = dto.GraphEdgeDTO{
+			OfferID:   edge.OfferID,
+			RequestID: edge.RequestID,
+			Path:      c.pathToDTO(edge.Path),
+		}
+	}
+	return edgesDTO
+}
+
+// EdgesFromDTO converts a GraphEdgesDTO to the edges found by a graph worker
+func (c *GraphTaskConverter) EdgesFromDTO(edgesDTO dto.GraphEdgesDTO) ([]matcher.GraphEdge, error) {
+	edges := make([]matcher.GraphEdge, len(edgesDTO.Edges))
+	for i, edgeDTO := range edgesDTO.Edges {
+		path, err := c.pathFromDTO(edgeDTO.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path for offer %s and request %s: %w", edgeDTO.OfferID, edgeDTO.RequestID, err)
+		}
+		edges[i] = matcher.GraphEdge{
+			OfferID:   edgeDTO.OfferID,
+			RequestID: edgeDTO.RequestID,
+			Path:      path,
+		}
+	}
+	return edges, nil
+}
+
+func (c *GraphTaskConverter) pathToDTO(path []matcher.RemotePathPoint) []dto.GraphPathPointDTO {
+	pathDTO := make([]dto.GraphPathPointDTO, len(path))
+	for i, point := range path {
+		pathDTO[i] = dto.GraphPathPointDTO{
+			OwnerType: point.OwnerType.String(),
+			OwnerID:   point.OwnerID,
+			Point: dto.CoordinateDTO{
+				Lat: point.Coordinate.Lat(),
+				Lng: point.Coordinate.Lng(),
+			},
+			Time:            point.ExpectedArrivalTime.Format(time.RFC3339Nano),
+			PointType:       point.PointType.String(),
+			WalkingDuration: int64(point.WalkingDuration),
+			TransitDuration: int64(point.TransitDuration),
+		}
+	}
+	return pathDTO
+}
+
+func (c *GraphTaskConverter) pathFromDTO(pathDTO []dto.GraphPathPointDTO) ([]matcher.RemotePathPoint, error) {
+	path := make([]matcher.RemotePathPoint, len(pathDTO))
+	for i, pointDTO := range pathDTO {
+		coordinate, err := model.NewCoordinate(pointDTO.Point.Lat, pointDTO.Point.Lng)
+		if err != nil {
+			return nil, fmt.Errorf("point %d: %w", i, err)
+		}
+		expectedArrivalTime, err := time.Parse(time.RFC3339Nano, pointDTO.Time)
+		if err != nil {
+			return nil, fmt.Errorf("point %d: %w", i, err)
+		}
+		path[i] = matcher.RemotePathPoint{
+			OwnerType:           enums.RoleType(pointDTO.OwnerType),
+			OwnerID:             pointDTO.OwnerID,
+			Coordinate:          *coordinate,
+			PointType:           enums.PointType(pointDTO.PointType),
+			ExpectedArrivalTime: expectedArrivalTime,
+			WalkingDuration:     time.Duration(pointDTO.WalkingDuration),
+			TransitDuration:     time.Duration(pointDTO.TransitDuration),
+		}
+	}
+	return path, nil
+}
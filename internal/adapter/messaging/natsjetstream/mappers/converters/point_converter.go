@@ -13,19 +13,22 @@ type PointConverter struct{}
 // ToDTO converts a domain Point to a PointDTO
 func (c *PointConverter) ToDTO(p *model.PathPoint) dto.PointDTO {
 	owner := p.Owner()
-	var id, ownerType string
+	var id, occurrenceDate, ownerType string
 
 	if offer, ok := owner.AsOffer(); ok {
-		id = offer.ID()
+		id = offer.SeriesID()
+		occurrenceDate = offer.OccurrenceDate()
 		ownerType = string(enums.Offer)
 	} else if request, ok := owner.AsRequest(); ok {
-		id = request.ID()
+		id = request.SeriesID()
+		occurrenceDate = request.OccurrenceDate()
 		ownerType = string(enums.Request)
 	}
 
 	return dto.PointDTO{
-		OwnerType: ownerType,
-		OwnerID:   id,
+		OwnerType:           ownerType,
+		OwnerID:             id,
+		OwnerOccurrenceDate: occurrenceDate,
 		Point: dto.CoordinateDTO{
 			Lat: p.Coordinate().Lat(),
 			Lng: p.Coordinate().Lng(),
@@ -33,6 +36,7 @@ func (c *PointConverter) ToDTO(p *model.PathPoint) dto.PointDTO {
 		Time:                   p.ExpectedArrivalTime().Format(time.RFC3339),
 		PointType:              p.PointType().String(),
 		WalkingDurationMinutes: int(p.WalkingDuration().Minutes()),
+		TransitDurationMinutes: int(p.TransitDuration().Minutes()),
 	}
 }
 
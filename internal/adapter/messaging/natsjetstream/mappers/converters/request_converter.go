@@ -10,11 +10,13 @@ type RequestConverter struct {
 	pointConverter *PointConverter
 }
 
-// ToDTO converts a domain MatchedRequest to a MatchedRequestDTO
+// ToDTO converts a domain MatchedRequest to a MatchedRequestDTO.
+// The occurrence of a recurring request is identified by the ID of the series and the date of the occurrence.
 func (c *RequestConverter) ToDTO(req *model.Request) dto.MatchedRequestDTO {
 	return dto.MatchedRequestDTO{
-		UserID:    req.UserID(),
-		RequestID: req.ID(),
+		UserID:         req.UserID(),
+		RequestID:      req.SeriesID(),
+		OccurrenceDate: req.OccurrenceDate(),
 	}
 }
 
@@ -11,15 +11,24 @@ type ResultConverter struct {
 	pointConverter   *PointConverter
 }
 
-// ToDTO converts a domain MatchingResult to a MatchingResultDTO
+// ToDTO converts a domain MatchingResult to a MatchingResultDTO.
+// The occurrence of a recurring offer is identified by the ID of the series and the date of the occurrence.
 func (c *ResultConverter) ToDTO(result *model.MatchingResult) dto.MatchingResultDTO {
-	return dto.MatchingResultDTO{
+	resultDTO := dto.MatchingResultDTO{
 		UserID:                  result.UserID(),
-		OfferID:                 result.OfferID(),
+		OfferID:                 result.OfferSeriesID(),
+		OccurrenceDate:          result.OccurrenceDate(),
 		AssignedMatchedRequests: c.requestConverter.ToMatchedRequestsDTO(result.AssignedMatchedRequests()),
 		Path:                    c.pointConverter.ToPointsDTO(result.NewPath()),
 		CurrentNumberOfRequests: result.CurrentNumberOfRequests(),
+		TrafficProfile:          result.TrafficProfile(),
+		Mode:                    result.Mode(),
 	}
+	if polyline := result.RoutePolyline(); polyline != nil {
+		resultDTO.RoutePolyline = polyline.Encoded()
+		resultDTO.RoutePathSignature = result.RoutePathSignature()
+	}
+	return resultDTO
 }
 
 // NewResultConverter creates a new ResultConverter
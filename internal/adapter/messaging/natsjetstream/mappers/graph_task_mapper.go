@@ -0,0 +1,55 @@
+package mappers
+
+import (
+	"encoding/json"
+	"fmt"
+	"matching-engine/internal/adapter/messaging/natsjetstream/dto"
+	"matching-engine/internal/adapter/messaging/natsjetstream/mappers/converters"
+	"matching-engine/internal/service/matcher"
+)
+
+// GraphTaskMapper serializes the graph tasks and the replies of the graph workers to JSON
+type GraphTaskMapper struct {
+	converter *converters.GraphTaskConverter
+}
+
+// NewGraphTaskMapper creates a new GraphTaskMapper
+func NewGraphTaskMapper() *GraphTaskMapper {
+	return &GraphTaskMapper{
+		converter: converters.NewGraphTaskConverter(),
+	}
+}
+
+// MarshalTask serializes a graph task to JSON bytes
+func (mapper *GraphTaskMapper) MarshalTask(task *matcher.GraphTask) ([]byte, error) {
+	return json.Marshal(mapper.converter.TaskToDTO(task))
+}
+
+// UnmarshalTask deserializes a graph task from JSON bytes
+func (mapper *GraphTaskMapper) UnmarshalTask(data []byte) (*matcher.GraphTask, error) {
+	var taskDTO dto.GraphTaskDTO
+	if err := json.Unmarshal(data, &taskDTO); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal graph task: %w", err)
+	}
+	return mapper.converter.TaskFromDTO(taskDTO)
+}
+
+// MarshalEdges serializes the edges found by a graph worker, or the error of the task when it failed
+func (mapper *GraphTaskMapper) MarshalEdges(edges []matcher.GraphEdge, taskErr error) ([]byte, error) {
+	if taskErr != nil {
+		return json.Marshal(dto.GraphEdgesDTO{Error: taskErr.Error()})
+	}
+	return json.Marshal(mapper.converter.EdgesToDTO(edges))
+}
+
+// UnmarshalEdges deserializes the reply of a graph worker, the error of the task is returned when it failed
+func (mapper *GraphTaskMapper) UnmarshalEdges(data []byte) ([]matcher.GraphEdge, error) {
+	var edgesDTO dto.GraphEdgesDTO
+	if err := json.Unmarshal(data, &edgesDTO); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal graph edges: %w", err)
+	}
+	if edgesDTO.Error != "" {
+		return nil, fmt.Errorf("graph worker failed: %s", edgesDTO.Error)
+	}
+	return mapper.converter.EdgesFromDTO(edgesDTO)
+}
@@ -27,37 +27,11 @@ func NewNATSPublisher() (re.Publisher, error) {
 
 // NewNATSPublisherWithConfig creates a new publisher that uses NATS JetStream with the provided configuration
 func NewNATSPublisherWithConfig(config Config) (re.Publisher, error) {
-	// Connection options
-	opts := []nats.Option{
-		nats.Name(config.ConnectionName),
-		//nats.RetryOnFailedConnect(true),
-		//nats.MaxReconnects(config.MaxReconnects),
-		//nats.ReconnectWait(config.ReconnectWait),
-		nats.Timeout(config.ConnectTimeout),
-		nats.UserInfo(config.NatsUsername, config.NatsPassword),
-
-		// Connection event handlers for logging
-		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
-			log.Error().Err(err).Msg("NATS connection disconnected")
-		}),
-		nats.ReconnectHandler(func(nc *nats.Conn) {
-			log.Info().Str("url", nc.ConnectedUrl()).Msg("NATS reconnected")
-		}),
-		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
-			log.Error().Err(err).Msg("NATS error")
-		}),
-	}
-
-	log.Info().Str("url", config.URL).Msg("Connecting to NATS")
-
-	nc, err := nats.Connect(config.URL, opts...)
+	nc, err := connect(config)
 	if err != nil {
-		log.Error().Err(err).Str("url", config.URL).Msg("Failed to connect to NATS")
-		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+		return nil, err
 	}
 
-	log.Info().Str("url", nc.ConnectedUrl()).Msg("Connected to NATS")
-
 	js, err := jetstream.New(nc)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create JetStream context")
@@ -164,3 +138,38 @@ func (p *NATSPublisher) Close() error {
 	log.Info().Msg("NATS publisher closed")
 	return nil
 }
+
+// connect opens a connection to the NATS server with the connection options of the configuration
+func connect(config Config) (*nats.Conn, error) {
+	// Connection options
+	opts := []nats.Option{
+		nats.Name(config.ConnectionName),
+		//nats.RetryOnFailedConnect(true),
+		//nats.MaxReconnects(config.MaxReconnects),
+		//nats.ReconnectWait(config.ReconnectWait),
+		nats.Timeout(config.ConnectTimeout),
+		nats.UserInfo(config.NatsUsername, config.NatsPassword),
+
+		// Connection event handlers for logging
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			log.Error().Err(err).Msg("NATS connection disconnected")
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Info().Str("url", nc.ConnectedUrl()).Msg("NATS reconnected")
+		}),
+		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			log.Error().Err(err).Msg("NATS error")
+		}),
+	}
+
+	log.Info().Str("url", config.URL).Msg("Connecting to NATS")
+
+	nc, err := nats.Connect(config.URL, opts...)
+	if err != nil {
+		log.Error().Err(err).Str("url", config.URL).Msg("Failed to connect to NATS")
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	log.Info().Str("url", nc.ConnectedUrl()).Msg("Connected to NATS")
+	return nc, nil
+}
@@ -0,0 +1,335 @@
+package tests
+
+import (
+	"context"
+	"matching-engine/internal/adapter/messaging/natsjetstream"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/affinity"
+	"matching-engine/internal/service/earlypruning"
+	"matching-engine/internal/service/matcher"
+	"matching-engine/internal/service/maximummatching"
+	"matching-engine/internal/service/timematrix"
+	"matching-engine/internal/service/timematrix/cache"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// countingRequester sends the requests through a NATS connection and counts them
+type countingRequester struct {
+	conn     *nats.Conn
+	requests atomic.Int32
+}
+
+func (r *countingRequester) Request(subject string, data []byte, timeout time.Duration) (*nats.Msg, error) {
+	r.requests.Add(1)
+	return r.conn.Request(subject, data, timeout)
+}
+
+// batchLoader returns the batches in turn, the last one once they were all read
+type batchLoader struct {
+	batches []func() ([]*model.Offer, []*model.Request)
+	loads   atomic.Int32
+}
+
+func (l *batchLoader) load(ctx context.Context) ([]*model.Offer, []*model.Request, error) {
+	i := int(l.loads.Add(1)) - 1
+	offers, requests := l.batches[min(i, len(l.batches)-1)]()
+	return offers, requests, nil
+}
+
+type allPairsChecker struct{}
+
+func (c *allPairsChecker) Check(offer *model.Offer, request *model.Request) (bool, error) {
+	return true, nil
+}
+
+type noMatrixGenerator struct{}
+
+func (g *noMatrixGenerator) Generate(offer *model.OfferNode, requestNodes []*model.RequestNode) (*cache.PathPointMappedTimeMatrix, error) {
+	return nil, nil
+}
+
+// pairEvaluator accepts the listed pairs, inserting the pickup and the dropoff before the destination of the offer
+type pairEvaluator struct {
+	feasible map[string]string // request ID to offer ID
+}
+
+func (e *pairEvaluator) Evaluate(offerNode *model.OfferNode, requestNode *model.RequestNode) ([]model.PathPoint, bool, error) {
+	offer, request := offerNode.Offer(), requestNode.Request()
+	if e.feasible[request.ID()] != offer.ID() {
+		return nil, false, nil
+	}
+	path := offer.Path()
+	newPath := make([]model.PathPoint, 0, len(path)+2)
+	newPath = append(newPath, path[:len(path)-1]...)
+	pickup := model.NewPathPoint(*request.Source(), enums.Pickup, request.EarliestDepartureTime(), request, 3*time.Minute)
+	pickup.SetTransitDuration(90 * time.Second)
+	newPath = append(newPath,
+		*pickup,
+		*model.NewPathPoint(*request.Destination(), enums.Dropoff, request.LatestArrivalTime(), request, 0),
+		path[len(path)-1],
+	)
+	return newPath, true, nil
+}
+
+func newTestMatcher(evaluator *pairEvaluator, graphWorkers matcher.GraphWorkerPool) *matcher.Matcher {
+	return matcher.NewMatcher(
+		evaluator,
+		earlypruning.NewPreChecksCandidateGenerator(&allPairsChecker{}),
+		maximummatching.NewHopcroftKarp(),
+		timematrix.NewCacheWithOfferIdPopulator(&noMatrixGenerator{}, cache.NewTimeMatrixCacheWithOfferId()),
+		affinity.NewTracker(),
+		matcher.OfferCaches{},
+		nil, nil, nil, nil,
+		graphWorkers,
+	)
+}
+
+func coordinate(lat, lng float64) model.Coordinate {
+	c, err := model.NewCoordinate(lat, lng)
+	if err != nil {
+		panic(err)
+	}
+	return *c
+}
+
+func newOffer(id string, departure time.Time, source, destination model.Coordinate) *model.Offer {
+	path := []model.PathPoint{
+		*model.NewPathPoint(source, enums.Source, departure, nil, 0),
+		*model.NewPathPoint(destination, enums.Destination, departure.Add(time.Hour), nil, 0),
+	}
+	return model.NewOffer(id, "driver_"+id, source, destination, departure, 10*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, path, nil)
+}
+
+func newRequest(id string, departure time.Time, source, destination model.Coordinate) *model.Request {
+	return model.NewRequest(id, "rider_"+id, source, destination, departure,
+		departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+}
+
+// newBatch creates the offers and requests as read by a process, the coordinator and the worker each read their own
+func newBatch(departure time.Time) ([]*model.Offer, []*model.Request) {
+	offers := []*model.Offer{
+		newOffer("north", departure, coordinate(30.0, 31.2), coordinate(30.1, 31.3)),
+		newOffer("south", departure, coordinate(29.0, 31.2), coordinate(29.1, 31.3)),
+	}
+	requests := []*model.Request{
+		newRequest("north_rider_1", departure, coordinate(30.01, 31.21), coordinate(30.09, 31.29)),
+		newRequest("north_rider_2", departure, coordinate(30.02, 31.22), coordinate(30.08, 31.28)),
+		newRequest("south_rider", departure, coordinate(29.01, 31.21), coordinate(29.09, 31.29)),
+	}
+	return offers, requests
+}
+
+// newNextBatch creates the batch of the next run, with a new offer and its rider
+func newNextBatch(departure time.Time) ([]*model.Offer, []*model.Request) {
+	offers, requests := newBatch(departure)
+	offers = append(offers, newOffer("east", departure, coordinate(30.0, 32.2), coordinate(30.1, 32.3)))
+	requests = append(requests, newRequest("east_rider", departure, coordinate(30.01, 32.21), coordinate(30.09, 32.29)))
+	return offers, requests
+}
+
+// startNATSServer starts an embedded NATS server accepting the default credentials, the clients connect to it through NATS_URL
+func startNATSServer(t *testing.T) *server.Server {
+	config := natsjetstream.DefaultConfig()
+	ns, err := server.NewServer(&server.Options{
+		Host:     "127.0.0.1",
+		Port:     server.RANDOM_PORT,
+		Username: config.NatsUsername,
+		Password: config.NatsPassword,
+		NoLog:    true,
+		NoSigs:   true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create the NATS server: %v", err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	t.Cleanup(ns.Shutdown)
+	t.Setenv("NATS_URL", ns.ClientURL())
+	return ns
+}
+
+// serveGraphTasks runs a graph worker on the batches of the loader until the end of the test
+func serveGraphTasks(t *testing.T, ns *server.Server, evaluator *pairEvaluator, loader *batchLoader) {
+	subscriptions := ns.NumSubscriptions()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	workerServer := natsjetstream.NewGraphWorkerServer(matcher.NewGraphWorker(newTestMatcher(evaluator, nil)))
+	go func() {
+		done <- workerServer.Serve(ctx, loader.load)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("unexpected graph worker error: %v", err)
+		}
+	})
+
+	for deadline := time.Now().Add(5 * time.Second); ns.NumSubscriptions() == subscriptions; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("graph worker didn't subscribe to the graph tasks")
+		}
+	}
+}
+
+// newGraphWorkerClient connects a coordinator to the embedded NATS server
+func newGraphWorkerClient(t *testing.T, ns *server.Server) (matcher.GraphWorkerPool, *countingRequester) {
+	config := natsjetstream.LoadConfig()
+	nc, err := nats.Connect(ns.ClientURL(), nats.UserInfo(config.NatsUsername, config.NatsPassword))
+	if err != nil {
+		t.Fatalf("failed to connect to NATS: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	requester := &countingRequester{conn: nc}
+	return natsjetstream.NewGraphWorkerClientWithRequester(requester, config), requester
+}
+
+func assignedRequests(results []*model.MatchingResult) map[string][]string {
+	assigned := make(map[string][]string)
+	for _, result := range results {
+		for _, request := range result.AssignedMatchedRequests() {
+			assigned[result.OfferID()] = append(assigned[result.OfferID()], request.ID())
+		}
+		sort.Strings(assigned[result.OfferID()])
+	}
+	return assigned
+}
+
+func TestGraphWorker_CoordinatorMatchesLikeLocal(t *testing.T) {
+	t.Setenv("MATCHING_GRAPH_TASK_OFFERS", "1")
+
+	departure := time.Now().Add(time.Hour)
+	evaluator := &pairEvaluator{feasible: map[string]string{
+		"north_rider_1": "north",
+		"north_rider_2": "north",
+		"south_rider":   "south",
+	}}
+
+	localOffers, localRequests := newBatch(departure)
+	localResults, err := newTestMatcher(evaluator, nil).Match(localOffers, localRequests)
+	if err != nil {
+		t.Fatalf("unexpected local matching error: %v", err)
+	}
+
+	ns := startNATSServer(t)
+	serveGraphTasks(t, ns, evaluator, &batchLoader{batches: []func() ([]*model.Offer, []*model.Request){
+		func() ([]*model.Offer, []*model.Request) { return newBatch(departure) },
+	}})
+	client, requester := newGraphWorkerClient(t, ns)
+
+	offers, requests := newBatch(departure)
+	results, err := newTestMatcher(evaluator, client).Match(offers, requests)
+	if err != nil {
+		t.Fatalf("unexpected coordinated matching error: %v", err)
+	}
+
+	// One task per offer in the first round, then the north offer alone picks up its second rider
+	if requester.requests.Load() < 3 {
+		t.Errorf("expected the rounds to be dispatched to the worker, got %d tasks", requester.requests.Load())
+	}
+	local, coordinated := assignedRequests(localResults), assignedRequests(results)
+	if len(coordinated) != 2 || strings.Join(coordinated["north"], ",") != "north_rider_1,north_rider_2" ||
+		strings.Join(coordinated["south"], ",") != "south_rider" {
+		t.Fatalf("unexpected assignment %v", coordinated)
+	}
+	for offerID, requestIDs := range local {
+		if strings.Join(coordinated[offerID], ",") != strings.Join(requestIDs, ",") {
+			t.Errorf("offer %s: expected %v as matched locally, got %v", offerID, requestIDs, coordinated[offerID])
+		}
+	}
+
+	for _, result := range results {
+		if result.OfferID() != "north" {
+			continue
+		}
+		if len(result.NewPath()) != 6 {
+			t.Fatalf("expected a path through both riders, got %d points", len(result.NewPath()))
+		}
+		pickup := result.NewPath()[1]
+		if pickup.GetOwnerID() != "north_rider_1" && pickup.GetOwnerID() != "north_rider_2" {
+			t.Errorf("expected the owner of the pickup to be resolved, got %q", pickup.GetOwnerID())
+		}
+		if pickup.WalkingDuration() != 3*time.Minute || pickup.TransitDuration() != 90*time.Second {
+			t.Errorf("expected the access durations to survive the round trip, got %s and %s", pickup.WalkingDuration(), pickup.TransitDuration())
+		}
+		if !pickup.ExpectedArrivalTime().Equal(departure) {
+			t.Errorf("expected the exact pickup time, got %s", pickup.ExpectedArrivalTime())
+		}
+	}
+}
+
+func TestGraphWorker_LoadsTheBatchOfANewRun(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	evaluator := &pairEvaluator{feasible: map[string]string{
+		"north_rider_1": "north",
+		"south_rider":   "south",
+		"east_rider":    "east",
+	}}
+
+	// The worker starts on the batch of the first run, and reads the batch of the second run when it gets its tasks
+	ns := startNATSServer(t)
+	loader := &batchLoader{batches: []func() ([]*model.Offer, []*model.Request){
+		func() ([]*model.Offer, []*model.Request) { return newBatch(departure) },
+		func() ([]*model.Offer, []*model.Request) { return newNextBatch(departure) },
+	}}
+	serveGraphTasks(t, ns, evaluator, loader)
+	client, _ := newGraphWorkerClient(t, ns)
+
+	offers, requests := newBatch(departure)
+	if _, err := newTestMatcher(evaluator, client).Match(offers, requests); err != nil {
+		t.Fatalf("unexpected error in the first run: %v", err)
+	}
+	if loader.loads.Load() != 1 {
+		t.Fatalf("expected the worker to match the first run on the batch it started with, got %d loads", loader.loads.Load())
+	}
+
+	offers, requests = newNextBatch(departure)
+	results, err := newTestMatcher(evaluator, client).Match(offers, requests)
+	if err != nil {
+		t.Fatalf("unexpected error in the second run: %v", err)
+	}
+	if loader.loads.Load() != 2 {
+		t.Errorf("expected the worker to load the batch of the second run once, got %d loads", loader.loads.Load())
+	}
+	if got := assignedRequests(results)["east"]; len(got) != 1 || got[0] != "east_rider" {
+		t.Errorf("expected the new offer to pick up its rider, got %v", got)
+	}
+}
+
+func TestGraphWorker_UnknownBatchFailsTheMatching(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	evaluator := &pairEvaluator{feasible: map[string]string{"south_rider": "south"}}
+
+	// The worker keeps reading a batch without the south offer
+	ns := startNATSServer(t)
+	loader := &batchLoader{batches: []func() ([]*model.Offer, []*model.Request){
+		func() ([]*model.Offer, []*model.Request) {
+			offers, requests := newBatch(departure)
+			return offers[:1], requests
+		},
+	}}
+	serveGraphTasks(t, ns, evaluator, loader)
+	client, _ := newGraphWorkerClient(t, ns)
+
+	for run := 1; run <= 2; run++ {
+		offers, requests := newBatch(departure)
+		_, err := newTestMatcher(evaluator, client).Match(offers, requests)
+		if err == nil || !strings.Contains(err.Error(), "same batch as the coordinator") {
+			t.Fatalf("run %d: expected the worker error to fail the matching, got %v", run, err)
+		}
+	}
+	// The batch is read again once, not for every task of the batch it can't read
+	if loader.loads.Load() != 2 {
+		t.Errorf("expected the batch to be loaded twice, got %d loads", loader.loads.Load())
+	}
+}
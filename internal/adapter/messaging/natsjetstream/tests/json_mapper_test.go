@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"matching-engine/internal/adapter/messaging/natsjetstream/dto"
+	"matching-engine/internal/adapter/messaging/natsjetstream/mappers"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"testing"
+	"time"
+)
+
+func TestJsonMapper_PublishesTheSeriesAndOccurrenceDate(t *testing.T) {
+	// Monday 2025-09-15 08:00
+	firstTrip := time.Date(2025, 9, 15, 8, 0, 0, 0, time.UTC)
+	preference := *model.NewPreference(enums.Female, false)
+
+	offer := model.NewOffer(
+		"offer1", "driver1",
+		model.Coordinate{}, model.Coordinate{},
+		firstTrip, 15*time.Minute, 3, preference, firstTrip.Add(1*time.Hour), 0, nil, nil,
+	)
+	offer.SetPath([]model.PathPoint{
+		*model.NewPathPoint(model.Coordinate{}, enums.Source, firstTrip, offer, 0),
+		*model.NewPathPoint(model.Coordinate{}, enums.Destination, firstTrip.Add(40*time.Minute), offer, 0),
+	})
+	occurrence := offer.Instance(firstTrip.Add(24 * time.Hour))
+
+	rider := model.NewRequest(
+		"request1", "rider1",
+		model.Coordinate{}, model.Coordinate{},
+		firstTrip, firstTrip.Add(1*time.Hour),
+		10*time.Minute,
+		1,
+		preference,
+	).Instance(firstTrip.Add(24*time.Hour + 5*time.Minute))
+
+	path := append([]model.PathPoint(nil), occurrence.Path()...)
+	path = append(path[:1], append([]model.PathPoint{
+		*model.NewPathPoint(model.Coordinate{}, enums.Pickup, firstTrip.Add(24*time.Hour+10*time.Minute), rider, 0),
+		*model.NewPathPoint(model.Coordinate{}, enums.Dropoff, firstTrip.Add(24*time.Hour+30*time.Minute), rider, 0),
+	}, path[1:]...)...)
+	occurrence.SetPath(path)
+
+	offerNode := model.NewOfferNode(occurrence)
+	offerNode.SetNewlyAssignedMatchedRequests([]*model.Request{rider})
+	result, err := model.NewMatchingResultFromOfferNode(offerNode)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := mappers.NewJsonMapper().Marshal(result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var resultDTO dto.MatchingResultDTO
+	if err := json.Unmarshal(data, &resultDTO); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resultDTO.OfferID != "offer1" || resultDTO.OccurrenceDate != "2025-09-16" {
+		t.Errorf("Expected offer offer1 on 2025-09-16 but got %s on %s", resultDTO.OfferID, resultDTO.OccurrenceDate)
+	}
+	if len(resultDTO.AssignedMatchedRequests) != 1 {
+		t.Fatalf("Expected 1 assigned request but got %d", len(resultDTO.AssignedMatchedRequests))
+	}
+	if request := resultDTO.AssignedMatchedRequests[0]; request.RequestID != "request1" || request.OccurrenceDate != "2025-09-16" {
+		t.Errorf("Expected request request1 on 2025-09-16 but got %s on %s", request.RequestID, request.OccurrenceDate)
+	}
+	for _, point := range resultDTO.Path {
+		if point.OwnerOccurrenceDate != "2025-09-16" {
+			t.Errorf("Expected the %s point of %s to be on 2025-09-16 but got %s", point.PointType, point.OwnerID, point.OwnerOccurrenceDate)
+		}
+		if point.OwnerID != "offer1" && point.OwnerID != "request1" {
+			t.Errorf("Expected the point to belong to offer1 or request1 but got %s", point.OwnerID)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package offline
+
+import (
+	"fmt"
+	"matching-engine/internal/app/config"
+)
+
+// Config holds the speed profiles of the offline engine.
+// The detour factors stretch the great-circle distance to approximate the road network distance.
+type Config struct {
+	drivingSpeedKmh     float64
+	walkingSpeedKmh     float64
+	drivingDetourFactor float64
+	walkingDetourFactor float64
+	routeSpacingMeters  float64
+}
+
+func LoadConfig() (*Config, error) {
+	c := &Config{
+		drivingSpeedKmh:     config.GetEnvFloat("OFFLINE_DRIVING_SPEED_KMH", config.GetEnvFloat("FIXED_SPEED_KMH", 27)),
+		walkingSpeedKmh:     config.GetEnvFloat("OFFLINE_WALKING_SPEED_KMH", 5),
+		drivingDetourFactor: config.GetEnvFloat("OFFLINE_DRIVING_DETOUR_FACTOR", 1.3),
+		walkingDetourFactor: config.GetEnvFloat("OFFLINE_WALKING_DETOUR_FACTOR", 1.2),
+		routeSpacingMeters:  config.GetEnvFloat("OFFLINE_ROUTE_SPACING_METERS", 50),
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewConfig creates a configuration with the given speeds and detour factors
+func NewConfig(drivingSpeedKmh, walkingSpeedKmh, drivingDetourFactor, walkingDetourFactor float64) (*Config, error) {
+	c := &Config{
+		drivingSpeedKmh:     drivingSpeedKmh,
+		walkingSpeedKmh:     walkingSpeedKmh,
+		drivingDetourFactor: drivingDetourFactor,
+		walkingDetourFactor: walkingDetourFactor,
+		routeSpacingMeters:  50,
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.drivingSpeedKmh <= 0 || c.walkingSpeedKmh <= 0 {
+		return fmt.Errorf("speeds must be positive, got driving %f km/h and walking %f km/h", c.drivingSpeedKmh, c.walkingSpeedKmh)
+	}
+	if c.drivingDetourFactor < 1 || c.walkingDetourFactor < 1 {
+		return fmt.Errorf("detour factors must be at least 1, got driving %f and walking %f", c.drivingDetourFactor, c.walkingDetourFactor)
+	}
+	if c.routeSpacingMeters <= 0 {
+		return fmt.Errorf("route spacing must be positive, got %f", c.routeSpacingMeters)
+	}
+	return nil
+}
+
+func (c *Config) DrivingSpeedKmh() float64     { return c.drivingSpeedKmh }
+func (c *Config) WalkingSpeedKmh() float64     { return c.walkingSpeedKmh }
+func (c *Config) DrivingDetourFactor() float64 { return c.drivingDetourFactor }
+func (c *Config) WalkingDetourFactor() float64 { return c.walkingDetourFactor }
+func (c *Config) RouteSpacingMeters() float64  { return c.routeSpacingMeters }
@@ -0,0 +1,204 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"github.com/umahmood/haversine"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"math"
+	"time"
+)
+
+// isochroneVertices is the number of vertices of the synthetic isochrone ring
+const isochroneVertices = 64
+
+// Offline is a routing engine that needs no external service.
+// Travel times come from the great-circle distance stretched by a detour factor and a fixed speed per profile,
+// routes are straight lines through the waypoints and points are snapped to themselves.
+type Offline struct {
+	cfg *Config
+}
+
+func NewOffline() (re.Engine, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load offline engine config: %w", err)
+	}
+	return NewOfflineWithConfig(cfg), nil
+}
+
+func NewOfflineWithConfig(cfg *Config) re.Engine {
+	return &Offline{cfg: cfg}
+}
+
+func (o *Offline) PlanDrivingRoute(
+	ctx context.Context,
+	routeParams *model.RouteParams,
+) (*model.Route, error) {
+	if routeParams == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	waypoints := routeParams.Waypoints()
+
+	line := model.LineString{waypoints[0]}
+	var totalMeters float64
+	for i := 1; i < len(waypoints); i++ {
+		segmentMeters := distanceMeters(&waypoints[i-1], &waypoints[i])
+		totalMeters += segmentMeters
+		line = append(line, o.interpolate(&waypoints[i-1], &waypoints[i], segmentMeters)...)
+	}
+
+	polyline, err := model.NewPolylineFromCoordinates(line)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode route: %w", err)
+	}
+	roadMeters := totalMeters * o.cfg.drivingDetourFactor
+	distance, err := model.NewDistance(float32(roadMeters/1000), model.DistanceUnitKilometer)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewRoute(polyline, distance, o.travelTime(totalMeters, model.ProfileAuto))
+}
+
+func (o *Offline) ComputeDrivingTime(
+	ctx context.Context,
+	routeParams *model.RouteParams,
+) ([]time.Duration, error) {
+	if routeParams == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	waypoints := routeParams.Waypoints()
+
+	cumulativeDurations := make([]time.Duration, len(waypoints))
+	for i := 1; i < len(waypoints); i++ {
+		segmentMeters := distanceMeters(&waypoints[i-1], &waypoints[i])
+		cumulativeDurations[i] = cumulativeDurations[i-1] + o.travelTime(segmentMeters, model.ProfileAuto)
+	}
+	return cumulativeDurations, nil
+}
+
+func (o *Offline) ComputeWalkingTime(
+	ctx context.Context,
+	walkParams *model.WalkParams,
+) (time.Duration, error) {
+	if walkParams == nil {
+		return 0, fmt.Errorf("params cannot be nil")
+	}
+	meters := distanceMeters(walkParams.Origin(), walkParams.Destination())
+	return o.travelTime(meters, model.ProfilePedestrian), nil
+}
+
+// ComputeIsochrone returns a circle whose radius is the great-circle distance reachable within the contour
+func (o *Offline) ComputeIsochrone(
+	ctx context.Context,
+	req *model.IsochroneParams,
+) (*model.Isochrone, error) {
+	if req == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+
+	var radiusMeters float64
+	switch req.Contour().Metric() {
+	case model.ContourMetricTimeInMinutes:
+		minutes := float64(req.Contour().Value())
+		speedKmh, detourFactor := o.profileSpeed(req.Profile())
+		radiusMeters = speedKmh * 1000 * minutes / 60 / detourFactor
+	case model.ContourMetricDistanceInKilometers:
+		_, detourFactor := o.profileSpeed(req.Profile())
+		radiusMeters = float64(req.Contour().Value()) * 1000 / detourFactor
+	default:
+		return nil, fmt.Errorf("unsupported contour metric %q", req.Contour().Metric())
+	}
+
+	ring := circle(req.Origin(), radiusMeters)
+	return model.NewIsochrone(req.Contour(), &ring)
+}
+
+func (o *Offline) ComputeDistanceTimeMatrix(
+	ctx context.Context,
+	req *model.DistanceTimeMatrixParams,
+) (*model.DistanceTimeMatrix, error) {
+	if req == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	_, detourFactor := o.profileSpeed(req.Profile())
+
+	sources, targets := req.Sources(), req.Targets()
+	distanceMatrix := make([][]model.Distance, len(sources))
+	timeMatrix := make([][]time.Duration, len(sources))
+	for i := range sources {
+		distanceMatrix[i] = make([]model.Distance, len(targets))
+		timeMatrix[i] = make([]time.Duration, len(targets))
+		for j := range targets {
+			meters := distanceMeters(&sources[i], &targets[j])
+			distance, err := model.NewDistance(float32(meters*detourFactor), model.DistanceUnitMeter)
+			if err != nil {
+				return nil, err
+			}
+			distanceMatrix[i][j] = *distance
+			timeMatrix[i][j] = o.travelTime(meters, req.Profile())
+		}
+	}
+	return model.NewDistanceTimeMatrix(distanceMatrix, timeMatrix)
+}
+
+// SnapPointToRoad returns the point itself as there is no road network
+func (o *Offline) SnapPointToRoad(
+	ctx context.Context,
+	point *model.Coordinate,
+) (*model.Coordinate, error) {
+	return point, nil
+}
+
+// travelTime converts a great-circle distance to a travel time for the profile
+func (o *Offline) travelTime(meters float64, profile model.Profile) time.Duration {
+	speedKmh, detourFactor := o.profileSpeed(profile)
+	hours := meters * detourFactor / 1000 / speedKmh
+	return time.Duration(hours * float64(time.Hour))
+}
+
+func (o *Offline) profileSpeed(profile model.Profile) (speedKmh, detourFactor float64) {
+	if profile == model.ProfilePedestrian {
+		return o.cfg.walkingSpeedKmh, o.cfg.walkingDetourFactor
+	}
+	return o.cfg.drivingSpeedKmh, o.cfg.drivingDetourFactor
+}
+
+// interpolate returns points spaced along the segment so route based processing has enough vertices, the start is excluded
+func (o *Offline) interpolate(from, to *model.Coordinate, segmentMeters float64) model.LineString {
+	steps := int(math.Ceil(segmentMeters / o.cfg.routeSpacingMeters))
+	if steps < 1 {
+		steps = 1
+	}
+	points := make(model.LineString, 0, steps)
+	for step := 1; step <= steps; step++ {
+		fraction := float64(step) / float64(steps)
+		point, _ := model.NewCoordinate(from.Lat()+(to.Lat()-from.Lat())*fraction, from.Lng()+(to.Lng()-from.Lng())*fraction)
+		points = append(points, *point)
+	}
+	return points
+}
+
+func distanceMeters(from, to *model.Coordinate) float64 {
+	_, km := haversine.Distance(
+		haversine.Coord{Lat: from.Lat(), Lon: from.Lng()},
+		haversine.Coord{Lat: to.Lat(), Lon: to.Lng()},
+	)
+	return km * 1000
+}
+
+// circle approximates a circle around the center as a closed ring
+func circle(center *model.Coordinate, radiusMeters float64) model.LineString {
+	radiusDegrees := radiusMeters / 111319.5
+	ring := make(model.LineString, 0, isochroneVertices+1)
+	for i := 0; i < isochroneVertices; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(isochroneVertices)
+		lat := math.Max(-90, math.Min(90, center.Lat()+radiusDegrees*math.Sin(angle)))
+		lng := center.Lng() + radiusDegrees*math.Cos(angle)/math.Cos(center.Lat()*math.Pi/180)
+		lng = math.Mod(lng+540, 360) - 180
+		point, _ := model.NewCoordinate(lat, lng)
+		ring = append(ring, *point)
+	}
+	return append(ring, ring[0])
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"matching-engine/internal/adapter/offline"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"math"
+	"testing"
+	"time"
+)
+
+func newEngine(t *testing.T) routing.Engine {
+	// 36 km/h is 10 m/s and 3.6 km/h is 1 m/s, which keeps the expected times easy to compute
+	cfg, err := offline.NewConfig(36, 3.6, 1.5, 1.2)
+	if err != nil {
+		t.Fatalf("failed to create config: %v", err)
+	}
+	return offline.NewOfflineWithConfig(cfg)
+}
+
+func coordinate(t *testing.T, lat, lng float64) model.Coordinate {
+	c, err := model.NewCoordinate(lat, lng)
+	if err != nil {
+		t.Fatalf("invalid coordinate: %v", err)
+	}
+	return *c
+}
+
+// About 1112m between each point along the equator
+func equatorPoints(t *testing.T) []model.Coordinate {
+	return []model.Coordinate{coordinate(t, 0, 0), coordinate(t, 0, 0.01), coordinate(t, 0, 0.02)}
+}
+
+func assertDurationNear(t *testing.T, expected, actual time.Duration) {
+	t.Helper()
+	if math.Abs(float64(expected-actual)) > float64(time.Second) {
+		t.Errorf("expected about %v, got %v", expected, actual)
+	}
+}
+
+func TestOffline_DrivingAndWalkingTimes(t *testing.T) {
+	engine := newEngine(t)
+	points := equatorPoints(t)
+
+	params, err := model.NewRouteParams(points, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create route params: %v", err)
+	}
+	durations, err := engine.ComputeDrivingTime(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 1112m * 1.5 at 10 m/s
+	assertDurationNear(t, 0, durations[0])
+	assertDurationNear(t, 167*time.Second, durations[1])
+	assertDurationNear(t, 334*time.Second, durations[2])
+
+	walkParams, _ := model.NewWalkParams(&points[0], &points[1])
+	walkingTime, err := engine.ComputeWalkingTime(context.Background(), walkParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 1112m * 1.2 at 1 m/s
+	assertDurationNear(t, 1334*time.Second, walkingTime)
+
+	route, err := engine.PlanDrivingRoute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDurationNear(t, durations[2], route.Time())
+	coords, err := route.Polyline().Coordinates()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The route is densified between the waypoints and goes through all of them
+	if len(coords) <= len(points) {
+		t.Errorf("expected a densified route, got %d points", len(coords))
+	}
+	if !coords[0].Equal(&points[0]) || !coords[len(coords)-1].Equal(&points[2]) {
+		t.Errorf("route should start and end at the waypoints, got %v and %v", coords[0], coords[len(coords)-1])
+	}
+}
+
+func TestOffline_DistanceTimeMatrix(t *testing.T) {
+	engine := newEngine(t)
+	points := equatorPoints(t)
+
+	params, err := model.NewDistanceTimeMatrixParams(points[:1], model.ProfilePedestrian, model.WithTargets(points))
+	if err != nil {
+		t.Fatalf("failed to create matrix params: %v", err)
+	}
+	matrix, err := engine.ComputeDistanceTimeMatrix(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDurationNear(t, 0, matrix.Times()[0][0])
+	assertDurationNear(t, 1334*time.Second, matrix.Times()[0][1])
+	assertDurationNear(t, 2668*time.Second, matrix.Times()[0][2])
+}
+
+func TestOffline_IsochroneAndSnap(t *testing.T) {
+	engine := newEngine(t)
+	origin := coordinate(t, 30, 31)
+
+	contour, _ := model.NewContour(10, model.ContourMetricTimeInMinutes)
+	params, _ := model.NewIsochroneParams(&origin, contour, model.ProfilePedestrian)
+	isochrone, err := engine.ComputeIsochrone(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 600m walked in 10 minutes at 1 m/s, reduced by the 1.2 detour factor
+	for _, point := range *isochrone.Geometry() {
+		walkParams, _ := model.NewWalkParams(&origin, &point)
+		walkingTime, _ := engine.ComputeWalkingTime(context.Background(), walkParams)
+		if math.Abs(walkingTime.Minutes()-10) > 0.2 {
+			t.Fatalf("expected the ring to be about 10 minutes away, got %v", walkingTime)
+		}
+	}
+
+	snapped, err := engine.SnapPointToRoad(context.Background(), &origin)
+	if err != nil || !snapped.Equal(&origin) {
+		t.Errorf("expected the point itself, got %v (%v)", snapped, err)
+	}
+}
+
+// failingEngine fails every call, except for the snapping which is used to check the context handling
+type failingEngine struct {
+	routing.Engine
+	calls int
+}
+
+func (f *failingEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	f.calls++
+	return 0, errors.New("routing service unavailable")
+}
+
+func (f *failingEngine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	f.calls++
+	return nil, errors.New("routing service unavailable")
+}
+
+func TestFallbackEngine(t *testing.T) {
+	primary := &failingEngine{}
+	engine := routing.NewFallbackEngine(primary, newEngine(t))
+	points := equatorPoints(t)
+
+	walkParams, _ := model.NewWalkParams(&points[0], &points[1])
+	walkingTime, err := engine.ComputeWalkingTime(context.Background(), walkParams)
+	if err != nil {
+		t.Fatalf("expected the fallback engine to answer, got %v", err)
+	}
+	assertDurationNear(t, 1334*time.Second, walkingTime)
+	if primary.calls != 1 {
+		t.Errorf("expected the primary engine to be called once, got %d", primary.calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := engine.SnapPointToRoad(ctx, &points[0]); err == nil {
+		t.Error("expected the primary error when the context is cancelled")
+	}
+}
@@ -2,20 +2,36 @@ package ortool
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	re "matching-engine/internal/adapter/routing"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+const solveEndpoint = "/solve"
+
 type ORToolClient struct {
+	cfg    *Config
+	client re.Client[*ORToolData, *ORToolSolutionResponse]
+}
+
+// httpSolverClient is the bare transport to the python solver, ORToolClient wraps it with the resilience decorators
+type httpSolverClient struct {
 	cfg        *Config
 	httpClient *http.Client
 }
 
+var _ re.Client[
+	*ORToolData,
+	*ORToolSolutionResponse,
+] = (*httpSolverClient)(nil)
+
 func NewORToolClient() (*ORToolClient, error) {
 	cfg, err := LoadConfig()
 	if err != nil {
@@ -30,7 +46,21 @@ func NewORToolClient() (*ORToolClient, error) {
 		},
 	}
 
-	client := &ORToolClient{cfg: cfg, httpClient: httpClient}
+	resilience, err := re.LoadResilienceConfig("ORTOOL", defaultResilienceConfig())
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to load resilience configuration for ORToolClient")
+		return nil, fmt.Errorf("failed to load resilience config: %w", err)
+	}
+
+	client := &ORToolClient{
+		cfg: cfg,
+		client: re.NewResilientClient[*ORToolData, *ORToolSolutionResponse](
+			&httpSolverClient{cfg: cfg, httpClient: httpClient},
+			resilience,
+		),
+	}
 
 	baseURL := client.cfg.ORToolURL()
 	if _, err := url.ParseRequestURI(baseURL); err != nil {
@@ -48,7 +78,24 @@ func NewORToolClient() (*ORToolClient, error) {
 	return client, nil
 }
 
+// defaultResilienceConfig allows a longer timeout than the routing backends as a solve may search for a while
+func defaultResilienceConfig() *re.ResilienceConfig {
+	cfg := re.DefaultResilienceConfig()
+	cfg.Timeout = 30 * time.Second
+	return cfg
+}
+
 func (orc *ORToolClient) CallPythonORToolSolver(payload *ORToolData) (*ORToolSolutionResponse, error) {
+	return orc.Solve(context.Background(), payload)
+}
+
+func (orc *ORToolClient) Solve(ctx context.Context, payload *ORToolData) (*ORToolSolutionResponse, error) {
+	return orc.client.Post(ctx, solveEndpoint, payload)
+}
+
+func (sc *httpSolverClient) Post(ctx context.Context, endpoint string, payload *ORToolData) (*ORToolSolutionResponse, error) {
+	solverURL := sc.cfg.ORToolBaseURL() + endpoint
+
 	// Marshal data
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -59,15 +106,16 @@ func (orc *ORToolClient) CallPythonORToolSolver(payload *ORToolData) (*ORToolSol
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
-		orc.cfg.ORToolURL(),
+		solverURL,
 		bytes.NewReader(body),
 	)
 	if err != nil {
 		log.Error().
 			Err(err).
-			Str("url", orc.cfg.ORToolURL()).
+			Str("url", solverURL).
 			Msg("Failed to create HTTP request for ORTool solver")
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -75,11 +123,11 @@ func (orc *ORToolClient) CallPythonORToolSolver(payload *ORToolData) (*ORToolSol
 	req.Header.Set("Content-Type", "application/json")
 
 	// Use the configured httpClient with connection pooling
-	resp, err := orc.httpClient.Do(req)
+	resp, err := sc.httpClient.Do(req)
 	if err != nil {
 		log.Error().
 			Err(err).
-			Str("url", orc.cfg.ORToolURL()).
+			Str("url", solverURL).
 			Msg("Failed to send HTTP POST request to ORTool solver")
 		return nil, fmt.Errorf("failed to call VRP solver: %w", err)
 	}
@@ -98,15 +146,21 @@ func (orc *ORToolClient) CallPythonORToolSolver(payload *ORToolData) (*ORToolSol
 		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
 		log.Error().
 			Int("status", resp.StatusCode).
-			Str("url", orc.cfg.ORToolURL()).
+			Str("url", solverURL).
 			Bytes("body_snippet", snippet).
 			Msg("Received non-success HTTP response from ORTool solver")
 
 		var errMsg map[string]any
 		if json.Unmarshal(snippet, &errMsg) == nil {
-			return nil, fmt.Errorf("VRP solver error (%d): %v", resp.StatusCode, errMsg)
+			err = fmt.Errorf("VRP solver error (%d): %v", resp.StatusCode, errMsg)
+		} else {
+			err = fmt.Errorf("VRP solver error (%d): %s", resp.StatusCode, string(snippet))
+		}
+		// The solver rejected the payload, sending it again gives the same answer
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, re.Permanent(err)
 		}
-		return nil, fmt.Errorf("VRP solver error (%d): %s", resp.StatusCode, string(snippet))
+		return nil, err
 	}
 
 	// Read response body
@@ -114,7 +168,7 @@ func (orc *ORToolClient) CallPythonORToolSolver(payload *ORToolData) (*ORToolSol
 	if err != nil {
 		log.Error().
 			Err(err).
-			Str("url", orc.cfg.ORToolURL()).
+			Str("url", solverURL).
 			Msg("Failed to read ORTool solver response body")
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
@@ -124,14 +178,14 @@ func (orc *ORToolClient) CallPythonORToolSolver(payload *ORToolData) (*ORToolSol
 	if err := json.Unmarshal(responseBody, &solution); err != nil {
 		log.Error().
 			Err(err).
-			Str("url", orc.cfg.ORToolURL()).
+			Str("url", solverURL).
 			Bytes("response_snippet", responseBody[:min(len(responseBody), 256)]).
 			Msg("Failed to decode ORTool solver response")
 		return nil, fmt.Errorf("failed to decode solver response: %w", err)
 	}
 
 	log.Debug().
-		Str("url", orc.cfg.ORToolURL()).
+		Str("url", solverURL).
 		Int("status", resp.StatusCode).
 		Msg("Successfully received and processed response from ORTool solver")
 
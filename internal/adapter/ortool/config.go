@@ -38,6 +38,9 @@ func defaultConfig() *Config {
 
 func (c *Config) ORToolHost() string { return c.host }
 func (c *Config) ORToolPort() int    { return c.port }
+func (c *Config) ORToolBaseURL() string {
+	return fmt.Sprintf("http://%s:%d", c.host, c.port)
+}
 func (c *Config) ORToolURL() string {
-	return fmt.Sprintf("http://%s:%d/solve", c.host, c.port)
+	return c.ORToolBaseURL() + solveEndpoint
 }
@@ -26,6 +26,8 @@ type ORToolData struct {
 	VehicleCapacity         int      `json:"vehicle_capacity"`
 	PickupAndDropoffs       [][2]int `json:"pickup_and_dropoffs"`
 	MaxRouteDuration        int      `json:"max_route_duration,omitempty"`
+	CapacityDemands         [][]int  `json:"capacity_demands,omitempty"`           // Demand of every node on every capacity dimension (seats, luggage, wheelchairs, child seats)
+	VehicleCapacities       []int    `json:"vehicle_capacities,omitempty"`         // Vehicle capacity on every capacity dimension, in the same order as the demands
 	Timeout                 int      `json:"timeout,omitempty"`                    // Timeout in milliseconds for the OR-Tools solver
 	Method                  string   `json:"method,omitempty"`                     // Method to use for solving, e.g., "parallel_cheapest_insertion" or :path_cheapest_arc" or "global_cheapest_arch" or ...
 	EnableGuidedLocalSearch bool     `json:"enable_guided_local_search,omitempty"` // Enable guided local search for the solver
@@ -52,3 +54,9 @@ func (s *ORToolData) SetTimeout(timeout int) {
 func (s *ORToolData) SetEnableGuidedLocalSearch(enable bool) {
 	s.EnableGuidedLocalSearch = enable
 }
+
+// SetCapacityDimensions sets the demands and the vehicle capacities of every capacity dimension
+func (s *ORToolData) SetCapacityDimensions(capacityDemands [][]int, vehicleCapacities []int) {
+	s.CapacityDemands = capacityDemands
+	s.VehicleCapacities = vehicleCapacities
+}
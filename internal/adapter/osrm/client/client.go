@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const okCode = "Ok"
+
+type OSRMClient struct {
+	cfg        *Config
+	httpClient *http.Client
+}
+
+type Option func(*OSRMClient)
+
+func WithConfig(c *Config) Option {
+	return func(oc *OSRMClient) {
+		if c != nil {
+			oc.cfg = c
+		}
+	}
+}
+
+func NewOSRMClient(opts ...Option) (*OSRMClient, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to load configuration for OSRMClient")
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	oc := &OSRMClient{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				MaxIdleConnsPerHost: 50,
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(oc)
+	}
+
+	for _, baseURL := range []string{oc.cfg.OSRMURL(), oc.cfg.WalkingOSRMURL()} {
+		if _, err = url.ParseRequestURI(baseURL); err != nil {
+			log.Error().
+				Err(err).
+				Str("baseURL", baseURL).
+				Msg("Invalid base URL format provided in configuration")
+			return nil, fmt.Errorf("invalid base URL: %w", err)
+		}
+	}
+
+	log.Info().
+		Str("baseURL", oc.cfg.OSRMURL()).
+		Str("walkingBaseURL", oc.cfg.WalkingOSRMURL()).
+		Msg("OSRMClient initialized with the following base URLs")
+
+	return oc, nil
+}
+
+// MaxTableSize returns the most sources and the most destinations accepted in a single table request
+func (oc *OSRMClient) MaxTableSize() int {
+	return oc.cfg.MaxTableSize()
+}
+
+var _ re.Client[
+	*Request,
+	*Response,
+] = (*OSRMClient)(nil)
+
+// Post sends the request to the given OSRM service.
+// The OSRM HTTP API only accepts GET requests, so the request is encoded in the URL.
+func (oc *OSRMClient) Post(ctx context.Context, service string, request *Request) (*Response, error) {
+	requestURL, err := oc.buildURL(service, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request url: %w", err)
+	}
+
+	body, err := oc.doGet(ctx, service, requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	response := &Response{}
+	if err = json.Unmarshal(body, response); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to unmarshal response body from json format")
+		return nil, fmt.Errorf("failed to deserialize response: %w", err)
+	}
+
+	if response.Code != okCode {
+		// Codes such as NoRoute or InvalidQuery describe the request, not the state of the server
+		return nil, re.Permanent(fmt.Errorf("osrm %s returned code %s: %s", service, response.Code, response.Message))
+	}
+
+	return response, nil
+}
+
+func (oc *OSRMClient) buildURL(service string, request *Request) (string, error) {
+	if request == nil || len(request.Coordinates) == 0 {
+		return "", fmt.Errorf("request has no coordinates")
+	}
+
+	baseURL, profile := oc.cfg.OSRMURL(), oc.cfg.DrivingProfile()
+	if request.Profile == model.ProfilePedestrian {
+		baseURL, profile = oc.cfg.WalkingOSRMURL(), oc.cfg.WalkingProfile()
+	}
+
+	coordinates := make([]string, len(request.Coordinates))
+	for i, c := range request.Coordinates {
+		coordinates[i] = strconv.FormatFloat(c.Lng(), 'f', 6, 64) + "," + strconv.FormatFloat(c.Lat(), 'f', 6, 64)
+	}
+
+	// Keep the options sorted so the same request always gives the same url
+	keys := make([]string, 0, len(request.Options))
+	for key := range request.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	query := make([]string, len(keys))
+	for i, key := range keys {
+		query[i] = url.QueryEscape(key) + "=" + url.QueryEscape(request.Options[key])
+	}
+
+	requestURL := fmt.Sprintf("%s/%s/v1/%s/%s", baseURL, service, profile, strings.Join(coordinates, ";"))
+	if len(query) > 0 {
+		requestURL += "?" + strings.Join(query, "&")
+	}
+	return requestURL, nil
+}
+
+func (oc *OSRMClient) doGet(ctx context.Context, service, requestURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := oc.httpClient.Do(req)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("service", service).
+			Msg("HTTP GET request failed")
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Msg("Failed to close the response body")
+		}
+	}(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("service", service).
+			Msg("Failed to read response body")
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	// OSRM reports errors such as NoRoute with a 400 status and a json body holding the code
+	if resp.StatusCode >= 500 || (resp.StatusCode >= 300 && !json.Valid(body)) {
+		snippet := body
+		if len(snippet) > 1024 {
+			snippet = snippet[:1024]
+		}
+		log.Error().
+			Int("status", resp.StatusCode).
+			Str("service", service).
+			Bytes("body_snippet", snippet).
+			Msg("Received non-success HTTP response")
+		return nil, fmt.Errorf(
+			"unexpected HTTP status %d from %s: %q",
+			resp.StatusCode, service, snippet,
+		)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// DefaultMaxTableSize is the default --max-table-size of osrm-routed
+const DefaultMaxTableSize = 100
+
+// Config holds the OSRM servers configuration.
+// OSRM serves a single profile per server, so walking requests can be sent to a separate server.
+type Config struct {
+	host           string
+	port           int
+	walkingHost    string
+	walkingPort    int
+	drivingProfile string
+	walkingProfile string
+	maxTableSize   int // Most sources and most destinations of a table request, the --max-table-size of the server
+}
+
+func LoadConfig() (*Config, error) {
+	c := defaultConfig()
+
+	if v, ok := os.LookupEnv("OSRM_HOST"); ok && v != "" {
+		c.host = v
+		c.walkingHost = v
+	}
+
+	if v, ok := os.LookupEnv("OSRM_PORT"); ok && v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OSRM_PORT %q: %w", v, err)
+		}
+		c.port = p
+		c.walkingPort = p
+	}
+
+	if v, ok := os.LookupEnv("OSRM_WALKING_HOST"); ok && v != "" {
+		c.walkingHost = v
+	}
+
+	if v, ok := os.LookupEnv("OSRM_WALKING_PORT"); ok && v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OSRM_WALKING_PORT %q: %w", v, err)
+		}
+		c.walkingPort = p
+	}
+
+	if v, ok := os.LookupEnv("OSRM_DRIVING_PROFILE"); ok && v != "" {
+		c.drivingProfile = v
+	}
+
+	if v, ok := os.LookupEnv("OSRM_WALKING_PROFILE"); ok && v != "" {
+		c.walkingProfile = v
+	}
+
+	if v, ok := os.LookupEnv("OSRM_MAX_TABLE_SIZE"); ok && v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid OSRM_MAX_TABLE_SIZE %q", v)
+		}
+		c.maxTableSize = size
+	}
+
+	return c, nil
+}
+
+// NewConfig creates a configuration with the same server for driving and walking requests
+func NewConfig(host string, port int) *Config {
+	c := defaultConfig()
+	c.host, c.walkingHost = host, host
+	c.port, c.walkingPort = port, port
+	return c
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		host:           "localhost",
+		port:           5000,
+		walkingHost:    "localhost",
+		walkingPort:    5000,
+		drivingProfile: "driving",
+		walkingProfile: "foot",
+		maxTableSize:   DefaultMaxTableSize,
+	}
+}
+
+func (c *Config) OSRMURL() string {
+	return fmt.Sprintf("http://%s:%d", c.host, c.port)
+}
+
+func (c *Config) WalkingOSRMURL() string {
+	return fmt.Sprintf("http://%s:%d", c.walkingHost, c.walkingPort)
+}
+
+func (c *Config) DrivingProfile() string { return c.drivingProfile }
+func (c *Config) WalkingProfile() string { return c.walkingProfile }
+func (c *Config) MaxTableSize() int      { return c.maxTableSize }
+
+// WithMaxTableSize returns a copy of the configuration splitting the table requests into chunks of the given size
+func (c *Config) WithMaxTableSize(size int) *Config {
+	copied := *c
+	copied.maxTableSize = size
+	return &copied
+}
@@ -0,0 +1,39 @@
+package client
+
+import "matching-engine/internal/model"
+
+// Request is an OSRM HTTP API request, the service (route, table, nearest) is given as the endpoint
+type Request struct {
+	Profile     model.Profile
+	Coordinates []model.Coordinate
+	Options     map[string]string
+}
+
+// Response is the subset of the OSRM HTTP API response used by the engine
+type Response struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Routes    []Route      `json:"routes"`
+	Durations [][]*float64 `json:"durations"`
+	Distances [][]*float64 `json:"distances"`
+	Waypoints []Waypoint   `json:"waypoints"`
+}
+
+type Route struct {
+	Geometry string  `json:"geometry"`
+	Distance float64 `json:"distance"`
+	Duration float64 `json:"duration"`
+	Legs     []Leg   `json:"legs"`
+}
+
+type Leg struct {
+	Distance float64 `json:"distance"`
+	Duration float64 `json:"duration"`
+}
+
+type Waypoint struct {
+	// Location is the snapped location as [longitude, latitude]
+	Location []float64 `json:"location"`
+	Distance float64   `json:"distance"`
+	Name     string    `json:"name"`
+}
@@ -0,0 +1,186 @@
+package osrm
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/adapter/osrm/client"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+)
+
+// ErrIsochroneNotSupported is returned by ComputeIsochrone as OSRM has no isochrone service
+var ErrIsochroneNotSupported = fmt.Errorf("%w by OSRM", re.ErrIsochroneNotSupported)
+
+type OSRM struct {
+	client       re.Client[*client.Request, *client.Response]
+	mapper       *Mapper
+	maxTableSize int
+}
+
+func NewOSRM(clientOpts ...client.Option) (re.Engine, error) {
+	c, err := client.NewOSRMClient(clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create osrm client: %w", err)
+	}
+
+	resilience, err := re.LoadResilienceConfig("OSRM", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load osrm resilience config: %w", err)
+	}
+
+	return &OSRM{
+		client: re.NewResilientClient[*client.Request, *client.Response](c, resilience),
+		mapper:       NewMapper(),
+		maxTableSize: c.MaxTableSize(),
+	}, nil
+}
+
+func (o *OSRM) PlanDrivingRoute(
+	ctx context.Context,
+	routeParams *model.RouteParams,
+) (*model.Route, error) {
+	route, err := re.RunOperation(
+		ctx,
+		o.client,
+		"route",
+		routeParams,
+		o.mapper.RouteMapper,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute route: %w", err)
+	}
+
+	return route, nil
+}
+
+func (o *OSRM) ComputeDrivingTime(
+	ctx context.Context,
+	routeParams *model.RouteParams,
+) ([]time.Duration, error) {
+	durations, err := re.RunOperation(
+		ctx,
+		o.client,
+		"route",
+		routeParams,
+		o.mapper.DrivingTimeMapper,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute driving time: %w", err)
+	}
+
+	return durations, nil
+}
+
+func (o *OSRM) ComputeWalkingTime(
+	ctx context.Context,
+	walkParams *model.WalkParams,
+) (time.Duration, error) {
+	duration, err := re.RunOperation(
+		ctx,
+		o.client,
+		"route",
+		walkParams,
+		o.mapper.WalkingTimeMapper,
+	)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute time: %w", err)
+	}
+
+	return duration, nil
+}
+
+func (o *OSRM) ComputeIsochrone(
+	ctx context.Context,
+	req *model.IsochroneParams,
+) (*model.Isochrone, error) {
+	return nil, ErrIsochroneNotSupported
+}
+
+func (o *OSRM) ComputeDistanceTimeMatrix(
+	ctx context.Context,
+	req *model.DistanceTimeMatrixParams,
+) (*model.DistanceTimeMatrix, error) {
+	sources, targets := req.Sources(), req.Targets()
+	if len(sources) <= o.maxTableSize && len(targets) <= o.maxTableSize {
+		matrix, err := re.RunOperation(
+			ctx,
+			o.client,
+			"table",
+			req,
+			o.mapper.MatrixMapper,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute distance time matrix: %w", err)
+		}
+
+		return matrix, nil
+	}
+
+	// The server rejects the tables larger than its --max-table-size, so the matrix is computed by blocks
+	distances := make([][]model.Distance, len(sources))
+	times := make([][]time.Duration, len(sources))
+	for i := range sources {
+		distances[i] = make([]model.Distance, len(targets))
+		times[i] = make([]time.Duration, len(targets))
+	}
+	for sourceStart := 0; sourceStart < len(sources); sourceStart += o.maxTableSize {
+		sourceEnd := min(sourceStart+o.maxTableSize, len(sources))
+		for targetStart := 0; targetStart < len(targets); targetStart += o.maxTableSize {
+			targetEnd := min(targetStart+o.maxTableSize, len(targets))
+
+			// OSRM ignores the departure time, the block keeps the default one
+			block, err := model.NewDistanceTimeMatrixParams(sources[sourceStart:sourceEnd], req.Profile(),
+				model.WithTargets(targets[targetStart:targetEnd]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create distance time matrix block: %w", err)
+			}
+			matrix, err := re.RunOperation(
+				ctx,
+				o.client,
+				"table",
+				block,
+				o.mapper.MatrixMapper,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute distance time matrix: %w", err)
+			}
+			if len(matrix.Times()) != sourceEnd-sourceStart || len(matrix.Times()[0]) != targetEnd-targetStart {
+				return nil, fmt.Errorf("distance time matrix block has %dx%d entries instead of %dx%d",
+					len(matrix.Times()), len(matrix.Times()[0]), sourceEnd-sourceStart, targetEnd-targetStart)
+			}
+			for i := range matrix.Times() {
+				copy(distances[sourceStart+i][targetStart:], matrix.Distances()[i])
+				copy(times[sourceStart+i][targetStart:], matrix.Times()[i])
+			}
+		}
+	}
+
+	return model.NewDistanceTimeMatrix(distances, times)
+}
+
+func (o *OSRM) SnapPointToRoad(
+	ctx context.Context,
+	point *model.Coordinate,
+) (*model.Coordinate, error) {
+	snappedPoint, err := re.RunOperation(
+		ctx,
+		o.client,
+		"nearest",
+		point,
+		o.mapper.SnapToRoadMapper,
+	)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to snap point to road")
+		log.Info().Msg("Returning original point as fallback")
+		return point, nil
+	}
+
+	return snappedPoint, nil
+}
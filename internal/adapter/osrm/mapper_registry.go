@@ -0,0 +1,27 @@
+package osrm
+
+import (
+	"matching-engine/internal/adapter/osrm/client"
+	"matching-engine/internal/adapter/osrm/mappers"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+)
+
+type Mapper struct {
+	RouteMapper       re.OperationMapper[*model.RouteParams, *model.Route, *client.Request, *client.Response]
+	DrivingTimeMapper re.OperationMapper[*model.RouteParams, []time.Duration, *client.Request, *client.Response]
+	WalkingTimeMapper re.OperationMapper[*model.WalkParams, time.Duration, *client.Request, *client.Response]
+	MatrixMapper      re.OperationMapper[*model.DistanceTimeMatrixParams, *model.DistanceTimeMatrix, *client.Request, *client.Response]
+	SnapToRoadMapper  re.OperationMapper[*model.Coordinate, *model.Coordinate, *client.Request, *client.Response]
+}
+
+func NewMapper() *Mapper {
+	return &Mapper{
+		RouteMapper:       mappers.RouteMapper{},
+		DrivingTimeMapper: mappers.DrivingTimeMapper{},
+		WalkingTimeMapper: mappers.WalkingTimeMapper{},
+		MatrixMapper:      mappers.MatrixMapper{},
+		SnapToRoadMapper:  mappers.SnapToRoadMapper{},
+	}
+}
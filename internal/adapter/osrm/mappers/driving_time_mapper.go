@@ -0,0 +1,50 @@
+package mappers
+
+import (
+	"fmt"
+	"matching-engine/internal/adapter/osrm/client"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+)
+
+// DrivingTimeMapper computes the cumulative duration at each waypoint from the legs of a single route
+type DrivingTimeMapper struct{}
+
+var _ re.OperationMapper[
+	*model.RouteParams,
+	[]time.Duration,
+	*client.Request,
+	*client.Response,
+] = DrivingTimeMapper{}
+
+func (DrivingTimeMapper) ToTransport(params *model.RouteParams) (*client.Request, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+
+	return &client.Request{
+		Profile:     model.ProfileAuto,
+		Coordinates: params.Waypoints(),
+		Options: map[string]string{
+			"overview": "false",
+			"steps":    "false",
+		},
+	}, nil
+}
+
+func (DrivingTimeMapper) FromTransport(response *client.Response) ([]time.Duration, error) {
+	route, err := firstRoute(response)
+	if err != nil {
+		return nil, err
+	}
+
+	cumulativeDurations := make([]time.Duration, len(route.Legs)+1)
+	for i, leg := range route.Legs {
+		if leg.Duration < 0 {
+			return nil, fmt.Errorf("negative duration found between points %d and %d", i, i+1)
+		}
+		cumulativeDurations[i+1] = cumulativeDurations[i] + secondsToDuration(leg.Duration)
+	}
+	return cumulativeDurations, nil
+}
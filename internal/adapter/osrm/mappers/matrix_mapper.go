@@ -0,0 +1,91 @@
+package mappers
+
+import (
+	"fmt"
+	"matching-engine/internal/adapter/osrm/client"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type MatrixMapper struct{}
+
+var _ re.OperationMapper[
+	*model.DistanceTimeMatrixParams,
+	*model.DistanceTimeMatrix,
+	*client.Request,
+	*client.Response,
+] = MatrixMapper{}
+
+// ToTransport sends the sources followed by the targets, and selects them with the sources and destinations indices
+func (MatrixMapper) ToTransport(params *model.DistanceTimeMatrixParams) (*client.Request, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+
+	sources := params.Sources()
+	targets := params.Targets()
+
+	coordinates := make([]model.Coordinate, 0, len(sources)+len(targets))
+	coordinates = append(coordinates, sources...)
+	coordinates = append(coordinates, targets...)
+
+	return &client.Request{
+		Profile:     params.Profile(),
+		Coordinates: coordinates,
+		Options: map[string]string{
+			"sources":      indexRange(0, len(sources)),
+			"destinations": indexRange(len(sources), len(sources)+len(targets)),
+			"annotations":  "duration,distance",
+		},
+	}, nil
+}
+
+func (MatrixMapper) FromTransport(response *client.Response) (*model.DistanceTimeMatrix, error) {
+	if response == nil {
+		return nil, fmt.Errorf("response cannot be nil")
+	}
+	if len(response.Durations) == 0 {
+		return nil, fmt.Errorf("durations are empty")
+	}
+	if len(response.Distances) != len(response.Durations) {
+		return nil, fmt.Errorf("distances and durations have different sizes")
+	}
+
+	distanceMatrix := make([][]model.Distance, len(response.Durations))
+	timeMatrix := make([][]time.Duration, len(response.Durations))
+
+	for i, row := range response.Durations {
+		if len(response.Distances[i]) != len(row) {
+			return nil, fmt.Errorf("distances and durations have different sizes at row %d", i)
+		}
+		distanceMatrix[i] = make([]model.Distance, len(row))
+		timeMatrix[i] = make([]time.Duration, len(row))
+
+		for j, duration := range row {
+			// OSRM returns null when no route is found between two points
+			if duration == nil || response.Distances[i][j] == nil {
+				return nil, fmt.Errorf("no route found from source %d to target %d", i, j)
+			}
+			distance, err := model.NewDistance(float32(*response.Distances[i][j]), model.DistanceUnitMeter)
+			if err != nil {
+				return nil, err
+			}
+			distanceMatrix[i][j] = *distance
+			timeMatrix[i][j] = secondsToDuration(*duration)
+		}
+	}
+
+	return model.NewDistanceTimeMatrix(distanceMatrix, timeMatrix)
+}
+
+// indexRange formats the indices in [from, to) as expected by the OSRM table service
+func indexRange(from, to int) string {
+	indices := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		indices = append(indices, strconv.Itoa(i))
+	}
+	return strings.Join(indices, ";")
+}
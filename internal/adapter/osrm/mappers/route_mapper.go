@@ -0,0 +1,72 @@
+package mappers
+
+import (
+	"fmt"
+	"matching-engine/internal/adapter/osrm/client"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+)
+
+type RouteMapper struct{}
+
+var _ re.OperationMapper[
+	*model.RouteParams,
+	*model.Route,
+	*client.Request,
+	*client.Response,
+] = RouteMapper{}
+
+func (RouteMapper) ToTransport(params *model.RouteParams) (*client.Request, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+
+	return &client.Request{
+		Profile:     model.ProfileAuto,
+		Coordinates: params.Waypoints(),
+		Options:     routeOptions(),
+	}, nil
+}
+
+func (RouteMapper) FromTransport(response *client.Response) (*model.Route, error) {
+	route, err := firstRoute(response)
+	if err != nil {
+		return nil, err
+	}
+
+	polyline, err := model.NewPolyline(route.Geometry)
+	if err != nil {
+		return nil, err
+	}
+
+	distance, err := model.NewDistance(float32(route.Distance/1000), model.DistanceUnitKilometer)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewRoute(polyline, distance, secondsToDuration(route.Duration))
+}
+
+// routeOptions asks for the full geometry with the same polyline precision as model.Polyline
+func routeOptions() map[string]string {
+	return map[string]string{
+		"overview":   "full",
+		"geometries": "polyline6",
+		"steps":      "false",
+	}
+}
+
+func firstRoute(response *client.Response) (*client.Route, error) {
+	if response == nil {
+		return nil, fmt.Errorf("response cannot be nil")
+	}
+	if len(response.Routes) == 0 {
+		return nil, fmt.Errorf("response has no routes")
+	}
+	return &response.Routes[0], nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
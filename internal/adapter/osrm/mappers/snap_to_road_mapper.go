@@ -0,0 +1,46 @@
+package mappers
+
+import (
+	"fmt"
+	"matching-engine/internal/adapter/osrm/client"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+)
+
+type SnapToRoadMapper struct{}
+
+var _ re.OperationMapper[
+	*model.Coordinate,
+	*model.Coordinate,
+	*client.Request,
+	*client.Response,
+] = SnapToRoadMapper{}
+
+func (SnapToRoadMapper) ToTransport(point *model.Coordinate) (*client.Request, error) {
+	if point == nil {
+		return nil, fmt.Errorf("point cannot be nil")
+	}
+
+	return &client.Request{
+		Profile:     model.ProfileAuto,
+		Coordinates: []model.Coordinate{*point},
+		Options: map[string]string{
+			"number": "1",
+		},
+	}, nil
+}
+
+func (SnapToRoadMapper) FromTransport(response *client.Response) (*model.Coordinate, error) {
+	if response == nil {
+		return nil, fmt.Errorf("response cannot be nil")
+	}
+	if len(response.Waypoints) == 0 {
+		return nil, fmt.Errorf("response has no waypoints")
+	}
+
+	location := response.Waypoints[0].Location
+	if len(location) != 2 {
+		return nil, fmt.Errorf("invalid waypoint location %v", location)
+	}
+	return model.NewCoordinate(location[1], location[0])
+}
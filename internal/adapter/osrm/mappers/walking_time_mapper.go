@@ -0,0 +1,41 @@
+package mappers
+
+import (
+	"fmt"
+	"matching-engine/internal/adapter/osrm/client"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+)
+
+type WalkingTimeMapper struct{}
+
+var _ re.OperationMapper[
+	*model.WalkParams,
+	time.Duration,
+	*client.Request,
+	*client.Response,
+] = WalkingTimeMapper{}
+
+func (WalkingTimeMapper) ToTransport(params *model.WalkParams) (*client.Request, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+
+	return &client.Request{
+		Profile:     model.ProfilePedestrian,
+		Coordinates: []model.Coordinate{*params.Origin(), *params.Destination()},
+		Options: map[string]string{
+			"overview": "false",
+			"steps":    "false",
+		},
+	}, nil
+}
+
+func (WalkingTimeMapper) FromTransport(response *client.Response) (time.Duration, error) {
+	route, err := firstRoute(response)
+	if err != nil {
+		return 0, err
+	}
+	return secondsToDuration(route.Duration), nil
+}
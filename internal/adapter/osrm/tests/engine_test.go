@@ -0,0 +1,316 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"matching-engine/internal/adapter/osrm"
+	"matching-engine/internal/adapter/osrm/client"
+	"matching-engine/internal/model"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubOSRM is a local OSRM stub that records the requested paths and answers with canned responses
+type stubOSRM struct {
+	server *httptest.Server
+	paths  []string
+	// responses maps the service (route, table, nearest) to the response body
+	responses map[string]any
+}
+
+func newStubOSRM(t *testing.T, responses map[string]any) *stubOSRM {
+	stub := &stubOSRM{responses: responses}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.paths = append(stub.paths, r.URL.RequestURI())
+		service := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		response, ok := stub.responses[service]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"code": "InvalidService", "message": service})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func (s *stubOSRM) engine(t *testing.T) *osrm.OSRM {
+	host, portStr, err := net.SplitHostPort(strings.TrimPrefix(s.server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("invalid stub url: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	engine, err := osrm.NewOSRM(client.WithConfig(client.NewConfig(host, port)))
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	return engine.(*osrm.OSRM)
+}
+
+func coordinate(t *testing.T, lat, lng float64) model.Coordinate {
+	c, err := model.NewCoordinate(lat, lng)
+	if err != nil {
+		t.Fatalf("invalid coordinate: %v", err)
+	}
+	return *c
+}
+
+func TestOSRM_PlanDrivingRouteAndDrivingTime(t *testing.T) {
+	stub := newStubOSRM(t, map[string]any{
+		"route": map[string]any{
+			"code": "Ok",
+			"routes": []map[string]any{{
+				// polyline6 of (42.5078, 1.5211) -> (42.5057, 1.5265)
+				"geometry": "ca~ypAa|fz@`hBkkI",
+				"distance": 1500.0,
+				"duration": 240.0,
+				"legs": []map[string]any{
+					{"distance": 500.0, "duration": 90.0},
+					{"distance": 1000.0, "duration": 150.0},
+				},
+			}},
+		},
+	})
+	engine := stub.engine(t)
+
+	params, err := model.NewRouteParams([]model.Coordinate{
+		coordinate(t, 42.5078, 1.5211),
+		coordinate(t, 42.5036, 1.5148),
+		coordinate(t, 42.5057, 1.5265),
+	}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create route params: %v", err)
+	}
+
+	route, err := engine.PlanDrivingRoute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if route.Time() != 240*time.Second {
+		t.Errorf("expected 240s, got %v", route.Time())
+	}
+	if route.Distance().Value() != 1.5 || route.Distance().Unit() != model.DistanceUnitKilometer {
+		t.Errorf("expected 1.5 km, got %v", route.Distance())
+	}
+	coords, err := route.Polyline().Coordinates()
+	if err != nil || len(coords) != 2 {
+		t.Fatalf("expected 2 decoded coordinates, got %v (%v)", coords, err)
+	}
+
+	durations, err := engine.ComputeDrivingTime(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []time.Duration{0, 90 * time.Second, 240 * time.Second}
+	for i := range expected {
+		if durations[i] != expected[i] {
+			t.Errorf("expected cumulative durations %v, got %v", expected, durations)
+			break
+		}
+	}
+
+	// OSRM expects lng,lat pairs and the driving profile
+	if !strings.HasPrefix(stub.paths[0], "/route/v1/driving/1.521100,42.507800;1.514800,42.503600;1.526500,42.505700?") {
+		t.Errorf("unexpected request path %s", stub.paths[0])
+	}
+	if !strings.Contains(stub.paths[0], "geometries=polyline6") {
+		t.Errorf("expected polyline6 geometries in %s", stub.paths[0])
+	}
+}
+
+func TestOSRM_ComputeDistanceTimeMatrix(t *testing.T) {
+	stub := newStubOSRM(t, map[string]any{
+		"table": map[string]any{
+			"code":      "Ok",
+			"durations": [][]float64{{60, 120, 180}},
+			"distances": [][]float64{{100, 200, 300}},
+		},
+	})
+	engine := stub.engine(t)
+
+	params, err := model.NewDistanceTimeMatrixParams(
+		[]model.Coordinate{coordinate(t, 42.5078, 1.5211)},
+		model.ProfilePedestrian,
+		model.WithTargets([]model.Coordinate{
+			coordinate(t, 42.5057, 1.5265),
+			coordinate(t, 42.5036, 1.5148),
+			coordinate(t, 42.5083, 1.5353),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create matrix params: %v", err)
+	}
+
+	matrix, err := engine.ComputeDistanceTimeMatrix(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matrix.Times()) != 1 || len(matrix.Times()[0]) != 3 {
+		t.Fatalf("expected a 1x3 matrix, got %v", matrix.Times())
+	}
+	if matrix.Times()[0][2] != 3*time.Minute || matrix.Distances()[0][2].Value() != 300 {
+		t.Errorf("unexpected matrix %v", matrix)
+	}
+
+	path := stub.paths[0]
+	if !strings.HasPrefix(path, "/table/v1/foot/") {
+		t.Errorf("expected the walking profile, got %s", path)
+	}
+	if !strings.Contains(path, "sources=0") || !strings.Contains(path, "destinations=1%3B2%3B3") {
+		t.Errorf("unexpected sources and destinations in %s", path)
+	}
+}
+
+func TestOSRM_ComputeDistanceTimeMatrixUnreachable(t *testing.T) {
+	stub := newStubOSRM(t, map[string]any{
+		"table": map[string]any{
+			"code":      "Ok",
+			"durations": [][]any{{60, nil}},
+			"distances": [][]any{{100, nil}},
+		},
+	})
+	engine := stub.engine(t)
+
+	params, _ := model.NewDistanceTimeMatrixParams(
+		[]model.Coordinate{coordinate(t, 42.5078, 1.5211)},
+		model.ProfileAuto,
+		model.WithTargets([]model.Coordinate{coordinate(t, 42.5057, 1.5265), coordinate(t, 42.5036, 1.5148)}),
+	)
+	if _, err := engine.ComputeDistanceTimeMatrix(context.Background(), params); err == nil {
+		t.Error("expected an error for unreachable targets")
+	}
+}
+
+func TestOSRM_WalkingTimeAndSnap(t *testing.T) {
+	stub := newStubOSRM(t, map[string]any{
+		"route": map[string]any{
+			"code":   "Ok",
+			"routes": []map[string]any{{"geometry": "", "distance": 420.0, "duration": 300.0}},
+		},
+		"nearest": map[string]any{
+			"code":      "Ok",
+			"waypoints": []map[string]any{{"location": []float64{1.5212, 42.5079}, "distance": 12.3}},
+		},
+	})
+	engine := stub.engine(t)
+
+	origin := coordinate(t, 42.5078, 1.5211)
+	destination := coordinate(t, 42.5057, 1.5265)
+	walkParams, _ := model.NewWalkParams(&origin, &destination)
+	duration, err := engine.ComputeWalkingTime(context.Background(), walkParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if duration != 5*time.Minute {
+		t.Errorf("expected 5m, got %v", duration)
+	}
+	if !strings.HasPrefix(stub.paths[0], "/route/v1/foot/") {
+		t.Errorf("expected the walking profile, got %s", stub.paths[0])
+	}
+
+	snapped, err := engine.SnapPointToRoad(context.Background(), &origin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapped.Lat() != 42.5079 || snapped.Lng() != 1.5212 {
+		t.Errorf("unexpected snapped point %v", snapped)
+	}
+}
+
+func TestOSRM_ErrorsAndUnsupportedIsochrone(t *testing.T) {
+	stub := newStubOSRM(t, map[string]any{})
+	engine := stub.engine(t)
+
+	origin := coordinate(t, 42.5078, 1.5211)
+	destination := coordinate(t, 42.5057, 1.5265)
+	walkParams, _ := model.NewWalkParams(&origin, &destination)
+	if _, err := engine.ComputeWalkingTime(context.Background(), walkParams); err == nil {
+		t.Error("expected an error for a non Ok code")
+	}
+
+	// Snapping falls back to the original point
+	snapped, err := engine.SnapPointToRoad(context.Background(), &origin)
+	if err != nil || !snapped.Equal(&origin) {
+		t.Errorf("expected the original point, got %v (%v)", snapped, err)
+	}
+
+	if _, err := engine.ComputeIsochrone(context.Background(), nil); !errors.Is(err, osrm.ErrIsochroneNotSupported) {
+		t.Errorf("expected ErrIsochroneNotSupported, got %v", err)
+	}
+}
+
+func TestOSRM_ComputeDistanceTimeMatrixByBlocks(t *testing.T) {
+	// The stub answers every table with source i to target j taking i*100+j+1 seconds,
+	// the indices being encoded in the latitude of the sources and the longitude of the targets
+	var tables int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tables++
+		parts := strings.Split(r.URL.Path, "/")
+		coordinates := strings.Split(parts[len(parts)-1], ";")
+		indices := func(option string) []int {
+			values := make([]int, 0)
+			for _, value := range strings.Split(r.URL.Query().Get(option), ";") {
+				index, _ := strconv.Atoi(value)
+				values = append(values, index)
+			}
+			return values
+		}
+		sources, destinations := indices("sources"), indices("destinations")
+		if len(sources) > 2 || len(destinations) > 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"code": "TooBig"})
+			return
+		}
+		durations := make([][]float64, len(sources))
+		for i, source := range sources {
+			sourceLat, _ := strconv.ParseFloat(strings.Split(coordinates[source], ",")[1], 64)
+			for _, destination := range destinations {
+				targetLng, _ := strconv.ParseFloat(strings.Split(coordinates[destination], ",")[0], 64)
+				sourceIndex, targetIndex := int((sourceLat-40)*1000+0.5), int((targetLng-1)*1000+0.5)
+				durations[i] = append(durations[i], float64(sourceIndex*100+targetIndex+1))
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": "Ok", "durations": durations, "distances": durations})
+	}))
+	t.Cleanup(server.Close)
+
+	host, portStr, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	port, _ := strconv.Atoi(portStr)
+	engine, err := osrm.NewOSRM(client.WithConfig(client.NewConfig(host, port).WithMaxTableSize(2)))
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	sources := make([]model.Coordinate, 3)
+	for i := range sources {
+		sources[i] = coordinate(t, 40+float64(i)*0.001, 2)
+	}
+	targets := make([]model.Coordinate, 5)
+	for j := range targets {
+		targets[j] = coordinate(t, 41, 1+float64(j)*0.001)
+	}
+	params, _ := model.NewDistanceTimeMatrixParams(sources, model.ProfileAuto, model.WithTargets(targets))
+
+	matrix, err := engine.ComputeDistanceTimeMatrix(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tables != 6 {
+		t.Errorf("expected 2x3 blocks, got %d tables", tables)
+	}
+	for i := range sources {
+		for j := range targets {
+			expected := time.Duration(i*100+j+1) * time.Second
+			if matrix.Times()[i][j] != expected || matrix.Distances()[i][j].Value() != float32(i*100+j+1) {
+				t.Errorf("expected %s from source %d to target %d, got %s", expected, i, j, matrix.Times()[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"matching-engine/internal/model"
+	"math"
+	"time"
+)
+
+const (
+	opPlanDrivingRoute          = "plan_driving_route"
+	opComputeDrivingTime        = "compute_driving_time"
+	opComputeWalkingTime        = "compute_walking_time"
+	opComputeIsochrone          = "compute_isochrone"
+	opComputeDistanceTimeMatrix = "compute_distance_time_matrix"
+	opSnapPointToRoad           = "snap_point_to_road"
+)
+
+// keyPrecision is the number of decimals kept from the coordinates of the params, about 10cm
+const keyPrecision = 1e6
+
+// The params are normalized before being used as keys: coordinates are rounded and departure times
+// are truncated to the minute in UTC, the precision the routing backends work with
+
+type pointParams [2]float64
+
+type routeParams struct {
+	Waypoints     []pointParams `json:"waypoints"`
+	DepartureTime string        `json:"departure_time"`
+}
+
+type walkParams struct {
+	Origin      pointParams `json:"origin"`
+	Destination pointParams `json:"destination"`
+}
+
+type isochroneParams struct {
+	Origin        pointParams `json:"origin"`
+	ContourValue  float32     `json:"contour_value"`
+	ContourMetric string      `json:"contour_metric"`
+	Profile       string      `json:"profile"`
+}
+
+type matrixParams struct {
+	Sources       []pointParams `json:"sources"`
+	Targets       []pointParams `json:"targets"`
+	Profile       string        `json:"profile"`
+	DepartureTime string        `json:"departure_time"`
+}
+
+type snapParams struct {
+	Point pointParams `json:"point"`
+}
+
+func normalizePoint(c *model.Coordinate) pointParams {
+	return pointParams{
+		math.Round(c.Lat()*keyPrecision) / keyPrecision,
+		math.Round(c.Lng()*keyPrecision) / keyPrecision,
+	}
+}
+
+func normalizePoints(coordinates []model.Coordinate) []pointParams {
+	points := make([]pointParams, len(coordinates))
+	for i := range coordinates {
+		points[i] = normalizePoint(&coordinates[i])
+	}
+	return points
+}
+
+func normalizeTime(t time.Time) string {
+	return t.UTC().Truncate(time.Minute).Format("2006-01-02T15:04")
+}
+
+func newRouteParams(p *model.RouteParams) routeParams {
+	return routeParams{Waypoints: normalizePoints(p.Waypoints()), DepartureTime: normalizeTime(p.DepartureTime())}
+}
+
+func newWalkParams(p *model.WalkParams) walkParams {
+	return walkParams{Origin: normalizePoint(p.Origin()), Destination: normalizePoint(p.Destination())}
+}
+
+func newIsochroneParams(p *model.IsochroneParams) isochroneParams {
+	return isochroneParams{
+		Origin:        normalizePoint(p.Origin()),
+		ContourValue:  p.Contour().Value(),
+		ContourMetric: p.Contour().Metric().String(),
+		Profile:       p.Profile().String(),
+	}
+}
+
+func newMatrixParams(p *model.DistanceTimeMatrixParams) matrixParams {
+	return matrixParams{
+		Sources:       normalizePoints(p.Sources()),
+		Targets:       normalizePoints(p.Targets()),
+		Profile:       p.Profile().String(),
+		DepartureTime: normalizeTime(p.DepartureTime()),
+	}
+}
+
+func newSnapParams(point *model.Coordinate) snapParams {
+	return snapParams{Point: normalizePoint(point)}
+}
+
+// fixtureKey builds the lookup key of a call from its operation and normalized params
+func fixtureKey(operation string, params any) (string, json.RawMessage, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode %s params: %w", operation, err)
+	}
+	return operation + ":" + string(encoded), encoded, nil
+}
+
+// The responses keep the full precision of the backend
+
+type coordinateResponse [2]float64
+
+type routeResponse struct {
+	Polyline          string        `json:"polyline"`
+	PolylinePrecision int           `json:"polyline_precision"`
+	Distance          float32       `json:"distance"`
+	DistanceUnit      string        `json:"distance_unit"`
+	Time              time.Duration `json:"time"`
+}
+
+type isochroneResponse struct {
+	ContourValue  float32              `json:"contour_value"`
+	ContourMetric string               `json:"contour_metric"`
+	Ring          []coordinateResponse `json:"ring"`
+}
+
+type distanceResponse struct {
+	Value float32 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+type matrixResponse struct {
+	Distances [][]distanceResponse `json:"distances"`
+	Times     [][]time.Duration    `json:"times"`
+}
+
+func newCoordinateResponse(c *model.Coordinate) coordinateResponse {
+	return coordinateResponse{c.Lat(), c.Lng()}
+}
+
+func (c coordinateResponse) toCoordinate() (*model.Coordinate, error) {
+	return model.NewCoordinate(c[0], c[1])
+}
+
+func newRouteResponse(route *model.Route) routeResponse {
+	return routeResponse{
+		Polyline:          route.Polyline().Encoded(),
+		PolylinePrecision: route.Polyline().Precision(),
+		Distance:          route.Distance().Value(),
+		DistanceUnit:      route.Distance().Unit().String(),
+		Time:              route.Time(),
+	}
+}
+
+func (r routeResponse) toRoute() (*model.Route, error) {
+	polyline, err := model.NewPolyline(r.Polyline, model.WithPrecision(r.PolylinePrecision))
+	if err != nil {
+		return nil, err
+	}
+	distance, err := model.NewDistance(r.Distance, model.DistanceUnit(r.DistanceUnit))
+	if err != nil {
+		return nil, err
+	}
+	return model.NewRoute(polyline, distance, r.Time)
+}
+
+func newIsochroneResponse(isochrone *model.Isochrone) isochroneResponse {
+	ring := *isochrone.Geometry()
+	response := isochroneResponse{
+		ContourValue:  isochrone.Contour().Value(),
+		ContourMetric: isochrone.Contour().Metric().String(),
+		Ring:          make([]coordinateResponse, len(ring)),
+	}
+	for i := range ring {
+		response.Ring[i] = newCoordinateResponse(&ring[i])
+	}
+	return response
+}
+
+func (r isochroneResponse) toIsochrone() (*model.Isochrone, error) {
+	contour, err := model.NewContour(r.ContourValue, model.ContourMetric(r.ContourMetric))
+	if err != nil {
+		return nil, err
+	}
+	ring := make(model.LineString, len(r.Ring))
+	for i, point := range r.Ring {
+		c, err := point.toCoordinate()
+		if err != nil {
+			return nil, err
+		}
+		ring[i] = *c
+	}
+	return model.NewIsochrone(contour, &ring)
+}
+
+func newMatrixResponse(matrix *model.DistanceTimeMatrix) matrixResponse {
+	response := matrixResponse{
+		Distances: make([][]distanceResponse, len(matrix.Distances())),
+		Times:     matrix.Times(),
+	}
+	for i, row := range matrix.Distances() {
+		response.Distances[i] = make([]distanceResponse, len(row))
+		for j := range row {
+			response.Distances[i][j] = distanceResponse{Value: row[j].Value(), Unit: row[j].Unit().String()}
+		}
+	}
+	return response
+}
+
+func (r matrixResponse) toMatrix() (*model.DistanceTimeMatrix, error) {
+	distances := make([][]model.Distance, len(r.Distances))
+	for i, row := range r.Distances {
+		distances[i] = make([]model.Distance, len(row))
+		for j, cell := range row {
+			distance, err := model.NewDistance(cell.Value, model.DistanceUnit(cell.Unit))
+			if err != nil {
+				return nil, err
+			}
+			distances[i][j] = *distance
+		}
+	}
+	return model.NewDistanceTimeMatrix(distances, r.Times)
+}
@@ -0,0 +1,71 @@
+package replay
+
+import (
+	"fmt"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/app/config"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Mode string
+
+const (
+	ModeOff    Mode = "off"
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+const defaultFixturesPath = "testdata/routing_fixtures.jsonl"
+
+// WrapFromEnv builds the engine selected by ROUTING_FIXTURES_MODE:
+// "record" decorates the engine returned by newEngine with a RecordingEngine,
+// "replay" serves the fixtures without calling newEngine and "off" returns the engine as is.
+// The fixtures file is ROUTING_FIXTURES_PATH, relative paths are resolved from the working directory.
+func WrapFromEnv(newEngine func() (routing.Engine, error)) (routing.Engine, error) {
+	mode := Mode(config.GetEnv("ROUTING_FIXTURES_MODE", string(ModeOff)))
+	return wrap(mode, config.GetEnv("ROUTING_FIXTURES_PATH", defaultFixturesPath), newEngine)
+}
+
+// WrapWithFixtures is WrapFromEnv for the tests committing their fixtures at path: the fixtures are replayed
+// when the file exists and ROUTING_FIXTURES_MODE is not set, so the tests don't need a live routing backend.
+// ROUTING_FIXTURES_MODE=record records the fixtures again.
+func WrapWithFixtures(path string, newEngine func() (routing.Engine, error)) (routing.Engine, error) {
+	defaultMode := ModeOff
+	if _, err := os.Stat(path); err == nil {
+		defaultMode = ModeReplay
+	}
+	mode := Mode(config.GetEnv("ROUTING_FIXTURES_MODE", string(defaultMode)))
+	return wrap(mode, config.GetEnv("ROUTING_FIXTURES_PATH", path), newEngine)
+}
+
+func wrap(mode Mode, path string, newEngine func() (routing.Engine, error)) (routing.Engine, error) {
+	if mode == ModeOff {
+		return newEngine()
+	}
+	if mode != ModeRecord && mode != ModeReplay {
+		return nil, fmt.Errorf("invalid ROUTING_FIXTURES_MODE %q", mode)
+	}
+
+	store, err := OpenStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open routing fixtures: %w", err)
+	}
+
+	log.Info().
+		Str("mode", string(mode)).
+		Str("path", store.Path()).
+		Int("fixtures", store.Len()).
+		Msg("Routing fixtures enabled")
+
+	if mode == ModeReplay {
+		return NewReplayEngine(store), nil
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return nil, err
+	}
+	return NewRecordingEngine(engine, store), nil
+}
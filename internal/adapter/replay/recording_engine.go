@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var _ routing.Engine = (*RecordingEngine)(nil)
+
+// RecordingEngine forwards every call to the wrapped engine and stores its params and outcome in the fixtures store
+type RecordingEngine struct {
+	next  routing.Engine
+	store *Store
+}
+
+func NewRecordingEngine(next routing.Engine, store *Store) routing.Engine {
+	return &RecordingEngine{
+		next:  next,
+		store: store,
+	}
+}
+
+func (r *RecordingEngine) PlanDrivingRoute(ctx context.Context, routeParams *model.RouteParams) (*model.Route, error) {
+	route, err := r.next.PlanDrivingRoute(ctx, routeParams)
+	r.record(ctx, opPlanDrivingRoute, newRouteParams(routeParams), func() any { return newRouteResponse(route) }, err)
+	return route, err
+}
+
+func (r *RecordingEngine) ComputeDrivingTime(ctx context.Context, routeParams *model.RouteParams) ([]time.Duration, error) {
+	durations, err := r.next.ComputeDrivingTime(ctx, routeParams)
+	r.record(ctx, opComputeDrivingTime, newRouteParams(routeParams), func() any { return durations }, err)
+	return durations, err
+}
+
+func (r *RecordingEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	duration, err := r.next.ComputeWalkingTime(ctx, walkParams)
+	r.record(ctx, opComputeWalkingTime, newWalkParams(walkParams), func() any { return duration }, err)
+	return duration, err
+}
+
+func (r *RecordingEngine) ComputeIsochrone(ctx context.Context, req *model.IsochroneParams) (*model.Isochrone, error) {
+	isochrone, err := r.next.ComputeIsochrone(ctx, req)
+	r.record(ctx, opComputeIsochrone, newIsochroneParams(req), func() any { return newIsochroneResponse(isochrone) }, err)
+	return isochrone, err
+}
+
+func (r *RecordingEngine) ComputeDistanceTimeMatrix(ctx context.Context, req *model.DistanceTimeMatrixParams) (*model.DistanceTimeMatrix, error) {
+	matrix, err := r.next.ComputeDistanceTimeMatrix(ctx, req)
+	r.record(ctx, opComputeDistanceTimeMatrix, newMatrixParams(req), func() any { return newMatrixResponse(matrix) }, err)
+	return matrix, err
+}
+
+func (r *RecordingEngine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	snapped, err := r.next.SnapPointToRoad(ctx, point)
+	r.record(ctx, opSnapPointToRoad, newSnapParams(point), func() any { return newCoordinateResponse(snapped) }, err)
+	return snapped, err
+}
+
+// record stores the outcome of a call, a failure to record is logged and never fails the call itself.
+// Calls cut short by the caller say nothing about the backend and are not recorded.
+func (r *RecordingEngine) record(ctx context.Context, operation string, params any, response func() any, callErr error) {
+	if callErr != nil && (ctx.Err() != nil || errors.Is(callErr, context.Canceled)) {
+		return
+	}
+
+	key, encodedParams, err := fixtureKey(operation, params)
+	if err != nil {
+		log.Warn().Err(err).Str("operation", operation).Msg("Failed to record routing call")
+		return
+	}
+
+	fixture := &Fixture{
+		Key:        key,
+		Operation:  operation,
+		Params:     encodedParams,
+		RecordedAt: time.Now().UTC(),
+	}
+	if callErr != nil {
+		fixture.Error = callErr.Error()
+	} else if fixture.Response, err = json.Marshal(response()); err != nil {
+		log.Warn().Err(err).Str("operation", operation).Msg("Failed to encode routing response")
+		return
+	}
+
+	if err := r.store.Record(fixture); err != nil {
+		log.Warn().Err(err).Str("operation", operation).Msg("Failed to record routing call")
+	}
+}
@@ -0,0 +1,88 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+)
+
+// ErrFixtureNotFound is returned by the replay engine for a call that was never recorded
+var ErrFixtureNotFound = errors.New("no recorded routing call matches the params")
+
+var _ routing.Engine = (*ReplayEngine)(nil)
+
+// ReplayEngine answers every call from the fixtures store without any network access
+type ReplayEngine struct {
+	store *Store
+}
+
+func NewReplayEngine(store *Store) routing.Engine {
+	return &ReplayEngine{store: store}
+}
+
+func (r *ReplayEngine) PlanDrivingRoute(_ context.Context, routeParams *model.RouteParams) (*model.Route, error) {
+	response, err := replay[routeResponse](r.store, opPlanDrivingRoute, newRouteParams(routeParams))
+	if err != nil {
+		return nil, err
+	}
+	return response.toRoute()
+}
+
+func (r *ReplayEngine) ComputeDrivingTime(_ context.Context, routeParams *model.RouteParams) ([]time.Duration, error) {
+	return replay[[]time.Duration](r.store, opComputeDrivingTime, newRouteParams(routeParams))
+}
+
+func (r *ReplayEngine) ComputeWalkingTime(_ context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	return replay[time.Duration](r.store, opComputeWalkingTime, newWalkParams(walkParams))
+}
+
+func (r *ReplayEngine) ComputeIsochrone(_ context.Context, req *model.IsochroneParams) (*model.Isochrone, error) {
+	response, err := replay[isochroneResponse](r.store, opComputeIsochrone, newIsochroneParams(req))
+	if err != nil {
+		return nil, err
+	}
+	return response.toIsochrone()
+}
+
+func (r *ReplayEngine) ComputeDistanceTimeMatrix(_ context.Context, req *model.DistanceTimeMatrixParams) (*model.DistanceTimeMatrix, error) {
+	response, err := replay[matrixResponse](r.store, opComputeDistanceTimeMatrix, newMatrixParams(req))
+	if err != nil {
+		return nil, err
+	}
+	return response.toMatrix()
+}
+
+func (r *ReplayEngine) SnapPointToRoad(_ context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	response, err := replay[coordinateResponse](r.store, opSnapPointToRoad, newSnapParams(point))
+	if err != nil {
+		return nil, err
+	}
+	return response.toCoordinate()
+}
+
+// replay looks up the fixture of a call and decodes its response, a recorded failure is returned as an error
+func replay[T any](store *Store, operation string, params any) (T, error) {
+	var response T
+
+	key, _, err := fixtureKey(operation, params)
+	if err != nil {
+		return response, err
+	}
+
+	fixture, ok := store.Lookup(key)
+	if !ok {
+		return response, fmt.Errorf("%w: %s", ErrFixtureNotFound, key)
+	}
+	if fixture.Error != "" {
+		return response, errors.New(fixture.Error)
+	}
+
+	if err := json.Unmarshal(fixture.Response, &response); err != nil {
+		return response, fmt.Errorf("invalid %s fixture: %w", operation, err)
+	}
+	return response, nil
+}
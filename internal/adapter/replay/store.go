@@ -0,0 +1,132 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Fixture is one recorded routing call, the failed calls are kept too so that a replay fails the same way
+type Fixture struct {
+	Key        string          `json:"key"`
+	Operation  string          `json:"operation"`
+	Params     json.RawMessage `json:"params"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// Store keeps the fixtures of a file in memory.
+// The file holds one fixture per line and is only appended to, a later line overrides an earlier one with the same key.
+type Store struct {
+	mu       sync.RWMutex
+	path     string
+	fixtures map[string]*Fixture
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]*Store)
+)
+
+// OpenStore loads the fixtures of the file at path, a missing file gives an empty store.
+// Engines opened on the same path share the store so that concurrent recordings do not interleave lines.
+func OpenStore(path string) (*Store, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixtures path %q: %w", path, err)
+	}
+
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if store, ok := stores[absPath]; ok {
+		return store, nil
+	}
+
+	store := &Store{path: absPath, fixtures: make(map[string]*Fixture)}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	stores[absPath] = store
+	return store, nil
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read fixtures file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		fixture := &Fixture{}
+		if err := json.Unmarshal(scanner.Bytes(), fixture); err != nil {
+			return fmt.Errorf("invalid fixture at %s:%d: %w", s.path, line, err)
+		}
+		s.fixtures[fixture.Key] = fixture
+	}
+	return scanner.Err()
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.fixtures)
+}
+
+func (s *Store) Lookup(key string) (*Fixture, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fixture, ok := s.fixtures[key]
+	return fixture, ok
+}
+
+// Record appends the fixture to the file, a call already recorded with the same outcome is skipped
+func (s *Store) Record(fixture *Fixture) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.fixtures[fixture.Key]; ok &&
+		existing.Error == fixture.Error && bytes.Equal(existing.Response, fixture.Response) {
+		return nil
+	}
+
+	line, err := json.Marshal(fixture)
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open fixtures file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	s.fixtures[fixture.Key] = fixture
+	return nil
+}
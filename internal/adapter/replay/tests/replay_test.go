@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"matching-engine/internal/adapter/offline"
+	"matching-engine/internal/adapter/replay"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func coordinate(t *testing.T, lat, lng float64) *model.Coordinate {
+	c, err := model.NewCoordinate(lat, lng)
+	if err != nil {
+		t.Fatalf("invalid coordinate: %v", err)
+	}
+	return c
+}
+
+func newOffline(t *testing.T) routing.Engine {
+	engine, err := offline.NewOffline()
+	if err != nil {
+		t.Fatalf("failed to create offline engine: %v", err)
+	}
+	return engine
+}
+
+// reopen copies the fixtures file so that the store is loaded again from disk
+func reopen(t *testing.T, store *replay.Store) *replay.Store {
+	data, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatalf("failed to read fixtures: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "copy.jsonl")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to copy fixtures: %v", err)
+	}
+	copied, err := replay.OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to open fixtures: %v", err)
+	}
+	return copied
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	store, err := replay.OpenStore(filepath.Join(t.TempDir(), "fixtures.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	recorder := replay.NewRecordingEngine(newOffline(t), store)
+
+	origin, destination := coordinate(t, 30.0444, 31.2357), coordinate(t, 30.0626, 31.2497)
+	departure := time.Now().Add(2 * time.Hour)
+	routeParams, _ := model.NewRouteParams([]model.Coordinate{*origin, *destination}, departure)
+	walkParams, _ := model.NewWalkParams(origin, destination)
+	contour, _ := model.NewContour(10, model.ContourMetricTimeInMinutes)
+	isochroneParams, _ := model.NewIsochroneParams(origin, contour, model.ProfilePedestrian)
+	matrixParams, _ := model.NewDistanceTimeMatrixParams(
+		[]model.Coordinate{*origin, *destination}, model.ProfileAuto, model.WithDepartureTime(departure),
+	)
+
+	route, err := recorder.PlanDrivingRoute(ctx, routeParams)
+	if err != nil {
+		t.Fatalf("failed to plan route: %v", err)
+	}
+	durations, _ := recorder.ComputeDrivingTime(ctx, routeParams)
+	walk, _ := recorder.ComputeWalkingTime(ctx, walkParams)
+	isochrone, _ := recorder.ComputeIsochrone(ctx, isochroneParams)
+	matrix, _ := recorder.ComputeDistanceTimeMatrix(ctx, matrixParams)
+	snapped, _ := recorder.SnapPointToRoad(ctx, origin)
+
+	if store.Len() != 6 {
+		t.Fatalf("expected 6 recorded calls, got %d", store.Len())
+	}
+
+	replayer := replay.NewReplayEngine(reopen(t, store))
+
+	replayedRoute, err := replayer.PlanDrivingRoute(ctx, routeParams)
+	if err != nil {
+		t.Fatalf("failed to replay route: %v", err)
+	}
+	if replayedRoute.Polyline().Encoded() != route.Polyline().Encoded() || replayedRoute.Time() != route.Time() {
+		t.Errorf("replayed route differs: %v vs %v", replayedRoute, route)
+	}
+
+	replayedDurations, err := replayer.ComputeDrivingTime(ctx, routeParams)
+	if err != nil || len(replayedDurations) != len(durations) || replayedDurations[1] != durations[1] {
+		t.Errorf("replayed driving time differs: %v vs %v (%v)", replayedDurations, durations, err)
+	}
+
+	if replayedWalk, err := replayer.ComputeWalkingTime(ctx, walkParams); err != nil || replayedWalk != walk {
+		t.Errorf("replayed walking time differs: %v vs %v (%v)", replayedWalk, walk, err)
+	}
+
+	replayedIsochrone, err := replayer.ComputeIsochrone(ctx, isochroneParams)
+	if err != nil || len(*replayedIsochrone.Geometry()) != len(*isochrone.Geometry()) {
+		t.Errorf("replayed isochrone differs (%v)", err)
+	}
+
+	replayedMatrix, err := replayer.ComputeDistanceTimeMatrix(ctx, matrixParams)
+	if err != nil || replayedMatrix.Times()[0][1] != matrix.Times()[0][1] ||
+		replayedMatrix.Distances()[0][1] != matrix.Distances()[0][1] {
+		t.Errorf("replayed matrix differs (%v)", err)
+	}
+
+	if replayedSnap, err := replayer.SnapPointToRoad(ctx, origin); err != nil || !replayedSnap.Equal(snapped) {
+		t.Errorf("replayed snap differs: %v vs %v (%v)", replayedSnap, snapped, err)
+	}
+}
+
+func TestReplay_NormalizesParams(t *testing.T) {
+	ctx := context.Background()
+	store, _ := replay.OpenStore(filepath.Join(t.TempDir(), "fixtures.jsonl"))
+	recorder := replay.NewRecordingEngine(newOffline(t), store)
+	replayer := replay.NewReplayEngine(store)
+
+	departure := time.Now().Add(2 * time.Hour).Truncate(time.Minute)
+	params, _ := model.NewRouteParams(
+		[]model.Coordinate{*coordinate(t, 30.0444, 31.2357), *coordinate(t, 30.0626, 31.2497)}, departure,
+	)
+	if _, err := recorder.ComputeDrivingTime(ctx, params); err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+
+	// A few centimeters and seconds away from the recorded call
+	near, _ := model.NewRouteParams(
+		[]model.Coordinate{*coordinate(t, 30.04440001, 31.2357), *coordinate(t, 30.0626, 31.24969999)},
+		departure.Add(20*time.Second),
+	)
+	if _, err := replayer.ComputeDrivingTime(ctx, near); err != nil {
+		t.Errorf("expected the normalized params to hit the fixture, got %v", err)
+	}
+
+	far, _ := model.NewRouteParams(
+		[]model.Coordinate{*coordinate(t, 30.05, 31.2357), *coordinate(t, 30.0626, 31.2497)}, departure,
+	)
+	if _, err := replayer.ComputeDrivingTime(ctx, far); !errors.Is(err, replay.ErrFixtureNotFound) {
+		t.Errorf("expected ErrFixtureNotFound, got %v", err)
+	}
+}
+
+// failingEngine fails every walking time call, the other calls go to the offline engine
+type failingEngine struct {
+	routing.Engine
+}
+
+func (failingEngine) ComputeWalkingTime(context.Context, *model.WalkParams) (time.Duration, error) {
+	return 0, errors.New("no path found")
+}
+
+func TestReplay_ReplaysRecordedErrors(t *testing.T) {
+	ctx := context.Background()
+	store, _ := replay.OpenStore(filepath.Join(t.TempDir(), "fixtures.jsonl"))
+	recorder := replay.NewRecordingEngine(failingEngine{newOffline(t)}, store)
+
+	walkParams, _ := model.NewWalkParams(coordinate(t, 30.0444, 31.2357), coordinate(t, 30.0626, 31.2497))
+	if _, err := recorder.ComputeWalkingTime(ctx, walkParams); err == nil {
+		t.Fatal("expected the recorded call to fail")
+	}
+
+	_, err := replay.NewReplayEngine(reopen(t, store)).ComputeWalkingTime(ctx, walkParams)
+	if err == nil || err.Error() != "no path found" {
+		t.Errorf("expected the recorded error, got %v", err)
+	}
+}
+
+// unreachableEngine fails the test when the wrapped engine is built
+func unreachableEngine(t *testing.T) func() (routing.Engine, error) {
+	return func() (routing.Engine, error) {
+		t.Fatalf("the routing backend should not be built when the fixtures are replayed")
+		return nil, nil
+	}
+}
+
+func TestWrapWithFixtures_ReplaysExistingFixtures(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "fixtures.jsonl")
+	store, err := replay.OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	origin, destination := coordinate(t, 30.0444, 31.2357), coordinate(t, 30.0626, 31.2497)
+	walkParams, _ := model.NewWalkParams(origin, destination)
+	walk, err := replay.NewRecordingEngine(newOffline(t), store).ComputeWalkingTime(ctx, walkParams)
+	if err != nil {
+		t.Fatalf("failed to record walking time: %v", err)
+	}
+
+	engine, err := replay.WrapWithFixtures(path, unreachableEngine(t))
+	if err != nil {
+		t.Fatalf("failed to wrap engine: %v", err)
+	}
+	if replayedWalk, err := engine.ComputeWalkingTime(ctx, walkParams); err != nil || replayedWalk != walk {
+		t.Errorf("replayed walking time differs: %v vs %v (%v)", replayedWalk, walk, err)
+	}
+}
+
+func TestWrapWithFixtures_UsesTheBackendWithoutFixtures(t *testing.T) {
+	built := false
+	_, err := replay.WrapWithFixtures(filepath.Join(t.TempDir(), "missing.jsonl"), func() (routing.Engine, error) {
+		built = true
+		return newOffline(t), nil
+	})
+	if err != nil {
+		t.Fatalf("failed to wrap engine: %v", err)
+	}
+	if !built {
+		t.Errorf("expected the routing backend to be used without fixtures")
+	}
+}
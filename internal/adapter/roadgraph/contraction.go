@@ -0,0 +1,221 @@
+package roadgraph
+
+import (
+	"container/heap"
+	"math"
+)
+
+const (
+	// witnessSettleLimit bounds the witness searches, a missed witness only adds a useless shortcut
+	witnessSettleLimit = 200
+	noMiddle           = int32(-1)
+)
+
+// chEdges holds the upward or downward edges of a contraction hierarchy in compressed sparse row form.
+// Middles is the contracted node a shortcut skips, or -1 for an edge of the road graph.
+type chEdges struct {
+	First   []int32
+	Heads   []int32
+	Times   []float64
+	Lengths []float64
+	Middles []int32
+}
+
+func (e *chEdges) edges(u int32) (from, to int32) {
+	return e.First[u], e.First[u+1]
+}
+
+// contractedGraph is a road graph with its contraction hierarchy.
+// Up holds the edges u->v with rank(v) > rank(u) at u, Down holds the edges u->v with rank(u) > rank(v) at v (Heads is u).
+type contractedGraph struct {
+	Base *graph
+	Rank []int32
+	Up   chEdges
+	Down chEdges
+}
+
+type dynamicEdge struct {
+	to     int32
+	time   float64
+	length float64
+	middle int32
+}
+
+type contractor struct {
+	out        [][]dynamicEdge
+	in         [][]dynamicEdge
+	contracted []bool
+	deleted    []int32
+	witness    *dijkstraState
+}
+
+// contract builds the contraction hierarchy of the graph, nodes are contracted by their edge difference with lazy updates
+func contract(g *graph) *contractedGraph {
+	n := g.numNodes()
+	c := &contractor{
+		out:        make([][]dynamicEdge, n),
+		in:         make([][]dynamicEdge, n),
+		contracted: make([]bool, n),
+		deleted:    make([]int32, n),
+		witness:    newDijkstraState(n),
+	}
+	for u := int32(0); u < int32(n); u++ {
+		for i := g.First[u]; i < g.First[u+1]; i++ {
+			e := dynamicEdge{to: g.Heads[i], time: g.Times[i], length: g.Lengths[i], middle: noMiddle}
+			c.out[u] = append(c.out[u], e)
+			c.in[e.to] = append(c.in[e.to], dynamicEdge{to: u, time: e.time, length: e.length, middle: noMiddle})
+		}
+	}
+
+	queue := make(priorityQueue, 0, n)
+	for v := int32(0); v < int32(n); v++ {
+		queue = append(queue, queueItem{node: v, priority: c.priority(v)})
+	}
+	heap.Init(&queue)
+
+	up := make([][]dynamicEdge, n)
+	down := make([][]dynamicEdge, n)
+	rank := make([]int32, n)
+	nextRank := int32(0)
+	for queue.Len() > 0 {
+		item := heap.Pop(&queue).(queueItem)
+		// Lazy update: contract the node only if it is still the best candidate
+		if priority := c.priority(item.node); queue.Len() > 0 && priority > queue[0].priority {
+			heap.Push(&queue, queueItem{node: item.node, priority: priority})
+			continue
+		}
+
+		v := item.node
+		for _, e := range c.out[v] {
+			if !c.contracted[e.to] {
+				up[v] = append(up[v], e)
+			}
+		}
+		for _, e := range c.in[v] {
+			if !c.contracted[e.to] {
+				down[v] = append(down[v], e)
+			}
+		}
+		for _, shortcut := range c.shortcuts(v) {
+			c.addEdge(shortcut.from, dynamicEdge{to: shortcut.to, time: shortcut.time, length: shortcut.length, middle: v})
+		}
+		c.contracted[v] = true
+		rank[v] = nextRank
+		nextRank++
+		for _, e := range up[v] {
+			c.deleted[e.to]++
+		}
+		for _, e := range down[v] {
+			c.deleted[e.to]++
+		}
+	}
+
+	return &contractedGraph{
+		Base: g,
+		Rank: rank,
+		Up:   toCHEdges(up),
+		Down: toCHEdges(down),
+	}
+}
+
+// priority is the edge difference of the node plus the number of its contracted neighbours, which spreads the contraction
+func (c *contractor) priority(v int32) float64 {
+	degree := 0
+	for _, e := range c.out[v] {
+		if !c.contracted[e.to] {
+			degree++
+		}
+	}
+	for _, e := range c.in[v] {
+		if !c.contracted[e.to] {
+			degree++
+		}
+	}
+	return float64(len(c.shortcuts(v))-degree) + float64(c.deleted[v])
+}
+
+// shortcuts returns the shortcuts needed to keep the shortest paths through v once it is contracted
+func (c *contractor) shortcuts(v int32) []edge {
+	shortcuts := make([]edge, 0)
+	for _, in := range c.in[v] {
+		u := in.to
+		if c.contracted[u] {
+			continue
+		}
+		maxTime := 0.0
+		for _, out := range c.out[v] {
+			if !c.contracted[out.to] && out.to != u {
+				maxTime = math.Max(maxTime, in.time+out.time)
+			}
+		}
+		if maxTime == 0 {
+			continue
+		}
+
+		c.witnessSearch(u, v, maxTime)
+		for _, out := range c.out[v] {
+			w := out.to
+			if c.contracted[w] || w == u {
+				continue
+			}
+			viaTime := in.time + out.time
+			if witnessTime, found := c.witness.distance(w); found && witnessTime <= viaTime {
+				continue
+			}
+			shortcuts = append(shortcuts, edge{from: u, to: w, time: viaTime, length: in.length + out.length})
+		}
+	}
+	return shortcuts
+}
+
+// witnessSearch runs a bounded Dijkstra from u that avoids v and the contracted nodes
+func (c *contractor) witnessSearch(u, v int32, maxTime float64) {
+	c.witness.reset()
+	c.witness.push(u, 0, 0)
+	for settled := 0; settled < witnessSettleLimit; settled++ {
+		x, time, ok := c.witness.pop()
+		if !ok || time > maxTime {
+			return
+		}
+		for _, e := range c.out[x] {
+			if e.to == v || c.contracted[e.to] {
+				continue
+			}
+			c.witness.push(e.to, time+e.time, 0)
+		}
+	}
+}
+
+// addEdge adds the edge or improves the existing one between the same nodes
+func (c *contractor) addEdge(from int32, e dynamicEdge) {
+	for i := range c.out[from] {
+		if c.out[from][i].to == e.to {
+			if c.out[from][i].time <= e.time {
+				return
+			}
+			c.out[from][i] = e
+			for j := range c.in[e.to] {
+				if c.in[e.to][j].to == from {
+					c.in[e.to][j] = dynamicEdge{to: from, time: e.time, length: e.length, middle: e.middle}
+				}
+			}
+			return
+		}
+	}
+	c.out[from] = append(c.out[from], e)
+	c.in[e.to] = append(c.in[e.to], dynamicEdge{to: from, time: e.time, length: e.length, middle: e.middle})
+}
+
+func toCHEdges(adjacency [][]dynamicEdge) chEdges {
+	edges := chEdges{First: make([]int32, len(adjacency)+1)}
+	for u, list := range adjacency {
+		edges.First[u+1] = edges.First[u] + int32(len(list))
+		for _, e := range list {
+			edges.Heads = append(edges.Heads, e.to)
+			edges.Times = append(edges.Times, e.time)
+			edges.Lengths = append(edges.Lengths, e.length)
+			edges.Middles = append(edges.Middles, e.middle)
+		}
+	}
+	return edges
+}
@@ -0,0 +1,102 @@
+package roadgraph
+
+import "container/heap"
+
+type queueItem struct {
+	node     int32
+	priority float64
+}
+
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int           { return len(q) }
+func (q priorityQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q priorityQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x any)        { *q = append(*q, x.(queueItem)) }
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// dijkstraState holds the tentative distances of a Dijkstra search, it is reset in time proportional to the visited nodes
+type dijkstraState struct {
+	times       []float64
+	lengths     []float64
+	parents     []int32
+	parentEdges []int32
+	settled     []bool
+	visited     []int32
+	queue       priorityQueue
+}
+
+func newDijkstraState(n int) *dijkstraState {
+	s := &dijkstraState{
+		times:       make([]float64, n),
+		lengths:     make([]float64, n),
+		parents:     make([]int32, n),
+		parentEdges: make([]int32, n),
+		settled:     make([]bool, n),
+	}
+	for i := range s.times {
+		s.times[i] = -1
+		s.parents[i] = -1
+	}
+	return s
+}
+
+func (s *dijkstraState) reset() {
+	for _, v := range s.visited {
+		s.times[v] = -1
+		s.parents[v] = -1
+		s.settled[v] = false
+	}
+	s.visited = s.visited[:0]
+	s.queue = s.queue[:0]
+}
+
+// push relaxes the node with the given time and length
+func (s *dijkstraState) push(v int32, time, length float64) bool {
+	return s.pushWithParent(v, time, length, -1, -1)
+}
+
+// pushWithParent relaxes the node and remembers the node and the index of the edge it was reached from
+func (s *dijkstraState) pushWithParent(v int32, time, length float64, parent, parentEdge int32) bool {
+	if s.settled[v] || (s.times[v] >= 0 && s.times[v] <= time) {
+		return false
+	}
+	if s.times[v] < 0 {
+		s.visited = append(s.visited, v)
+	}
+	s.times[v] = time
+	s.lengths[v] = length
+	s.parents[v] = parent
+	s.parentEdges[v] = parentEdge
+	heap.Push(&s.queue, queueItem{node: v, priority: time})
+	return true
+}
+
+// pop settles the closest node, skipping the outdated queue entries
+func (s *dijkstraState) pop() (int32, float64, bool) {
+	for s.queue.Len() > 0 {
+		item := heap.Pop(&s.queue).(queueItem)
+		if s.settled[item.node] || item.priority > s.times[item.node] {
+			continue
+		}
+		s.settled[item.node] = true
+		return item.node, item.priority, true
+	}
+	return 0, 0, false
+}
+
+func (s *dijkstraState) isSettled(v int32) bool {
+	return s.settled[v]
+}
+
+func (s *dijkstraState) distance(v int32) (float64, bool) {
+	if s.times[v] < 0 {
+		return 0, false
+	}
+	return s.times[v], true
+}
@@ -0,0 +1,307 @@
+package roadgraph
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/app/config"
+	"matching-engine/internal/model"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RoadGraph is an embedded routing engine answering the queries in-process on contraction hierarchies
+// of the driving and walking road graphs of an OSM extract. The query points are snapped to the closest graph node.
+type RoadGraph struct {
+	driving      *router
+	walking      *router
+	drivingIndex *spatialIndex
+	walkingIndex *spatialIndex
+}
+
+// NewRoadGraph loads the road graph from ROAD_GRAPH_PATH, either an OSM PBF extract (*.osm.pbf or *.pbf)
+// contracted at startup, or a road graph preprocessed with Preprocess
+func NewRoadGraph() (re.Engine, error) {
+	path := config.GetEnv("ROAD_GRAPH_PATH", "road_graph.bin")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open road graph %s: %w", path, err)
+	}
+	defer file.Close()
+
+	start := time.Now()
+	reader := bufio.NewReaderSize(file, 1<<20)
+	var engine re.Engine
+	if strings.HasSuffix(path, ".pbf") {
+		engine, err = NewRoadGraphFromOSM(reader)
+	} else {
+		engine, err = LoadRoadGraph(reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("path", path).Dur("duration", time.Since(start)).Msg("Road graph loaded")
+	return engine, nil
+}
+
+// NewRoadGraphFromOSM builds and contracts the road graphs of an OSM PBF extract
+func NewRoadGraphFromOSM(osmExtract io.Reader) (re.Engine, error) {
+	driving, walking, err := buildGraphsFromOSM(osmExtract)
+	if err != nil {
+		return nil, err
+	}
+	return newRoadGraph(contract(driving), contract(walking)), nil
+}
+
+// LoadRoadGraph loads a road graph preprocessed with Preprocess
+func LoadRoadGraph(r io.Reader) (re.Engine, error) {
+	stored, err := loadStoredGraphs(r)
+	if err != nil {
+		return nil, err
+	}
+	return newRoadGraph(stored.Driving, stored.Walking), nil
+}
+
+func newRoadGraph(driving, walking *contractedGraph) *RoadGraph {
+	return &RoadGraph{
+		driving:      newRouter(driving),
+		walking:      newRouter(walking),
+		drivingIndex: newSpatialIndex(driving.Base),
+		walkingIndex: newSpatialIndex(walking.Base),
+	}
+}
+
+func (rg *RoadGraph) PlanDrivingRoute(
+	ctx context.Context,
+	routeParams *model.RouteParams,
+) (*model.Route, error) {
+	if routeParams == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	nodes, err := rg.snapAll(rg.drivingIndex, routeParams.Waypoints())
+	if err != nil {
+		return nil, err
+	}
+
+	g := rg.driving.ch.Base
+	line := model.LineString{*nodeCoordinate(g, nodes[0])}
+	var totalTime, totalLength float64
+	for i := 1; i < len(nodes); i++ {
+		legTime, legLength, path, ok := rg.driving.shortestPath(nodes[i-1], nodes[i])
+		if !ok {
+			return nil, fmt.Errorf("no route found between waypoints %d and %d", i-1, i)
+		}
+		totalTime += legTime
+		totalLength += legLength
+		for _, v := range path[1:] {
+			line = append(line, *nodeCoordinate(g, v))
+		}
+	}
+	// A route through a single node still needs two points
+	if len(line) == 1 {
+		line = append(line, line[0])
+	}
+
+	polyline, err := model.NewPolylineFromCoordinates(line)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode route: %w", err)
+	}
+	distance, err := model.NewDistance(float32(totalLength/1000), model.DistanceUnitKilometer)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewRoute(polyline, distance, secondsToDuration(totalTime))
+}
+
+func (rg *RoadGraph) ComputeDrivingTime(
+	ctx context.Context,
+	routeParams *model.RouteParams,
+) ([]time.Duration, error) {
+	if routeParams == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	nodes, err := rg.snapAll(rg.drivingIndex, routeParams.Waypoints())
+	if err != nil {
+		return nil, err
+	}
+
+	cumulativeDurations := make([]time.Duration, len(nodes))
+	for i := 1; i < len(nodes); i++ {
+		legTime, _, _, ok := rg.driving.shortestPath(nodes[i-1], nodes[i])
+		if !ok {
+			return nil, fmt.Errorf("no route found between waypoints %d and %d", i-1, i)
+		}
+		cumulativeDurations[i] = cumulativeDurations[i-1] + secondsToDuration(legTime)
+	}
+	return cumulativeDurations, nil
+}
+
+func (rg *RoadGraph) ComputeWalkingTime(
+	ctx context.Context,
+	walkParams *model.WalkParams,
+) (time.Duration, error) {
+	if walkParams == nil {
+		return 0, fmt.Errorf("params cannot be nil")
+	}
+	nodes, err := rg.snapAll(rg.walkingIndex, []model.Coordinate{*walkParams.Origin(), *walkParams.Destination()})
+	if err != nil {
+		return 0, err
+	}
+	walkingTime, _, _, ok := rg.walking.shortestPath(nodes[0], nodes[1])
+	if !ok {
+		return 0, fmt.Errorf("no walking route found")
+	}
+	return secondsToDuration(walkingTime), nil
+}
+
+// ComputeIsochrone returns the convex hull of the road graph nodes reachable within the contour
+func (rg *RoadGraph) ComputeIsochrone(
+	ctx context.Context,
+	req *model.IsochroneParams,
+) (*model.Isochrone, error) {
+	if req == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	r, index := rg.profileRouter(req.Profile())
+	origin, err := rg.snapAll(index, []model.Coordinate{*req.Origin()})
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []int32
+	switch req.Contour().Metric() {
+	case model.ContourMetricTimeInMinutes:
+		nodes = r.reachable(origin[0], float64(req.Contour().Value())*60, false)
+	case model.ContourMetricDistanceInKilometers:
+		nodes = r.reachable(origin[0], float64(req.Contour().Value())*1000, true)
+	default:
+		return nil, fmt.Errorf("unsupported contour metric %q", req.Contour().Metric())
+	}
+
+	ring := convexHull(r.ch.Base, nodes)
+	if len(ring) < 4 {
+		return nil, fmt.Errorf("not enough reachable roads to build an isochrone")
+	}
+	return model.NewIsochrone(req.Contour(), &ring)
+}
+
+func (rg *RoadGraph) ComputeDistanceTimeMatrix(
+	ctx context.Context,
+	req *model.DistanceTimeMatrixParams,
+) (*model.DistanceTimeMatrix, error) {
+	if req == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	r, index := rg.profileRouter(req.Profile())
+	sources, err := rg.snapAll(index, req.Sources())
+	if err != nil {
+		return nil, err
+	}
+	targets, err := rg.snapAll(index, req.Targets())
+	if err != nil {
+		return nil, err
+	}
+
+	times, lengths := r.manyToMany(sources, targets)
+	distanceMatrix := make([][]model.Distance, len(sources))
+	timeMatrix := make([][]time.Duration, len(sources))
+	for i := range times {
+		distanceMatrix[i] = make([]model.Distance, len(targets))
+		timeMatrix[i] = make([]time.Duration, len(targets))
+		for j := range times[i] {
+			if math.IsInf(times[i][j], 1) {
+				return nil, fmt.Errorf("no route found from source %d to target %d", i, j)
+			}
+			distance, err := model.NewDistance(float32(lengths[i][j]), model.DistanceUnitMeter)
+			if err != nil {
+				return nil, err
+			}
+			distanceMatrix[i][j] = *distance
+			timeMatrix[i][j] = secondsToDuration(times[i][j])
+		}
+	}
+	return model.NewDistanceTimeMatrix(distanceMatrix, timeMatrix)
+}
+
+// SnapPointToRoad returns the closest node of the driving graph
+func (rg *RoadGraph) SnapPointToRoad(
+	ctx context.Context,
+	point *model.Coordinate,
+) (*model.Coordinate, error) {
+	nodes, err := rg.snapAll(rg.drivingIndex, []model.Coordinate{*point})
+	if err != nil {
+		return nil, err
+	}
+	return nodeCoordinate(rg.driving.ch.Base, nodes[0]), nil
+}
+
+func (rg *RoadGraph) profileRouter(profile model.Profile) (*router, *spatialIndex) {
+	if profile == model.ProfilePedestrian {
+		return rg.walking, rg.walkingIndex
+	}
+	return rg.driving, rg.drivingIndex
+}
+
+func (rg *RoadGraph) snapAll(index *spatialIndex, coordinates []model.Coordinate) ([]int32, error) {
+	nodes := make([]int32, len(coordinates))
+	for i, c := range coordinates {
+		node, ok := index.nearest(c.Lat(), c.Lng())
+		if !ok {
+			return nil, fmt.Errorf("no road found near %s", c.String())
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+func nodeCoordinate(g *graph, v int32) *model.Coordinate {
+	coordinate, _ := model.NewCoordinate(g.Lats[v], g.Lngs[v])
+	return coordinate
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// convexHull returns the closed convex hull of the nodes with the monotone chain algorithm
+func convexHull(g *graph, nodes []int32) model.LineString {
+	points := make([]int32, len(nodes))
+	copy(points, nodes)
+	sort.Slice(points, func(i, j int) bool {
+		if g.Lngs[points[i]] != g.Lngs[points[j]] {
+			return g.Lngs[points[i]] < g.Lngs[points[j]]
+		}
+		return g.Lats[points[i]] < g.Lats[points[j]]
+	})
+	cross := func(o, a, b int32) float64 {
+		return (g.Lngs[a]-g.Lngs[o])*(g.Lats[b]-g.Lats[o]) - (g.Lats[a]-g.Lats[o])*(g.Lngs[b]-g.Lngs[o])
+	}
+
+	hull := make([]int32, 0, 2*len(points))
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], points[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, points[i])
+	}
+
+	ring := make(model.LineString, len(hull))
+	for i, v := range hull {
+		ring[i] = *nodeCoordinate(g, v)
+	}
+	return ring
+}
@@ -0,0 +1,169 @@
+package roadgraph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/umahmood/haversine"
+)
+
+// graph is a directed road graph in compressed sparse row form,
+// the outgoing edges of node u are the indices in [First[u], First[u+1])
+type graph struct {
+	Lats    []float64
+	Lngs    []float64
+	First   []int32
+	Heads   []int32
+	Times   []float64 // seconds
+	Lengths []float64 // meters
+}
+
+func (g *graph) numNodes() int {
+	return len(g.Lats)
+}
+
+// edge is a directed edge used while building and contracting graphs
+type edge struct {
+	from, to int32
+	time     float64
+	length   float64
+}
+
+// newGraph builds the compressed graph from the edges, keeping only the fastest of parallel edges
+func newGraph(lats, lngs []float64, edges []edge) *graph {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].from != edges[j].from {
+			return edges[i].from < edges[j].from
+		}
+		if edges[i].to != edges[j].to {
+			return edges[i].to < edges[j].to
+		}
+		return edges[i].time < edges[j].time
+	})
+
+	g := &graph{
+		Lats:  lats,
+		Lngs:  lngs,
+		First: make([]int32, len(lats)+1),
+	}
+	for i, e := range edges {
+		if e.from == e.to || (i > 0 && edges[i-1].from == e.from && edges[i-1].to == e.to) {
+			continue
+		}
+		g.First[e.from+1]++
+		g.Heads = append(g.Heads, e.to)
+		g.Times = append(g.Times, e.time)
+		g.Lengths = append(g.Lengths, e.length)
+	}
+	for u := 1; u < len(g.First); u++ {
+		g.First[u] += g.First[u-1]
+	}
+	return g
+}
+
+// osmGraphBuilder collects the nodes and ways of an OSM extract and builds the graph of a profile.
+// All the node coordinates are kept in memory until the ways are read, which is fine for a city extract.
+type osmGraphBuilder struct {
+	profiles []*profile
+	nodes    map[int64][2]float64
+	ways     [][]osmWay
+}
+
+func newOSMGraphBuilder(profiles ...*profile) *osmGraphBuilder {
+	return &osmGraphBuilder{
+		profiles: profiles,
+		nodes:    make(map[int64][2]float64),
+		ways:     make([][]osmWay, len(profiles)),
+	}
+}
+
+func (b *osmGraphBuilder) node(node osmNode) {
+	b.nodes[node.id] = [2]float64{node.lat, node.lng}
+}
+
+func (b *osmGraphBuilder) way(way osmWay) {
+	if len(way.refs) < 2 {
+		return
+	}
+	for i, p := range b.profiles {
+		if _, _, _, ok := p.wayDirections(way.tags); ok {
+			b.ways[i] = append(b.ways[i], way)
+		}
+	}
+}
+
+// build returns the graph of every profile, in the same order as the profiles
+func (b *osmGraphBuilder) build() ([]*graph, error) {
+	graphs := make([]*graph, len(b.profiles))
+	for i, p := range b.profiles {
+		g, err := b.buildProfile(p, b.ways[i])
+		if err != nil {
+			return nil, err
+		}
+		graphs[i] = g
+	}
+	return graphs, nil
+}
+
+func (b *osmGraphBuilder) buildProfile(p *profile, ways []osmWay) (*graph, error) {
+	ids := make(map[int64]int32)
+	lats, lngs := make([]float64, 0), make([]float64, 0)
+	nodeIndex := func(osmID int64) (int32, bool) {
+		if index, exists := ids[osmID]; exists {
+			return index, true
+		}
+		coordinate, exists := b.nodes[osmID]
+		if !exists {
+			return 0, false
+		}
+		index := int32(len(lats))
+		ids[osmID] = index
+		lats = append(lats, coordinate[0])
+		lngs = append(lngs, coordinate[1])
+		return index, true
+	}
+
+	edges := make([]edge, 0)
+	for _, way := range ways {
+		speedKmh, forward, backward, _ := p.wayDirections(way.tags)
+		for i := 1; i < len(way.refs); i++ {
+			from, fromOK := nodeIndex(way.refs[i-1])
+			to, toOK := nodeIndex(way.refs[i])
+			if !fromOK || !toOK {
+				// Ways cut at the border of the extract reference missing nodes
+				continue
+			}
+			length := distanceMeters(lats[from], lngs[from], lats[to], lngs[to])
+			travelTime := length / (speedKmh / 3.6)
+			if forward {
+				edges = append(edges, edge{from: from, to: to, time: travelTime, length: length})
+			}
+			if backward {
+				edges = append(edges, edge{from: to, to: from, time: travelTime, length: length})
+			}
+		}
+	}
+	if len(lats) == 0 {
+		return nil, fmt.Errorf("no usable roads found in the extract")
+	}
+	return newGraph(lats, lngs, edges), nil
+}
+
+// buildGraphsFromOSM reads an OSM PBF extract and builds the driving and walking graphs
+func buildGraphsFromOSM(r io.Reader) (driving, walking *graph, err error) {
+	builder := newOSMGraphBuilder(drivingProfile, walkingProfile)
+	if err := readOSMPBF(r, builder); err != nil {
+		return nil, nil, fmt.Errorf("failed to read osm extract: %w", err)
+	}
+	graphs, err := builder.build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return graphs[0], graphs[1], nil
+}
+
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	_, km := haversine.Distance(haversine.Coord{Lat: lat1, Lon: lng1}, haversine.Coord{Lat: lat2, Lon: lng2})
+	return km * 1000
+}
@@ -0,0 +1,357 @@
+package roadgraph
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The OSM PBF format is a sequence of blobs, each one preceded by its length and a BlobHeader.
+// Only the messages needed to build a road graph are decoded (dense nodes, nodes and ways),
+// see https://wiki.openstreetmap.org/wiki/PBF_Format for the protobuf definitions.
+
+const (
+	maxBlobHeaderSize = 64 * 1024
+	maxBlobSize       = 32 * 1024 * 1024
+)
+
+// osmNode is a node of an OSM extract
+type osmNode struct {
+	id       int64
+	lat, lng float64
+}
+
+// osmWay is a way of an OSM extract with its tags
+type osmWay struct {
+	id   int64
+	refs []int64
+	tags map[string]string
+}
+
+// osmHandler receives the nodes and ways of an OSM extract
+type osmHandler interface {
+	node(node osmNode)
+	way(way osmWay)
+}
+
+// readOSMPBF decodes the OSM PBF stream and sends its nodes and ways to the handler
+func readOSMPBF(r io.Reader, handler osmHandler) error {
+	for {
+		var headerSize uint32
+		if err := binary.Read(r, binary.BigEndian, &headerSize); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read blob header size: %w", err)
+		}
+		if headerSize > maxBlobHeaderSize {
+			return fmt.Errorf("blob header too large: %d bytes", headerSize)
+		}
+
+		header := make([]byte, headerSize)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return fmt.Errorf("failed to read blob header: %w", err)
+		}
+		blobType, blobSize, err := decodeBlobHeader(header)
+		if err != nil {
+			return err
+		}
+		if blobSize > maxBlobSize {
+			return fmt.Errorf("blob too large: %d bytes", blobSize)
+		}
+
+		blob := make([]byte, blobSize)
+		if _, err := io.ReadFull(r, blob); err != nil {
+			return fmt.Errorf("failed to read blob: %w", err)
+		}
+		if blobType != "OSMData" {
+			continue
+		}
+
+		data, err := decodeBlob(blob)
+		if err != nil {
+			return err
+		}
+		if err := decodePrimitiveBlock(data, handler); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeBlobHeader decodes a BlobHeader {type = 1, indexdata = 2, datasize = 3}
+func decodeBlobHeader(b []byte) (blobType string, blobSize int, err error) {
+	err = forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			blobType = string(value)
+		case num == 3 && typ == protowire.VarintType:
+			blobSize = int(varint)
+		}
+		return nil
+	})
+	return blobType, blobSize, err
+}
+
+// decodeBlob decodes a Blob {raw = 1, raw_size = 2, zlib_data = 3} and returns its uncompressed content
+func decodeBlob(b []byte) ([]byte, error) {
+	var raw, zlibData []byte
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			raw = value
+		case num == 3 && typ == protowire.BytesType:
+			zlibData = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		return raw, nil
+	}
+	if zlibData == nil {
+		return nil, fmt.Errorf("unsupported blob compression")
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(zlibData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zlib blob: %w", err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress blob: %w", err)
+	}
+	return data, nil
+}
+
+// primitiveBlock holds the fields of a PrimitiveBlock needed to decode its groups
+type primitiveBlock struct {
+	strings     []string
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+}
+
+func (pb *primitiveBlock) coordinate(lat, lon int64) (float64, float64) {
+	return 1e-9 * float64(pb.latOffset+pb.granularity*lat), 1e-9 * float64(pb.lonOffset+pb.granularity*lon)
+}
+
+func (pb *primitiveBlock) string(index uint64) string {
+	if index < uint64(len(pb.strings)) {
+		return pb.strings[index]
+	}
+	return ""
+}
+
+// decodePrimitiveBlock decodes a PrimitiveBlock {stringtable = 1, primitivegroup = 2, granularity = 17, lat_offset = 19, lon_offset = 20}
+func decodePrimitiveBlock(b []byte, handler osmHandler) error {
+	block := &primitiveBlock{granularity: 100}
+	groups := make([][]byte, 0)
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return forEachField(value, func(num protowire.Number, typ protowire.Type, s []byte, _ uint64) error {
+				if num == 1 && typ == protowire.BytesType {
+					block.strings = append(block.strings, string(s))
+				}
+				return nil
+			})
+		case num == 2 && typ == protowire.BytesType:
+			groups = append(groups, value)
+		case num == 17 && typ == protowire.VarintType:
+			block.granularity = int64(varint)
+		case num == 19 && typ == protowire.VarintType:
+			block.latOffset = int64(varint)
+		case num == 20 && typ == protowire.VarintType:
+			block.lonOffset = int64(varint)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The groups are decoded once the whole block is read, as the string table and offsets may come after them
+	for _, group := range groups {
+		err := forEachField(group, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+			if typ != protowire.BytesType {
+				return nil
+			}
+			switch num {
+			case 1:
+				return block.decodeNode(value, handler)
+			case 2:
+				return block.decodeDenseNodes(value, handler)
+			case 3:
+				return block.decodeWay(value, handler)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeNode decodes a Node {id = 1, lat = 8, lon = 9}
+func (pb *primitiveBlock) decodeNode(b []byte, handler osmHandler) error {
+	var id, lat, lon int64
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		switch num {
+		case 1:
+			id = protowire.DecodeZigZag(varint)
+		case 8:
+			lat = protowire.DecodeZigZag(varint)
+		case 9:
+			lon = protowire.DecodeZigZag(varint)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	nodeLat, nodeLng := pb.coordinate(lat, lon)
+	handler.node(osmNode{id: id, lat: nodeLat, lng: nodeLng})
+	return nil
+}
+
+// decodeDenseNodes decodes DenseNodes {id = 1, lat = 8, lon = 9}, all of them delta coded
+func (pb *primitiveBlock) decodeDenseNodes(b []byte, handler osmHandler) error {
+	var ids, lats, lons []int64
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		var err error
+		switch num {
+		case 1:
+			ids, err = decodePackedSint64(value)
+		case 8:
+			lats, err = decodePackedSint64(value)
+		case 9:
+			lons, err = decodePackedSint64(value)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(ids) != len(lats) || len(ids) != len(lons) {
+		return fmt.Errorf("dense nodes have %d ids, %d latitudes and %d longitudes", len(ids), len(lats), len(lons))
+	}
+
+	var id, lat, lon int64
+	for i := range ids {
+		id, lat, lon = id+ids[i], lat+lats[i], lon+lons[i]
+		nodeLat, nodeLng := pb.coordinate(lat, lon)
+		handler.node(osmNode{id: id, lat: nodeLat, lng: nodeLng})
+	}
+	return nil
+}
+
+// decodeWay decodes a Way {id = 1, keys = 2, vals = 3, refs = 8}, the refs are delta coded
+func (pb *primitiveBlock) decodeWay(b []byte, handler osmHandler) error {
+	var id int64
+	var keys, vals []uint64
+	var refs []int64
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		var err error
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			id = int64(varint)
+		case num == 2 && typ == protowire.BytesType:
+			keys, err = decodePackedUint64(value)
+		case num == 3 && typ == protowire.BytesType:
+			vals, err = decodePackedUint64(value)
+		case num == 8 && typ == protowire.BytesType:
+			refs, err = decodePackedSint64(value)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(keys) != len(vals) {
+		return fmt.Errorf("way %d has %d keys and %d values", id, len(keys), len(vals))
+	}
+
+	tags := make(map[string]string, len(keys))
+	for i := range keys {
+		tags[pb.string(keys[i])] = pb.string(vals[i])
+	}
+	var ref int64
+	for i := range refs {
+		ref += refs[i]
+		refs[i] = ref
+	}
+	handler.way(osmWay{id: id, refs: refs, tags: tags})
+	return nil
+}
+
+// forEachField calls fn with every field of the message, value is set for bytes fields and varint for varint fields
+func forEachField(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var value []byte
+		var varint uint64
+		switch typ {
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, value, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodePackedSint64(b []byte) ([]int64, error) {
+	values := make([]int64, 0, len(b)/2)
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid packed varint: %w", protowire.ParseError(n))
+		}
+		values = append(values, protowire.DecodeZigZag(v))
+		b = b[n:]
+	}
+	return values, nil
+}
+
+func decodePackedUint64(b []byte) ([]uint64, error) {
+	values := make([]uint64, 0, len(b)/2)
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid packed varint: %w", protowire.ParseError(n))
+		}
+		values = append(values, v)
+		b = b[n:]
+	}
+	return values, nil
+}
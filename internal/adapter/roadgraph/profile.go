@@ -0,0 +1,121 @@
+package roadgraph
+
+import (
+	"strconv"
+	"strings"
+)
+
+// profile decides which OSM ways can be used and how fast they are travelled
+type profile struct {
+	// speedsKmh is the default speed for each accepted highway type
+	speedsKmh map[string]float64
+	// accessTag is the mode specific access tag, such as "motor_vehicle" or "foot"
+	accessTag string
+	// useOneway tells if one-way streets are travelled in one direction only
+	useOneway bool
+	// useMaxspeed tells if the maxspeed tag overrides the default speed
+	useMaxspeed bool
+}
+
+var drivingProfile = &profile{
+	speedsKmh: map[string]float64{
+		"motorway":       90,
+		"motorway_link":  45,
+		"trunk":          70,
+		"trunk_link":     40,
+		"primary":        55,
+		"primary_link":   30,
+		"secondary":      45,
+		"secondary_link": 25,
+		"tertiary":       35,
+		"tertiary_link":  20,
+		"unclassified":   30,
+		"residential":    25,
+		"living_street":  10,
+		"service":        15,
+	},
+	accessTag:   "motor_vehicle",
+	useOneway:   true,
+	useMaxspeed: true,
+}
+
+var walkingProfile = &profile{
+	speedsKmh: map[string]float64{
+		"trunk":          5,
+		"trunk_link":     5,
+		"primary":        5,
+		"primary_link":   5,
+		"secondary":      5,
+		"secondary_link": 5,
+		"tertiary":       5,
+		"tertiary_link":  5,
+		"unclassified":   5,
+		"residential":    5,
+		"living_street":  5,
+		"service":        5,
+		"pedestrian":     5,
+		"footway":        5,
+		"path":           5,
+		"steps":          3,
+		"track":          5,
+	},
+	accessTag: "foot",
+}
+
+// wayDirections returns the speed of the way and the directions it can be travelled in, or ok false if the way is not usable
+func (p *profile) wayDirections(tags map[string]string) (speedKmh float64, forward, backward, ok bool) {
+	speedKmh, ok = p.speedsKmh[tags["highway"]]
+	if !ok {
+		return 0, false, false, false
+	}
+	if isNoAccess(tags["access"]) && !isYesAccess(tags[p.accessTag]) {
+		return 0, false, false, false
+	}
+	if isNoAccess(tags[p.accessTag]) || tags["area"] == "yes" {
+		return 0, false, false, false
+	}
+
+	if p.useMaxspeed {
+		if maxspeed, parsed := parseMaxspeed(tags["maxspeed"]); parsed {
+			speedKmh = maxspeed
+		}
+	}
+
+	forward, backward = true, true
+	if p.useOneway {
+		switch tags["oneway"] {
+		case "yes", "true", "1":
+			backward = false
+		case "-1", "reverse":
+			forward = false
+		default:
+			if tags["junction"] == "roundabout" || tags["highway"] == "motorway" {
+				backward = false
+			}
+		}
+	}
+	return speedKmh, forward, backward, true
+}
+
+func isNoAccess(value string) bool {
+	return value == "no" || value == "private"
+}
+
+func isYesAccess(value string) bool {
+	return value == "yes" || value == "designated" || value == "permissive"
+}
+
+// parseMaxspeed parses values such as "50" or "30 mph"
+func parseMaxspeed(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	factor := 1.0
+	if strings.HasSuffix(value, "mph") {
+		factor = 1.609344
+		value = strings.TrimSpace(strings.TrimSuffix(value, "mph"))
+	}
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil || speed <= 0 {
+		return 0, false
+	}
+	return speed * factor, true
+}
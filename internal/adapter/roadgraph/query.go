@@ -0,0 +1,181 @@
+package roadgraph
+
+import (
+	"math"
+	"sync"
+)
+
+// router answers shortest path queries on a contraction hierarchy, it is safe for concurrent use
+type router struct {
+	ch     *contractedGraph
+	states sync.Pool
+}
+
+func newRouter(ch *contractedGraph) *router {
+	n := ch.Base.numNodes()
+	return &router{
+		ch: ch,
+		states: sync.Pool{New: func() any {
+			return newDijkstraState(n)
+		}},
+	}
+}
+
+func (r *router) acquire() *dijkstraState {
+	return r.states.Get().(*dijkstraState)
+}
+
+func (r *router) release(s *dijkstraState) {
+	s.reset()
+	r.states.Put(s)
+}
+
+// search runs a full Dijkstra from the node on the upward or downward edges, the search space of a hierarchy is small
+func (r *router) search(state *dijkstraState, from int32, edges *chEdges) {
+	state.push(from, 0, 0)
+	for {
+		x, time, ok := state.pop()
+		if !ok {
+			return
+		}
+		first, last := edges.edges(x)
+		for i := first; i < last; i++ {
+			state.pushWithParent(edges.Heads[i], time+edges.Times[i], state.lengths[x]+edges.Lengths[i], x, i)
+		}
+	}
+}
+
+// shortestPath returns the travel time, the length and the nodes of the fastest path, ok is false when the target is unreachable
+func (r *router) shortestPath(source, target int32) (time, length float64, nodes []int32, ok bool) {
+	if source == target {
+		return 0, 0, []int32{source}, true
+	}
+
+	forward, backward := r.acquire(), r.acquire()
+	defer r.release(forward)
+	defer r.release(backward)
+	r.search(forward, source, &r.ch.Up)
+	r.search(backward, target, &r.ch.Down)
+
+	meeting, best := int32(-1), math.Inf(1)
+	for _, v := range forward.visited {
+		if !backward.isSettled(v) {
+			continue
+		}
+		if total := forward.times[v] + backward.times[v]; total < best {
+			meeting, best = v, total
+		}
+	}
+	if meeting < 0 {
+		return 0, 0, nil, false
+	}
+
+	// The upward half from the source, its parents point towards the source
+	upward := make([]int32, 0)
+	for v := meeting; v != source; v = forward.parents[v] {
+		upward = append(upward, v)
+	}
+	nodes = []int32{source}
+	for i := len(upward) - 1; i >= 0; i-- {
+		v := upward[i]
+		nodes = append(nodes, r.unpack(forward.parents[v], v, r.ch.Up.Middles[forward.parentEdges[v]])...)
+	}
+
+	// The downward half to the target, its parents point towards the target
+	for v := meeting; v != target; v = backward.parents[v] {
+		e := backward.parentEdges[v]
+		nodes = append(nodes, r.unpack(v, backward.parents[v], r.ch.Down.Middles[e])...)
+	}
+
+	return best, forward.lengths[meeting] + backward.lengths[meeting], nodes, true
+}
+
+// unpack returns the road graph nodes of the edge from -> to, without the from node
+func (r *router) unpack(from, to, middle int32) []int32 {
+	if middle == noMiddle {
+		return []int32{to}
+	}
+	// The middle node is ranked below both ends: from -> middle is a downward edge stored at middle,
+	// middle -> to is an upward edge stored at middle
+	nodes := r.unpack(from, middle, r.bestMiddle(&r.ch.Down, middle, from))
+	return append(nodes, r.unpack(middle, to, r.bestMiddle(&r.ch.Up, middle, to))...)
+}
+
+// bestMiddle returns the middle of the fastest edge stored at the node with the given head
+func (r *router) bestMiddle(edges *chEdges, at, head int32) int32 {
+	middle, best := noMiddle, math.Inf(1)
+	first, last := edges.edges(at)
+	for i := first; i < last; i++ {
+		if edges.Heads[i] == head && edges.Times[i] < best {
+			middle, best = edges.Middles[i], edges.Times[i]
+		}
+	}
+	return middle
+}
+
+// bucketEntry is a target reached by a backward search
+type bucketEntry struct {
+	target int
+	time   float64
+	length float64
+}
+
+// manyToMany returns the travel times and lengths between every source and target, unreachable pairs have an infinite time
+func (r *router) manyToMany(sources, targets []int32) (times, lengths [][]float64) {
+	buckets := make(map[int32][]bucketEntry)
+	state := r.acquire()
+	for j, target := range targets {
+		r.search(state, target, &r.ch.Down)
+		for _, v := range state.visited {
+			buckets[v] = append(buckets[v], bucketEntry{target: j, time: state.times[v], length: state.lengths[v]})
+		}
+		state.reset()
+	}
+
+	times = make([][]float64, len(sources))
+	lengths = make([][]float64, len(sources))
+	for i, source := range sources {
+		times[i] = make([]float64, len(targets))
+		lengths[i] = make([]float64, len(targets))
+		for j := range times[i] {
+			times[i][j] = math.Inf(1)
+		}
+		r.search(state, source, &r.ch.Up)
+		for _, v := range state.visited {
+			for _, entry := range buckets[v] {
+				if total := state.times[v] + entry.time; total < times[i][entry.target] {
+					times[i][entry.target] = total
+					lengths[i][entry.target] = state.lengths[v] + entry.length
+				}
+			}
+		}
+		state.reset()
+	}
+	r.states.Put(state)
+	return times, lengths
+}
+
+// reachable returns the nodes reachable from the source on the road graph within the limit,
+// the limit applies to the travel time in seconds or to the length in meters
+func (r *router) reachable(source int32, limit float64, byLength bool) []int32 {
+	g := r.ch.Base
+	state := r.acquire()
+	defer r.release(state)
+
+	nodes := make([]int32, 0)
+	state.push(source, 0, 0)
+	for {
+		x, time, ok := state.pop()
+		if !ok {
+			return nodes
+		}
+		nodes = append(nodes, x)
+		for i := g.First[x]; i < g.First[x+1]; i++ {
+			nextTime, nextLength := time+g.Times[i], state.lengths[x]+g.Lengths[i]
+			if (byLength && nextLength > limit) || (!byLength && nextTime > limit) {
+				continue
+			}
+			state.push(g.Heads[i], nextTime, nextLength)
+		}
+	}
+}
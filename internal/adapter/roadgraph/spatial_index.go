@@ -0,0 +1,70 @@
+package roadgraph
+
+import "math"
+
+// gridCellDegrees is the size of the cells of the spatial index, about 1km
+const gridCellDegrees = 0.01
+
+type cell struct {
+	lat, lng int32
+}
+
+// spatialIndex finds the closest graph node to a point with a uniform grid
+type spatialIndex struct {
+	g        *graph
+	cells    map[cell][]int32
+	min, max cell
+}
+
+func newSpatialIndex(g *graph) *spatialIndex {
+	index := &spatialIndex{g: g, cells: make(map[cell][]int32)}
+	for v := range g.Lats {
+		c := cellOf(g.Lats[v], g.Lngs[v])
+		index.cells[c] = append(index.cells[c], int32(v))
+		if v == 0 {
+			index.min, index.max = c, c
+		}
+		index.min = cell{lat: min(index.min.lat, c.lat), lng: min(index.min.lng, c.lng)}
+		index.max = cell{lat: max(index.max.lat, c.lat), lng: max(index.max.lng, c.lng)}
+	}
+	return index
+}
+
+func cellOf(lat, lng float64) cell {
+	return cell{lat: int32(math.Floor(lat / gridCellDegrees)), lng: int32(math.Floor(lng / gridCellDegrees))}
+}
+
+// nearest returns the closest node, the rings of cells around the point are searched until a node is found,
+// plus one more ring since a node of the next ring can be closer than one in a corner of the current ring
+func (index *spatialIndex) nearest(lat, lng float64) (int32, bool) {
+	center := cellOf(lat, lng)
+	best, bestDistance := int32(-1), math.Inf(1)
+	// No node lies beyond the ring that covers the whole graph
+	maxRing := max(abs32(center.lat-index.min.lat), abs32(center.lat-index.max.lat),
+		abs32(center.lng-index.min.lng), abs32(center.lng-index.max.lng))
+	for ring, lastRing := int32(0), int32(-1); ring <= maxRing && (lastRing < 0 || ring <= lastRing); ring++ {
+		for dLat := -ring; dLat <= ring; dLat++ {
+			for dLng := -ring; dLng <= ring; dLng++ {
+				if abs32(dLat) != ring && abs32(dLng) != ring {
+					continue
+				}
+				for _, v := range index.cells[cell{lat: center.lat + dLat, lng: center.lng + dLng}] {
+					if d := distanceMeters(lat, lng, index.g.Lats[v], index.g.Lngs[v]); d < bestDistance {
+						best, bestDistance = v, d
+					}
+				}
+			}
+		}
+		if best >= 0 && lastRing < 0 {
+			lastRing = ring + 1
+		}
+	}
+	return best, best >= 0
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,38 @@
+package roadgraph
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+// storedGraphs is the preprocessed road graph file content
+type storedGraphs struct {
+	Driving *contractedGraph
+	Walking *contractedGraph
+}
+
+// Preprocess reads an OSM PBF extract, contracts its driving and walking graphs and writes them to w,
+// loading the result with LoadRoadGraph skips the slow contraction at startup
+func Preprocess(osmExtract io.Reader, w io.Writer) error {
+	driving, walking, err := buildGraphsFromOSM(osmExtract)
+	if err != nil {
+		return err
+	}
+	stored := storedGraphs{Driving: contract(driving), Walking: contract(walking)}
+	if err := gob.NewEncoder(w).Encode(&stored); err != nil {
+		return fmt.Errorf("failed to write road graph: %w", err)
+	}
+	return nil
+}
+
+func loadStoredGraphs(r io.Reader) (*storedGraphs, error) {
+	stored := &storedGraphs{}
+	if err := gob.NewDecoder(r).Decode(stored); err != nil {
+		return nil, fmt.Errorf("failed to read road graph: %w", err)
+	}
+	if stored.Driving == nil || stored.Walking == nil {
+		return nil, fmt.Errorf("road graph file misses the driving or walking graph")
+	}
+	return stored, nil
+}
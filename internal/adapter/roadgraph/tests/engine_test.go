@@ -0,0 +1,365 @@
+package tests
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"matching-engine/internal/adapter/roadgraph"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/umahmood/haversine"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	gridSize    = 6
+	gridSpacing = 0.002
+	originLat   = 42.5
+	originLng   = 1.5
+)
+
+type testWay struct {
+	refs []int64
+	tags map[string]string
+}
+
+// testGrid is a grid of streets with random speeds, node ids are row*gridSize+col+1
+type testGrid struct {
+	lats, lngs map[int64]float64
+	ways       []testWay
+}
+
+func newTestGrid() *testGrid {
+	grid := &testGrid{lats: make(map[int64]float64), lngs: make(map[int64]float64)}
+	random := rand.New(rand.NewSource(42))
+	for row := 0; row < gridSize; row++ {
+		for col := 0; col < gridSize; col++ {
+			id := nodeID(row, col)
+			// Coordinates as stored in the extract with the default granularity of 100 nanodegrees
+			grid.lats[id] = 1e-9 * 100 * math.Round((originLat+float64(row)*gridSpacing)*1e7)
+			grid.lngs[id] = 1e-9 * 100 * math.Round((originLng+float64(col)*gridSpacing)*1e7)
+		}
+	}
+	speeds := []string{"20", "30", "50", "70"}
+	for row := 0; row < gridSize; row++ {
+		for col := 0; col < gridSize; col++ {
+			if col+1 < gridSize {
+				grid.ways = append(grid.ways, testWay{
+					refs: []int64{nodeID(row, col), nodeID(row, col+1)},
+					tags: map[string]string{"highway": "residential", "maxspeed": speeds[random.Intn(len(speeds))]},
+				})
+			}
+			if row+1 < gridSize {
+				tags := map[string]string{"highway": "secondary", "maxspeed": speeds[random.Intn(len(speeds))]}
+				if col == 2 {
+					tags["oneway"] = "yes"
+				}
+				grid.ways = append(grid.ways, testWay{refs: []int64{nodeID(row, col), nodeID(row+1, col)}, tags: tags})
+			}
+		}
+	}
+	// A footway and a private road, the first one only for walking and the second one for nobody
+	grid.ways = append(grid.ways,
+		testWay{refs: []int64{nodeID(0, 0), nodeID(gridSize-1, gridSize-1)}, tags: map[string]string{"highway": "footway"}},
+		testWay{refs: []int64{nodeID(0, gridSize-1), nodeID(gridSize-1, 0)}, tags: map[string]string{"highway": "primary", "access": "private"}},
+	)
+	return grid
+}
+
+func nodeID(row, col int) int64 {
+	return int64(row*gridSize + col + 1)
+}
+
+func (grid *testGrid) coordinate(t *testing.T, id int64) model.Coordinate {
+	c, err := model.NewCoordinate(grid.lats[id], grid.lngs[id])
+	if err != nil {
+		t.Fatalf("invalid coordinate: %v", err)
+	}
+	return *c
+}
+
+// expectedTimes runs a Dijkstra on the grid with the driving rules and returns the travel times from the source in seconds
+func (grid *testGrid) expectedTimes(source int64) map[int64]float64 {
+	type arc struct {
+		to   int64
+		time float64
+	}
+	adjacency := make(map[int64][]arc)
+	for _, way := range grid.ways {
+		if way.tags["highway"] == "footway" || way.tags["access"] == "private" {
+			continue
+		}
+		var speed float64
+		_, _ = fmt.Sscanf(way.tags["maxspeed"], "%f", &speed)
+		from, to := way.refs[0], way.refs[1]
+		_, km := haversine.Distance(
+			haversine.Coord{Lat: grid.lats[from], Lon: grid.lngs[from]},
+			haversine.Coord{Lat: grid.lats[to], Lon: grid.lngs[to]},
+		)
+		travelTime := km * 1000 / (speed / 3.6)
+		adjacency[from] = append(adjacency[from], arc{to: to, time: travelTime})
+		if way.tags["oneway"] != "yes" {
+			adjacency[to] = append(adjacency[to], arc{to: from, time: travelTime})
+		}
+	}
+
+	times := map[int64]float64{source: 0}
+	settled := make(map[int64]bool)
+	for {
+		current, best := int64(-1), math.Inf(1)
+		for id, t := range times {
+			if !settled[id] && t < best {
+				current, best = id, t
+			}
+		}
+		if current < 0 {
+			return times
+		}
+		settled[current] = true
+		for _, a := range adjacency[current] {
+			if previous, ok := times[a.to]; !ok || best+a.time < previous {
+				times[a.to] = best + a.time
+			}
+		}
+	}
+}
+
+// encodePBF encodes the grid as an OSM PBF extract with a header blob and a zlib compressed data blob
+func (grid *testGrid) encodePBF(t *testing.T) []byte {
+	strings := []string{""}
+	stringIndex := func(s string) uint64 {
+		for i, existing := range strings {
+			if existing == s {
+				return uint64(i)
+			}
+		}
+		strings = append(strings, s)
+		return uint64(len(strings) - 1)
+	}
+
+	var ids, lats, lons []byte
+	var previousID, previousLat, previousLon int64
+	for id := int64(1); id <= gridSize*gridSize; id++ {
+		lat := int64(math.Round(grid.lats[id] * 1e7))
+		lon := int64(math.Round(grid.lngs[id] * 1e7))
+		ids = protowire.AppendVarint(ids, protowire.EncodeZigZag(id-previousID))
+		lats = protowire.AppendVarint(lats, protowire.EncodeZigZag(lat-previousLat))
+		lons = protowire.AppendVarint(lons, protowire.EncodeZigZag(lon-previousLon))
+		previousID, previousLat, previousLon = id, lat, lon
+	}
+	var dense []byte
+	dense = appendBytesField(dense, 1, ids)
+	dense = appendBytesField(dense, 8, lats)
+	dense = appendBytesField(dense, 9, lons)
+	var nodesGroup []byte
+	nodesGroup = appendBytesField(nodesGroup, 2, dense)
+
+	var waysGroup []byte
+	for i, way := range grid.ways {
+		var keys, vals, refs []byte
+		for key, value := range way.tags {
+			keys = protowire.AppendVarint(keys, stringIndex(key))
+			vals = protowire.AppendVarint(vals, stringIndex(value))
+		}
+		var previousRef int64
+		for _, ref := range way.refs {
+			refs = protowire.AppendVarint(refs, protowire.EncodeZigZag(ref-previousRef))
+			previousRef = ref
+		}
+		var encoded []byte
+		encoded = protowire.AppendTag(encoded, 1, protowire.VarintType)
+		encoded = protowire.AppendVarint(encoded, uint64(1000+i))
+		encoded = appendBytesField(encoded, 2, keys)
+		encoded = appendBytesField(encoded, 3, vals)
+		encoded = appendBytesField(encoded, 8, refs)
+		waysGroup = appendBytesField(waysGroup, 3, encoded)
+	}
+
+	var stringTable []byte
+	for _, s := range strings {
+		stringTable = appendBytesField(stringTable, 1, []byte(s))
+	}
+	var block []byte
+	block = appendBytesField(block, 1, stringTable)
+	block = appendBytesField(block, 2, nodesGroup)
+	block = appendBytesField(block, 2, waysGroup)
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(block); err != nil {
+		t.Fatalf("failed to compress block: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to compress block: %v", err)
+	}
+
+	var out bytes.Buffer
+	writeBlob(&out, "OSMHeader", appendBytesField(nil, 1, []byte("ignored header block")))
+	writeBlob(&out, "OSMData", appendBytesField(nil, 3, compressed.Bytes()))
+	return out.Bytes()
+}
+
+func appendBytesField(b []byte, num protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+func writeBlob(out *bytes.Buffer, blobType string, blob []byte) {
+	var header []byte
+	header = appendBytesField(header, 1, []byte(blobType))
+	header = protowire.AppendTag(header, 3, protowire.VarintType)
+	header = protowire.AppendVarint(header, uint64(len(blob)))
+	_ = binary.Write(out, binary.BigEndian, uint32(len(header)))
+	out.Write(header)
+	out.Write(blob)
+}
+
+func newEngines(t *testing.T, grid *testGrid) map[string]routing.Engine {
+	extract := grid.encodePBF(t)
+	fromOSM, err := roadgraph.NewRoadGraphFromOSM(bytes.NewReader(extract))
+	if err != nil {
+		t.Fatalf("failed to build road graph: %v", err)
+	}
+
+	var preprocessed bytes.Buffer
+	if err := roadgraph.Preprocess(bytes.NewReader(extract), &preprocessed); err != nil {
+		t.Fatalf("failed to preprocess road graph: %v", err)
+	}
+	loaded, err := roadgraph.LoadRoadGraph(&preprocessed)
+	if err != nil {
+		t.Fatalf("failed to load road graph: %v", err)
+	}
+	return map[string]routing.Engine{"osm extract": fromOSM, "preprocessed": loaded}
+}
+
+func TestRoadGraph_MatrixMatchesDijkstra(t *testing.T) {
+	grid := newTestGrid()
+	coordinates := make([]model.Coordinate, 0, gridSize*gridSize)
+	for id := int64(1); id <= gridSize*gridSize; id++ {
+		coordinates = append(coordinates, grid.coordinate(t, id))
+	}
+
+	for name, engine := range newEngines(t, grid) {
+		t.Run(name, func(t *testing.T) {
+			params, err := model.NewDistanceTimeMatrixParams(coordinates, model.ProfileAuto)
+			if err != nil {
+				t.Fatalf("failed to create matrix params: %v", err)
+			}
+			matrix, err := engine.ComputeDistanceTimeMatrix(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i := range coordinates {
+				expected := grid.expectedTimes(int64(i + 1))
+				for j := range coordinates {
+					actual := matrix.Times()[i][j].Seconds()
+					if math.Abs(actual-expected[int64(j+1)]) > 1e-3 {
+						t.Fatalf("time from %d to %d: expected %.3fs, got %.3fs", i+1, j+1, expected[int64(j+1)], actual)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRoadGraph_RouteAndDrivingTime(t *testing.T) {
+	grid := newTestGrid()
+	source := grid.coordinate(t, nodeID(0, 0))
+	via := grid.coordinate(t, nodeID(3, 4))
+	target := grid.coordinate(t, nodeID(gridSize-1, gridSize-1))
+
+	for name, engine := range newEngines(t, grid) {
+		t.Run(name, func(t *testing.T) {
+			params, _ := model.NewRouteParams([]model.Coordinate{source, via, target}, time.Now().Add(time.Hour))
+			route, err := engine.PlanDrivingRoute(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			durations, err := engine.ComputeDrivingTime(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			firstLeg := grid.expectedTimes(nodeID(0, 0))[nodeID(3, 4)]
+			secondLeg := grid.expectedTimes(nodeID(3, 4))[nodeID(gridSize-1, gridSize-1)]
+			if math.Abs(durations[1].Seconds()-firstLeg) > 1e-3 || math.Abs(durations[2].Seconds()-firstLeg-secondLeg) > 1e-3 {
+				t.Errorf("expected cumulative times %.3f and %.3f, got %v", firstLeg, firstLeg+secondLeg, durations)
+			}
+			if math.Abs(route.Time().Seconds()-durations[2].Seconds()) > 1e-3 {
+				t.Errorf("route time %v differs from the driving time %v", route.Time(), durations[2])
+			}
+
+			// The unpacked route only moves between neighbouring grid nodes and goes through the waypoints
+			coords, err := route.Polyline().Coordinates()
+			if err != nil {
+				t.Fatalf("failed to decode route: %v", err)
+			}
+			passesVia := false
+			for i, c := range coords {
+				if math.Abs(c.Lat()-via.Lat()) < 1e-6 && math.Abs(c.Lng()-via.Lng()) < 1e-6 {
+					passesVia = true
+				}
+				if i == 0 {
+					continue
+				}
+				steps := math.Round(math.Abs(c.Lat()-coords[i-1].Lat())/gridSpacing) + math.Round(math.Abs(c.Lng()-coords[i-1].Lng())/gridSpacing)
+				if steps != 1 {
+					t.Fatalf("route jumps from %v to %v", coords[i-1], c)
+				}
+			}
+			if !passesVia {
+				t.Error("route does not go through the via waypoint")
+			}
+		})
+	}
+}
+
+func TestRoadGraph_WalkingSnapAndIsochrone(t *testing.T) {
+	grid := newTestGrid()
+	for name, engine := range newEngines(t, grid) {
+		t.Run(name, func(t *testing.T) {
+			// The diagonal footway is shorter than any walk along the streets
+			origin := grid.coordinate(t, nodeID(0, 0))
+			destination := grid.coordinate(t, nodeID(gridSize-1, gridSize-1))
+			walkParams, _ := model.NewWalkParams(&origin, &destination)
+			walkingTime, err := engine.ComputeWalkingTime(context.Background(), walkParams)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, km := haversine.Distance(
+				haversine.Coord{Lat: origin.Lat(), Lon: origin.Lng()},
+				haversine.Coord{Lat: destination.Lat(), Lon: destination.Lng()},
+			)
+			if expected := km * 1000 / (5 / 3.6); math.Abs(walkingTime.Seconds()-expected) > 1e-3 {
+				t.Errorf("expected %.1fs along the footway, got %v", expected, walkingTime)
+			}
+
+			point, _ := model.NewCoordinate(originLat+gridSpacing*1.1, originLng+gridSpacing*2.05)
+			snapped, err := engine.SnapPointToRoad(context.Background(), point)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectedSnap := grid.coordinate(t, nodeID(1, 2))
+			if !snapped.Equal(&expectedSnap) {
+				t.Errorf("expected snap to %v, got %v", expectedSnap, snapped)
+			}
+
+			contour, _ := model.NewContour(3, model.ContourMetricTimeInMinutes)
+			isochroneParams, _ := model.NewIsochroneParams(&origin, contour, model.ProfileAuto)
+			isochrone, err := engine.ComputeIsochrone(context.Background(), isochroneParams)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !isochrone.Geometry().IsClosed() {
+				t.Error("expected a closed isochrone ring")
+			}
+		})
+	}
+}
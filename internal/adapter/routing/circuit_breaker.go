@@ -0,0 +1,156 @@
+package routing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops calls to a backend after threshold consecutive failures.
+// Once cooldown has passed a single probe call is let through, its outcome closes the circuit or opens it again.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+	probing   bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		state:     CircuitClosed,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= cb.cooldown {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// Allow reports whether a call may be sent, every allowed call must be followed by Record or Release
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return ErrCircuitOpen
+		}
+		cb.setState(CircuitHalfOpen)
+		cb.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if cb.probing {
+			return ErrCircuitOpen
+		}
+		cb.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record counts the outcome of an allowed call
+func (cb *CircuitBreaker) Record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	if success {
+		cb.failures = 0
+		cb.setState(CircuitClosed)
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = time.Now()
+		cb.setState(CircuitOpen)
+	}
+}
+
+// Release gives back an allowed call whose outcome says nothing about the backend, e.g. a cancelled call
+func (cb *CircuitBreaker) Release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if cb.state == state {
+		return
+	}
+	log.Warn().
+		Stringer("from", cb.state).
+		Stringer("to", state).
+		Int("failures", cb.failures).
+		Msg("Routing backend circuit breaker changed state")
+	cb.state = state
+}
+
+type circuitBreakerClient[TransReq any, TransRes any] struct {
+	next    Client[TransReq, TransRes]
+	breaker *CircuitBreaker
+}
+
+// NewCircuitBreakerClient fails fast with ErrCircuitOpen while the breaker is open.
+// Permanent errors and calls cancelled by the caller are not counted as backend failures.
+func NewCircuitBreakerClient[TransReq any, TransRes any](
+	next Client[TransReq, TransRes],
+	breaker *CircuitBreaker,
+) Client[TransReq, TransRes] {
+	return &circuitBreakerClient[TransReq, TransRes]{
+		next:    next,
+		breaker: breaker,
+	}
+}
+
+func (c *circuitBreakerClient[TransReq, TransRes]) Post(ctx context.Context, endpoint string, req TransReq) (TransRes, error) {
+	if err := c.breaker.Allow(); err != nil {
+		var zero TransRes
+		return zero, err
+	}
+
+	res, err := c.next.Post(ctx, endpoint, req)
+	switch {
+	case err == nil || IsPermanent(err):
+		c.breaker.Record(true)
+	case ctx.Err() != nil:
+		c.breaker.Release()
+	default:
+		c.breaker.Record(false)
+	}
+	return res, err
+}
@@ -1,5 +1,7 @@
 package routing
 
+import "context"
+
 type Client[TransReq any, TransRes any] interface {
-	Post(endpoint string, req TransReq) (TransRes, error)
+	Post(ctx context.Context, endpoint string, req TransReq) (TransRes, error)
 }
@@ -0,0 +1,31 @@
+package routing
+
+import "errors"
+
+// ErrCircuitOpen is returned without calling the backend while the circuit breaker is open
+var ErrCircuitOpen = errors.New("routing backend circuit breaker is open")
+
+// ErrIsochroneNotSupported is returned by ComputeIsochrone by the engines without an isochrone service
+var ErrIsochroneNotSupported = errors.New("isochrones are not supported")
+
+// PermanentError marks a failure that will not go away by sending the same request again,
+// such as a 4xx response, it is neither retried nor counted against the backend by the circuit breaker
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err in a PermanentError, it returns nil for a nil error
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
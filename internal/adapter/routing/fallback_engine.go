@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/model"
+)
+
+var _ Engine = (*FallbackEngine)(nil)
+
+// FallbackEngine sends every call to the primary engine and retries it on the fallback engine when the primary fails,
+// it keeps the matching running in a degraded mode while the primary routing service is down
+type FallbackEngine struct {
+	primary  Engine
+	fallback Engine
+}
+
+func NewFallbackEngine(primary, fallback Engine) Engine {
+	return &FallbackEngine{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (f *FallbackEngine) PlanDrivingRoute(ctx context.Context, routeParams *model.RouteParams) (*model.Route, error) {
+	route, err := f.primary.PlanDrivingRoute(ctx, routeParams)
+	if err == nil || !f.shouldFallback(ctx, "PlanDrivingRoute", err) {
+		return route, err
+	}
+	return f.fallback.PlanDrivingRoute(ctx, routeParams)
+}
+
+func (f *FallbackEngine) ComputeDrivingTime(ctx context.Context, routeParams *model.RouteParams) ([]time.Duration, error) {
+	durations, err := f.primary.ComputeDrivingTime(ctx, routeParams)
+	if err == nil || !f.shouldFallback(ctx, "ComputeDrivingTime", err) {
+		return durations, err
+	}
+	return f.fallback.ComputeDrivingTime(ctx, routeParams)
+}
+
+func (f *FallbackEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	duration, err := f.primary.ComputeWalkingTime(ctx, walkParams)
+	if err == nil || !f.shouldFallback(ctx, "ComputeWalkingTime", err) {
+		return duration, err
+	}
+	return f.fallback.ComputeWalkingTime(ctx, walkParams)
+}
+
+func (f *FallbackEngine) ComputeIsochrone(ctx context.Context, req *model.IsochroneParams) (*model.Isochrone, error) {
+	isochrone, err := f.primary.ComputeIsochrone(ctx, req)
+	if err == nil || !f.shouldFallback(ctx, "ComputeIsochrone", err) {
+		return isochrone, err
+	}
+	return f.fallback.ComputeIsochrone(ctx, req)
+}
+
+func (f *FallbackEngine) ComputeDistanceTimeMatrix(ctx context.Context, req *model.DistanceTimeMatrixParams) (*model.DistanceTimeMatrix, error) {
+	matrix, err := f.primary.ComputeDistanceTimeMatrix(ctx, req)
+	if err == nil || !f.shouldFallback(ctx, "ComputeDistanceTimeMatrix", err) {
+		return matrix, err
+	}
+	return f.fallback.ComputeDistanceTimeMatrix(ctx, req)
+}
+
+func (f *FallbackEngine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	snapped, err := f.primary.SnapPointToRoad(ctx, point)
+	if err == nil || !f.shouldFallback(ctx, "SnapPointToRoad", err) {
+		return snapped, err
+	}
+	return f.fallback.SnapPointToRoad(ctx, point)
+}
+
+// shouldFallback logs the primary failure, a cancelled context is returned as is
+func (f *FallbackEngine) shouldFallback(ctx context.Context, operation string, err error) bool {
+	if ctx != nil && ctx.Err() != nil {
+		return false
+	}
+	log.Warn().Err(err).Str("operation", operation).Msg("Primary routing engine failed, using the fallback engine")
+	return true
+}
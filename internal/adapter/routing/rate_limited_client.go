@@ -0,0 +1,104 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type rateLimitedClient[TransReq any, TransRes any] struct {
+	next   Client[TransReq, TransRes]
+	slots  chan struct{}
+	bucket *tokenBucket
+}
+
+// NewRateLimitedClient allows at most maxConcurrent calls in flight and starts at most rps calls per second,
+// callers wait for their turn until their context is done. A zero value disables the matching limit.
+func NewRateLimitedClient[TransReq any, TransRes any](
+	next Client[TransReq, TransRes],
+	maxConcurrent int,
+	rps float64,
+) Client[TransReq, TransRes] {
+	c := &rateLimitedClient[TransReq, TransRes]{next: next}
+	if maxConcurrent > 0 {
+		c.slots = make(chan struct{}, maxConcurrent)
+	}
+	if rps > 0 {
+		c.bucket = newTokenBucket(rps)
+	}
+	return c
+}
+
+func (c *rateLimitedClient[TransReq, TransRes]) Post(ctx context.Context, endpoint string, req TransReq) (TransRes, error) {
+	var zero TransRes
+
+	if c.slots != nil {
+		select {
+		case c.slots <- struct{}{}:
+			defer func() { <-c.slots }()
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+
+	if c.bucket != nil {
+		if err := c.bucket.wait(ctx); err != nil {
+			return zero, err
+		}
+	}
+
+	return c.next.Post(ctx, endpoint, req)
+}
+
+// tokenBucket refills rate tokens per second and holds up to one second worth of tokens
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	capacity := math.Max(1, math.Ceil(rate))
+	return &tokenBucket{
+		rate:     rate,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		delay := b.take()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take consumes a token when one is available, otherwise it returns how long to wait for the next one
+func (b *tokenBucket) take() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
@@ -0,0 +1,143 @@
+package routing
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResilienceConfig holds the settings of the decorators built by NewResilientClient.
+// A zero value for a limit disables the matching decorator.
+type ResilienceConfig struct {
+	// Timeout bounds a single attempt of any endpoint without its own entry in EndpointTimeouts
+	Timeout          time.Duration
+	EndpointTimeouts map[string]time.Duration
+
+	// MaxRetries is the number of attempts made after the first one for idempotent endpoints
+	MaxRetries             int
+	RetryBaseDelay         time.Duration
+	RetryMaxDelay          time.Duration
+	NonIdempotentEndpoints map[string]bool
+
+	// BreakerThreshold consecutive failures open the circuit for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	MaxConcurrentRequests int
+	RateLimitRPS          float64
+}
+
+func DefaultResilienceConfig() *ResilienceConfig {
+	return &ResilienceConfig{
+		Timeout:                10 * time.Second,
+		EndpointTimeouts:       map[string]time.Duration{},
+		MaxRetries:             2,
+		RetryBaseDelay:         100 * time.Millisecond,
+		RetryMaxDelay:          2 * time.Second,
+		NonIdempotentEndpoints: map[string]bool{},
+		BreakerThreshold:       5,
+		BreakerCooldown:        30 * time.Second,
+		// Same as the idle connections kept per host by the http clients
+		MaxConcurrentRequests: 50,
+		RateLimitRPS:          0,
+	}
+}
+
+// LoadResilienceConfig reads the settings of one backend from the environment,
+// every key is prefixed with the backend name, e.g. VALHALLA_TIMEOUT_MS.
+// Keys that are not set keep the value from def, or from DefaultResilienceConfig when def is nil.
+func LoadResilienceConfig(prefix string, def *ResilienceConfig) (*ResilienceConfig, error) {
+	if def == nil {
+		def = DefaultResilienceConfig()
+	}
+	c := *def
+	c.EndpointTimeouts = make(map[string]time.Duration, len(def.EndpointTimeouts))
+	for endpoint, timeout := range def.EndpointTimeouts {
+		c.EndpointTimeouts[endpoint] = timeout
+	}
+	c.NonIdempotentEndpoints = make(map[string]bool, len(def.NonIdempotentEndpoints))
+	for endpoint := range def.NonIdempotentEndpoints {
+		c.NonIdempotentEndpoints[endpoint] = true
+	}
+
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"TIMEOUT_MS", &c.Timeout},
+		{"RETRY_BASE_DELAY_MS", &c.RetryBaseDelay},
+		{"RETRY_MAX_DELAY_MS", &c.RetryMaxDelay},
+		{"CIRCUIT_BREAKER_COOLDOWN_MS", &c.BreakerCooldown},
+	}
+	for _, d := range durations {
+		key := prefix + "_" + d.key
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			ms, err := strconv.Atoi(v)
+			if err != nil || ms < 0 {
+				return nil, fmt.Errorf("invalid %s %q", key, v)
+			}
+			*d.target = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	ints := []struct {
+		key    string
+		target *int
+	}{
+		{"MAX_RETRIES", &c.MaxRetries},
+		{"CIRCUIT_BREAKER_THRESHOLD", &c.BreakerThreshold},
+		{"MAX_CONCURRENT_REQUESTS", &c.MaxConcurrentRequests},
+	}
+	for _, i := range ints {
+		key := prefix + "_" + i.key
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s %q", key, v)
+			}
+			*i.target = n
+		}
+	}
+
+	key := prefix + "_RATE_LIMIT_RPS"
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil || rps < 0 {
+			return nil, fmt.Errorf("invalid %s %q", key, v)
+		}
+		c.RateLimitRPS = rps
+	}
+
+	// Endpoint timeouts are given as a comma separated list, e.g. "/matrix=5000,/route=2000"
+	key = prefix + "_ENDPOINT_TIMEOUTS_MS"
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		for _, entry := range strings.Split(v, ",") {
+			endpoint, ms, found := strings.Cut(strings.TrimSpace(entry), "=")
+			if !found || endpoint == "" {
+				return nil, fmt.Errorf("invalid %s entry %q", key, entry)
+			}
+			timeout, err := strconv.Atoi(ms)
+			if err != nil || timeout < 0 {
+				return nil, fmt.Errorf("invalid %s entry %q", key, entry)
+			}
+			c.EndpointTimeouts[endpoint] = time.Duration(timeout) * time.Millisecond
+		}
+	}
+
+	key = prefix + "_NON_IDEMPOTENT_ENDPOINTS"
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		for _, endpoint := range strings.Split(v, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				c.NonIdempotentEndpoints[endpoint] = true
+			}
+		}
+	}
+
+	return &c, nil
+}
+
+func (c *ResilienceConfig) isIdempotent(endpoint string) bool {
+	return !c.NonIdempotentEndpoints[endpoint]
+}
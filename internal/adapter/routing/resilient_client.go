@@ -0,0 +1,26 @@
+package routing
+
+// NewResilientClient wraps a transport client with the decorators configured in cfg.
+// From the outside in: retries, circuit breaker, concurrency and rate limit, per attempt timeout.
+// The breaker sits inside the retries so that every attempt counts, and the timeout is innermost
+// so that time spent waiting for a slot is not taken from the attempt.
+func NewResilientClient[TransReq any, TransRes any](
+	next Client[TransReq, TransRes],
+	cfg *ResilienceConfig,
+) Client[TransReq, TransRes] {
+	if cfg == nil {
+		cfg = DefaultResilienceConfig()
+	}
+
+	client := NewTimeoutClient(next, cfg.Timeout, cfg.EndpointTimeouts)
+	if cfg.MaxConcurrentRequests > 0 || cfg.RateLimitRPS > 0 {
+		client = NewRateLimitedClient(client, cfg.MaxConcurrentRequests, cfg.RateLimitRPS)
+	}
+	if cfg.BreakerThreshold > 0 {
+		client = NewCircuitBreakerClient(client, NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown))
+	}
+	if cfg.MaxRetries > 0 {
+		client = NewRetryClient(client, cfg.MaxRetries, cfg.RetryBaseDelay, cfg.RetryMaxDelay, cfg.isIdempotent)
+	}
+	return client
+}
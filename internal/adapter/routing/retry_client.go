@@ -0,0 +1,84 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type retryClient[TransReq any, TransRes any] struct {
+	next       Client[TransReq, TransRes]
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	idempotent func(endpoint string) bool
+}
+
+// NewRetryClient sends failed calls to idempotent endpoints again up to maxRetries times,
+// waiting an exponential backoff starting at baseDelay and capped at maxDelay, with jitter so that
+// concurrent callers do not hit a recovering backend at the same moment
+func NewRetryClient[TransReq any, TransRes any](
+	next Client[TransReq, TransRes],
+	maxRetries int,
+	baseDelay, maxDelay time.Duration,
+	idempotent func(endpoint string) bool,
+) Client[TransReq, TransRes] {
+	if idempotent == nil {
+		idempotent = func(string) bool { return true }
+	}
+	return &retryClient[TransReq, TransRes]{
+		next:       next,
+		maxRetries: maxRetries,
+		baseDelay:  baseDelay,
+		maxDelay:   maxDelay,
+		idempotent: idempotent,
+	}
+}
+
+func (c *retryClient[TransReq, TransRes]) Post(ctx context.Context, endpoint string, req TransReq) (TransRes, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := c.next.Post(ctx, endpoint, req)
+		if err == nil || attempt >= c.maxRetries || !c.idempotent(endpoint) || !isRetryable(ctx, err) {
+			return res, err
+		}
+
+		delay := c.backoff(attempt)
+		log.Warn().
+			Err(err).
+			Str("endpoint", endpoint).
+			Int("attempt", attempt+1).
+			Dur("delay", delay).
+			Msg("Routing request failed, retrying")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a delay in [d/2, d) where d doubles with every attempt
+func (c *retryClient[TransReq, TransRes]) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << attempt
+	if delay <= 0 || (c.maxDelay > 0 && delay > c.maxDelay) {
+		delay = c.maxDelay
+	}
+	if delay <= 1 {
+		return delay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half)
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !IsPermanent(err) && !errors.Is(err, ErrCircuitOpen)
+}
@@ -18,6 +18,9 @@ func RunOperation[
 	mapper OperationMapper[DomainReq, DomainRes, TransReq, TransRes],
 ) (DomainRes, error) {
 	var zero DomainRes
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	request, err := mapper.ToTransport(params)
 	if err != nil {
@@ -26,7 +29,7 @@ func RunOperation[
 		)
 	}
 
-	response, err := client.Post(endpoint, request)
+	response, err := client.Post(ctx, endpoint, request)
 	if err != nil {
 		return zero, fmt.Errorf(
 			"failed to send request to routing backend: %w", err,
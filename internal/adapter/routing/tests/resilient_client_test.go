@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"matching-engine/internal/adapter/routing"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("backend unavailable")
+
+// fakeClient answers every call with the result of respond and counts the calls
+type fakeClient struct {
+	calls   atomic.Int32
+	respond func(ctx context.Context, call int32) (string, error)
+}
+
+func (f *fakeClient) Post(ctx context.Context, _ string, _ string) (string, error) {
+	return f.respond(ctx, f.calls.Add(1))
+}
+
+func failingTimes(n int32) *fakeClient {
+	return &fakeClient{respond: func(_ context.Context, call int32) (string, error) {
+		if call <= n {
+			return "", errUnavailable
+		}
+		return "ok", nil
+	}}
+}
+
+func TestRetryClient_RetriesTransientFailures(t *testing.T) {
+	fake := failingTimes(2)
+	client := routing.NewRetryClient[string, string](fake, 2, time.Millisecond, 5*time.Millisecond, nil)
+
+	res, err := client.Post(context.Background(), "/route", "req")
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if res != "ok" || fake.calls.Load() != 3 {
+		t.Errorf("expected ok after 3 calls, got %q after %d calls", res, fake.calls.Load())
+	}
+}
+
+func TestRetryClient_GivesUpAfterMaxRetries(t *testing.T) {
+	fake := failingTimes(10)
+	client := routing.NewRetryClient[string, string](fake, 2, time.Millisecond, 5*time.Millisecond, nil)
+
+	if _, err := client.Post(context.Background(), "/route", "req"); !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the backend error, got %v", err)
+	}
+	if fake.calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", fake.calls.Load())
+	}
+}
+
+func TestRetryClient_DoesNotRetryPermanentErrorsOrNonIdempotentEndpoints(t *testing.T) {
+	permanent := &fakeClient{respond: func(context.Context, int32) (string, error) {
+		return "", routing.Permanent(errors.New("bad request"))
+	}}
+	client := routing.NewRetryClient[string, string](permanent, 3, time.Millisecond, time.Millisecond, nil)
+	if _, err := client.Post(context.Background(), "/route", "req"); !routing.IsPermanent(err) {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+	if permanent.calls.Load() != 1 {
+		t.Errorf("expected a permanent error to be sent once, got %d calls", permanent.calls.Load())
+	}
+
+	fake := failingTimes(10)
+	idempotent := func(endpoint string) bool { return endpoint != "/book" }
+	client = routing.NewRetryClient[string, string](fake, 3, time.Millisecond, time.Millisecond, idempotent)
+	_, _ = client.Post(context.Background(), "/book", "req")
+	if fake.calls.Load() != 1 {
+		t.Errorf("expected a non idempotent call to be sent once, got %d calls", fake.calls.Load())
+	}
+}
+
+func TestRetryClient_StopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fake := &fakeClient{respond: func(context.Context, int32) (string, error) {
+		cancel()
+		return "", errUnavailable
+	}}
+	client := routing.NewRetryClient[string, string](fake, 5, time.Second, time.Second, nil)
+
+	if _, err := client.Post(ctx, "/route", "req"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if fake.calls.Load() != 1 {
+		t.Errorf("expected no retry after cancellation, got %d calls", fake.calls.Load())
+	}
+}
+
+func TestTimeoutClient_UsesEndpointTimeout(t *testing.T) {
+	fake := &fakeClient{respond: func(ctx context.Context, _ int32) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+	client := routing.NewTimeoutClient[string, string](fake, time.Hour, map[string]time.Duration{
+		"/matrix": 10 * time.Millisecond,
+	})
+
+	start := time.Now()
+	_, err := client.Post(context.Background(), "/matrix", "req")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the endpoint timeout to apply, call took %v", elapsed)
+	}
+}
+
+func TestCircuitBreakerClient_OpensAndRecovers(t *testing.T) {
+	healthy := atomic.Bool{}
+	fake := &fakeClient{respond: func(context.Context, int32) (string, error) {
+		if healthy.Load() {
+			return "ok", nil
+		}
+		return "", errUnavailable
+	}}
+	breaker := routing.NewCircuitBreaker(3, 20*time.Millisecond)
+	client := routing.NewCircuitBreakerClient[string, string](fake, breaker)
+
+	for i := 0; i < 3; i++ {
+		_, _ = client.Post(context.Background(), "/route", "req")
+	}
+	if breaker.State() != routing.CircuitOpen {
+		t.Fatalf("expected the circuit to be open, got %v", breaker.State())
+	}
+	if _, err := client.Post(context.Background(), "/route", "req"); !errors.Is(err, routing.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if fake.calls.Load() != 3 {
+		t.Errorf("expected the open circuit to skip the backend, got %d calls", fake.calls.Load())
+	}
+
+	// A failed probe opens the circuit again
+	time.Sleep(30 * time.Millisecond)
+	if breaker.State() != routing.CircuitHalfOpen {
+		t.Fatalf("expected the circuit to be half-open, got %v", breaker.State())
+	}
+	if _, err := client.Post(context.Background(), "/route", "req"); !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the probe to reach the backend, got %v", err)
+	}
+	if breaker.State() != routing.CircuitOpen {
+		t.Fatalf("expected the failed probe to open the circuit, got %v", breaker.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	healthy.Store(true)
+	if _, err := client.Post(context.Background(), "/route", "req"); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if breaker.State() != routing.CircuitClosed {
+		t.Errorf("expected the circuit to be closed, got %v", breaker.State())
+	}
+}
+
+func TestCircuitBreakerClient_IgnoresPermanentErrors(t *testing.T) {
+	fake := &fakeClient{respond: func(context.Context, int32) (string, error) {
+		return "", routing.Permanent(errors.New("no route"))
+	}}
+	breaker := routing.NewCircuitBreaker(2, time.Minute)
+	client := routing.NewCircuitBreakerClient[string, string](fake, breaker)
+
+	for i := 0; i < 5; i++ {
+		_, _ = client.Post(context.Background(), "/route", "req")
+	}
+	if breaker.State() != routing.CircuitClosed {
+		t.Errorf("expected permanent errors to keep the circuit closed, got %v", breaker.State())
+	}
+}
+
+func TestRateLimitedClient_LimitsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	fake := &fakeClient{respond: func(context.Context, int32) (string, error) {
+		current := inFlight.Add(1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		return "ok", nil
+	}}
+	client := routing.NewRateLimitedClient[string, string](fake, 2, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = client.Post(context.Background(), "/route", "req")
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 calls in flight, got %d", peak.Load())
+	}
+	if fake.calls.Load() != 10 {
+		t.Errorf("expected every call to go through, got %d", fake.calls.Load())
+	}
+}
+
+func TestRateLimitedClient_LimitsRate(t *testing.T) {
+	fake := failingTimes(0)
+	client := routing.NewRateLimitedClient[string, string](fake, 0, 100)
+
+	// The bucket starts with 100 tokens, the next 10 calls wait about 10ms each
+	start := time.Now()
+	for i := 0; i < 110; i++ {
+		if _, err := client.Post(context.Background(), "/route", "req"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected the rate limit to slow the calls down, took %v", elapsed)
+	}
+}
+
+func TestResilientClient_RetriesThroughTheStack(t *testing.T) {
+	fake := failingTimes(1)
+	cfg := routing.DefaultResilienceConfig()
+	cfg.RetryBaseDelay = time.Millisecond
+	client := routing.NewResilientClient[string, string](fake, cfg)
+
+	if res, err := client.Post(context.Background(), "/route", "req"); err != nil || res != "ok" {
+		t.Fatalf("expected ok, got %q, %v", res, err)
+	}
+}
+
+func TestLoadResilienceConfig(t *testing.T) {
+	t.Setenv("TESTBACKEND_TIMEOUT_MS", "1500")
+	t.Setenv("TESTBACKEND_ENDPOINT_TIMEOUTS_MS", "/matrix=5000, /route=2000")
+	t.Setenv("TESTBACKEND_MAX_RETRIES", "4")
+	t.Setenv("TESTBACKEND_NON_IDEMPOTENT_ENDPOINTS", "/book")
+	t.Setenv("TESTBACKEND_RATE_LIMIT_RPS", "12.5")
+
+	cfg, err := routing.LoadResilienceConfig("TESTBACKEND", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timeout != 1500*time.Millisecond || cfg.MaxRetries != 4 || cfg.RateLimitRPS != 12.5 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.EndpointTimeouts["/matrix"] != 5*time.Second || cfg.EndpointTimeouts["/route"] != 2*time.Second {
+		t.Errorf("unexpected endpoint timeouts: %v", cfg.EndpointTimeouts)
+	}
+	if !cfg.NonIdempotentEndpoints["/book"] {
+		t.Errorf("expected /book to be non idempotent")
+	}
+	if cfg.BreakerThreshold != routing.DefaultResilienceConfig().BreakerThreshold {
+		t.Errorf("expected unset keys to keep their default")
+	}
+
+	t.Setenv("TESTBACKEND_MAX_RETRIES", "many")
+	if _, err := routing.LoadResilienceConfig("TESTBACKEND", nil); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
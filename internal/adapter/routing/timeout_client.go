@@ -0,0 +1,40 @@
+package routing
+
+import (
+	"context"
+	"time"
+)
+
+type timeoutClient[TransReq any, TransRes any] struct {
+	next      Client[TransReq, TransRes]
+	timeoutOf func(endpoint string) time.Duration
+}
+
+// NewTimeoutClient bounds every call with the timeout of its endpoint,
+// endpoints missing from endpointTimeouts use defaultTimeout and a zero timeout means no bound
+func NewTimeoutClient[TransReq any, TransRes any](
+	next Client[TransReq, TransRes],
+	defaultTimeout time.Duration,
+	endpointTimeouts map[string]time.Duration,
+) Client[TransReq, TransRes] {
+	return &timeoutClient[TransReq, TransRes]{
+		next: next,
+		timeoutOf: func(endpoint string) time.Duration {
+			if timeout, ok := endpointTimeouts[endpoint]; ok {
+				return timeout
+			}
+			return defaultTimeout
+		},
+	}
+}
+
+func (c *timeoutClient[TransReq, TransRes]) Post(ctx context.Context, endpoint string, req TransReq) (TransRes, error) {
+	timeout := c.timeoutOf(endpoint)
+	if timeout <= 0 {
+		return c.next.Post(ctx, endpoint, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.next.Post(ctx, endpoint, req)
+}
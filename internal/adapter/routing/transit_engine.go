@@ -0,0 +1,17 @@
+package routing
+
+import (
+	"context"
+	"matching-engine/internal/model"
+)
+
+// TransitEngine plans the public transit journeys of the riders, combining transit rides with walks.
+// It is separate from Engine as few routing engines have the transit schedules.
+type TransitEngine interface {
+	// ComputeTransitJourney get the walking and transit durations of the fastest journey between two points
+	// departing at, or arriving by, the time of the params
+	ComputeTransitJourney(
+		ctx context.Context,
+		transitParams *model.TransitParams,
+	) (*model.TransitJourney, error)
+}
@@ -0,0 +1,16 @@
+package routingcache
+
+import (
+	"context"
+	"time"
+)
+
+// Backend stores the cached values across runs.
+// Implementations must be safe for concurrent use, a value that expired is reported as missing.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// GetMany returns the values of keys in the same order, nil for the missing ones
+	GetMany(ctx context.Context, keys []string) ([][]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Close() error
+}
@@ -0,0 +1,70 @@
+package routingcache
+
+import (
+	"fmt"
+	"matching-engine/internal/app/config"
+	"time"
+)
+
+const (
+	BackendFile = "file"
+	BackendRESP = "redis"
+)
+
+// Config holds how the cache keys are built and how long the entries live.
+// Coordinates are rounded to coordinatePrecision decimals, 5 decimals is about a meter,
+// and the departure times of the matrices are grouped in buckets of timeBucket within the day.
+type Config struct {
+	ttl                 time.Duration
+	timeBucket          time.Duration
+	coordinatePrecision int
+}
+
+func LoadConfig() (*Config, error) {
+	return NewConfig(
+		time.Duration(config.GetEnvFloat("PERSISTENT_CACHE_TTL_HOURS", 168)*float64(time.Hour)),
+		time.Duration(config.GetEnvFloat("PERSISTENT_CACHE_TIME_BUCKET_MINUTES", 15)*float64(time.Minute)),
+		int(config.GetEnvFloat("PERSISTENT_CACHE_COORDINATE_PRECISION", 5)),
+	)
+}
+
+func NewConfig(ttl, timeBucket time.Duration, coordinatePrecision int) (*Config, error) {
+	if ttl < 0 {
+		return nil, fmt.Errorf("ttl must not be negative, got %v", ttl)
+	}
+	if timeBucket <= 0 || timeBucket > 24*time.Hour {
+		return nil, fmt.Errorf("time bucket must be within a day, got %v", timeBucket)
+	}
+	if coordinatePrecision < 0 || coordinatePrecision > 9 {
+		return nil, fmt.Errorf("coordinate precision must be between 0 and 9, got %d", coordinatePrecision)
+	}
+	return &Config{
+		ttl:                 ttl,
+		timeBucket:          timeBucket,
+		coordinatePrecision: coordinatePrecision,
+	}, nil
+}
+
+func (c *Config) TTL() time.Duration        { return c.ttl }
+func (c *Config) TimeBucket() time.Duration { return c.timeBucket }
+func (c *Config) CoordinatePrecision() int  { return c.coordinatePrecision }
+
+// NewBackendFromEnv opens the backend selected by PERSISTENT_CACHE_BACKEND ("file" or "redis")
+func NewBackendFromEnv() (Backend, error) {
+	switch backend := config.GetEnv("PERSISTENT_CACHE_BACKEND", BackendFile); backend {
+	case BackendFile:
+		return NewFileBackend(
+			config.GetEnv("PERSISTENT_CACHE_PATH", "data/routing_cache.jsonl"),
+			int(config.GetEnvFloat("PERSISTENT_CACHE_MAX_ENTRIES", 1_000_000)),
+		)
+	case BackendRESP:
+		return NewRESPBackend(
+			config.GetEnv("PERSISTENT_CACHE_REDIS_ADDR", "localhost:6379"),
+			config.GetEnv("PERSISTENT_CACHE_REDIS_PASSWORD", ""),
+			config.GetEnv("PERSISTENT_CACHE_REDIS_PREFIX", "matching-engine:routing:"),
+			int(config.GetEnvFloat("PERSISTENT_CACHE_REDIS_POOL_SIZE", 16)),
+		), nil
+	default:
+		return nil, fmt.Errorf("invalid PERSISTENT_CACHE_BACKEND %q", backend)
+	}
+}
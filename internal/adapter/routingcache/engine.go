@@ -0,0 +1,231 @@
+package routingcache
+
+import (
+	"context"
+	"encoding/json"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var _ routing.Engine = (*CachingEngine)(nil)
+
+// CachingEngine keeps the matrix cells, walking times and snapped points computed by the wrapped engine
+// in a persistent backend, so that the offers recurring every day do not hit the routing backend again.
+// The routes and the isochrones are not cached. A failing backend never fails a call, it only misses.
+type CachingEngine struct {
+	next    routing.Engine
+	backend Backend
+	cfg     *Config
+}
+
+func NewCachingEngine(next routing.Engine, backend Backend, cfg *Config) routing.Engine {
+	return &CachingEngine{
+		next:    next,
+		backend: backend,
+		cfg:     cfg,
+	}
+}
+
+type matrixCell struct {
+	Distance float32       `json:"d"`
+	Unit     string        `json:"u"`
+	Time     time.Duration `json:"t"`
+}
+
+func (c *CachingEngine) PlanDrivingRoute(ctx context.Context, routeParams *model.RouteParams) (*model.Route, error) {
+	return c.next.PlanDrivingRoute(ctx, routeParams)
+}
+
+func (c *CachingEngine) ComputeDrivingTime(ctx context.Context, routeParams *model.RouteParams) ([]time.Duration, error) {
+	return c.next.ComputeDrivingTime(ctx, routeParams)
+}
+
+func (c *CachingEngine) ComputeIsochrone(ctx context.Context, req *model.IsochroneParams) (*model.Isochrone, error) {
+	return c.next.ComputeIsochrone(ctx, req)
+}
+
+func (c *CachingEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	key := c.cfg.walkKey(walkParams.Origin(), walkParams.Destination())
+	if value, ok := c.get(ctx, key); ok {
+		if nanos, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			return time.Duration(nanos), nil
+		}
+	}
+
+	duration, err := c.next.ComputeWalkingTime(ctx, walkParams)
+	if err != nil {
+		return 0, err
+	}
+	c.set(ctx, key, []byte(strconv.FormatInt(int64(duration), 10)))
+	return duration, nil
+}
+
+func (c *CachingEngine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	key := c.cfg.snapKey(point)
+	if value, ok := c.get(ctx, key); ok {
+		var latLng [2]float64
+		if json.Unmarshal(value, &latLng) == nil {
+			if snapped, err := model.NewCoordinate(latLng[0], latLng[1]); err == nil {
+				return snapped, nil
+			}
+		}
+	}
+
+	snapped, err := c.next.SnapPointToRoad(ctx, point)
+	if err != nil {
+		return nil, err
+	}
+	// Valhalla returns the input point when the snap fails, it is not kept so the snap is retried on the next call
+	if snapped.Equal(point) {
+		return snapped, nil
+	}
+	if value, err := json.Marshal([2]float64{snapped.Lat(), snapped.Lng()}); err == nil {
+		c.set(ctx, key, value)
+	}
+	return snapped, nil
+}
+
+// ComputeDistanceTimeMatrix serves the cached cells and asks the wrapped engine only for the sub matrix
+// made of the sources and targets that have at least one missing cell
+func (c *CachingEngine) ComputeDistanceTimeMatrix(
+	ctx context.Context,
+	req *model.DistanceTimeMatrixParams,
+) (*model.DistanceTimeMatrix, error) {
+	sources, targets := req.Sources(), req.Targets()
+	bucket := c.cfg.bucket(req.DepartureTime())
+
+	keys := make([]string, 0, len(sources)*len(targets))
+	for i := range sources {
+		for j := range targets {
+			keys = append(keys, c.cfg.matrixCellKey(req.Profile(), bucket, &sources[i], &targets[j]))
+		}
+	}
+
+	values, err := c.backend.GetMany(ctx, keys)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read the routing cache, computing the whole matrix")
+		return c.computeAndStore(ctx, req, keys)
+	}
+
+	distances := make([][]model.Distance, len(sources))
+	times := make([][]time.Duration, len(sources))
+	missingSources, missingTargets := make([]int, 0), make(map[int]bool)
+	for i := range sources {
+		distances[i] = make([]model.Distance, len(targets))
+		times[i] = make([]time.Duration, len(targets))
+		sourceMissing := false
+		for j := range targets {
+			if !c.decodeCell(values[i*len(targets)+j], &distances[i][j], &times[i][j]) {
+				sourceMissing = true
+				missingTargets[j] = true
+			}
+		}
+		if sourceMissing {
+			missingSources = append(missingSources, i)
+		}
+	}
+
+	if len(missingSources) == 0 {
+		return model.NewDistanceTimeMatrix(distances, times)
+	}
+
+	targetIndexes := make([]int, 0, len(missingTargets))
+	for j := range targets {
+		if missingTargets[j] {
+			targetIndexes = append(targetIndexes, j)
+		}
+	}
+	subSources, subTargets := make([]model.Coordinate, len(missingSources)), make([]model.Coordinate, len(targetIndexes))
+	for k, i := range missingSources {
+		subSources[k] = sources[i]
+	}
+	for k, j := range targetIndexes {
+		subTargets[k] = targets[j]
+	}
+
+	subReq, err := model.NewDistanceTimeMatrixParams(
+		subSources,
+		req.Profile(),
+		model.WithTargets(subTargets),
+		model.WithDepartureTime(req.DepartureTime()),
+	)
+	if err != nil {
+		return c.computeAndStore(ctx, req, keys)
+	}
+	subMatrix, err := c.next.ComputeDistanceTimeMatrix(ctx, subReq)
+	if err != nil {
+		return nil, err
+	}
+
+	for si, i := range missingSources {
+		for ti, j := range targetIndexes {
+			distances[i][j] = subMatrix.Distances()[si][ti]
+			times[i][j] = subMatrix.Times()[si][ti]
+			if values[i*len(targets)+j] == nil {
+				c.storeCell(ctx, keys[i*len(targets)+j], &distances[i][j], times[i][j])
+			}
+		}
+	}
+
+	return model.NewDistanceTimeMatrix(distances, times)
+}
+
+func (c *CachingEngine) computeAndStore(
+	ctx context.Context,
+	req *model.DistanceTimeMatrixParams,
+	keys []string,
+) (*model.DistanceTimeMatrix, error) {
+	matrix, err := c.next.ComputeDistanceTimeMatrix(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	columns := len(req.Targets())
+	for i, row := range matrix.Distances() {
+		for j := range row {
+			c.storeCell(ctx, keys[i*columns+j], &row[j], matrix.Times()[i][j])
+		}
+	}
+	return matrix, nil
+}
+
+func (c *CachingEngine) decodeCell(value []byte, distance *model.Distance, duration *time.Duration) bool {
+	if value == nil {
+		return false
+	}
+	var cell matrixCell
+	if json.Unmarshal(value, &cell) != nil {
+		return false
+	}
+	decoded, err := model.NewDistance(cell.Distance, model.DistanceUnit(cell.Unit))
+	if err != nil {
+		return false
+	}
+	*distance, *duration = *decoded, cell.Time
+	return true
+}
+
+func (c *CachingEngine) storeCell(ctx context.Context, key string, distance *model.Distance, duration time.Duration) {
+	value, err := json.Marshal(matrixCell{Distance: distance.Value(), Unit: distance.Unit().String(), Time: duration})
+	if err == nil {
+		c.set(ctx, key, value)
+	}
+}
+
+func (c *CachingEngine) get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to read the routing cache")
+		return nil, false
+	}
+	return value, ok
+}
+
+func (c *CachingEngine) set(ctx context.Context, key string, value []byte) {
+	if err := c.backend.Set(ctx, key, value, c.cfg.ttl); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to write the routing cache")
+	}
+}
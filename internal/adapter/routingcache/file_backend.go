@@ -0,0 +1,252 @@
+package routingcache
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// compactionMinRecords avoids rewriting small files whose log is mostly made of stale records
+const compactionMinRecords = 10_000
+
+// fileRecord is one line of the log, a deleted record hides the earlier lines with the same key
+type fileRecord struct {
+	Key       string `json:"k"`
+	Value     []byte `json:"v,omitempty"`
+	ExpiresAt int64  `json:"e,omitempty"` // unix milliseconds, zero never expires
+	Deleted   bool   `json:"d,omitempty"`
+}
+
+type fileEntry struct {
+	key       string
+	value     []byte
+	expiresAt int64
+}
+
+// FileBackend is an embedded backend keeping every entry in memory and persisting them in an append only log.
+// At most maxEntries entries are kept, the least recently used ones are evicted first.
+// The log is rewritten with the live entries only once the stale records outnumber them.
+type FileBackend struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	writer     *bufio.Writer
+	entries    map[string]*list.Element
+	lru        *list.List
+	maxEntries int
+	records    int
+}
+
+var _ Backend = (*FileBackend)(nil)
+
+func NewFileBackend(path string, maxEntries int) (*FileBackend, error) {
+	b := &FileBackend{
+		path:       path,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	// Start from a compact log holding the entries that survived the load
+	if err := b.compact(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *FileBackend) load() error {
+	file, err := os.Open(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open cache file: %w", err)
+	}
+	defer file.Close()
+
+	now := time.Now().UnixMilli()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record fileRecord
+		// A truncated last line is expected after a crash, the entry is simply lost
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if record.Deleted || (record.ExpiresAt != 0 && record.ExpiresAt <= now) {
+			b.remove(record.Key)
+			continue
+		}
+		b.put(record.Key, record.Value, record.ExpiresAt)
+	}
+	return scanner.Err()
+}
+
+func (b *FileBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	element, ok := b.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*fileEntry)
+	if entry.expiresAt != 0 && entry.expiresAt <= time.Now().UnixMilli() {
+		b.remove(key)
+		return nil, false, b.append(fileRecord{Key: key, Deleted: true})
+	}
+	b.lru.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (b *FileBackend) GetMany(ctx context.Context, keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, ok, err := b.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+func (b *FileBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixMilli()
+	}
+
+	for _, evicted := range b.put(key, value, expiresAt) {
+		if err := b.append(fileRecord{Key: evicted, Deleted: true}); err != nil {
+			return err
+		}
+	}
+	if err := b.append(fileRecord{Key: key, Value: value, ExpiresAt: expiresAt}); err != nil {
+		return err
+	}
+
+	if b.records > compactionMinRecords && b.records > 2*len(b.entries) {
+		return b.compact()
+	}
+	return nil
+}
+
+func (b *FileBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file == nil {
+		return nil
+	}
+	err := errors.Join(b.writer.Flush(), b.file.Close())
+	b.file, b.writer = nil, nil
+	return err
+}
+
+// put stores the entry in memory and returns the keys evicted to make room for it
+func (b *FileBackend) put(key string, value []byte, expiresAt int64) []string {
+	if element, ok := b.entries[key]; ok {
+		entry := element.Value.(*fileEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		b.lru.MoveToFront(element)
+		return nil
+	}
+
+	b.entries[key] = b.lru.PushFront(&fileEntry{key: key, value: value, expiresAt: expiresAt})
+
+	var evicted []string
+	for b.maxEntries > 0 && len(b.entries) > b.maxEntries {
+		oldest := b.lru.Back().Value.(*fileEntry)
+		b.remove(oldest.key)
+		evicted = append(evicted, oldest.key)
+	}
+	return evicted
+}
+
+func (b *FileBackend) remove(key string) {
+	if element, ok := b.entries[key]; ok {
+		b.lru.Remove(element)
+		delete(b.entries, key)
+	}
+}
+
+func (b *FileBackend) append(record fileRecord) error {
+	if b.writer == nil {
+		return errors.New("cache file is closed")
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache record: %w", err)
+	}
+	if _, err := b.writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write cache record: %w", err)
+	}
+	b.records++
+	// Flush every record so that a crash loses at most the line being written
+	return b.writer.Flush()
+}
+
+// compact rewrites the log with the live entries, oldest first so that a reload restores the same LRU order
+func (b *FileBackend) compact() error {
+	if b.file != nil {
+		if err := errors.Join(b.writer.Flush(), b.file.Close()); err != nil {
+			return fmt.Errorf("failed to close cache file: %w", err)
+		}
+		b.file, b.writer = nil, nil
+	}
+
+	tmpPath := b.path + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for element := b.lru.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*fileEntry)
+		if err := encoder.Encode(fileRecord{Key: entry.key, Value: entry.value, ExpiresAt: entry.expiresAt}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write cache file: %w", err)
+		}
+	}
+	if err := errors.Join(writer.Flush(), tmp.Close()); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmpPath, b.path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+
+	file, err := os.OpenFile(b.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open cache file: %w", err)
+	}
+	b.file, b.writer = file, bufio.NewWriter(file)
+	b.records = len(b.entries)
+	return nil
+}
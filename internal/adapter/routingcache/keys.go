@@ -0,0 +1,55 @@
+package routingcache
+
+import (
+	"matching-engine/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The keys start with the kind of value followed by the parts identifying it:
+//   m:<profile>:<time bucket>:<source>:<target>  one cell of a distance time matrix
+//   w:<origin>:<destination>                     a walking time
+//   s:<point>                                    a point snapped to the road network
+
+func (c *Config) point(sb *strings.Builder, point *model.Coordinate) {
+	sb.WriteString(strconv.FormatFloat(point.Lat(), 'f', c.coordinatePrecision, 64))
+	sb.WriteByte(',')
+	sb.WriteString(strconv.FormatFloat(point.Lng(), 'f', c.coordinatePrecision, 64))
+}
+
+// bucket is the index of the time of day slot of t, commuter trips repeat at the same time every day
+func (c *Config) bucket(t time.Time) int {
+	t = t.UTC()
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	return int(sinceMidnight / c.timeBucket)
+}
+
+func (c *Config) matrixCellKey(profile model.Profile, bucket int, source, target *model.Coordinate) string {
+	var sb strings.Builder
+	sb.WriteString("m:")
+	sb.WriteString(profile.String())
+	sb.WriteByte(':')
+	sb.WriteString(strconv.Itoa(bucket))
+	sb.WriteByte(':')
+	c.point(&sb, source)
+	sb.WriteByte(':')
+	c.point(&sb, target)
+	return sb.String()
+}
+
+func (c *Config) walkKey(origin, destination *model.Coordinate) string {
+	var sb strings.Builder
+	sb.WriteString("w:")
+	c.point(&sb, origin)
+	sb.WriteByte(':')
+	c.point(&sb, destination)
+	return sb.String()
+}
+
+func (c *Config) snapKey(point *model.Coordinate) string {
+	var sb strings.Builder
+	sb.WriteString("s:")
+	c.point(&sb, point)
+	return sb.String()
+}
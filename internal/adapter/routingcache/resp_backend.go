@@ -0,0 +1,238 @@
+package routingcache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const respIOTimeout = 2 * time.Second
+
+// RESPBackend stores the entries in a Redis compatible server (Redis, Valkey, KeyDB, ...).
+// Size limits are left to the server eviction policy, e.g. maxmemory with allkeys-lru.
+type RESPBackend struct {
+	addr     string
+	password string
+	prefix   string
+	pool     chan *respConn
+}
+
+var _ Backend = (*RESPBackend)(nil)
+
+type respConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRESPBackend connects lazily to addr, keeping up to poolSize idle connections.
+// Every key is stored under prefix so that several deployments can share a server.
+func NewRESPBackend(addr, password, prefix string, poolSize int) *RESPBackend {
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+	return &RESPBackend{
+		addr:     addr,
+		password: password,
+		prefix:   prefix,
+		pool:     make(chan *respConn, poolSize),
+	}
+}
+
+func (b *RESPBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := b.do(ctx, "GET", b.prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected reply to GET: %v", reply)
+	}
+	return value, true, nil
+}
+
+func (b *RESPBackend) GetMany(ctx context.Context, keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "MGET")
+	for _, key := range keys {
+		args = append(args, b.prefix+key)
+	}
+	reply, err := b.do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := reply.([]any)
+	if !ok || len(items) != len(keys) {
+		return nil, fmt.Errorf("unexpected reply to MGET: %v", reply)
+	}
+	for i, item := range items {
+		if value, ok := item.([]byte); ok {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+func (b *RESPBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", b.prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := b.do(ctx, args...)
+	return err
+}
+
+func (b *RESPBackend) Close() error {
+	var err error
+	for {
+		select {
+		case c := <-b.pool:
+			err = errors.Join(err, c.conn.Close())
+		default:
+			return err
+		}
+	}
+}
+
+func (b *RESPBackend) do(ctx context.Context, args ...string) (any, error) {
+	c, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.roundTrip(ctx, args...)
+	var serverErr respError
+	if err != nil && !errors.As(err, &serverErr) {
+		// The connection state is unknown after an io error
+		_ = c.conn.Close()
+		return nil, err
+	}
+	b.release(c)
+	return reply, err
+}
+
+func (b *RESPBackend) acquire(ctx context.Context) (*respConn, error) {
+	select {
+	case c := <-b.pool:
+		return c, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: respIOTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cache server: %w", err)
+	}
+	c := &respConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if b.password != "" {
+		if _, err := c.roundTrip(ctx, "AUTH", b.password); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to authenticate to cache server: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (b *RESPBackend) release(c *respConn) {
+	select {
+	case b.pool <- c:
+	default:
+		_ = c.conn.Close()
+	}
+}
+
+type respError string
+
+func (e respError) Error() string { return "cache server error: " + string(e) }
+
+func (c *respConn) roundTrip(ctx context.Context, args ...string) (any, error) {
+	deadline := time.Now().Add(respIOTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// Commands are sent as an array of bulk strings
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+	return c.readReply()
+}
+
+// readReply reads a simple string, error, integer, bulk string or array reply, a null bulk string gives nil
+func (c *respConn) readReply() (any, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, respError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk length %q", payload)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, fmt.Errorf("failed to read reply: %w", err)
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed array length %q", payload)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, count)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported reply type %q", line[0])
+	}
+}
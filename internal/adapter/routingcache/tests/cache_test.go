@@ -0,0 +1,318 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"matching-engine/internal/adapter/offline"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/adapter/routingcache"
+	"matching-engine/internal/model"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func coordinate(t *testing.T, lat, lng float64) model.Coordinate {
+	c, err := model.NewCoordinate(lat, lng)
+	if err != nil {
+		t.Fatalf("invalid coordinate: %v", err)
+	}
+	return *c
+}
+
+func TestFileBackend_PersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	backend, err := routingcache.NewFileBackend(path, 0)
+	if err != nil {
+		t.Fatalf("failed to open backend: %v", err)
+	}
+	_ = backend.Set(ctx, "kept", []byte("1"), time.Hour)
+	_ = backend.Set(ctx, "expiring", []byte("2"), 20*time.Millisecond)
+	_ = backend.Set(ctx, "overwritten", []byte("old"), 0)
+	_ = backend.Set(ctx, "overwritten", []byte("new"), 0)
+	if err := backend.Close(); err != nil {
+		t.Fatalf("failed to close backend: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	backend, err = routingcache.NewFileBackend(path, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen backend: %v", err)
+	}
+	defer backend.Close()
+
+	if value, ok, _ := backend.Get(ctx, "kept"); !ok || string(value) != "1" {
+		t.Errorf("expected kept to survive the reopen, got %q, %v", value, ok)
+	}
+	if value, ok, _ := backend.Get(ctx, "overwritten"); !ok || string(value) != "new" {
+		t.Errorf("expected the last value, got %q, %v", value, ok)
+	}
+	if _, ok, _ := backend.Get(ctx, "expiring"); ok {
+		t.Error("expected the expired entry to be gone")
+	}
+}
+
+func TestFileBackend_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	backend, _ := routingcache.NewFileBackend(path, 2)
+	_ = backend.Set(ctx, "a", []byte("a"), 0)
+	_ = backend.Set(ctx, "b", []byte("b"), 0)
+	_, _, _ = backend.Get(ctx, "a")
+	_ = backend.Set(ctx, "c", []byte("c"), 0)
+
+	if _, ok, _ := backend.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	_ = backend.Close()
+
+	backend, _ = routingcache.NewFileBackend(path, 2)
+	defer backend.Close()
+	if backend.Len() != 2 {
+		t.Errorf("expected 2 entries after reopen, got %d", backend.Len())
+	}
+	values, _ := backend.GetMany(ctx, []string{"a", "b", "c"})
+	if values[0] == nil || values[1] != nil || values[2] == nil {
+		t.Errorf("expected a and c only, got %q", values)
+	}
+}
+
+// startRESPServer serves GET, SET and MGET from a map, enough for the backend
+func startRESPServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	var mu sync.Mutex
+	store := make(map[string]string)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(reader)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						value, ok := store[args[1]]
+						writeBulk(conn, value, ok)
+					case "SET":
+						store[args[1]] = args[2]
+						_, _ = io.WriteString(conn, "+OK\r\n")
+					case "MGET":
+						_, _ = fmt.Fprintf(conn, "*%d\r\n", len(args)-1)
+						for _, key := range args[1:] {
+							value, ok := store[key]
+							writeBulk(conn, value, ok)
+						}
+					default:
+						_, _ = io.WriteString(conn, "-ERR unknown command\r\n")
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func writeBulk(w io.Writer, value string, ok bool) {
+	if !ok {
+		_, _ = io.WriteString(w, "$-1\r\n")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+}
+
+func TestRESPBackend(t *testing.T) {
+	ctx := context.Background()
+	backend := routingcache.NewRESPBackend(startRESPServer(t), "", "test:", 2)
+	defer backend.Close()
+
+	if err := backend.Set(ctx, "walk", []byte("a\r\nvalue"), time.Minute); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+	if value, ok, err := backend.Get(ctx, "walk"); err != nil || !ok || string(value) != "a\r\nvalue" {
+		t.Errorf("unexpected get: %q, %v, %v", value, ok, err)
+	}
+	if _, ok, err := backend.Get(ctx, "missing"); err != nil || ok {
+		t.Errorf("expected a miss, got %v, %v", ok, err)
+	}
+	values, err := backend.GetMany(ctx, []string{"missing", "walk"})
+	if err != nil || values[0] != nil || string(values[1]) != "a\r\nvalue" {
+		t.Errorf("unexpected get many: %q, %v", values, err)
+	}
+}
+
+// countingEngine records the size of the matrices it is asked for
+type countingEngine struct {
+	routing.Engine
+	mu       sync.Mutex
+	matrices [][2]int
+	walks    int
+	snaps    int
+	snapTo   *model.Coordinate // Point every snap returns, the input point when nil
+}
+
+func (e *countingEngine) ComputeDistanceTimeMatrix(ctx context.Context, req *model.DistanceTimeMatrixParams) (*model.DistanceTimeMatrix, error) {
+	e.mu.Lock()
+	e.matrices = append(e.matrices, [2]int{len(req.Sources()), len(req.Targets())})
+	e.mu.Unlock()
+	return e.Engine.ComputeDistanceTimeMatrix(ctx, req)
+}
+
+func (e *countingEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	e.mu.Lock()
+	e.walks++
+	e.mu.Unlock()
+	return e.Engine.ComputeWalkingTime(ctx, walkParams)
+}
+
+func (e *countingEngine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	e.mu.Lock()
+	e.snaps++
+	e.mu.Unlock()
+	if e.snapTo != nil {
+		return e.snapTo, nil
+	}
+	return point, nil
+}
+
+func newCachingEngine(t *testing.T) (*countingEngine, routing.Engine) {
+	engine, err := offline.NewOffline()
+	if err != nil {
+		t.Fatalf("failed to create offline engine: %v", err)
+	}
+	backend, err := routingcache.NewFileBackend(filepath.Join(t.TempDir(), "cache.jsonl"), 0)
+	if err != nil {
+		t.Fatalf("failed to open backend: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+	cfg, err := routingcache.NewConfig(time.Hour, 15*time.Minute, 5)
+	if err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	counting := &countingEngine{Engine: engine}
+	return counting, routingcache.NewCachingEngine(counting, backend, cfg)
+}
+
+func TestCachingEngine_ComputesOnlyMissingCells(t *testing.T) {
+	ctx := context.Background()
+	counting, cached := newCachingEngine(t)
+
+	points := []model.Coordinate{
+		coordinate(t, 30.0444, 31.2357), coordinate(t, 30.0626, 31.2497), coordinate(t, 30.0500, 31.2400),
+	}
+	departure := time.Now().Add(24 * time.Hour).Truncate(time.Hour).Add(8 * time.Hour)
+	params, _ := model.NewDistanceTimeMatrixParams(points[:2], model.ProfileAuto, model.WithDepartureTime(departure))
+
+	first, err := cached.ComputeDistanceTimeMatrix(ctx, params)
+	if err != nil {
+		t.Fatalf("failed to compute matrix: %v", err)
+	}
+
+	// Same cells a few minutes later in the same bucket
+	params, _ = model.NewDistanceTimeMatrixParams(points[:2], model.ProfileAuto, model.WithDepartureTime(departure.Add(5*time.Minute)))
+	second, err := cached.ComputeDistanceTimeMatrix(ctx, params)
+	if err != nil {
+		t.Fatalf("failed to compute matrix: %v", err)
+	}
+	if second.Times()[0][1] != first.Times()[0][1] || second.Distances()[0][1] != first.Distances()[0][1] {
+		t.Errorf("expected the cached cells, got %v and %v", second, first)
+	}
+
+	// Only the column of the new target is missing
+	params, _ = model.NewDistanceTimeMatrixParams(
+		points[:2], model.ProfileAuto, model.WithTargets(points), model.WithDepartureTime(departure),
+	)
+	if _, err := cached.ComputeDistanceTimeMatrix(ctx, params); err != nil {
+		t.Fatalf("failed to compute matrix: %v", err)
+	}
+
+	// Another bucket misses again
+	params, _ = model.NewDistanceTimeMatrixParams(points[:2], model.ProfileAuto, model.WithDepartureTime(departure.Add(time.Hour)))
+	if _, err := cached.ComputeDistanceTimeMatrix(ctx, params); err != nil {
+		t.Fatalf("failed to compute matrix: %v", err)
+	}
+
+	expected := [][2]int{{2, 2}, {2, 1}, {2, 2}}
+	if fmt.Sprint(counting.matrices) != fmt.Sprint(expected) {
+		t.Errorf("expected backend matrices %v, got %v", expected, counting.matrices)
+	}
+}
+
+func TestCachingEngine_CachesWalkingTimes(t *testing.T) {
+	ctx := context.Background()
+	counting, cached := newCachingEngine(t)
+
+	origin, destination := coordinate(t, 30.0444, 31.2357), coordinate(t, 30.0626, 31.2497)
+	params, _ := model.NewWalkParams(&origin, &destination)
+
+	first, _ := cached.ComputeWalkingTime(ctx, params)
+	second, _ := cached.ComputeWalkingTime(ctx, params)
+	if first != second || counting.walks != 1 {
+		t.Errorf("expected one backend call and equal times, got %d calls, %v and %v", counting.walks, first, second)
+	}
+}
+
+func TestCachingEngine_SkipsFailedSnaps(t *testing.T) {
+	ctx := context.Background()
+	counting, cached := newCachingEngine(t)
+
+	// The input point is returned when the snap fails, it is computed again
+	point := coordinate(t, 30.0444, 31.2357)
+	_, _ = cached.SnapPointToRoad(ctx, &point)
+	_, _ = cached.SnapPointToRoad(ctx, &point)
+	if counting.snaps != 2 {
+		t.Errorf("expected the failed snap to be computed again, got %d calls", counting.snaps)
+	}
+
+	snapped := coordinate(t, 30.0445, 31.2358)
+	counting.snapTo = &snapped
+	other := coordinate(t, 30.0626, 31.2497)
+	first, _ := cached.SnapPointToRoad(ctx, &other)
+	second, _ := cached.SnapPointToRoad(ctx, &other)
+	if counting.snaps != 3 || !first.Equal(&snapped) || !second.Equal(&snapped) {
+		t.Errorf("expected one more call for the snapped point, got %d calls, %v and %v", counting.snaps, first, second)
+	}
+}
@@ -0,0 +1,54 @@
+package traffic
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultSegmentLevel is the S2 level (about 1 km wide cells) at which segment speeds are aggregated
+const DefaultSegmentLevel = 13
+
+// LoadProfileFromEnv loads the profile at TRAFFIC_PROFILE_PATH, it returns nil when the variable is not set.
+// TRAFFIC_PROFILE_ID names the profile in the results (the file name by default),
+// TRAFFIC_PROFILE_TIMEZONE is the zone of the hours of week (UTC by default)
+// and TRAFFIC_PROFILE_SEGMENT_LEVEL the S2 level at which segment rows are aggregated.
+func LoadProfileFromEnv() (*Profile, error) {
+	path := os.Getenv("TRAFFIC_PROFILE_PATH")
+	if path == "" {
+		return nil, nil
+	}
+
+	location := time.UTC
+	if name := os.Getenv("TRAFFIC_PROFILE_TIMEZONE"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRAFFIC_PROFILE_TIMEZONE %q: %w", name, err)
+		}
+		location = loc
+	}
+
+	segmentLevel := DefaultSegmentLevel
+	if v := os.Getenv("TRAFFIC_PROFILE_SEGMENT_LEVEL"); v != "" {
+		level, err := strconv.Atoi(v)
+		if err != nil {
+			log.Warn().Msgf("Invalid TRAFFIC_PROFILE_SEGMENT_LEVEL value %q, using default: %d", v, DefaultSegmentLevel)
+		} else {
+			segmentLevel = level
+		}
+	}
+
+	profile, err := LoadProfileFile(path, os.Getenv("TRAFFIC_PROFILE_ID"), location, segmentLevel)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("id", profile.ID()).
+		Int("zones", profile.Zones()).
+		Str("timezone", location.String()).
+		Msg("Loaded traffic profile")
+	return profile, nil
+}
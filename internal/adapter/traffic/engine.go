@@ -0,0 +1,133 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/model"
+	"time"
+)
+
+var _ routing.Engine = (*Engine)(nil)
+
+// Engine scales the driving times returned by the wrapped engine with a traffic profile.
+// A leg uses the mean multiplier of its endpoints at the time it starts, routes average the
+// multipliers along their polyline. Walking times, isochrones and snapping are not adjusted.
+type Engine struct {
+	next    routing.Engine
+	profile *Profile
+}
+
+func NewEngine(next routing.Engine, profile *Profile) routing.Engine {
+	return &Engine{
+		next:    next,
+		profile: profile,
+	}
+}
+
+// Profile returns the traffic profile applied by the engine
+func (e *Engine) Profile() *Profile {
+	return e.profile
+}
+
+func (e *Engine) PlanDrivingRoute(ctx context.Context, routeParams *model.RouteParams) (*model.Route, error) {
+	route, err := e.next.PlanDrivingRoute(ctx, routeParams)
+	if err != nil || route == nil {
+		return route, err
+	}
+
+	points, err := route.Polyline().Coordinates()
+	if err != nil || len(points) == 0 {
+		points = routeParams.Waypoints()
+	}
+
+	// each vertex is evaluated at the time the vehicle is expected to reach it, assuming a uniform pace
+	var sum float64
+	for i, point := range points {
+		var progress time.Duration
+		if len(points) > 1 {
+			progress = route.Time() * time.Duration(i) / time.Duration(len(points)-1)
+		}
+		sum += e.profile.Multiplier(point, routeParams.DepartureTime().Add(progress))
+	}
+	multiplier := sum / float64(len(points))
+
+	adjusted, err := model.NewRoute(route.Polyline(), route.Distance(), scale(route.Time(), multiplier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust route time: %w", err)
+	}
+	return adjusted, nil
+}
+
+func (e *Engine) ComputeDrivingTime(ctx context.Context, routeParams *model.RouteParams) ([]time.Duration, error) {
+	durations, err := e.next.ComputeDrivingTime(ctx, routeParams)
+	if err != nil {
+		return nil, err
+	}
+
+	waypoints := routeParams.Waypoints()
+	if len(durations) != len(waypoints) {
+		return nil, fmt.Errorf("driving times count %d does not match waypoints count %d", len(durations), len(waypoints))
+	}
+
+	adjusted := make([]time.Duration, len(durations))
+	for i := 1; i < len(durations); i++ {
+		start := routeParams.DepartureTime().Add(adjusted[i-1])
+		multiplier := (e.profile.Multiplier(waypoints[i-1], start) + e.profile.Multiplier(waypoints[i], start)) / 2
+		adjusted[i] = adjusted[i-1] + scale(durations[i]-durations[i-1], multiplier)
+	}
+	return adjusted, nil
+}
+
+func (e *Engine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	return e.next.ComputeWalkingTime(ctx, walkParams)
+}
+
+func (e *Engine) ComputeIsochrone(ctx context.Context, req *model.IsochroneParams) (*model.Isochrone, error) {
+	return e.next.ComputeIsochrone(ctx, req)
+}
+
+func (e *Engine) ComputeDistanceTimeMatrix(ctx context.Context, req *model.DistanceTimeMatrixParams) (*model.DistanceTimeMatrix, error) {
+	matrix, err := e.next.ComputeDistanceTimeMatrix(ctx, req)
+	if err != nil || matrix == nil || req.Profile() != model.ProfileAuto {
+		return matrix, err
+	}
+
+	departure := req.DepartureTime()
+	sourceMultipliers := e.multipliers(req.Sources(), departure)
+	targetMultipliers := e.multipliers(req.Targets(), departure)
+
+	times := make([][]time.Duration, len(matrix.Times()))
+	for i, row := range matrix.Times() {
+		times[i] = make([]time.Duration, len(row))
+		for j, d := range row {
+			if i >= len(sourceMultipliers) || j >= len(targetMultipliers) {
+				times[i][j] = d
+				continue
+			}
+			times[i][j] = scale(d, (sourceMultipliers[i]+targetMultipliers[j])/2)
+		}
+	}
+
+	adjusted, err := model.NewDistanceTimeMatrix(matrix.Distances(), times)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust matrix times: %w", err)
+	}
+	return adjusted, nil
+}
+
+func (e *Engine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	return e.next.SnapPointToRoad(ctx, point)
+}
+
+func (e *Engine) multipliers(points []model.Coordinate, departure time.Time) []float64 {
+	multipliers := make([]float64, len(points))
+	for i, point := range points {
+		multipliers[i] = e.profile.Multiplier(point, departure)
+	}
+	return multipliers
+}
+
+func scale(d time.Duration, multiplier float64) time.Duration {
+	return time.Duration(float64(d) * multiplier)
+}
@@ -0,0 +1,86 @@
+package traffic
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetBatchSize is the number of rows read from a Parquet file at once
+const parquetBatchSize = 1024
+
+// ReadParquetProfile parses a traffic profile from a Parquet file with the same columns as the CSV layouts of
+// ReadProfile. Columns may be strings or numbers, hour_of_week holding "*" or an hour as a string column.
+func ReadParquetProfile(r io.ReaderAt, size int64, id string, location *time.Location, segmentLevel int) (*Profile, error) {
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	header := make([]string, 0, len(file.Schema().Columns()))
+	for _, path := range file.Schema().Columns() {
+		if len(path) != 1 {
+			return nil, fmt.Errorf("nested column %v is not supported", path)
+		}
+		header = append(header, path[0])
+	}
+	return readProfileRecords(&parquetRecordReader{
+		header: header,
+		rows:   parquet.NewReader(file),
+		batch:  make([]parquet.Row, parquetBatchSize),
+	}, id, location, segmentLevel)
+}
+
+// parquetRecordReader returns the rows of a flat Parquet file as text, after the names of its columns
+type parquetRecordReader struct {
+	header  []string
+	rows    *parquet.Reader
+	batch   []parquet.Row
+	pending []parquet.Row
+	done    bool
+}
+
+func (p *parquetRecordReader) Read() ([]string, error) {
+	if p.header != nil {
+		header := p.header
+		p.header = nil
+		return header, nil
+	}
+	for len(p.pending) == 0 {
+		if p.done {
+			return nil, io.EOF
+		}
+		n, err := p.rows.ReadRows(p.batch)
+		if err == io.EOF {
+			p.done = true
+		} else if err != nil {
+			return nil, err
+		}
+		p.pending = p.batch[:n]
+	}
+	row := p.pending[0]
+	p.pending = p.pending[1:]
+
+	record := make([]string, 0, len(row))
+	for _, value := range row {
+		record = append(record, parquetValueString(value))
+	}
+	return record, nil
+}
+
+// parquetValueString formats a value like the matching CSV cell, an empty string for a null
+func parquetValueString(value parquet.Value) string {
+	if value.IsNull() {
+		return ""
+	}
+	switch value.Kind() {
+	case parquet.Float:
+		return strconv.FormatFloat(float64(value.Float()), 'g', -1, 32)
+	case parquet.Double:
+		return strconv.FormatFloat(value.Double(), 'g', -1, 64)
+	default:
+		return value.String()
+	}
+}
@@ -0,0 +1,237 @@
+package traffic
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"matching-engine/internal/model"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/geo/s2"
+)
+
+// HoursPerWeek is the number of hour-of-week slots of a profile, slot 0 starts on Monday at midnight
+const HoursPerWeek = 7 * 24
+
+// hourlyMultipliers holds one multiplier per hour of week, 0 marks an hour without data
+type hourlyMultipliers [HoursPerWeek]float64
+
+// Profile holds travel time multipliers per zone and hour of week.
+// Zones are S2 cells of any level; a coordinate uses the finest zone containing it that has data for the hour.
+type Profile struct {
+	id       string
+	location *time.Location
+	levels   []int
+	zones    map[s2.CellID]*hourlyMultipliers
+}
+
+// ID identifies the profile in the matching results
+func (p *Profile) ID() string {
+	return p.id
+}
+
+// Location is the time zone in which the hours of week are expressed
+func (p *Profile) Location() *time.Location {
+	return p.location
+}
+
+// Zones returns the number of zones holding data
+func (p *Profile) Zones() int {
+	return len(p.zones)
+}
+
+// Multiplier returns the factor applied to travel times starting at coordinate c at time t, 1 without data
+func (p *Profile) Multiplier(c model.Coordinate, t time.Time) float64 {
+	hour := hourOfWeek(t.In(p.location))
+	leaf := s2.CellIDFromLatLng(s2.LatLngFromDegrees(c.Lat(), c.Lng()))
+	for _, level := range p.levels {
+		if zone, ok := p.zones[leaf.Parent(level)]; ok && zone[hour] > 0 {
+			return zone[hour]
+		}
+	}
+	return 1
+}
+
+func hourOfWeek(t time.Time) int {
+	// time.Weekday starts on Sunday, the profile weeks start on Monday
+	day := (int(t.Weekday()) + 6) % 7
+	return day*24 + t.Hour()
+}
+
+// LoadProfileFile reads a traffic profile CSV or Parquet file, see ReadProfile for the accepted layouts.
+// Files ending in .parquet or .pq are read as Parquet, any other file as CSV.
+func LoadProfileFile(path, id string, location *time.Location, segmentLevel int) (*Profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traffic profile: %w", err)
+	}
+	defer file.Close()
+
+	if id == "" {
+		id = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	var profile *Profile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet", ".pq":
+		info, statErr := file.Stat()
+		if statErr != nil {
+			return nil, fmt.Errorf("failed to open traffic profile: %w", statErr)
+		}
+		profile, err = ReadParquetProfile(file, info.Size(), id, location, segmentLevel)
+	default:
+		profile, err = ReadProfile(file, id, location, segmentLevel)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read traffic profile %s: %w", path, err)
+	}
+	return profile, nil
+}
+
+// ReadProfile parses a traffic profile from CSV with a header row. Two layouts are accepted:
+//
+//	zone,hour_of_week,multiplier                 zone is an S2 cell token of any level
+//	segment_id,lat,lng,hour_of_week,multiplier   segments are averaged per S2 cell of segmentLevel
+//
+// hour_of_week ranges from 0 (Monday 00:00) to 167, or is "*" to apply the multiplier to every hour.
+// Rows falling in the same zone and hour are averaged.
+func ReadProfile(r io.Reader, id string, location *time.Location, segmentLevel int) (*Profile, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	return readProfileRecords(reader, id, location, segmentLevel)
+}
+
+// recordReader returns the header then the rows of a profile, as text, until io.EOF
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+// readProfileRecords builds a profile from the records of any of the layouts accepted by ReadProfile
+func readProfileRecords(reader recordReader, id string, location *time.Location, segmentLevel int) (*Profile, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	if segmentLevel < 0 || segmentLevel > s2.MaxLevel {
+		return nil, fmt.Errorf("invalid segment level %d", segmentLevel)
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	_, isZone := columns["zone"]
+	_, isSegment := columns["segment_id"]
+	var required []string
+	switch {
+	case isZone:
+		required = []string{"zone", "hour_of_week", "multiplier"}
+	case isSegment:
+		required = []string{"lat", "lng", "hour_of_week", "multiplier"}
+	default:
+		return nil, errors.New("header must contain a zone or a segment_id column")
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+
+	sums := make(map[s2.CellID]*hourlyMultipliers)
+	counts := make(map[s2.CellID]*[HoursPerWeek]int)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var cell s2.CellID
+		if isZone {
+			cell = s2.CellIDFromToken(strings.TrimSpace(record[columns["zone"]]))
+			if !cell.IsValid() {
+				return nil, fmt.Errorf("line %d: invalid zone token %q", line, record[columns["zone"]])
+			}
+		} else {
+			lat, latErr := strconv.ParseFloat(strings.TrimSpace(record[columns["lat"]]), 64)
+			lng, lngErr := strconv.ParseFloat(strings.TrimSpace(record[columns["lng"]]), 64)
+			if latErr != nil || lngErr != nil {
+				return nil, fmt.Errorf("line %d: invalid segment coordinate", line)
+			}
+			coordinate, err := model.NewCoordinate(lat, lng)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			cell = s2.CellIDFromLatLng(s2.LatLngFromDegrees(coordinate.Lat(), coordinate.Lng())).Parent(segmentLevel)
+		}
+
+		hours, err := parseHours(record[columns["hour_of_week"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		multiplier, err := strconv.ParseFloat(strings.TrimSpace(record[columns["multiplier"]]), 64)
+		if err != nil || multiplier <= 0 {
+			return nil, fmt.Errorf("line %d: invalid multiplier %q", line, record[columns["multiplier"]])
+		}
+
+		if sums[cell] == nil {
+			sums[cell] = &hourlyMultipliers{}
+			counts[cell] = &[HoursPerWeek]int{}
+		}
+		for _, hour := range hours {
+			sums[cell][hour] += multiplier
+			counts[cell][hour]++
+		}
+	}
+
+	profile := &Profile{
+		id:       id,
+		location: location,
+		zones:    make(map[s2.CellID]*hourlyMultipliers, len(sums)),
+	}
+	levels := make(map[int]bool)
+	for cell, sum := range sums {
+		zone := &hourlyMultipliers{}
+		for hour := range zone {
+			if n := counts[cell][hour]; n > 0 {
+				zone[hour] = sum[hour] / float64(n)
+			}
+		}
+		profile.zones[cell] = zone
+		levels[cell.Level()] = true
+	}
+	for level := range levels {
+		profile.levels = append(profile.levels, level)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(profile.levels)))
+	return profile, nil
+}
+
+func parseHours(value string) ([]int, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		hours := make([]int, HoursPerWeek)
+		for i := range hours {
+			hours[i] = i
+		}
+		return hours, nil
+	}
+	hour, err := strconv.Atoi(value)
+	if err != nil || hour < 0 || hour >= HoursPerWeek {
+		return nil, fmt.Errorf("invalid hour_of_week %q", value)
+	}
+	return []int{hour}, nil
+}
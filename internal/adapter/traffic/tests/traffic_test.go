@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"matching-engine/internal/adapter/offline"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/adapter/traffic"
+	"matching-engine/internal/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zoneToken(lat, lng float64, level int) string {
+	return s2.CellIDFromLatLng(s2.LatLngFromDegrees(lat, lng)).Parent(level).ToToken()
+}
+
+// nextWeekday returns the next occurrence of the weekday at the given hour, at least a day from now
+func nextWeekday(day time.Weekday, hour int) time.Time {
+	t := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	for t.Weekday() != day {
+		t = t.Add(24 * time.Hour)
+	}
+	return t.Add(time.Duration(hour) * time.Hour)
+}
+
+func newOffline(t *testing.T) routing.Engine {
+	engine, err := offline.NewOffline()
+	require.NoError(t, err)
+	return engine
+}
+
+func TestReadProfile_ZonesUseFinestLevel(t *testing.T) {
+	// Monday 08:00 is hour 8, the city-wide zone applies to every hour
+	csv := "zone,hour_of_week,multiplier\n" +
+		zoneToken(30.05, 31.24, 8) + ",*,1.1\n" +
+		zoneToken(30.05, 31.24, 14) + ",8,1.6\n"
+
+	profile, err := traffic.ReadProfile(strings.NewReader(csv), "cairo-2025", time.UTC, traffic.DefaultSegmentLevel)
+	require.NoError(t, err)
+	assert.Equal(t, "cairo-2025", profile.ID())
+	assert.Equal(t, 2, profile.Zones())
+
+	center, _ := model.NewCoordinate(30.05, 31.24)
+	monday := nextWeekday(time.Monday, 8)
+	assert.InDelta(t, 1.6, profile.Multiplier(*center, monday), 1e-9)
+	assert.InDelta(t, 1.1, profile.Multiplier(*center, monday.Add(2*time.Hour)), 1e-9)
+
+	elsewhere, _ := model.NewCoordinate(48.85, 2.35)
+	assert.Equal(t, 1.0, profile.Multiplier(*elsewhere, monday))
+}
+
+func TestReadProfile_SegmentsAreAveragedPerCell(t *testing.T) {
+	// two segments of the same ~1km cell on Tuesday 17:00 (hour 41)
+	csv := "segment_id,lat,lng,hour_of_week,multiplier\n" +
+		"a,30.0500,31.2400,41,1.2\n" +
+		"b,30.0501,31.2401,41,1.6\n"
+
+	profile, err := traffic.ReadProfile(strings.NewReader(csv), "segments", time.UTC, traffic.DefaultSegmentLevel)
+	require.NoError(t, err)
+
+	point, _ := model.NewCoordinate(30.05005, 31.24005)
+	assert.InDelta(t, 1.4, profile.Multiplier(*point, nextWeekday(time.Tuesday, 17)), 1e-9)
+	assert.Equal(t, 1.0, profile.Multiplier(*point, nextWeekday(time.Tuesday, 18)))
+}
+
+func TestReadProfile_InvalidRows(t *testing.T) {
+	for name, csv := range map[string]string{
+		"no key column":   "hour_of_week,multiplier\n8,1.2\n",
+		"invalid zone":    "zone,hour_of_week,multiplier\nnot-a-token,8,1.2\n",
+		"hour overflow":   "zone,hour_of_week,multiplier\n" + zoneToken(30, 31, 10) + ",168,1.2\n",
+		"zero multiplier": "zone,hour_of_week,multiplier\n" + zoneToken(30, 31, 10) + ",8,0\n",
+		"missing lng":     "segment_id,lat,hour_of_week,multiplier\na,30,8,1.2\n",
+	} {
+		_, err := traffic.ReadProfile(strings.NewReader(csv), "p", time.UTC, traffic.DefaultSegmentLevel)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadProfileFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "weekday_speeds.csv")
+	require.NoError(t, os.WriteFile(path, []byte("zone,hour_of_week,multiplier\n"+zoneToken(30, 31, 10)+",*,1.3\n"), 0o644))
+
+	profile, err := traffic.LoadProfileFile(path, "", time.UTC, traffic.DefaultSegmentLevel)
+	require.NoError(t, err)
+	assert.Equal(t, "weekday_speeds", profile.ID())
+
+	_, err = traffic.LoadProfileFile(filepath.Join(dir, "missing.csv"), "", time.UTC, traffic.DefaultSegmentLevel)
+	assert.Error(t, err)
+}
+
+// segmentSpeed is a row of a segment profile exported to Parquet
+type segmentSpeed struct {
+	SegmentID  string  `parquet:"segment_id"`
+	Lat        float64 `parquet:"lat"`
+	Lng        float64 `parquet:"lng"`
+	HourOfWeek string  `parquet:"hour_of_week"`
+	Multiplier float64 `parquet:"multiplier"`
+}
+
+func TestLoadProfileFile_Parquet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "segment_speeds.parquet")
+	require.NoError(t, parquet.WriteFile(path, []segmentSpeed{
+		{SegmentID: "a", Lat: 30.05, Lng: 31.24, HourOfWeek: "*", Multiplier: 1.1},
+		{SegmentID: "a", Lat: 30.05, Lng: 31.24, HourOfWeek: "8", Multiplier: 1.7},
+		{SegmentID: "b", Lat: 30.05, Lng: 31.24, HourOfWeek: "8", Multiplier: 1.3},
+	}))
+
+	profile, err := traffic.LoadProfileFile(path, "", time.UTC, traffic.DefaultSegmentLevel)
+	require.NoError(t, err)
+	assert.Equal(t, "segment_speeds", profile.ID())
+	assert.Equal(t, 1, profile.Zones())
+
+	inside, _ := model.NewCoordinate(30.05, 31.24)
+	mondayEight := nextWeekday(time.Monday, 8)
+	assert.InDelta(t, (1.1+1.7+1.3)/3, profile.Multiplier(*inside, mondayEight), 1e-9)
+	assert.InDelta(t, 1.1, profile.Multiplier(*inside, mondayEight.Add(time.Hour)), 1e-9)
+}
+
+func TestEngine_ScalesDrivingTimes(t *testing.T) {
+	next := newOffline(t)
+	csv := "zone,hour_of_week,multiplier\n" + zoneToken(30.05, 31.24, 10) + ",*,1.5\n"
+	profile, err := traffic.ReadProfile(strings.NewReader(csv), "rush", time.UTC, traffic.DefaultSegmentLevel)
+	require.NoError(t, err)
+	engine := traffic.NewEngine(next, profile)
+
+	inside1, _ := model.NewCoordinate(30.05, 31.24)
+	inside2, _ := model.NewCoordinate(30.051, 31.245)
+	outside, _ := model.NewCoordinate(31.2, 29.9)
+	departure := time.Now().Add(time.Hour)
+
+	params, err := model.NewDistanceTimeMatrixParams([]model.Coordinate{*inside1, *inside2, *outside}, model.ProfileAuto, model.WithDepartureTime(departure))
+	require.NoError(t, err)
+	raw, err := next.ComputeDistanceTimeMatrix(context.Background(), params)
+	require.NoError(t, err)
+	adjusted, err := engine.ComputeDistanceTimeMatrix(context.Background(), params)
+	require.NoError(t, err)
+
+	assert.Equal(t, raw.Distances(), adjusted.Distances())
+	assert.InDelta(t, float64(raw.Times()[0][1])*1.5, float64(adjusted.Times()[0][1]), 1)
+	// a leg leaving the zone uses the mean of both ends
+	assert.InDelta(t, float64(raw.Times()[0][2])*1.25, float64(adjusted.Times()[0][2]), 1)
+
+	routeParams, err := model.NewRouteParams([]model.Coordinate{*inside1, *inside2, *outside}, departure)
+	require.NoError(t, err)
+	rawTimes, err := next.ComputeDrivingTime(context.Background(), routeParams)
+	require.NoError(t, err)
+	adjustedTimes, err := engine.ComputeDrivingTime(context.Background(), routeParams)
+	require.NoError(t, err)
+	require.Len(t, adjustedTimes, 3)
+	assert.InDelta(t, float64(rawTimes[1])*1.5, float64(adjustedTimes[1]), 1)
+	assert.InDelta(t, float64(adjustedTimes[1])+float64(rawTimes[2]-rawTimes[1])*1.25, float64(adjustedTimes[2]), 1)
+
+	// walking is not affected by traffic
+	walkParams, err := model.NewWalkParams(inside1, inside2)
+	require.NoError(t, err)
+	rawWalk, err := next.ComputeWalkingTime(context.Background(), walkParams)
+	require.NoError(t, err)
+	adjustedWalk, err := engine.ComputeWalkingTime(context.Background(), walkParams)
+	require.NoError(t, err)
+	assert.Equal(t, rawWalk, adjustedWalk)
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	re "matching-engine/internal/adapter/routing"
@@ -72,7 +73,7 @@ var _ re.Client[
 	*pb.Api,
 ] = (*ValhallaClient)(nil)
 
-func (vc *ValhallaClient) Post(endpoint string, request *pb.Api) (*pb.Api, error) {
+func (vc *ValhallaClient) Post(ctx context.Context, endpoint string, request *pb.Api) (*pb.Api, error) {
 	data, err := vc.serializeRequest(request)
 	if err != nil {
 		log.Error().
@@ -81,7 +82,7 @@ func (vc *ValhallaClient) Post(endpoint string, request *pb.Api) (*pb.Api, error
 		return nil, fmt.Errorf("failed to serialize request: %w", err)
 	}
 
-	body, err := vc.doPost(endpoint, data)
+	body, err := vc.doPost(ctx, endpoint, data)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -105,8 +106,9 @@ func (vc *ValhallaClient) Post(endpoint string, request *pb.Api) (*pb.Api, error
 	return response, nil
 }
 
-func (vc *ValhallaClient) doPost(endpoint string, data []byte) ([]byte, error) {
-	req, err := http.NewRequest(
+func (vc *ValhallaClient) doPost(ctx context.Context, endpoint string, data []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
 		fmt.Sprintf("%v%v?format=proto", vc.cfg.ValhallaURL(), endpoint),
 		bytes.NewReader(data),
@@ -146,10 +148,15 @@ func (vc *ValhallaClient) doPost(endpoint string, data []byte) ([]byte, error) {
 			Str("endpoint", endpoint).
 			Bytes("body_snippet", snippet).
 			Msg("Received non-success HTTP response")
-		return nil, fmt.Errorf(
+		err := fmt.Errorf(
 			"unexpected HTTP status %d from %s: %q",
 			resp.StatusCode, endpoint, snippet,
 		)
+		// A rejected request fails the same way when sent again
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, re.Permanent(err)
+		}
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -38,3 +38,8 @@ var DefaultPedestrianCosting = &pb2.Costing{
 		},
 	},
 }
+
+// DefaultMultimodalCosting combines walking with the public transit of the loaded GTFS feeds
+var DefaultMultimodalCosting = &pb2.Costing{
+	Type: pb2.Costing_multimodal,
+}
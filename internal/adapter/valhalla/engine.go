@@ -6,23 +6,38 @@ import (
 	"github.com/rs/zerolog/log"
 	re "matching-engine/internal/adapter/routing"
 	"matching-engine/internal/adapter/valhalla/client"
+	"matching-engine/internal/adapter/valhalla/client/pb"
 	"matching-engine/internal/model"
 	"time"
 )
 
 type Valhalla struct {
-	client *client.ValhallaClient
+	client re.Client[*pb.Api, *pb.Api]
 	mapper *Mapper
 }
 
 func NewValhalla(clientOpts ...client.Option) (re.Engine, error) {
+	return newValhalla(clientOpts...)
+}
+
+// NewValhallaTransit creates a transit engine planning multimodal journeys on the GTFS feeds loaded in Valhalla
+func NewValhallaTransit(clientOpts ...client.Option) (re.TransitEngine, error) {
+	return newValhalla(clientOpts...)
+}
+
+func newValhalla(clientOpts ...client.Option) (*Valhalla, error) {
 	c, err := client.NewValhallaClient(clientOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create valhalla client: %w", err)
 	}
 
+	resilience, err := re.LoadResilienceConfig("VALHALLA", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load valhalla resilience config: %w", err)
+	}
+
 	return &Valhalla{
-		client: c,
+		client: re.NewResilientClient[*pb.Api, *pb.Api](c, resilience),
 		mapper: NewMapper(),
 	}, nil
 }
@@ -50,7 +65,7 @@ func (v *Valhalla) ComputeDrivingTime(
 	ctx context.Context,
 	routeParams *model.RouteParams,
 ) ([]time.Duration, error) {
-	timeMatrix, err := v.getTimeMatrix(routeParams.Waypoints(), routeParams.DepartureTime())
+	timeMatrix, err := v.getTimeMatrix(ctx, routeParams.Waypoints(), routeParams.DepartureTime())
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +90,7 @@ func (v *Valhalla) getCumulativeDurations(distanceTimeMatrix [][]time.Duration,
 	return cumulativeDurations, nil
 }
 
-func (v *Valhalla) getTimeMatrix(matrixPoints []model.Coordinate, departureTime time.Time) ([][]time.Duration, error) {
+func (v *Valhalla) getTimeMatrix(ctx context.Context, matrixPoints []model.Coordinate, departureTime time.Time) ([][]time.Duration, error) {
 	// Validate the input points
 	if len(matrixPoints) < 2 {
 		return nil, fmt.Errorf("not enough points to generate a distance/time matrix")
@@ -91,7 +106,7 @@ func (v *Valhalla) getTimeMatrix(matrixPoints []model.Coordinate, departureTime
 		return nil, fmt.Errorf("failed to create distance time matrix params: %w", err)
 	}
 
-	distanceTimeMatrix, err := v.ComputeDistanceTimeMatrix(nil, params)
+	distanceTimeMatrix, err := v.ComputeDistanceTimeMatrix(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute distance time matrix: %w", err)
 	}
@@ -175,3 +190,22 @@ func (v *Valhalla) SnapPointToRoad(
 
 	return snappedPoint, nil
 }
+
+func (v *Valhalla) ComputeTransitJourney(
+	ctx context.Context,
+	transitParams *model.TransitParams,
+) (*model.TransitJourney, error) {
+	journey, err := re.RunOperation(
+		ctx,
+		v.client,
+		"/route",
+		transitParams,
+		v.mapper.TransitMapper,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute transit journey: %w", err)
+	}
+
+	return journey, nil
+}
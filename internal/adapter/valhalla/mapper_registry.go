@@ -15,6 +15,7 @@ type Mapper struct {
 	IsochroneMapper   re.OperationMapper[*model.IsochroneParams, *model.Isochrone, *pb.Api, *pb.Api]
 	MatrixMapper      re.OperationMapper[*model.DistanceTimeMatrixParams, *model.DistanceTimeMatrix, *pb.Api, *pb.Api]
 	SnapToRoadMapper  re.OperationMapper[*model.Coordinate, *model.Coordinate, *pb.Api, *pb.Api]
+	TransitMapper     re.OperationMapper[*model.TransitParams, *model.TransitJourney, *pb.Api, *pb.Api]
 }
 
 func NewMapper() *Mapper {
@@ -25,5 +26,6 @@ func NewMapper() *Mapper {
 		IsochroneMapper:   mappers.IsochroneMapper{},
 		MatrixMapper:      mappers.MatrixMapper{},
 		SnapToRoadMapper:  mappers.SnapToRoadMapper{},
+		TransitMapper:     mappers.TransitMapper{},
 	}
 }
@@ -0,0 +1,90 @@
+package mappers
+
+import (
+	"fmt"
+	re "matching-engine/internal/adapter/routing"
+	"matching-engine/internal/adapter/valhalla/client/pb"
+	"matching-engine/internal/adapter/valhalla/common"
+	"matching-engine/internal/model"
+	"time"
+)
+
+type TransitMapper struct{}
+
+var _ re.OperationMapper[
+	*model.TransitParams,
+	*model.TransitJourney,
+	*pb.Api,
+	*pb.Api,
+] = TransitMapper{}
+
+func (TransitMapper) ToTransport(params *model.TransitParams) (*pb.Api, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+
+	locations := common.WayPointsToLocations(
+		[]model.Coordinate{*params.Origin(), *params.Destination()},
+		pb.Location_kBreak,
+	)
+
+	dateTimeType := pb.Options_depart_at
+	if params.ArriveBy() {
+		dateTimeType = pb.Options_arrive_by
+	}
+
+	return &pb.Api{
+		Options: &pb.Options{
+			Action:      pb.Options_route,
+			Units:       common.DefaultUnit,
+			Format:      common.DefaultResponseFormat,
+			CostingType: pb.Costing_multimodal,
+			Costings: map[int32]*pb.Costing{
+				int32(pb.Costing_multimodal): common.DefaultMultimodalCosting,
+				int32(pb.Costing_pedestrian): common.DefaultPedestrianCosting,
+			},
+			HasShapeFormat: &pb.Options_ShapeFormat{
+				ShapeFormat: common.DefaultShapeFormat,
+			},
+			Locations:    locations,
+			DateTimeType: dateTimeType,
+			HasDateTime: &pb.Options_DateTime{
+				DateTime: params.Time().Format(common.DefaultTimeFormat),
+			},
+			PbfFieldSelector: &pb.PbfFieldSelector{
+				Directions: true,
+			},
+		},
+	}, nil
+}
+
+// FromTransport splits the journey between the walking maneuvers and the rest,
+// which holds the transit rides and the waiting time at the stops
+func (TransitMapper) FromTransport(response *pb.Api) (*model.TransitJourney, error) {
+	if response == nil {
+		return nil, fmt.Errorf("response cannot be nil")
+	}
+	routes := response.GetDirections().GetRoutes()
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no transit route found")
+	}
+
+	var totalSeconds, walkingSeconds float64
+	for _, leg := range routes[0].GetLegs() {
+		totalSeconds += leg.GetSummary().GetTime()
+		for _, maneuver := range leg.GetManeuver() {
+			if maneuver.GetTravelMode() == pb.TravelMode_kPedestrian {
+				walkingSeconds += maneuver.GetTime()
+			}
+		}
+	}
+
+	transitSeconds := totalSeconds - walkingSeconds
+	if transitSeconds < 0 {
+		transitSeconds = 0
+	}
+	return model.NewTransitJourney(
+		time.Duration(walkingSeconds*float64(time.Second)),
+		time.Duration(transitSeconds*float64(time.Second)),
+	), nil
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"matching-engine/internal/adapter/valhalla/client/pb"
+	"matching-engine/internal/adapter/valhalla/mappers"
+	"matching-engine/internal/model"
+	"testing"
+	"time"
+)
+
+func TestTransitMapper_ToTransport(t *testing.T) {
+	origin := must(model.NewCoordinate(42.5078, 1.5211))
+	destination := must(model.NewCoordinate(42.5347, 1.5830))
+	arrival := time.Date(2030, 5, 6, 8, 30, 0, 0, time.UTC)
+
+	params := must(model.NewArriveByTransitParams(origin, destination, arrival))
+	api, err := mappers.TransitMapper{}.ToTransport(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options := api.GetOptions()
+	if options.GetCostingType() != pb.Costing_multimodal {
+		t.Errorf("expected multimodal costing, got %v", options.GetCostingType())
+	}
+	if options.GetDateTimeType() != pb.Options_arrive_by || options.GetDateTime() != "2030-05-06T08:30" {
+		t.Errorf("expected to arrive by 2030-05-06T08:30, got %v %s", options.GetDateTimeType(), options.GetDateTime())
+	}
+	if len(options.GetLocations()) != 2 {
+		t.Errorf("expected 2 locations, got %d", len(options.GetLocations()))
+	}
+}
+
+func TestTransitMapper_FromTransport(t *testing.T) {
+	response := &pb.Api{
+		Directions: &pb.Directions{
+			Routes: []*pb.DirectionsRoute{{
+				Legs: []*pb.DirectionsLeg{{
+					Summary: &pb.Summary{Time: 1800},
+					Maneuver: []*pb.DirectionsLeg_Maneuver{
+						{TravelMode: pb.TravelMode_kPedestrian, Time: 240},
+						{TravelMode: pb.TravelMode_kTransit, Time: 1200},
+						{TravelMode: pb.TravelMode_kPedestrian, Time: 60},
+					},
+				}},
+			}},
+		},
+	}
+
+	journey, err := mappers.TransitMapper{}.FromTransport(response)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if journey.WalkingDuration() != 5*time.Minute {
+		t.Errorf("expected 5 minutes of walking, got %v", journey.WalkingDuration())
+	}
+	// The 5 minutes of waiting at the stop count as transit
+	if journey.TransitDuration() != 25*time.Minute {
+		t.Errorf("expected 25 minutes of transit, got %v", journey.TransitDuration())
+	}
+
+	if _, err := (mappers.TransitMapper{}).FromTransport(&pb.Api{}); err == nil {
+		t.Errorf("expected an error without routes")
+	}
+}
@@ -1,10 +1,19 @@
 package di
 
 import (
+	"fmt"
 	"go.uber.org/dig"
+	"matching-engine/internal/app/config"
 	"matching-engine/internal/app/di/utils"
 
 	"matching-engine/internal/adapter/messaging/natsjetstream"
+	"matching-engine/internal/adapter/offline"
+	"matching-engine/internal/adapter/osrm"
+	"matching-engine/internal/adapter/replay"
+	"matching-engine/internal/adapter/roadgraph"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/adapter/routingcache"
+	"matching-engine/internal/adapter/traffic"
 	"matching-engine/internal/adapter/valhalla"
 )
 
@@ -12,6 +21,83 @@ import (
 
 // registerAdapters registers external adapters
 func registerAdapters(c *dig.Container) {
-	utils.Must(c.Provide(valhalla.NewValhalla))
+	utils.Must(c.Provide(traffic.LoadProfileFromEnv))
+	utils.Must(c.Provide(provideRoutingEngine))
+	utils.Must(c.Provide(provideTransitEngine))
 	utils.Must(c.Provide(natsjetstream.NewNATSPublisher))
 }
+
+// provideRoutingEngine creates the routing engine selected by ROUTING_ENGINE ("valhalla", "osrm", "roadgraph" or "offline").
+// When ROUTING_FALLBACK_ENGINE is set, the failed calls are retried on the fallback engine.
+// ROUTING_FIXTURES_MODE records the calls to a fixtures file or replays them from it.
+// The traffic profile at TRAFFIC_PROFILE_PATH scales the driving times of the resulting engine,
+// so that the fixtures and the persistent cache keep the raw engine times.
+func provideRoutingEngine(profile *traffic.Profile) (routing.Engine, error) {
+	engine, err := replay.WrapFromEnv(newConfiguredRoutingEngine)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		engine = traffic.NewEngine(engine, profile)
+	}
+	return engine, nil
+}
+
+// provideTransitEngine creates the engine planning the transit first and last mile of the riders
+// when ENABLE_TRANSIT_FIRST_LAST_MILE is set. Only Valhalla, with GTFS feeds loaded in its tiles, plans transit journeys.
+func provideTransitEngine() (routing.TransitEngine, error) {
+	if !config.GetEnvBool("ENABLE_TRANSIT_FIRST_LAST_MILE", false) {
+		return nil, nil
+	}
+	switch name := config.GetEnv("TRANSIT_ENGINE", "valhalla"); name {
+	case "valhalla":
+		return valhalla.NewValhallaTransit()
+	default:
+		return nil, fmt.Errorf("invalid transit engine %q", name)
+	}
+}
+
+func newConfiguredRoutingEngine() (routing.Engine, error) {
+	engine, err := newRoutingEngine(config.GetEnv("ROUTING_ENGINE", "valhalla"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The persistent cache keeps the travel times across runs, see PERSISTENT_CACHE_BACKEND.
+	// It only wraps the primary engine, so the estimates of the fallback engine are never kept.
+	if config.GetEnvBool("PERSISTENT_CACHE_ENABLED", false) {
+		cfg, err := routingcache.LoadConfig()
+		if err != nil {
+			return nil, fmt.Errorf("invalid persistent cache config: %w", err)
+		}
+		backend, err := routingcache.NewBackendFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open persistent cache: %w", err)
+		}
+		engine = routingcache.NewCachingEngine(engine, backend, cfg)
+	}
+
+	if fallbackName := config.GetEnv("ROUTING_FALLBACK_ENGINE", ""); fallbackName != "" {
+		fallback, err := newRoutingEngine(fallbackName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback routing engine: %w", err)
+		}
+		engine = routing.NewFallbackEngine(engine, fallback)
+	}
+	return engine, nil
+}
+
+func newRoutingEngine(name string) (routing.Engine, error) {
+	switch name {
+	case "valhalla":
+		return valhalla.NewValhalla()
+	case "osrm":
+		return osrm.NewOSRM()
+	case "roadgraph":
+		return roadgraph.NewRoadGraph()
+	case "offline":
+		return offline.NewOffline()
+	default:
+		return nil, fmt.Errorf("invalid routing engine %q", name)
+	}
+}
@@ -1,11 +1,19 @@
 package di
 
 import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
 	"go.uber.org/dig"
+	"matching-engine/internal/adapter/routing"
 	"matching-engine/internal/app/config"
 	"matching-engine/internal/app/di/utils"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/geo/downsampling"
+	"matching-engine/internal/repository"
 
 	"matching-engine/internal/service/checker"
+	"matching-engine/internal/service/pickupdropoffservice"
 )
 
 // This function is exported to be called from tests until a cleaner approach is implemented.
@@ -17,6 +25,9 @@ func RegisterCheckers(c *dig.Container) {
 	utils.Must(c.Provide(checker.NewDetourTimeChecker, dig.Name("detour_checker")))
 	utils.Must(c.Provide(checker.NewPreferenceChecker, dig.Name("preference_checker")))
 	utils.Must(c.Provide(checker.NewHaversineDistanceChecker, dig.Name("haversine_distance_checker")))
+	utils.Must(c.Provide(provideTrustSafetyChecker, dig.Name("trust_safety_checker")))
+	utils.Must(c.Provide(checker.NewWalkOnlyChecker, dig.Name("walk_only_checker")))
+	utils.Must(c.Provide(provideCorridorChecker, dig.Name("corridor_checker")))
 	utils.Must(c.Provide(provideCompositeChecker))
 }
 
@@ -29,23 +40,71 @@ type CheckerParams struct {
 	OverlapChecker           checker.Checker `name:"overlap_checker"`
 	PreferenceChecker        checker.Checker `name:"preference_checker"`
 	HaversineDistanceChecker checker.Checker `name:"haversine_distance_checker"`
+	TrustSafetyChecker       checker.Checker `name:"trust_safety_checker" optional:"true"`
+	WalkOnlyChecker          checker.Checker `name:"walk_only_checker"`
+	CorridorChecker          checker.Checker `name:"corridor_checker"`
 }
 
 // provideCompositeChecker provides a composite checker with all other checkers
 func provideCompositeChecker(params CheckerParams) checker.Checker {
-	if config.GetEnvBool("ENABLE_HAVERSINE_DISTANCE_CHECKER", false) {
-		return checker.NewCompositeChecker(
-			params.OverlapChecker,
-			params.CapacityChecker,
-			params.PreferenceChecker,
-			params.HaversineDistanceChecker,
-			params.DetourTimeChecker,
-		)
-	}
-	return checker.NewCompositeChecker(
+	checkers := []checker.Checker{
 		params.OverlapChecker,
 		params.CapacityChecker,
 		params.PreferenceChecker,
-		params.DetourTimeChecker,
-	)
+	}
+	if params.TrustSafetyChecker != nil {
+		checkers = append(checkers, params.TrustSafetyChecker)
+	}
+	if config.GetEnvBool("ENABLE_HAVERSINE_DISTANCE_CHECKER", false) {
+		checkers = append(checkers, params.HaversineDistanceChecker)
+	}
+	if config.GetEnvBool("ENABLE_CORRIDOR_CHECKER", false) {
+		checkers = append(checkers, params.CorridorChecker)
+	}
+	if config.GetEnvBool("ENABLE_WALK_ONLY_DETECTION", false) {
+		checkers = append(checkers, params.WalkOnlyChecker)
+	}
+	checkers = append(checkers, params.DetourTimeChecker)
+	return checker.NewCompositeChecker(checkers...)
+}
+
+// TrustSafetyCheckerParams contains the dependencies for the trust and safety checker
+type TrustSafetyCheckerParams struct {
+	dig.In
+
+	TrustSafetyRepo repository.TrustSafetyRepo `optional:"true"`
+}
+
+// provideTrustSafetyChecker provides the trust and safety checker when ENABLE_TRUST_SAFETY_CHECKER is set,
+// the containers without the database services don't need its repository otherwise
+func provideTrustSafetyChecker(params TrustSafetyCheckerParams) (checker.Checker, error) {
+	if !config.GetEnvBool("ENABLE_TRUST_SAFETY_CHECKER", false) {
+		return nil, nil
+	}
+	if params.TrustSafetyRepo == nil {
+		return nil, fmt.Errorf("ENABLE_TRUST_SAFETY_CHECKER needs the trust and safety repository of the database services")
+	}
+	return checker.NewTrustSafetyChecker(params.TrustSafetyRepo), nil
+}
+
+// provideCorridorChecker provides a corridor checker downsampling the routes with CORRIDOR_CHECKER_DOWNSAMPLER
+// ("rdp" or "time_threshold"), the RDP tolerance being CORRIDOR_CHECKER_RDP_EPSILON_METERS.
+// The routes of the offers without a stored route are planned with the routing engine.
+func provideCorridorChecker(engine routing.Engine) checker.Checker {
+	raw := config.GetEnv("CORRIDOR_CHECKER_DOWNSAMPLER", string(enums.DownsamplerRDP))
+	dsType := enums.DownsamplerType(raw)
+	if !dsType.IsValid() {
+		log.Warn().Msgf("Invalid CORRIDOR_CHECKER_DOWNSAMPLER %q, falling back to %s", raw, enums.DownsamplerRDP)
+		dsType = enums.DownsamplerRDP
+	}
+
+	var downSampler downsampling.RouteDownSampler
+	switch dsType {
+	case enums.DownsamplerTimeThreshold:
+		downSampler = downsampling.NewTimeThresholdDownSampler()
+	default:
+		epsilonMeters := config.GetEnvFloat("CORRIDOR_CHECKER_RDP_EPSILON_METERS", 50)
+		downSampler = downsampling.NewRDPDownSampler(downsampling.WithEpsilonMeters(epsilonMeters))
+	}
+	return checker.NewCorridorChecker(downSampler, pickupdropoffservice.NewOfferRoutePlanner(engine), riderMeetingReachMeters())
 }
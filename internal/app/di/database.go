@@ -3,9 +3,12 @@ package di
 import (
 	"context"
 	"go.uber.org/dig"
+	"matching-engine/internal/app/config"
 	"matching-engine/internal/app/di/utils"
+	"time"
 
 	"matching-engine/internal/reader"
+	"matching-engine/internal/repository"
 	"matching-engine/internal/repository/postgres"
 )
 
@@ -22,5 +25,15 @@ func registerDatabase(c *dig.Container) {
 func RegisterDatabaseRepositoriesAndServices(c *dig.Container) {
 	utils.Must(c.Provide(postgres.NewPostgresDriverOfferRepository))
 	utils.Must(c.Provide(postgres.NewPostgresRiderRequestRepo))
+	utils.Must(c.Provide(provideTrustSafetyRepo))
+	utils.Must(c.Provide(postgres.NewPostgresHubRepo))
+	utils.Must(c.Provide(postgres.NewPostgresRideMatchRepo))
 	utils.Must(c.Provide(reader.NewPostgresInputReader))
 }
+
+// provideTrustSafetyRepo provides the trust and safety repository, the blocks and trust profiles being loaded again
+// after TRUST_SAFETY_CACHE_TTL_MINUTES
+func provideTrustSafetyRepo(db *postgres.Database) repository.TrustSafetyRepo {
+	ttl := time.Duration(config.GetEnvFloat("TRUST_SAFETY_CACHE_TTL_MINUTES", 10) * float64(time.Minute))
+	return postgres.NewPostgresTrustSafetyRepo(db, ttl)
+}
@@ -2,9 +2,11 @@ package di
 
 import (
 	"go.uber.org/dig"
+	"matching-engine/internal/adapter/routing"
 	"matching-engine/internal/app/di/utils"
 
 	"matching-engine/internal/geo/downsampling"
+	"matching-engine/internal/geo/geojsonexport"
 	"matching-engine/internal/geo/processor"
 	"matching-engine/internal/geo/pruning"
 )
@@ -15,5 +17,22 @@ import (
 func RegisterGeoServices(c *dig.Container) {
 	utils.Must(c.Provide(pruning.NewRTreePrunerFactory))
 	utils.Must(c.Provide(downsampling.NewRDPDownSampler))
-	utils.Must(c.Provide(processor.NewProcessorFactory))
+	utils.Must(c.Provide(geojsonexport.NewExporterFromEnv))
+	utils.Must(c.Provide(provideProcessorFactory))
+}
+
+// ProcessorFactoryParams contains the dependencies for the processor factory
+type ProcessorFactoryParams struct {
+	dig.In
+
+	Engine   routing.Engine
+	Exporter *geojsonexport.Exporter `optional:"true"`
+}
+
+// provideProcessorFactory provides a processor factory, whose pruned routes are exported with the runs when enabled
+func provideProcessorFactory(params ProcessorFactoryParams) processor.ProcessorFactory {
+	if params.Exporter == nil {
+		return processor.NewProcessorFactory(params.Engine)
+	}
+	return processor.NewProcessorFactoryWithPruneObserver(params.Engine, params.Exporter)
 }
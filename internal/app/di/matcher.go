@@ -1,10 +1,20 @@
 package di
 
 import (
+	"fmt"
 	"go.uber.org/dig"
+	"matching-engine/internal/adapter/messaging/natsjetstream"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/adapter/traffic"
+	"matching-engine/internal/app/config"
 	"matching-engine/internal/app/di/utils"
+	"matching-engine/internal/geo/geojsonexport"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"matching-engine/internal/service/pickupdropoffservice/pickupdropoffcache"
 	"matching-engine/internal/service/timematrix"
+	"matching-engine/internal/service/timematrix/cache"
 
+	"matching-engine/internal/service/affinity"
 	"matching-engine/internal/service/checker"
 	"matching-engine/internal/service/earlypruning"
 	"matching-engine/internal/service/matcher"
@@ -18,21 +28,131 @@ import (
 // RegisterMatchingServices registers matching algorithm services
 func RegisterMatchingServices(c *dig.Container) {
 	utils.Must(c.Provide(provideMatchEvaluator))
-	utils.Must(c.Provide(earlypruning.NewPreChecksCandidateGenerator))
-	utils.Must(c.Provide(maximummatching.NewHopcroftKarp))
+	utils.Must(c.Provide(provideCandidateGenerator))
+	utils.Must(c.Provide(affinity.NewTracker))
+	utils.Must(c.Provide(provideMaximumMatching))
+	utils.Must(c.Provide(provideOfferCaches))
+	utils.Must(c.Provide(provideTrafficProfile))
+	utils.Must(c.Provide(provideWalkOnlyDetector))
+	utils.Must(c.Provide(provideRoutePlanner))
+	utils.Must(c.Provide(provideRunRecorder))
+	utils.Must(c.Provide(provideGraphWorkerPool))
 	utils.Must(c.Provide(matcher.NewMatcher))
 }
 
+// provideCandidateGenerator provides the candidate generator selected by CANDIDATE_GENERATOR ("prechecks" or "spatial")
+func provideCandidateGenerator(compositeChecker checker.Checker) (earlypruning.CandidateGenerator, error) {
+	switch generator := config.GetEnv("CANDIDATE_GENERATOR", "prechecks"); generator {
+	case "prechecks":
+		return earlypruning.NewPreChecksCandidateGenerator(compositeChecker), nil
+	case "spatial":
+		return earlypruning.NewSpatialCandidateGenerator(compositeChecker, riderMeetingReachMeters()), nil
+	default:
+		return nil, fmt.Errorf("invalid CANDIDATE_GENERATOR value %q", generator)
+	}
+}
+
+// OfferCachesParams contains the in-run caches holding values computed from the path of an offer
+type OfferCachesParams struct {
+	dig.In
+
+	TimeMatrixCacheWithOfferId             *cache.TimeMatrixCacheWithOfferId
+	TimeMatrixCacheWithOfferIdAndRequestId *cache.TimeMatrixCacheWithOfferIdAndRequestId
+	PickupDropoffCache                     *pickupdropoffcache.PickupDropoffCache
+	PickupDropoffGenerator                 pickupdropoffservice.PickupDropoffGenerator
+}
+
+// provideOfferCaches collects the caches the matcher invalidates when the path of an offer changes
+func provideOfferCaches(params OfferCachesParams) matcher.OfferCaches {
+	caches := matcher.OfferCaches{
+		"time_matrix_offer":         params.TimeMatrixCacheWithOfferId,
+		"time_matrix_offer_request": params.TimeMatrixCacheWithOfferIdAndRequestId,
+		"pickup_dropoff":            params.PickupDropoffCache,
+	}
+	if generatorCache, ok := params.PickupDropoffGenerator.(matcher.OfferCache); ok {
+		caches["pickup_dropoff_generator"] = generatorCache
+	}
+	return caches
+}
+
+// TrafficProfileParams contains the traffic profile, absent when the adapters are not registered
+type TrafficProfileParams struct {
+	dig.In
+
+	Profile *traffic.Profile `optional:"true"`
+}
+
+// provideTrafficProfile exposes the loaded traffic profile to the matcher, which reports it in the results
+func provideTrafficProfile(params TrafficProfileParams) matcher.TrafficProfile {
+	if params.Profile == nil {
+		return nil
+	}
+	return params.Profile
+}
+
+// provideWalkOnlyDetector provides the detector of walkable requests to the matcher when enabled
+func provideWalkOnlyDetector(detector *pickupdropoffservice.WalkOnlyDetector) matcher.WalkOnlyDetector {
+	if !config.GetEnvBool("ENABLE_WALK_ONLY_DETECTION", false) {
+		return nil
+	}
+	return detector
+}
+
+// provideRoutePlanner provides the planner of the routes reported in the results when enabled
+func provideRoutePlanner(engine routing.Engine) matcher.RoutePlanner {
+	if !config.GetEnvBool("ENABLE_RESULT_ROUTE_POLYLINE", false) {
+		return nil
+	}
+	return pickupdropoffservice.NewOfferRoutePlanner(engine)
+}
+
+// RunRecorderParams contains the GeoJSON exporter, absent when the runs are not exported
+type RunRecorderParams struct {
+	dig.In
+
+	Exporter *geojsonexport.Exporter `optional:"true"`
+}
+
+// provideRunRecorder provides the GeoJSON exporter to the matcher when a directory is configured
+func provideRunRecorder(params RunRecorderParams) matcher.RunRecorder {
+	if params.Exporter == nil {
+		return nil
+	}
+	return params.Exporter
+}
+
+// provideGraphWorkerPool provides the client of the graph workers when MATCHING_DISTRIBUTION_MODE is "coordinator"
+func provideGraphWorkerPool() (matcher.GraphWorkerPool, error) {
+	if matcher.GetDistributionMode() != matcher.DistributionModeCoordinator {
+		return nil, nil
+	}
+	return natsjetstream.NewGraphWorkerClient()
+}
+
 // MatchEvaluatorParams contains the dependencies for the match evaluator
 type MatchEvaluatorParams struct {
 	dig.In
 
 	PathPlanner                                           planner.PathPlanner
 	PreferenceChecker                                     checker.Checker `name:"preference_checker"`
+	TrustSafetyChecker                                    checker.Checker `name:"trust_safety_checker" optional:"true"`
 	TimeMatrixCacheWithDriverOfferIdAndRequestIdPopulator *timematrix.CacheWithOfferIdRequestIdPopulator
 }
 
 // provideMatchEvaluator provides a match evaluator
 func provideMatchEvaluator(params MatchEvaluatorParams) matchevaluator.Evaluator {
-	return matchevaluator.NewMatchEvaluator(params.PathPlanner, params.PreferenceChecker, params.TimeMatrixCacheWithDriverOfferIdAndRequestIdPopulator)
+	// The evaluator re-runs these checks against riders assigned earlier in the same run
+	matchedRequestsChecker := params.PreferenceChecker
+	if params.TrustSafetyChecker != nil {
+		matchedRequestsChecker = checker.NewCompositeChecker(params.PreferenceChecker, params.TrustSafetyChecker)
+	}
+	return matchevaluator.NewMatchEvaluator(params.PathPlanner, matchedRequestsChecker, params.TimeMatrixCacheWithDriverOfferIdAndRequestIdPopulator)
+}
+
+// provideMaximumMatching provides a maximum matching that keeps existing and recurring carpools together when enabled
+func provideMaximumMatching(affinityTracker *affinity.Tracker) maximummatching.MaximumMatching {
+	if config.GetEnvBool("ENABLE_CARPOOL_AFFINITY", true) {
+		return maximummatching.NewHopcroftKarpWithPreference(affinityTracker)
+	}
+	return maximummatching.NewHopcroftKarp()
 }
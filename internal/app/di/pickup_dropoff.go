@@ -1,11 +1,20 @@
 package di
 
 import (
+	"context"
+	"fmt"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/app/config"
 	"matching-engine/internal/app/di/utils"
+	"matching-engine/internal/geo/processor"
+	"matching-engine/internal/repository"
 	"matching-engine/internal/service/pickupdropoffservice"
+	"matching-engine/internal/service/pickupdropoffservice/hubcatalogue"
 	"matching-engine/internal/service/pickupdropoffservice/pickupdropoffcache"
+	"math"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"go.uber.org/dig"
@@ -16,14 +25,143 @@ import (
 // RegisterPickupDropoffServices registers pickup/dropoff services
 func RegisterPickupDropoffServices(c *dig.Container) {
 	walkingTimeEnabled := getWalkingTimeEnabled()
-	if walkingTimeEnabled {
-		utils.Must(c.Provide(pickupdropoffservice.NewIntersectionBasedGenerator))
+	if config.GetEnvBool("ENABLE_HUB_MEETING_POINTS", false) {
+		utils.Must(c.Provide(provideHubCatalogue))
+		utils.Must(c.Provide(provideHubBasedGenerator))
+	} else if walkingTimeEnabled {
+		utils.Must(c.Provide(provideWalkingGenerator))
 	} else {
 		utils.Must(c.Provide(pickupdropoffservice.NewSnappedSourceDestinationGenerator))
 	}
 
 	utils.Must(c.Provide(pickupdropoffcache.NewPickupDropoffCache))
-	utils.Must(c.Provide(pickupdropoffservice.NewPickupDropoffSelector))
+	utils.Must(c.Provide(providePickupDropoffSelector))
+	utils.Must(c.Provide(pickupdropoffservice.NewWalkOnlyDetector))
+}
+
+// providePickupDropoffSelector creates the selector of the pickup and dropoff points.
+// With ENABLE_FLEXIBLE_SOURCE the drivers of the flexible source offers may start at the pickup of a rider,
+// or at a park-and-ride of the optional PARK_AND_RIDE_GEOJSON_PATH catalogue.
+func providePickupDropoffSelector(
+	generator pickupdropoffservice.PickupDropoffGenerator,
+	cache *pickupdropoffcache.PickupDropoffCache,
+	engine routing.Engine,
+) (pickupdropoffservice.PickupDropoffSelectorInterface, error) {
+	if !config.GetEnvBool("ENABLE_FLEXIBLE_SOURCE", false) {
+		return pickupdropoffservice.NewPickupDropoffSelector(generator, cache), nil
+	}
+
+	var parkAndRides *hubcatalogue.Catalogue
+	if path := config.GetEnv("PARK_AND_RIDE_GEOJSON_PATH", ""); path != "" {
+		catalogue, err := hubcatalogue.LoadFromGeoJSON(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load park-and-rides: %w", err)
+		}
+		log.Info().Msgf("Using %d park-and-rides as flexible sources", catalogue.Size())
+		parkAndRides = catalogue
+	}
+	sourceGenerator := pickupdropoffservice.NewFlexibleSourceGenerator(engine, parkAndRides)
+	return pickupdropoffservice.NewFlexibleSourcePickupDropoffSelector(generator, sourceGenerator, cache), nil
+}
+
+// HubCatalogueParams holds the dependencies needed to load the hubs catalogue
+type HubCatalogueParams struct {
+	dig.In
+
+	HubRepo repository.HubRepo `optional:"true"`
+}
+
+// provideHubCatalogue loads the meeting points from the source selected by HUB_SOURCE ("database" or "geojson")
+func provideHubCatalogue(p HubCatalogueParams) (*hubcatalogue.Catalogue, error) {
+	switch source := config.GetEnv("HUB_SOURCE", "database"); source {
+	case "geojson":
+		return hubcatalogue.LoadFromGeoJSON(config.GetEnv("HUB_GEOJSON_PATH", "hubs.geojson"))
+	case "database":
+		if p.HubRepo == nil {
+			return nil, fmt.Errorf("HUB_SOURCE is database but no hub repository is registered")
+		}
+		return hubcatalogue.LoadFromRepo(context.Background(), p.HubRepo)
+	default:
+		return nil, fmt.Errorf("invalid HUB_SOURCE value %q", source)
+	}
+}
+
+// provideHubBasedGenerator creates the hub based generator, falling back to the generator selected by WALKING_TIME_ENABLED,
+// which lets the riders far from the route use public transit with ENABLE_TRANSIT_FIRST_LAST_MILE
+func provideHubBasedGenerator(catalogue *hubcatalogue.Catalogue, params WalkingGeneratorParams) (pickupdropoffservice.PickupDropoffGenerator, error) {
+	log.Info().Msgf("Using %d meeting hubs for pickups and dropoffs", catalogue.Size())
+	fallback := pickupdropoffservice.NewSnappedSourceDestinationGenerator(params.Engine)
+	if getWalkingTimeEnabled() {
+		walkingGenerator, err := provideWalkingGenerator(params)
+		if err != nil {
+			return nil, err
+		}
+		fallback = walkingGenerator
+	}
+	maxRouteDistance := config.GetEnvFloat("HUB_MAX_ROUTE_DISTANCE_METERS", pickupdropoffservice.DefaultMaxHubRouteDistanceMeters)
+	return pickupdropoffservice.NewHubBasedGenerator(catalogue, params.Engine, fallback, maxRouteDistance), nil
+}
+
+// WalkingGeneratorParams holds the dependencies of the walking generator,
+// the transit engine is absent when the adapters are not registered
+type WalkingGeneratorParams struct {
+	dig.In
+
+	ProcessorFactory processor.ProcessorFactory
+	Engine           routing.Engine
+	TransitEngine    routing.TransitEngine `optional:"true"`
+}
+
+// provideWalkingGenerator creates the walking generator.
+// With ENABLE_TRANSIT_FIRST_LAST_MILE the riders far from the route reach or leave it with public transit,
+// through the stops of the TRANSIT_STOPS_GEOJSON_PATH catalogue.
+func provideWalkingGenerator(params WalkingGeneratorParams) (pickupdropoffservice.PickupDropoffGenerator, error) {
+	walkingGenerator := newWalkingGenerator(params.ProcessorFactory, params.Engine)
+	if !config.GetEnvBool("ENABLE_TRANSIT_FIRST_LAST_MILE", false) {
+		return walkingGenerator, nil
+	}
+	if params.TransitEngine == nil {
+		log.Warn().Msg("ENABLE_TRANSIT_FIRST_LAST_MILE is set but no transit engine is registered, riders will only walk")
+		return walkingGenerator, nil
+	}
+
+	stops, err := hubcatalogue.LoadFromGeoJSON(config.GetEnv("TRANSIT_STOPS_GEOJSON_PATH", "transit_stops.geojson"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transit stops: %w", err)
+	}
+	log.Info().Msgf("Using %d transit stops for the first and last mile", stops.Size())
+
+	transitConfig := pickupdropoffservice.TransitConfig{
+		StopSearchRadiusMeters:     config.GetEnvFloat("TRANSIT_STOP_SEARCH_RADIUS_METERS", pickupdropoffservice.DefaultTransitStopSearchRadiusMeters),
+		MaxStopRouteDistanceMeters: config.GetEnvFloat("TRANSIT_STOP_MAX_ROUTE_DISTANCE_METERS", pickupdropoffservice.DefaultMaxHubRouteDistanceMeters),
+		MaxTransitDuration:         time.Duration(config.GetEnvFloat("TRANSIT_MAX_MINUTES", pickupdropoffservice.DefaultMaxTransitDurationMinutes) * float64(time.Minute)),
+		MaxStopCandidates:          getMaxTransitStopCandidates(),
+	}
+	return pickupdropoffservice.NewTransitBasedGenerator(stops, params.Engine, params.TransitEngine, walkingGenerator, transitConfig), nil
+}
+
+func getMaxTransitStopCandidates() int {
+	maxCandidates := pickupdropoffservice.DefaultMaxTransitStopCandidates
+	if v, ok := os.LookupEnv("TRANSIT_MAX_STOP_CANDIDATES"); ok && v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			log.Warn().Msgf("Invalid TRANSIT_MAX_STOP_CANDIDATES value %q, using default: %d", v, maxCandidates)
+		} else {
+			maxCandidates = parsed
+		}
+	}
+	return maxCandidates
+}
+
+// newWalkingGenerator creates the generator letting riders walk to the driver's route.
+// With ENABLE_ISOCHRONE_PICKUPS the reachable points follow the rider's walking isochrone,
+// the straight-line walking radius of the intersection based generator is used when the route does not enter it.
+func newWalkingGenerator(factory processor.ProcessorFactory, engine routing.Engine) pickupdropoffservice.PickupDropoffGenerator {
+	intersectionBased := pickupdropoffservice.NewIntersectionBasedGenerator(factory, engine)
+	if config.GetEnvBool("ENABLE_ISOCHRONE_PICKUPS", false) {
+		return pickupdropoffservice.NewIsochroneBasedGenerator(engine, intersectionBased)
+	}
+	return intersectionBased
 }
 
 func getWalkingTimeEnabled() bool {
@@ -41,3 +179,19 @@ func getWalkingTimeEnabled() bool {
 
 	return walkingTimeEnabled
 }
+
+// riderMeetingReachMeters returns how far beyond their walking radius the riders can be from the route of the driver:
+// the distance of the hubs to the route with ENABLE_HUB_MEETING_POINTS, and the transit stop search radius plus the
+// distance of the stops to the route with ENABLE_TRANSIT_FIRST_LAST_MILE. The corridors are widened to this reach.
+func riderMeetingReachMeters() float64 {
+	reach := 0.0
+	if config.GetEnvBool("ENABLE_HUB_MEETING_POINTS", false) {
+		reach = config.GetEnvFloat("HUB_MAX_ROUTE_DISTANCE_METERS", pickupdropoffservice.DefaultMaxHubRouteDistanceMeters)
+	}
+	if config.GetEnvBool("ENABLE_TRANSIT_FIRST_LAST_MILE", false) {
+		transitReach := config.GetEnvFloat("TRANSIT_STOP_SEARCH_RADIUS_METERS", pickupdropoffservice.DefaultTransitStopSearchRadiusMeters) +
+			config.GetEnvFloat("TRANSIT_STOP_MAX_ROUTE_DISTANCE_METERS", pickupdropoffservice.DefaultMaxHubRouteDistanceMeters)
+		reach = math.Max(reach, transitReach)
+	}
+	return reach
+}
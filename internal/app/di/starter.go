@@ -1,14 +1,42 @@
 package di
 
 import (
+	"fmt"
 	"go.uber.org/dig"
+	"matching-engine/internal/adapter/messaging/natsjetstream"
+	"matching-engine/internal/app/config"
 	"matching-engine/internal/app/di/utils"
 	"matching-engine/internal/app/starter"
+	"matching-engine/internal/repository"
+	"matching-engine/internal/service/affinity"
+	"matching-engine/internal/service/matcher"
 )
 
 // This function is exported to be called from tests until a cleaner approach is implemented.
 
 // RegisterStarterService registers the starter service
 func RegisterStarterService(c *dig.Container) {
+	utils.Must(c.Provide(provideGraphWorkerServer))
+	utils.Must(c.Provide(provideAffinityHistoryLoader))
 	utils.Must(c.Provide(starter.NewStarterService))
 }
+
+// provideAffinityHistoryLoader provides the loader of the carpools of the previous runs when ENABLE_CARPOOL_AFFINITY is set
+func provideAffinityHistoryLoader(tracker *affinity.Tracker, rideMatches repository.RideMatchRepo) starter.AffinityHistoryLoader {
+	if !config.GetEnvBool("ENABLE_CARPOOL_AFFINITY", true) {
+		return nil
+	}
+	return affinity.NewHistoryLoader(tracker, rideMatches)
+}
+
+// provideGraphWorkerServer provides the server of the graph tasks when MATCHING_DISTRIBUTION_MODE is "worker"
+func provideGraphWorkerServer(m *matcher.Matcher) (starter.GraphWorkerServer, error) {
+	switch mode := matcher.GetDistributionMode(); mode {
+	case matcher.DistributionModeWorker:
+		return natsjetstream.NewGraphWorkerServer(matcher.NewGraphWorker(m)), nil
+	case matcher.DistributionModeLocal, matcher.DistributionModeCoordinator:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid MATCHING_DISTRIBUTION_MODE value %q", mode)
+	}
+}
@@ -14,6 +14,7 @@ import (
 func RegisterTimeMatrixServices(c *dig.Container) {
 	utils.Must(c.Provide(cache.NewTimeMatrixCacheWithOfferId))
 	utils.Must(c.Provide(cache.NewTimeMatrixCacheWithOfferIdAndRequestId))
+	utils.Must(c.Provide(cache.NewPinnedTimeMatrices))
 	utils.Must(c.Provide(timematrix.NewDefaultSelector))
 	utils.Must(c.Provide(timematrix.NewService))
 	utils.Must(c.Provide(timematrix.NewDefaultGenerator))
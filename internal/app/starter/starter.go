@@ -4,46 +4,69 @@ import (
 	"context"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"matching-engine/internal/model"
 	"matching-engine/internal/publisher"
 	"matching-engine/internal/reader"
 	"matching-engine/internal/service/matcher"
 )
 
+// GraphWorkerServer serves the graph tasks of a coordinator on the batch read by load until the context is done
+type GraphWorkerServer interface {
+	Serve(ctx context.Context, load matcher.BatchLoader) error
+}
+
+// AffinityHistoryLoader loads the carpools of the previous runs before the matching keeps them together
+type AffinityHistoryLoader interface {
+	Load(ctx context.Context) error
+}
+
 type StarterService struct {
-	reader    reader.MatchInputReader
-	matcher   *matcher.Matcher
-	publisher publisher.Publisher
+	reader            reader.MatchInputReader
+	matcher           *matcher.Matcher
+	publisher         publisher.Publisher
+	graphWorkerServer GraphWorkerServer
+	affinityHistory   AffinityHistoryLoader
 }
 
-// NewStarterService creates a new starter service
-func NewStarterService(reader reader.MatchInputReader, matcher *matcher.Matcher, publisher publisher.Publisher) *StarterService {
+// NewStarterService creates a new starter service, the graph worker server is nil unless the process is a graph worker
+// and the affinity history loader is nil when the carpool affinity is disabled
+func NewStarterService(reader reader.MatchInputReader, matcher *matcher.Matcher, publisher publisher.Publisher, graphWorkerServer GraphWorkerServer, affinityHistory AffinityHistoryLoader) *StarterService {
 	return &StarterService{
-		reader:    reader,
-		matcher:   matcher,
-		publisher: publisher,
+		reader:            reader,
+		matcher:           matcher,
+		publisher:         publisher,
+		graphWorkerServer: graphWorkerServer,
+		affinityHistory:   affinityHistory,
 	}
 }
 
 // Start initiates the matching process
 func (s *StarterService) Start(ctx context.Context) error {
+	// A graph worker builds the edges of the coordinator's matching on the same batch
+	if s.graphWorkerServer != nil {
+		return s.serveGraphTasks(ctx)
+	}
+
 	log.Info().Msg("Starting matching process...")
 
+	// The reader is closed once the matching is done, as the checkers query the same database
+	defer s.closeReader()
+
 	// Get offers and requests
 	requests, offers, exists, err := s.reader.GetOffersAndRequests(ctx)
 	if err != nil {
-		s.reader.Close()
 		return fmt.Errorf("failed to get offers and requests: %w", err)
 	}
 	if !exists {
-		s.reader.Close()
 		log.Info().Msg("No offers or requests found")
 		return nil
 	}
 
-	// Close reader
-	err = s.reader.Close()
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to close reader")
+	// A failure only loses the preference for the existing carpools, the matching can go on without it
+	if s.affinityHistory != nil {
+		if err := s.affinityHistory.Load(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to load the carpool history")
+		}
 	}
 
 	// Process matching
@@ -69,3 +92,29 @@ func (s *StarterService) Start(ctx context.Context) error {
 
 	return nil
 }
+
+// serveGraphTasks serves the graph tasks until the context is done. The reader stays open,
+// the batch being read again when the coordinator matches a new one.
+func (s *StarterService) serveGraphTasks(ctx context.Context) error {
+	defer s.closeReader()
+
+	load := func(ctx context.Context) ([]*model.Offer, []*model.Request, error) {
+		requests, offers, _, err := s.reader.GetOffersAndRequests(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get offers and requests: %w", err)
+		}
+		return offers, requests, nil
+	}
+
+	log.Info().Msg("Serving graph tasks...")
+	if err := s.graphWorkerServer.Serve(ctx, load); err != nil {
+		return fmt.Errorf("failed to serve graph tasks: %w", err)
+	}
+	return nil
+}
+
+func (s *StarterService) closeReader() {
+	if err := s.reader.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close reader")
+	}
+}
@@ -0,0 +1,164 @@
+package collections
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CacheStats is a snapshot of the counters of an LRUCache
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	Cost      int64
+	Capacity  int64
+}
+
+// HitRate returns the share of lookups that found their key, zero before the first lookup
+func (s CacheStats) HitRate() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(lookups)
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int64
+}
+
+// LRUCache is a thread-safe cache bounded by the total cost of its entries.
+// The least recently used entries are evicted once the capacity is exceeded, a capacity <= 0 means unbounded.
+type LRUCache[K comparable, V any] struct {
+	mu        sync.Mutex
+	items     map[K]*list.Element
+	order     *list.List
+	capacity  int64
+	cost      int64
+	costOf    func(K, V) int64
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// NewLRUCache creates a cache holding entries up to capacity, each entry costs costOf or 1 when costOf is nil
+func NewLRUCache[K comparable, V any](capacity int64, costOf func(K, V) int64) *LRUCache[K, V] {
+	if costOf == nil {
+		costOf = func(K, V) int64 { return 1 }
+	}
+	return &LRUCache[K, V]{
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+		capacity: capacity,
+		costOf:   costOf,
+	}
+}
+
+// Get retrieves a value by key and marks it as the most recently used
+func (c *LRUCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// Set stores a value, an entry costing more than the whole capacity is not stored
+func (c *LRUCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cost := c.costOf(key, value)
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+	if c.capacity > 0 && cost > c.capacity {
+		c.evictions++
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, cost: cost})
+	c.cost += cost
+
+	for c.capacity > 0 && c.cost > c.capacity {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+}
+
+// Delete removes a value by key
+func (c *LRUCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// DeleteFunc removes every entry matching the predicate and returns how many were removed
+func (c *LRUCache[K, V]) DeleteFunc(match func(K, V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*lruEntry[K, V])
+		if match(entry.key, entry.value) {
+			c.removeElement(element)
+			removed++
+		}
+		element = next
+	}
+	return removed
+}
+
+// Len returns the number of entries
+func (c *LRUCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Clear removes all entries, the counters are kept
+func (c *LRUCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+	c.cost = 0
+}
+
+// Stats returns the counters of the cache
+func (c *LRUCache[K, V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   len(c.items),
+		Cost:      c.cost,
+		Capacity:  c.capacity,
+	}
+}
+
+func (c *LRUCache[K, V]) removeElement(element *list.Element) {
+	entry := element.Value.(*lruEntry[K, V])
+	c.order.Remove(element)
+	delete(c.items, entry.key)
+	c.cost -= entry.cost
+}
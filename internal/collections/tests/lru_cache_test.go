@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"matching-engine/internal/collections"
+	"testing"
+)
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := collections.NewLRUCache[string, int](2, nil)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Set("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if v, ok := cache.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a to be kept, got %d, %v", v, ok)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestLRUCache_BoundsTheTotalCost(t *testing.T) {
+	cache := collections.NewLRUCache[string, []int](10, func(_ string, v []int) int64 { return int64(len(v)) })
+	cache.Set("small", make([]int, 3))
+	cache.Set("medium", make([]int, 6))
+	cache.Set("large", make([]int, 5))
+
+	stats := cache.Stats()
+	if stats.Cost > 10 {
+		t.Errorf("expected the cost to stay within the capacity, got %d", stats.Cost)
+	}
+	if _, ok := cache.Get("small"); ok {
+		t.Error("expected the oldest entry to be evicted")
+	}
+
+	// An entry larger than the capacity is never stored
+	cache.Set("huge", make([]int, 11))
+	if _, ok := cache.Get("huge"); ok {
+		t.Error("expected the oversized entry to be rejected")
+	}
+}
+
+func TestLRUCache_StatsAndDeleteFunc(t *testing.T) {
+	cache := collections.NewLRUCache[string, int](0, nil)
+	for i, key := range []string{"offer1:a", "offer1:b", "offer2:a"} {
+		cache.Set(key, i)
+	}
+	cache.Get("offer1:a")
+	cache.Get("missing")
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.HitRate() != 0.5 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	removed := cache.DeleteFunc(func(key string, _ int) bool { return key[:6] == "offer1" })
+	if removed != 2 || cache.Len() != 1 {
+		t.Errorf("expected 2 removed and 1 left, got %d and %d", removed, cache.Len())
+	}
+}
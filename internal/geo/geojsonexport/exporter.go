@@ -0,0 +1,221 @@
+package geojsonexport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/paulmach/go.geojson"
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+)
+
+// Kinds of the exported features, set in the "kind" property to filter the layers in QGIS or kepler.gl
+const (
+	KindOriginalPath  = "original_path"
+	KindNewPath       = "new_path"
+	KindCandidateEdge = "candidate_edge"
+	KindPrunedRoute   = "pruned_route"
+	KindPickup        = "pickup"
+	KindDropoff       = "dropoff"
+	KindWalkingLeg    = "walking_leg"
+	KindWalk          = "walk"
+)
+
+// kindColors are the simplestyle colors of the features, so geojson.io renders the layers apart
+var kindColors = map[string]string{
+	KindOriginalPath:  "#7f7f7f",
+	KindNewPath:       "#1f77b4",
+	KindCandidateEdge: "#ff7f0e",
+	KindPrunedRoute:   "#9467bd",
+	KindPickup:        "#2ca02c",
+	KindDropoff:       "#d62728",
+	KindWalkingLeg:    "#8c564b",
+	KindWalk:          "#8c564b",
+}
+
+// Exporter exports a matching run to a GeoJSON FeatureCollection: the original and new paths of the offers,
+// the pickup and dropoff points with their walking legs, the candidate edges of every round and the route
+// segments kept by the pruner. A run starts with RecordOffers and is written to its own file by RecordResults.
+type Exporter struct {
+	dir      string
+	mu       sync.Mutex
+	features *geojson.FeatureCollection
+	runStart time.Time
+}
+
+// NewExporter creates an exporter writing the runs to the directory
+func NewExporter(dir string) *Exporter {
+	return &Exporter{
+		dir:      dir,
+		features: geojson.NewFeatureCollection(),
+		runStart: time.Now(),
+	}
+}
+
+// NewExporterFromEnv creates an exporter writing the runs to GEOJSON_EXPORT_DIR, it returns nil when the variable is not set
+func NewExporterFromEnv() *Exporter {
+	dir := os.Getenv("GEOJSON_EXPORT_DIR")
+	if dir == "" {
+		return nil
+	}
+	return NewExporter(dir)
+}
+
+// RecordOffers starts a new run with the paths of the offers before matching
+func (e *Exporter) RecordOffers(offers []*model.Offer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.features = geojson.NewFeatureCollection()
+	e.runStart = time.Now()
+	for _, offer := range offers {
+		e.addPath(offer.Path(), KindOriginalPath, map[string]interface{}{
+			"offer_id": offer.ID(),
+			"user_id":  offer.UserID(),
+		})
+	}
+}
+
+// RecordCandidateGraph adds the path proposed by every edge of the matching graph built in the round
+func (e *Exporter) RecordCandidateGraph(round int, graph *model.MaximumMatchingGraph) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	graph.Edges().ForEach(func(key model.OfferRequestKey, edge *model.Edge) error {
+		e.addPath(edge.NewPath(), KindCandidateEdge, map[string]interface{}{
+			"offer_id":   key.OfferID(),
+			"request_id": key.RequestID(),
+			"round":      round,
+		})
+		return nil
+	})
+}
+
+// RecordPrunedRoute adds the route segments kept around the origin, it implements pruning.PruneObserver
+func (e *Exporter) RecordPrunedRoute(origin *model.Coordinate, threshold time.Duration, pruned model.LineString) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.addLineString(pruned, KindPrunedRoute, map[string]interface{}{
+		"origin":            []float64{origin.Lng(), origin.Lat()},
+		"threshold_minutes": threshold.Minutes(),
+	})
+}
+
+// RecordResults adds the new paths of the results and writes the run to a file of the directory
+func (e *Exporter) RecordResults(results []*model.MatchingResult) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, result := range results {
+		e.addResult(result)
+	}
+
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return fmt.Errorf("create export directory: %w", err)
+	}
+	data, err := e.features.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal GeoJSON: %w", err)
+	}
+	filename := filepath.Join(e.dir, fmt.Sprintf("run-%s.geojson", e.runStart.UTC().Format("20060102T150405.000Z")))
+	if err = os.WriteFile(filename, data, 0o644); err != nil {
+		return fmt.Errorf("write GeoJSON export: %w", err)
+	}
+	log.Info().Str("file", filename).Int("features", len(e.features.Features)).Msg("Exported matching run to GeoJSON")
+	return nil
+}
+
+// addResult adds the new path of the result, its pickup and dropoff points, and the walking legs of the riders
+func (e *Exporter) addResult(result *model.MatchingResult) {
+	if result.IsWalk() {
+		for _, request := range result.AssignedMatchedRequests() {
+			e.addLineString(model.LineString{*request.Source(), *request.Destination()}, KindWalk, map[string]interface{}{
+				"request_id": request.ID(),
+			})
+		}
+		return
+	}
+
+	e.addPath(result.NewPath(), KindNewPath, map[string]interface{}{
+		"offer_id": result.OfferID(),
+		"user_id":  result.UserID(),
+	})
+	for i := range result.NewPath() {
+		point := &result.NewPath()[i]
+		if point.PointType() != enums.Pickup && point.PointType() != enums.Dropoff {
+			continue
+		}
+		kind := KindPickup
+		if point.PointType() == enums.Dropoff {
+			kind = KindDropoff
+		}
+		properties := map[string]interface{}{
+			"offer_id":         result.OfferID(),
+			"request_id":       point.GetOwnerID(),
+			"expected_arrival": point.ExpectedArrivalTime().Format(time.RFC3339),
+			"walking_minutes":  point.WalkingDuration().Minutes(),
+			"transit_minutes":  point.TransitDuration().Minutes(),
+		}
+		e.addPoint(point.Coordinate(), kind, properties)
+
+		if point.Owner() == nil {
+			continue
+		}
+		request, ok := point.Owner().AsRequest()
+		if !ok || request == nil {
+			continue
+		}
+		riderPoint := request.Source()
+		if kind == KindDropoff {
+			riderPoint = request.Destination()
+		}
+		if !riderPoint.Equal(point.Coordinate()) {
+			e.addLineString(model.LineString{*riderPoint, *point.Coordinate()}, KindWalkingLeg, map[string]interface{}{
+				"request_id":      point.GetOwnerID(),
+				"walking_minutes": point.WalkingDuration().Minutes(),
+			})
+		}
+	}
+}
+
+func (e *Exporter) addPath(path []model.PathPoint, kind string, properties map[string]interface{}) {
+	line := make(model.LineString, len(path))
+	for i := range path {
+		line[i] = *path[i].Coordinate()
+	}
+	e.addLineString(line, kind, properties)
+}
+
+// addLineString adds the line, the lines with less than two points are skipped
+func (e *Exporter) addLineString(line model.LineString, kind string, properties map[string]interface{}) {
+	if len(line) < 2 {
+		return
+	}
+	coords := make([][]float64, len(line))
+	for i, c := range line {
+		coords[i] = []float64{c.Lng(), c.Lat()} // GeoJSON wants [lon, lat]
+	}
+	feature := geojson.NewLineStringFeature(coords)
+	feature.SetProperty("stroke", kindColors[kind])
+	e.addFeature(feature, kind, properties)
+}
+
+func (e *Exporter) addPoint(c *model.Coordinate, kind string, properties map[string]interface{}) {
+	feature := geojson.NewPointFeature([]float64{c.Lng(), c.Lat()})
+	feature.SetProperty("marker-color", kindColors[kind])
+	e.addFeature(feature, kind, properties)
+}
+
+func (e *Exporter) addFeature(feature *geojson.Feature, kind string, properties map[string]interface{}) {
+	feature.SetProperty("kind", kind)
+	for key, value := range properties {
+		feature.SetProperty(key, value)
+	}
+	e.features.AddFeature(feature)
+}
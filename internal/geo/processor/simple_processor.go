@@ -14,7 +14,7 @@ import (
 var _ GeospatialProcessor = (*processorImpl)(nil)
 
 type processorImpl struct {
-	route *model.Route
+	polyline *model.Polyline
 	pruning.RoutePruner
 	downsampling.RouteDownSampler
 	routing.Engine
@@ -28,6 +28,18 @@ func NewGeospatialProcessor(
 	if route == nil {
 		return nil, errors.New("route cannot be nil")
 	}
+	return NewGeospatialProcessorFromPolyline(route.Polyline(), engine)
+}
+
+// NewGeospatialProcessorFromPolyline creates a processor for the route encoded in the polyline
+func NewGeospatialProcessorFromPolyline(
+	polyline *model.Polyline,
+	engine routing.Engine,
+) (GeospatialProcessor, error) {
+
+	if polyline == nil {
+		return nil, errors.New("polyline cannot be nil")
+	}
 
 	if engine == nil {
 		return nil, errors.New("engine cannot be nil")
@@ -36,7 +48,7 @@ func NewGeospatialProcessor(
 	config := Load()
 	log.Debug().Msg(config.String())
 
-	routeCoords, err := route.Polyline().Coordinates()
+	routeCoords, err := polyline.Coordinates()
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +61,7 @@ func NewGeospatialProcessor(
 	downSampler := SelectDownsampler(config.EnableDownsampling, config.DownsamplerType)
 
 	return &processorImpl{
-		route:            route,
+		polyline:         polyline,
 		RoutePruner:      pruner,
 		RouteDownSampler: downSampler,
 		Engine:           engine,
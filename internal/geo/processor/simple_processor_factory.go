@@ -12,6 +12,7 @@ import (
 type Factory struct {
 	prunerFactory pruning.RoutePrunerFactory
 	engine        routing.Engine
+	pruneObserver pruning.PruneObserver
 }
 
 // NewProcessorFactory creates a new ProcessorFactory instance.
@@ -23,8 +24,38 @@ func NewProcessorFactory(
 	}
 }
 
+// NewProcessorFactoryWithPruneObserver creates a ProcessorFactory whose processors pass the pruned routes to the observer.
+func NewProcessorFactoryWithPruneObserver(
+	engine routing.Engine,
+	observer pruning.PruneObserver,
+) ProcessorFactory {
+	return &Factory{
+		engine:        engine,
+		pruneObserver: observer,
+	}
+}
+
 // CreateProcessor creates a GeospatialProcessor for the given offer.
 func (f *Factory) CreateProcessor(offer *model.Offer) (GeospatialProcessor, error) {
+	polyline, err := f.getRoutePolyline(offer)
+	if err != nil {
+		return nil, err
+	}
+	geospatialProcessor, err := NewGeospatialProcessorFromPolyline(polyline, f.engine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geospatial processor: %w", err)
+	}
+	if impl, ok := geospatialProcessor.(*processorImpl); ok && f.pruneObserver != nil {
+		impl.RoutePruner = pruning.NewObservedPruner(impl.RoutePruner, f.pruneObserver)
+	}
+	return geospatialProcessor, nil
+}
+
+// getRoutePolyline returns the route stored on the offer while it follows the path, or plans it.
+func (f *Factory) getRoutePolyline(offer *model.Offer) (*model.Polyline, error) {
+	if polyline := offer.RoutePolyline(); polyline != nil {
+		return polyline, nil
+	}
 	coords := make([]model.Coordinate, len(offer.PathPoints()))
 	for i, point := range offer.PathPoints() {
 		coords[i] = *point.Coordinate()
@@ -37,9 +68,5 @@ func (f *Factory) CreateProcessor(offer *model.Offer) (GeospatialProcessor, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to plan route: %w", err)
 	}
-	geospatialProcessor, err := NewGeospatialProcessor(route, f.engine)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create geospatial processor: %w", err)
-	}
-	return geospatialProcessor, nil
+	return route.Polyline(), nil
 }
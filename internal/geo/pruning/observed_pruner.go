@@ -0,0 +1,29 @@
+package pruning
+
+import (
+	"matching-engine/internal/model"
+	"time"
+)
+
+// PruneObserver is notified of the route segments kept by a pruner, to inspect them while debugging
+type PruneObserver interface {
+	RecordPrunedRoute(origin *model.Coordinate, threshold time.Duration, pruned model.LineString)
+}
+
+// ObservedPruner passes the segments kept by the wrapped pruner to an observer
+type ObservedPruner struct {
+	next     RoutePruner
+	observer PruneObserver
+}
+
+func NewObservedPruner(next RoutePruner, observer PruneObserver) RoutePruner {
+	return &ObservedPruner{next: next, observer: observer}
+}
+
+func (p *ObservedPruner) Prune(origin *model.Coordinate, threshold time.Duration) (model.LineString, error) {
+	pruned, err := p.next.Prune(origin, threshold)
+	if err == nil {
+		p.observer.RecordPrunedRoute(origin, threshold, pruned)
+	}
+	return pruned, err
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/geo/geojsonexport"
+	"matching-engine/internal/model"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paulmach/go.geojson"
+)
+
+func TestExporter_ExportsRun(t *testing.T) {
+	departure := time.Now()
+	source := *must(model.NewCoordinate(42.50, 1.50))
+	destination := *must(model.NewCoordinate(42.55, 1.55))
+	riderSource := *must(model.NewCoordinate(42.51, 1.52))
+	riderDestination := *must(model.NewCoordinate(42.54, 1.53))
+
+	path := []model.PathPoint{
+		*model.NewPathPoint(source, enums.Source, departure, nil, 0),
+		*model.NewPathPoint(destination, enums.Destination, departure.Add(time.Hour), nil, 0),
+	}
+	offer := model.NewOffer("offer1", "driver1", source, destination, departure, 10*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, path, nil)
+	request := model.NewRequest("request1", "rider1", riderSource, riderDestination, departure,
+		departure.Add(2*time.Hour), 10*time.Minute, 1, model.Preference{})
+
+	// The pickup is a short walk away from the rider, the dropoff is at the destination of the rider
+	pickup := model.NewPathPoint(*must(model.NewCoordinate(42.512, 1.52)), enums.Pickup, departure.Add(5*time.Minute), request, 3*time.Minute)
+	dropoff := model.NewPathPoint(riderDestination, enums.Dropoff, departure.Add(20*time.Minute), request, 0)
+	newPath := []model.PathPoint{path[0], *pickup, *dropoff, path[1]}
+
+	graph := model.NewMaximumMatchingGraph()
+	offerNode := model.NewOfferNode(offer)
+	requestNode := model.NewRequestNode(request)
+	graph.AddEdge(offerNode, requestNode, model.NewEdge(requestNode, newPath))
+
+	walkingRequest := model.NewRequest("request2", "rider2", riderSource, *must(model.NewCoordinate(42.511, 1.521)),
+		departure, departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+	results := []*model.MatchingResult{
+		model.NewMatchingResult("driver1", "offer1", []*model.Request{request}, newPath, 1),
+		model.NewWalkMatchingResult(walkingRequest, 2*time.Minute),
+	}
+
+	dir := t.TempDir()
+	exporter := geojsonexport.NewExporter(dir)
+	exporter.RecordOffers([]*model.Offer{offer})
+	exporter.RecordCandidateGraph(1, graph)
+	exporter.RecordPrunedRoute(&riderSource, 10*time.Minute, model.LineString{source, *pickup.Coordinate()})
+	if err := exporter.RecordResults(results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "run-*.geojson"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one exported run, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	collection, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil {
+		t.Fatalf("expected a FeatureCollection: %v", err)
+	}
+
+	kinds := make(map[string]int)
+	for _, feature := range collection.Features {
+		kinds[feature.Properties["kind"].(string)]++
+	}
+	expected := map[string]int{
+		geojsonexport.KindOriginalPath:  1,
+		geojsonexport.KindCandidateEdge: 1,
+		geojsonexport.KindPrunedRoute:   1,
+		geojsonexport.KindNewPath:       1,
+		geojsonexport.KindPickup:        1,
+		geojsonexport.KindDropoff:       1,
+		geojsonexport.KindWalkingLeg:    1, // the rider walks to the pickup only
+		geojsonexport.KindWalk:          1,
+	}
+	for kind, count := range expected {
+		if kinds[kind] != count {
+			t.Errorf("expected %d %s features, got %d", count, kind, kinds[kind])
+		}
+	}
+
+}
@@ -0,0 +1,68 @@
+package model
+
+// Capacity represents the space of a vehicle, or the space needed by a request, along every capacity dimension
+type Capacity struct {
+	seats       int
+	luggage     int
+	wheelchairs int
+	childSeats  int
+}
+
+// NewCapacity creates a new Capacity. No need to validate parameters as they will be read from database
+func NewCapacity(seats, luggage, wheelchairs, childSeats int) *Capacity {
+	return &Capacity{
+		seats:       seats,
+		luggage:     luggage,
+		wheelchairs: wheelchairs,
+		childSeats:  childSeats,
+	}
+}
+
+// NewSeatsCapacity creates a Capacity that only uses seats
+func NewSeatsCapacity(seats int) *Capacity {
+	return NewCapacity(seats, 0, 0, 0)
+}
+
+// Getters
+func (c Capacity) Seats() int       { return c.seats }
+func (c Capacity) Luggage() int     { return c.luggage }
+func (c Capacity) Wheelchairs() int { return c.wheelchairs }
+func (c Capacity) ChildSeats() int  { return c.childSeats }
+
+// Add returns the sum of both capacities on every dimension
+func (c Capacity) Add(other Capacity) Capacity {
+	return Capacity{
+		seats:       c.seats + other.seats,
+		luggage:     c.luggage + other.luggage,
+		wheelchairs: c.wheelchairs + other.wheelchairs,
+		childSeats:  c.childSeats + other.childSeats,
+	}
+}
+
+// Sub returns the difference of both capacities on every dimension
+func (c Capacity) Sub(other Capacity) Capacity {
+	return Capacity{
+		seats:       c.seats - other.seats,
+		luggage:     c.luggage - other.luggage,
+		wheelchairs: c.wheelchairs - other.wheelchairs,
+		childSeats:  c.childSeats - other.childSeats,
+	}
+}
+
+// Negate returns the capacity with every dimension negated, used for dropoff demands
+func (c Capacity) Negate() Capacity {
+	return Capacity{}.Sub(c)
+}
+
+// FitsIn checks that no dimension exceeds the same dimension of the given capacity
+func (c Capacity) FitsIn(limit Capacity) bool {
+	return c.seats <= limit.seats &&
+		c.luggage <= limit.luggage &&
+		c.wheelchairs <= limit.wheelchairs &&
+		c.childSeats <= limit.childSeats
+}
+
+// Dimensions returns the capacity as a slice ordered as seats, luggage, wheelchairs and child seats
+func (c Capacity) Dimensions() []int {
+	return []int{c.seats, c.luggage, c.wheelchairs, c.childSeats}
+}
@@ -0,0 +1,31 @@
+package model
+
+import "fmt"
+
+// Hub is a fixed meeting point where riders can be picked up or dropped off,
+// such as a metro station, a parking lot or a campus gate
+type Hub struct {
+	id         string
+	name       string
+	hubType    string
+	coordinate Coordinate
+}
+
+// NewHub creates a new hub
+func NewHub(id, name, hubType string, coordinate Coordinate) *Hub {
+	return &Hub{
+		id:         id,
+		name:       name,
+		hubType:    hubType,
+		coordinate: coordinate,
+	}
+}
+
+func (h *Hub) ID() string              { return h.id }
+func (h *Hub) Name() string            { return h.name }
+func (h *Hub) Type() string            { return h.hubType }
+func (h *Hub) Coordinate() *Coordinate { return &h.coordinate }
+
+func (h *Hub) String() string {
+	return fmt.Sprintf("Hub{ID: %s, Name: %s, Type: %s, Coordinate: %s}", h.id, h.name, h.hubType, h.coordinate.String())
+}
@@ -2,16 +2,30 @@ package model
 
 import (
 	"fmt"
+	"matching-engine/internal/enums"
 	"matching-engine/internal/errors"
+	"time"
+)
+
+const (
+	// ResultModeRide marks a result assigning requests to a seat of an offer
+	ResultModeRide = "ride"
+	// ResultModeWalk marks a result telling the rider of a request to walk the whole way
+	ResultModeWalk = "walk"
 )
 
 // MatchingResult represents the result of a matching operation
 type MatchingResult struct {
 	userID                  string
 	offerID                 string
+	offerSeriesID           string
+	occurrenceDate          string
 	assignedMatchedRequests []*Request
 	newPath                 []PathPoint
 	currentNumberOfRequests int
+	trafficProfile          string
+	mode                    string
+	routePolyline           *Polyline
 }
 
 // NewMatchingResult creates a new MatchingResult
@@ -28,6 +42,7 @@ func NewMatchingResult(userID, offerID string, assignedMatchedRequests []*Reques
 		assignedMatchedRequests: assignedMatchedRequests,
 		newPath:                 newPath,
 		currentNumberOfRequests: currentNumberOfRequests,
+		mode:                    ResultModeRide,
 	}
 }
 
@@ -47,12 +62,31 @@ func NewMatchingResultFromOfferNode(node *OfferNode) (*MatchingResult, error) {
 	return &MatchingResult{
 		userID:                  node.Offer().UserID(),
 		offerID:                 node.Offer().ID(),
+		offerSeriesID:           node.Offer().SeriesID(),
+		occurrenceDate:          node.Offer().OccurrenceDate(),
 		assignedMatchedRequests: node.NewlyAssignedMatchedRequests(),
 		newPath:                 node.Offer().Path(),
 		currentNumberOfRequests: currentNumberOfRequests,
+		mode:                    ResultModeRide,
 	}, nil
 }
 
+// NewWalkMatchingResult creates a result telling the rider of the request to walk from the source to the destination.
+// The result has no offer, and its path holds the source and the destination with the walking ETA.
+func NewWalkMatchingResult(request *Request, walkingDuration time.Duration) *MatchingResult {
+	departure := request.EarliestDepartureTime()
+	path := []PathPoint{
+		*NewPathPoint(*request.Source(), enums.Pickup, departure, request, 0),
+		*NewPathPoint(*request.Destination(), enums.Dropoff, departure.Add(walkingDuration), request, walkingDuration),
+	}
+	return &MatchingResult{
+		userID:                  request.UserID(),
+		assignedMatchedRequests: []*Request{request},
+		newPath:                 path,
+		mode:                    ResultModeWalk,
+	}
+}
+
 // UserID returns the user ID
 func (mr *MatchingResult) UserID() string {
 	return mr.userID
@@ -68,6 +102,19 @@ func (mr *MatchingResult) OfferID() string {
 	return mr.offerID
 }
 
+// OfferSeriesID returns the ID of the recurring offer the offer of the result is an occurrence of, or the offer ID
+func (mr *MatchingResult) OfferSeriesID() string {
+	if mr.offerSeriesID == "" {
+		return mr.offerID
+	}
+	return mr.offerSeriesID
+}
+
+// OccurrenceDate returns the day of the occurrence of the recurring offer, empty for a one-off offer
+func (mr *MatchingResult) OccurrenceDate() string {
+	return mr.occurrenceDate
+}
+
 // SetOfferID sets the offer ID
 func (mr *MatchingResult) SetOfferID(offerID string) {
 	mr.offerID = offerID
@@ -102,3 +149,41 @@ func (mr *MatchingResult) CurrentNumberOfRequests() int {
 func (mr *MatchingResult) SetCurrentNumberOfRequests(count int) {
 	mr.currentNumberOfRequests = count
 }
+
+// TrafficProfile returns the ID of the traffic profile behind the path ETAs, empty when none was applied
+func (mr *MatchingResult) TrafficProfile() string {
+	return mr.trafficProfile
+}
+
+// SetTrafficProfile sets the ID of the traffic profile behind the path ETAs
+func (mr *MatchingResult) SetTrafficProfile(profileID string) {
+	mr.trafficProfile = profileID
+}
+
+// Mode returns whether the result assigns a ride or tells the rider to walk
+func (mr *MatchingResult) Mode() string {
+	return mr.mode
+}
+
+// IsWalk reports whether the result tells the rider to walk the whole way
+func (mr *MatchingResult) IsWalk() bool {
+	return mr.mode == ResultModeWalk
+}
+
+// RoutePolyline returns the driving route through the new path, nil when it was not planned
+func (mr *MatchingResult) RoutePolyline() *Polyline {
+	return mr.routePolyline
+}
+
+// SetRoutePolyline sets the driving route through the new path
+func (mr *MatchingResult) SetRoutePolyline(polyline *Polyline) {
+	mr.routePolyline = polyline
+}
+
+// RoutePathSignature returns the signature of the new path, stored with the route polyline to reuse it in later runs
+func (mr *MatchingResult) RoutePathSignature() string {
+	if mr.routePolyline == nil {
+		return ""
+	}
+	return PathSignature(mr.newPath)
+}
@@ -15,11 +15,24 @@ type Offer struct {
 	detourDurMins           time.Duration
 	departureTime           time.Time
 	preference              Preference
-	capacity                int
+	vehicleCapacity         Capacity
 	maxEstimatedArrivalTime time.Time
 	currentNumberOfRequests int
 	matchedRequests         []*Request
 	path                    []PathPoint
+	recurrence              *RecurrenceRule
+	seriesID                string
+	occurrenceDate          string        // Day of the occurrence of a recurring offer, empty for a one-off offer
+	matchedOccurrences      map[string]*occurrenceMatch
+	flexibleSourceDuration  time.Duration // How far from the source, in driving time, the driver accepts to start from
+	routePolyline           *Polyline     // Driving route through the path, valid while the path signature is unchanged
+	routePathSignature      string        // Signature of the path the route polyline was planned for
+}
+
+// occurrenceMatch holds the riders already matched with one occurrence of a recurring offer
+type occurrenceMatch struct {
+	points   []PathPoint // Pickup and dropoff points of the riders, in path order
+	requests []*Request
 }
 
 // NewOffer creates a new offer. No need to validate parameters as they will be read from database
@@ -49,7 +62,7 @@ func NewOffer(
 		destination:             destination,
 		detourDurMins:           detourDurMins,
 		departureTime:           departureTime,
-		capacity:                capacity,
+		vehicleCapacity:         *NewSeatsCapacity(capacity),
 		maxEstimatedArrivalTime: maxEstimatedArrivalTime,
 		matchedRequests:         matchedRequests,
 		preference:              preference,
@@ -65,7 +78,8 @@ func (o *Offer) Source() *Coordinate                  { return &o.source }
 func (o *Offer) Destination() *Coordinate             { return &o.destination }
 func (o *Offer) DepartureTime() time.Time             { return o.departureTime }
 func (o *Offer) DetourDurationMinutes() time.Duration { return o.detourDurMins }
-func (o *Offer) Capacity() int                        { return o.capacity }
+func (o *Offer) Capacity() int                        { return o.vehicleCapacity.Seats() }
+func (o *Offer) VehicleCapacity() Capacity            { return o.vehicleCapacity }
 func (o *Offer) MaxEstimatedArrivalTime() time.Time   { return o.maxEstimatedArrivalTime }
 func (o *Offer) Preferences() *Preference             { return &o.preference }
 func (o *Offer) CurrentNumberOfRequests() int         { return o.currentNumberOfRequests }
@@ -79,6 +93,127 @@ func (o *Offer) SetMatchedRequests(matchedRequests []*Request) {
 	o.matchedRequests = matchedRequests
 }
 
+// SetVehicleCapacity sets the capacity of the vehicle on every dimension, seats included
+func (o *Offer) SetVehicleCapacity(vehicleCapacity Capacity) {
+	o.vehicleCapacity = vehicleCapacity
+}
+
+// Recurrence returns the recurrence rule, nil for one-off offers
+func (o *Offer) Recurrence() *RecurrenceRule {
+	return o.recurrence
+}
+
+// SetRecurrence sets the recurrence rule
+func (o *Offer) SetRecurrence(recurrence *RecurrenceRule) {
+	o.recurrence = recurrence
+}
+
+// FlexibleSourceDuration returns the driving time from the source within which the driver accepts to start the trip,
+// zero when the trip starts at the source
+func (o *Offer) FlexibleSourceDuration() time.Duration {
+	return o.flexibleSourceDuration
+}
+
+// SetFlexibleSourceDuration sets the driving time from the source within which the driver accepts to start the trip
+func (o *Offer) SetFlexibleSourceDuration(duration time.Duration) {
+	o.flexibleSourceDuration = duration
+}
+
+// HasFlexibleSource reports whether the driver accepts to start the trip away from the source
+func (o *Offer) HasFlexibleSource() bool {
+	return o.flexibleSourceDuration > 0
+}
+
+// TripStartTime returns the time the driver leaves the first point of the path,
+// later than the departure time when the driver first drives from the source to a flexible start
+func (o *Offer) TripStartTime(path []PathPoint) time.Time {
+	if len(path) == 0 {
+		return o.departureTime
+	}
+	return o.departureTime.Add(path[0].AccessDrivingDuration())
+}
+
+// RoutePolyline returns the driving route through the current path, or nil if it was planned for a previous path
+func (o *Offer) RoutePolyline() *Polyline {
+	if o.routePolyline == nil || o.routePathSignature != PathSignature(o.path) {
+		return nil
+	}
+	return o.routePolyline
+}
+
+// RoutePathSignature returns the signature of the path the route polyline was planned for
+func (o *Offer) RoutePathSignature() string {
+	return o.routePathSignature
+}
+
+// SetRoutePolyline sets the driving route planned through the current path
+func (o *Offer) SetRoutePolyline(polyline *Polyline) {
+	o.RestoreRoutePolyline(polyline, PathSignature(o.path))
+}
+
+// RestoreRoutePolyline sets a driving route stored with the signature of the path it was planned for,
+// it is ignored by RoutePolyline once the path no longer matches the signature
+func (o *Offer) RestoreRoutePolyline(polyline *Polyline, pathSignature string) {
+	o.routePolyline = polyline
+	o.routePathSignature = pathSignature
+}
+
+// SeriesID returns the ID of the recurring offer this offer is an occurrence of, or its own ID
+func (o *Offer) SeriesID() string {
+	if o.seriesID == "" {
+		return o.id
+	}
+	return o.seriesID
+}
+
+// OccurrenceDate returns the day of the occurrence of a recurring offer, formatted as 2006-01-02,
+// or an empty string for a one-off offer
+func (o *Offer) OccurrenceDate() string {
+	return o.occurrenceDate
+}
+
+// AddMatchedOccurrence records the riders already matched with the occurrence of the recurring offer
+// on the day of the given date, along with their pickup and dropoff points in path order
+func (o *Offer) AddMatchedOccurrence(date time.Time, points []PathPoint, requests []*Request) {
+	if o.matchedOccurrences == nil {
+		o.matchedOccurrences = make(map[string]*occurrenceMatch)
+	}
+	o.matchedOccurrences[date.Format(dateLayout)] = &occurrenceMatch{points: points, requests: requests}
+}
+
+// Instance returns the occurrence of the recurring offer departing at the given time.
+// The source and destination of the offer are shifted by the same amount as the departure, and only the riders
+// matched with this very occurrence are added to its path.
+func (o *Offer) Instance(departureTime time.Time) *Offer {
+	shift := departureTime.Sub(o.departureTime)
+	instance := *o
+	instance.id = instanceID(o.SeriesID(), departureTime)
+	instance.seriesID = o.SeriesID()
+	instance.occurrenceDate = departureTime.Format(dateLayout)
+	instance.recurrence = nil
+	instance.matchedOccurrences = nil
+	instance.departureTime = departureTime
+	instance.maxEstimatedArrivalTime = o.maxEstimatedArrivalTime.Add(shift)
+	instance.matchedRequests = make([]*Request, 0)
+	instance.currentNumberOfRequests = 0
+	instance.path = make([]PathPoint, 0, len(o.path))
+	for _, point := range o.path {
+		if _, ok := point.Owner().(*Offer); !ok {
+			continue
+		}
+		instancePoint := NewPathPoint(point.coordinate, point.pointType, point.expectedArrivalTime.Add(shift), &instance, point.walkingDuration)
+		instancePoint.SetTransitDuration(point.transitDuration)
+		instance.path = append(instance.path, *instancePoint)
+	}
+	if match, ok := o.matchedOccurrences[instance.occurrenceDate]; ok && len(instance.path) > 0 {
+		destination := instance.path[len(instance.path)-1]
+		instance.path = append(append(instance.path[:len(instance.path)-1], match.points...), destination)
+		instance.matchedRequests = append(instance.matchedRequests, match.requests...)
+		instance.currentNumberOfRequests = len(match.requests)
+	}
+	return &instance
+}
+
 // Path returns the path
 func (o *Offer) Path() []PathPoint {
 	return o.path
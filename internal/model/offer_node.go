@@ -82,6 +82,16 @@ func (node *OfferNode) AddNewlyMatchedRequest(request *Request) {
 	node.newlyAssignedMatchedRequests = append(node.newlyAssignedMatchedRequests, request)
 }
 
+// OfferWithAllRequests returns a copy of the offer whose matched requests also contain the newly assigned ones,
+// so checks run during matching see every rider sharing the car
+func (node *OfferNode) OfferWithAllRequests() *Offer {
+	offer := *node.offer
+	offer.matchedRequests = make([]*Request, 0, len(node.offer.matchedRequests)+len(node.newlyAssignedMatchedRequests))
+	offer.matchedRequests = append(offer.matchedRequests, node.offer.matchedRequests...)
+	offer.matchedRequests = append(offer.matchedRequests, node.newlyAssignedMatchedRequests...)
+	return &offer
+}
+
 func (node *OfferNode) Validate() error {
 	if node == nil {
 		return fmt.Errorf(errors.ErrNilOfferNode)
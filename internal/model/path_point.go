@@ -14,12 +14,14 @@ var NextPointID int64 = 1
 
 // PathPoint represents a PathPoint in a driver's path
 type PathPoint struct {
-	id                  PathPointID
-	owner               Role
-	coordinate          Coordinate
-	pointType           enums.PointType
-	expectedArrivalTime time.Time // When the point doesn't yet belong to a path, this represents the earliest pickup or latest dropoff time possible for a request
-	walkingDuration     time.Duration
+	id                    PathPointID
+	owner                 Role
+	coordinate            Coordinate
+	pointType             enums.PointType
+	expectedArrivalTime   time.Time // When the point doesn't yet belong to a path, this represents the earliest pickup or latest dropoff time possible for a request
+	walkingDuration       time.Duration
+	transitDuration       time.Duration // Public transit part of the rider's first or last mile, zero when the rider only walks
+	accessDrivingDuration time.Duration // Driving time from the offer source to a flexible start, zero for the other points
 }
 
 func NewPathPoint(
@@ -93,6 +95,32 @@ func (p *PathPoint) SetWalkingDuration(duration time.Duration) {
 	p.walkingDuration = duration
 }
 
+// TransitDuration returns the public transit duration of the rider's first or last mile
+func (p *PathPoint) TransitDuration() time.Duration {
+	return p.transitDuration
+}
+
+// SetTransitDuration sets the public transit duration of the rider's first or last mile
+func (p *PathPoint) SetTransitDuration(duration time.Duration) {
+	p.transitDuration = duration
+}
+
+// AccessDrivingDuration returns the driving time from the offer source when the point is a flexible start
+func (p *PathPoint) AccessDrivingDuration() time.Duration {
+	return p.accessDrivingDuration
+}
+
+// SetAccessDrivingDuration sets the driving time from the offer source to a flexible start
+func (p *PathPoint) SetAccessDrivingDuration(duration time.Duration) {
+	p.accessDrivingDuration = duration
+}
+
+// AccessDuration returns the time the rider takes to reach a pickup point or to finish the trip from a dropoff point,
+// walking and riding public transit
+func (p *PathPoint) AccessDuration() time.Duration {
+	return p.walkingDuration + p.transitDuration
+}
+
 func (p *PathPoint) GetOwnerID() string {
 	if p.Owner() == nil {
 		return ""
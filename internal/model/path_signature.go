@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"strconv"
+)
+
+// pathSignaturePrecision is the scale the coordinates are rounded to before hashing, about 10 cm,
+// so the signature survives the rounding of the coordinates stored in the database
+const pathSignaturePrecision = 1e6
+
+// PathSignature returns a short signature of the coordinates of the path, in order.
+// Two paths through the same coordinates share the same driving route, so the signature tells
+// whether a stored route still follows a path.
+func PathSignature(path []PathPoint) string {
+	hash := fnv.New64a()
+	buf := make([]byte, 8)
+	for _, point := range path {
+		for _, value := range []float64{point.coordinate.Lat(), point.coordinate.Lng()} {
+			binary.LittleEndian.PutUint64(buf, uint64(int64(math.Round(value*pathSignaturePrecision))))
+			hash.Write(buf)
+		}
+	}
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
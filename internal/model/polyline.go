@@ -44,6 +44,46 @@ func NewPolyline(encoded string, opts ...Option) (*Polyline, error) {
 	return p, nil
 }
 
+// NewPolylineFromCoordinates encodes the coordinates into a polyline
+func NewPolylineFromCoordinates(coordinates LineString, opts ...Option) (*Polyline, error) {
+	if len(coordinates) == 0 {
+		return nil, errors.New("coordinates are empty")
+	}
+
+	p := &Polyline{precision: 6}
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	factor := math.Pow10(p.precision)
+	var sb strings.Builder
+	prevLat, prevLng := 0, 0
+	for _, c := range coordinates {
+		lat := int(math.Round(c.Lat() * factor))
+		lng := int(math.Round(c.Lng() * factor))
+		encodeDelta(&sb, lat-prevLat)
+		encodeDelta(&sb, lng-prevLng)
+		prevLat, prevLng = lat, lng
+	}
+	p.encoded = sb.String()
+	p.coordinates = coordinates
+	return p, nil
+}
+
+func encodeDelta(sb *strings.Builder, delta int) {
+	value := delta << 1
+	if delta < 0 {
+		value = ^value
+	}
+	for value >= 0x20 {
+		sb.WriteByte(byte((0x20 | (value & 0x1F)) + 63))
+		value >>= 5
+	}
+	sb.WriteByte(byte(value + 63))
+}
+
 func (p *Polyline) Encoded() string {
 	return p.encoded
 }
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+)
+
+// dateLayout is the layout used to identify the days of a recurrence
+const dateLayout = "2006-01-02"
+
+// RecurrenceRule describes on which days a recurring offer or request repeats
+type RecurrenceRule struct {
+	weekdays   [7]bool
+	startDate  time.Time
+	endDate    time.Time // zero value means the recurrence never ends
+	exceptions map[string]bool
+}
+
+// NewRecurrenceRule creates a new RecurrenceRule. No need to validate parameters as they will be read from database
+func NewRecurrenceRule(weekdays []time.Weekday, startDate, endDate time.Time, exceptions []time.Time) *RecurrenceRule {
+	rule := &RecurrenceRule{
+		startDate:  startDate,
+		endDate:    endDate,
+		exceptions: make(map[string]bool, len(exceptions)),
+	}
+	for _, weekday := range weekdays {
+		rule.weekdays[weekday] = true
+	}
+	for _, exception := range exceptions {
+		rule.exceptions[exception.Format(dateLayout)] = true
+	}
+	return rule
+}
+
+// Getters
+func (r *RecurrenceRule) StartDate() time.Time { return r.startDate }
+func (r *RecurrenceRule) EndDate() time.Time   { return r.endDate }
+
+// Weekdays returns the days of the week on which the rule repeats
+func (r *RecurrenceRule) Weekdays() []time.Weekday {
+	weekdays := make([]time.Weekday, 0, len(r.weekdays))
+	for weekday, repeats := range r.weekdays {
+		if repeats {
+			weekdays = append(weekdays, time.Weekday(weekday))
+		}
+	}
+	return weekdays
+}
+
+// OccursOn checks if the rule repeats on the day of the given date
+func (r *RecurrenceRule) OccursOn(date time.Time) bool {
+	day := date.Format(dateLayout)
+	if day < r.startDate.Format(dateLayout) {
+		return false
+	}
+	if !r.endDate.IsZero() && day > r.endDate.Format(dateLayout) {
+		return false
+	}
+	return r.weekdays[date.Weekday()] && !r.exceptions[day]
+}
+
+// Occurrences returns the instants, between from and to, at which a trip first scheduled at template repeats.
+// Every occurrence keeps the time of day of the template.
+func (r *RecurrenceRule) Occurrences(template, from, to time.Time) []time.Time {
+	occurrences := make([]time.Time, 0)
+	location := template.Location()
+	from, to = from.In(location), to.In(location)
+
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location); !day.After(to); day = day.AddDate(0, 0, 1) {
+		occurrence := OccurrenceOn(template, day)
+		if occurrence.Before(from) || occurrence.After(to) || !r.OccursOn(occurrence) {
+			continue
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences
+}
+
+// OccurrenceOn returns the instant at which a trip first scheduled at template repeats on the day of the given date,
+// keeping the time of day and the location of the template
+func OccurrenceOn(template, date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), template.Hour(), template.Minute(), template.Second(), template.Nanosecond(), template.Location())
+}
+
+// instanceID returns the ID of the occurrence of a recurring offer or request on the day of the given date
+func instanceID(seriesID string, date time.Time) string {
+	return seriesID + "@" + date.Format(dateLayout)
+}
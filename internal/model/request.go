@@ -12,8 +12,13 @@ type Request struct {
 	earliestDepartureTime     time.Time
 	latestArrivalTime         time.Time
 	maxWalkingDurationMinutes time.Duration
-	numberOfRiders            int
+	capacityNeeds             Capacity
 	preferences               Preference
+	recurrence                *RecurrenceRule
+	seriesID                  string
+	occurrenceDate            string          // Day of the occurrence of a recurring request, empty for a one-off request
+	matchedOccurrences        map[string]bool // Days of the occurrences of a recurring request already matched
+	linkedRequestID           string
 }
 
 // newRequest creates a new Request. No need to validate parameters as they will be read from the database.
@@ -37,7 +42,7 @@ func NewRequest(
 		earliestDepartureTime:     earliestDepartureTime,
 		latestArrivalTime:         latestArrivalTime,
 		maxWalkingDurationMinutes: maxWalkingDurationMinutes,
-		numberOfRiders:            numberOfRiders,
+		capacityNeeds:             *NewSeatsCapacity(numberOfRiders),
 		preferences:               preferences,
 	}
 }
@@ -56,9 +61,79 @@ func (r *Request) SetLatestArrivalTime(t time.Time) {
 	r.latestArrivalTime = t
 }
 func (r *Request) MaxWalkingDurationMinutes() time.Duration { return r.maxWalkingDurationMinutes }
-func (r *Request) NumberOfRiders() int                      { return r.numberOfRiders }
+func (r *Request) NumberOfRiders() int                      { return r.capacityNeeds.Seats() }
+func (r *Request) CapacityNeeds() Capacity                  { return r.capacityNeeds }
 func (r *Request) Preferences() *Preference                 { return &r.preferences }
 
+// SetCapacityNeeds sets the space needed by the request on every dimension, the seats being the number of riders
+func (r *Request) SetCapacityNeeds(capacityNeeds Capacity) {
+	r.capacityNeeds = capacityNeeds
+}
+
+// Recurrence returns the recurrence rule, nil for one-off requests
+func (r *Request) Recurrence() *RecurrenceRule {
+	return r.recurrence
+}
+
+// SetRecurrence sets the recurrence rule
+func (r *Request) SetRecurrence(recurrence *RecurrenceRule) {
+	r.recurrence = recurrence
+}
+
+// LinkedRequestID returns the ID of the other leg of a round trip, empty for one-way requests
+func (r *Request) LinkedRequestID() string {
+	return r.linkedRequestID
+}
+
+// SetLinkedRequestID links the request to the other leg of its round trip
+func (r *Request) SetLinkedRequestID(linkedRequestID string) {
+	r.linkedRequestID = linkedRequestID
+}
+
+// SeriesID returns the ID of the recurring request this request is an occurrence of, or its own ID
+func (r *Request) SeriesID() string {
+	if r.seriesID == "" {
+		return r.id
+	}
+	return r.seriesID
+}
+
+// OccurrenceDate returns the day of the occurrence of a recurring request, formatted as 2006-01-02,
+// or an empty string for a one-off request
+func (r *Request) OccurrenceDate() string {
+	return r.occurrenceDate
+}
+
+// AddMatchedOccurrence records that the occurrence of the recurring request on the day of the given date is matched
+func (r *Request) AddMatchedOccurrence(date time.Time) {
+	if r.matchedOccurrences == nil {
+		r.matchedOccurrences = make(map[string]bool)
+	}
+	r.matchedOccurrences[date.Format(dateLayout)] = true
+}
+
+// IsOccurrenceMatched checks if the occurrence of the recurring request on the day of the given date is already matched
+func (r *Request) IsOccurrenceMatched(date time.Time) bool {
+	return r.matchedOccurrences[date.Format(dateLayout)]
+}
+
+// Instance returns the occurrence of the recurring request whose earliest departure is the given time
+func (r *Request) Instance(earliestDepartureTime time.Time) *Request {
+	instance := *r
+	instance.id = instanceID(r.SeriesID(), earliestDepartureTime)
+	instance.seriesID = r.SeriesID()
+	instance.occurrenceDate = earliestDepartureTime.Format(dateLayout)
+	instance.recurrence = nil
+	instance.matchedOccurrences = nil
+	instance.earliestDepartureTime = earliestDepartureTime
+	instance.latestArrivalTime = r.latestArrivalTime.Add(earliestDepartureTime.Sub(r.earliestDepartureTime))
+	if r.linkedRequestID != "" {
+		// Both legs of a recurring round trip repeat on the same days
+		instance.linkedRequestID = instanceID(r.linkedRequestID, earliestDepartureTime)
+	}
+	return &instance
+}
+
 func (r *Request) AsOffer() (*Offer, bool) {
 	return nil, false
 }
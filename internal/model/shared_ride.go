@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// SharedRide is a driver and a rider who were matched together, as last recorded at sharedAt
+type SharedRide struct {
+	driverUserID string
+	riderUserID  string
+	sharedAt     time.Time
+}
+
+// NewSharedRide creates a new shared ride
+func NewSharedRide(driverUserID, riderUserID string, sharedAt time.Time) *SharedRide {
+	return &SharedRide{
+		driverUserID: driverUserID,
+		riderUserID:  riderUserID,
+		sharedAt:     sharedAt,
+	}
+}
+
+func (s *SharedRide) DriverUserID() string { return s.driverUserID }
+func (s *SharedRide) RiderUserID() string  { return s.riderUserID }
+func (s *SharedRide) SharedAt() time.Time  { return s.sharedAt }
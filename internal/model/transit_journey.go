@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// TransitJourney is a journey combining public transit rides with walks.
+// The waiting time at the stops is part of the transit duration.
+type TransitJourney struct {
+	walkingDuration time.Duration
+	transitDuration time.Duration
+}
+
+// NewTransitJourney creates a new transit journey
+func NewTransitJourney(walkingDuration, transitDuration time.Duration) *TransitJourney {
+	return &TransitJourney{
+		walkingDuration: walkingDuration,
+		transitDuration: transitDuration,
+	}
+}
+
+// WalkingDuration returns the time spent walking to, from and between the stops
+func (j *TransitJourney) WalkingDuration() time.Duration {
+	return j.walkingDuration
+}
+
+// TransitDuration returns the time spent riding and waiting for public transit
+func (j *TransitJourney) TransitDuration() time.Duration {
+	return j.transitDuration
+}
+
+// Duration returns the total duration of the journey
+func (j *TransitJourney) Duration() time.Duration {
+	return j.walkingDuration + j.transitDuration
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TransitParams describes a public transit journey between two points.
+// The journey departs at the given time, or arrives by it when arriveBy is set.
+type TransitParams struct {
+	origin, destination *Coordinate
+	time                time.Time
+	arriveBy            bool
+}
+
+// NewDepartAtTransitParams creates the params of a transit journey departing at the given time
+func NewDepartAtTransitParams(origin, destination *Coordinate, departureTime time.Time) (*TransitParams, error) {
+	return newTransitParams(origin, destination, departureTime, false)
+}
+
+// NewArriveByTransitParams creates the params of a transit journey arriving by the given time
+func NewArriveByTransitParams(origin, destination *Coordinate, arrivalTime time.Time) (*TransitParams, error) {
+	return newTransitParams(origin, destination, arrivalTime, true)
+}
+
+func newTransitParams(origin, destination *Coordinate, t time.Time, arriveBy bool) (*TransitParams, error) {
+	if origin == nil {
+		return nil, errors.New("origin coordinate is nil")
+	}
+	if destination == nil {
+		return nil, errors.New("destination coordinate is nil")
+	}
+	if t.IsZero() {
+		return nil, errors.New("transit journey time is not set")
+	}
+	return &TransitParams{
+		origin:      origin,
+		destination: destination,
+		time:        t,
+		arriveBy:    arriveBy,
+	}, nil
+}
+
+func (p *TransitParams) Origin() *Coordinate {
+	return p.origin
+}
+
+func (p *TransitParams) Destination() *Coordinate {
+	return p.destination
+}
+
+// Time returns the departure time, or the arrival time when ArriveBy is set
+func (p *TransitParams) Time() time.Time {
+	return p.time
+}
+
+func (p *TransitParams) ArriveBy() bool {
+	return p.arriveBy
+}
+
+func (p *TransitParams) String() string {
+	return fmt.Sprintf("TransitParams{origin=%s, destination=%s, time=%s, arriveBy=%t}", p.origin, p.destination, p.time, p.arriveBy)
+}
@@ -7,10 +7,14 @@ import (
 	"time"
 )
 
+// roundTripModeBothLegs is the ROUND_TRIP_MODE committing both legs of a round trip or neither
+const roundTripModeBothLegs = "both_legs"
+
 type Config struct {
-	datasetId string
-	start     time.Time
-	end       time.Time
+	datasetId          string
+	start              time.Time
+	end                time.Time
+	completeRoundTrips bool // Adds the other leg of the round trips outside the window, when both legs are matched together
 }
 
 func DefaultConfig() Config {
@@ -32,6 +36,7 @@ func LoadConfig() (Config, error) {
 	}
 	// Override dataset ID from environment variable if set
 	override("DATASET_ID", &cfg.datasetId)
+	cfg.completeRoundTrips = os.Getenv("ROUND_TRIP_MODE") == roundTripModeBothLegs
 
 	// Parse start and end times from environment variables if set
 	if err := parseTimeEnv("START", &cfg.start); err != nil {
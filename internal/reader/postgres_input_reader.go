@@ -38,6 +38,16 @@ func (r *PostgresInputReader) GetOffersAndRequests(ctx context.Context) ([]*mode
 	if err != nil {
 		return nil, nil, false, fmt.Errorf("failed to get requests %w", err)
 	}
+	templates := requests
+	requests = ExpandRecurringRequests(requests, cfg.start, cfg.end)
+	if cfg.completeRoundTrips {
+		// A leg must not be matched alone while its other leg, outside the window, is still waiting for a driver
+		linked, err := r.requestsRepository.GetUnmatchedByIDs(ctx, MissingLinkedRequestIDs(templates))
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to get the other legs of the round trips %w", err)
+		}
+		requests = CompleteRoundTrips(requests, append(templates, linked...))
+	}
 	if len(requests) == 0 {
 		return nil, nil, false, nil
 	}
@@ -46,6 +56,7 @@ func (r *PostgresInputReader) GetOffersAndRequests(ctx context.Context) ([]*mode
 	if err != nil {
 		return nil, nil, false, fmt.Errorf("failed to get offers %w", err)
 	}
+	offers = ExpandRecurringOffers(offers, cfg.start, cfg.end)
 	if len(offers) == 0 {
 		return nil, nil, false, nil
 	}
@@ -0,0 +1,58 @@
+package reader
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/constants"
+	"matching-engine/internal/model"
+)
+
+// ExpandRecurringOffers replaces every recurring offer by its occurrences within the [start, end] window,
+// leaving out the occurrences already full. One-off offers are kept as they are.
+func ExpandRecurringOffers(offers []*model.Offer, start, end time.Time) []*model.Offer {
+	expanded := make([]*model.Offer, 0, len(offers))
+	for _, offer := range offers {
+		if offer.Recurrence() == nil {
+			expanded = append(expanded, offer)
+			continue
+		}
+		occurrences := offer.Recurrence().Occurrences(offer.DepartureTime(), start, end)
+		for _, departureTime := range occurrences {
+			instance := offer.Instance(departureTime)
+			if instance.CurrentNumberOfRequests() >= constants.MaxDriverCapacity {
+				continue
+			}
+			expanded = append(expanded, instance)
+		}
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Int("occurrences", len(occurrences)).
+			Msg("Expanded recurring offer")
+	}
+	return expanded
+}
+
+// ExpandRecurringRequests replaces every recurring request by its occurrences within the [start, end] window,
+// leaving out the occurrences already matched. One-off requests are kept as they are.
+func ExpandRecurringRequests(requests []*model.Request, start, end time.Time) []*model.Request {
+	expanded := make([]*model.Request, 0, len(requests))
+	for _, request := range requests {
+		if request.Recurrence() == nil {
+			expanded = append(expanded, request)
+			continue
+		}
+		occurrences := request.Recurrence().Occurrences(request.EarliestDepartureTime(), start, end)
+		for _, earliestDepartureTime := range occurrences {
+			if request.IsOccurrenceMatched(earliestDepartureTime) {
+				continue
+			}
+			expanded = append(expanded, request.Instance(earliestDepartureTime))
+		}
+		log.Debug().
+			Str("request_id", request.ID()).
+			Int("occurrences", len(occurrences)).
+			Msg("Expanded recurring request")
+	}
+	return expanded
+}
@@ -0,0 +1,85 @@
+package reader
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/model"
+)
+
+// MissingLinkedRequestIDs returns the IDs of the other legs of the round trips not part of the given requests
+func MissingLinkedRequestIDs(requests []*model.Request) []string {
+	known := make(map[string]bool, len(requests))
+	for _, request := range requests {
+		known[request.ID()] = true
+	}
+	missing := make([]string, 0)
+	for _, request := range requests {
+		linkedRequestID := request.LinkedRequestID()
+		if linkedRequestID != "" && !known[linkedRequestID] {
+			known[linkedRequestID] = true
+			missing = append(missing, linkedRequestID)
+		}
+	}
+	return missing
+}
+
+// CompleteRoundTrips adds to the expanded requests the other leg of every round trip left outside the window,
+// so a leg is never matched without its other leg. The other legs are taken from the unmatched templates,
+// a leg whose other leg is missing from them being left alone as its other leg was already matched.
+func CompleteRoundTrips(requests []*model.Request, templates []*model.Request) []*model.Request {
+	templatesByID := make(map[string]*model.Request, len(templates))
+	for _, template := range templates {
+		templatesByID[template.ID()] = template
+	}
+	known := make(map[string]bool, len(requests))
+	for _, request := range requests {
+		known[request.ID()] = true
+	}
+
+	completed := requests
+	for _, request := range requests {
+		if request.LinkedRequestID() == "" || known[request.LinkedRequestID()] {
+			continue
+		}
+		template, ok := templatesByID[request.SeriesID()]
+		if !ok {
+			continue
+		}
+		linkedTemplate, ok := templatesByID[template.LinkedRequestID()]
+		if !ok {
+			continue
+		}
+		linked := linkedOccurrence(request, linkedTemplate)
+		if linked == nil || linked.ID() != request.LinkedRequestID() {
+			continue
+		}
+		known[linked.ID()] = true
+		completed = append(completed, linked)
+		log.Debug().
+			Str("request_id", request.ID()).
+			Str("linked_request_id", linked.ID()).
+			Msg("Added the other leg of a round trip outside the window")
+	}
+	return completed
+}
+
+// linkedOccurrence returns the other leg of the round trip of the request, taken on the same day for the occurrences
+// of recurring requests, or nil when the other leg doesn't take place or is already matched on that day
+func linkedOccurrence(request, linkedTemplate *model.Request) *model.Request {
+	if linkedTemplate.Recurrence() == nil {
+		return linkedTemplate
+	}
+	if request.OccurrenceDate() == "" {
+		return nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", request.OccurrenceDate(), linkedTemplate.EarliestDepartureTime().Location())
+	if err != nil {
+		return nil
+	}
+	occurrence := model.OccurrenceOn(linkedTemplate.EarliestDepartureTime(), day)
+	if !linkedTemplate.Recurrence().OccursOn(occurrence) || linkedTemplate.IsOccurrenceMatched(occurrence) {
+		return nil
+	}
+	return linkedTemplate.Instance(occurrence)
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/reader"
+	"matching-engine/internal/service/pathgeneration/validator"
+	"testing"
+	"time"
+)
+
+func TestExpandRecurringRequests(t *testing.T) {
+	// Monday 2025-09-15 08:00
+	firstTrip := time.Date(2025, 9, 15, 8, 0, 0, 0, time.UTC)
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	newRequest := func(recurrence *model.RecurrenceRule) *model.Request {
+		request := model.NewRequest(
+			"request1", "rider1",
+			model.Coordinate{}, model.Coordinate{},
+			firstTrip, firstTrip.Add(1*time.Hour),
+			10*time.Minute,
+			1,
+			*model.NewPreference(enums.Female, false),
+		)
+		request.SetRecurrence(recurrence)
+		return request
+	}
+
+	tests := []struct {
+		name        string
+		request     *model.Request
+		start       time.Time
+		end         time.Time
+		expectedIDs []string
+	}{
+		{
+			name:        "One-off request is kept",
+			request:     newRequest(nil),
+			start:       firstTrip.Add(-1 * time.Hour),
+			end:         firstTrip.Add(1 * time.Hour),
+			expectedIDs: []string{"request1"},
+		},
+		{
+			name:        "Weekdays over a full week",
+			request:     newRequest(model.NewRecurrenceRule(weekdays, firstTrip, time.Time{}, nil)),
+			start:       time.Date(2025, 9, 14, 0, 0, 0, 0, time.UTC),
+			end:         time.Date(2025, 9, 20, 23, 59, 59, 0, time.UTC),
+			expectedIDs: []string{"request1@2025-09-15", "request1@2025-09-16", "request1@2025-09-17", "request1@2025-09-18", "request1@2025-09-19"},
+		},
+		{
+			name: "Exceptions and end date are skipped",
+			request: newRequest(model.NewRecurrenceRule(weekdays, firstTrip, time.Date(2025, 9, 18, 0, 0, 0, 0, time.UTC),
+				[]time.Time{time.Date(2025, 9, 16, 0, 0, 0, 0, time.UTC)})),
+			start:       time.Date(2025, 9, 14, 0, 0, 0, 0, time.UTC),
+			end:         time.Date(2025, 9, 20, 23, 59, 59, 0, time.UTC),
+			expectedIDs: []string{"request1@2025-09-15", "request1@2025-09-17", "request1@2025-09-18"},
+		},
+		{
+			name:        "Occurrence before the window start is skipped",
+			request:     newRequest(model.NewRecurrenceRule(weekdays, firstTrip, time.Time{}, nil)),
+			start:       time.Date(2025, 9, 16, 9, 0, 0, 0, time.UTC),
+			end:         time.Date(2025, 9, 17, 23, 59, 59, 0, time.UTC),
+			expectedIDs: []string{"request1@2025-09-17"},
+		},
+		{
+			name: "Matched occurrence is skipped",
+			request: func() *model.Request {
+				request := newRequest(model.NewRecurrenceRule(weekdays, firstTrip, time.Time{}, nil))
+				request.AddMatchedOccurrence(time.Date(2025, 9, 16, 0, 0, 0, 0, time.UTC))
+				return request
+			}(),
+			start:       time.Date(2025, 9, 14, 0, 0, 0, 0, time.UTC),
+			end:         time.Date(2025, 9, 17, 23, 59, 59, 0, time.UTC),
+			expectedIDs: []string{"request1@2025-09-15", "request1@2025-09-17"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			expanded := reader.ExpandRecurringRequests([]*model.Request{tc.request}, tc.start, tc.end)
+
+			if len(expanded) != len(tc.expectedIDs) {
+				t.Fatalf("Expected %d requests but got %d", len(tc.expectedIDs), len(expanded))
+			}
+			for i, request := range expanded {
+				if request.ID() != tc.expectedIDs[i] {
+					t.Errorf("Expected request %s but got %s", tc.expectedIDs[i], request.ID())
+				}
+				if request.SeriesID() != "request1" {
+					t.Errorf("Expected series request1 but got %s", request.SeriesID())
+				}
+				if request.LatestArrivalTime().Sub(request.EarliestDepartureTime()) != 1*time.Hour {
+					t.Errorf("Expected the time window to be kept for %s", request.ID())
+				}
+			}
+		})
+	}
+}
+
+// fixedTimeMatrix drives 10 minutes to the pickup, 20 minutes to the dropoff and 10 minutes to the destination,
+// the direct trip taking 35 minutes
+type fixedTimeMatrix struct{}
+
+func (fixedTimeMatrix) GetCumulativeTravelDurations(*model.OfferNode, *model.RequestNode, []model.PathPoint) ([]time.Duration, error) {
+	return []time.Duration{0, 10 * time.Minute, 30 * time.Minute, 40 * time.Minute}, nil
+}
+
+func (fixedTimeMatrix) GetCumulativeTravelTimes(*model.OfferNode, *model.RequestNode, []model.PathPoint) ([]time.Time, error) {
+	panic("GetCumulativeTravelTimes should not be called in these tests")
+}
+
+func (fixedTimeMatrix) GetTravelDuration(*model.OfferNode, *model.RequestNode, model.PathPointID, model.PathPointID) (time.Duration, error) {
+	return 35 * time.Minute, nil
+}
+
+func TestExpandRecurringOffers_MatchedRiderStaysOnItsOccurrence(t *testing.T) {
+	// Monday 2025-09-15 08:00
+	firstTrip := time.Date(2025, 9, 15, 8, 0, 0, 0, time.UTC)
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	preference := *model.NewPreference(enums.Female, false)
+
+	rider := model.NewRequest(
+		"request1", "rider1",
+		model.Coordinate{}, model.Coordinate{},
+		firstTrip.Add(5*time.Minute), firstTrip.Add(1*time.Hour),
+		10*time.Minute,
+		1,
+		preference,
+	)
+	rider.SetRecurrence(model.NewRecurrenceRule(weekdays, firstTrip, time.Time{}, nil))
+	offer := model.NewOffer(
+		"offer1", "driver1",
+		model.Coordinate{}, model.Coordinate{},
+		firstTrip, 15*time.Minute, 3, preference, firstTrip.Add(1*time.Hour), 0, nil, nil,
+	)
+	offer.SetPath([]model.PathPoint{
+		*model.NewPathPoint(model.Coordinate{}, enums.Source, firstTrip, offer, 0),
+		*model.NewPathPoint(model.Coordinate{}, enums.Destination, firstTrip.Add(40*time.Minute), offer, 0),
+	})
+	offer.SetRecurrence(model.NewRecurrenceRule(weekdays, firstTrip, time.Time{}, nil))
+
+	// The rider is only matched with the Tuesday occurrence
+	tuesday := firstTrip.AddDate(0, 0, 1)
+	riderOccurrence := rider.Instance(rider.EarliestDepartureTime().AddDate(0, 0, 1))
+	offer.AddMatchedOccurrence(tuesday, []model.PathPoint{
+		*model.NewPathPoint(model.Coordinate{}, enums.Pickup, tuesday.Add(10*time.Minute), riderOccurrence, 0),
+		*model.NewPathPoint(model.Coordinate{}, enums.Dropoff, tuesday.Add(30*time.Minute), riderOccurrence, 0),
+	}, []*model.Request{riderOccurrence})
+
+	expanded := reader.ExpandRecurringOffers([]*model.Offer{offer},
+		time.Date(2025, 9, 14, 0, 0, 0, 0, time.UTC), time.Date(2025, 9, 16, 23, 59, 59, 0, time.UTC))
+	if len(expanded) != 2 {
+		t.Fatalf("Expected 2 offers but got %d", len(expanded))
+	}
+
+	monday := expanded[0]
+	if len(monday.MatchedRequests()) != 0 || len(monday.Path()) != 2 || monday.CurrentNumberOfRequests() != 0 {
+		t.Errorf("Expected the Monday occurrence to have no rider but got %d", len(monday.MatchedRequests()))
+	}
+
+	occurrence := expanded[1]
+	if len(occurrence.MatchedRequests()) != 1 || occurrence.CurrentNumberOfRequests() != 1 {
+		t.Fatalf("Expected 1 matched rider but got %d", len(occurrence.MatchedRequests()))
+	}
+	if occurrence.MatchedRequests()[0].ID() != "request1@2025-09-16" {
+		t.Errorf("Expected rider request1@2025-09-16 but got %s", occurrence.MatchedRequests()[0].ID())
+	}
+	if len(occurrence.Path()) != 4 {
+		t.Fatalf("Expected 4 points on the Tuesday occurrence but got %d", len(occurrence.Path()))
+	}
+	for _, i := range []int{0, 3} {
+		if owner, _ := occurrence.Path()[i].Owner().AsOffer(); owner != occurrence {
+			t.Errorf("Expected point %d to belong to the occurrence", i)
+		}
+	}
+
+	path := append([]model.PathPoint(nil), occurrence.Path()...)
+	valid, err := validator.NewDefaultPathValidator(fixedTimeMatrix{}).
+		ValidatePath(model.NewOfferNode(occurrence), model.NewRequestNode(riderOccurrence), path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !valid {
+		t.Errorf("Expected the path of the second occurrence to be valid")
+	}
+}
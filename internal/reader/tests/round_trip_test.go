@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/reader"
+	"testing"
+	"time"
+)
+
+func newLeg(id, linkedRequestID string, departure time.Time, recurrence *model.RecurrenceRule) *model.Request {
+	request := model.NewRequest(
+		id, "rider1",
+		model.Coordinate{}, model.Coordinate{},
+		departure, departure.Add(1*time.Hour),
+		10*time.Minute,
+		1,
+		*model.NewPreference(enums.Female, false),
+	)
+	request.SetLinkedRequestID(linkedRequestID)
+	request.SetRecurrence(recurrence)
+	return request
+}
+
+func TestCompleteRoundTrips(t *testing.T) {
+	// Monday 2025-09-15, the window only covers the morning
+	morning := time.Date(2025, 9, 15, 8, 0, 0, 0, time.UTC)
+	evening := morning.Add(9 * time.Hour)
+	start, end := morning.Add(-1*time.Hour), morning.Add(1*time.Hour)
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	tests := []struct {
+		name        string
+		templates   []*model.Request
+		linked      []*model.Request // Unmatched other legs loaded by ID
+		expectedIDs []string
+	}{
+		{
+			name:        "Unmatched return leg outside the window is added",
+			templates:   []*model.Request{newLeg("outbound", "return", morning, nil)},
+			linked:      []*model.Request{newLeg("return", "outbound", evening, nil)},
+			expectedIDs: []string{"outbound", "return"},
+		},
+		{
+			name:        "Matched return leg is left out",
+			templates:   []*model.Request{newLeg("outbound", "return", morning, nil)},
+			expectedIDs: []string{"outbound"},
+		},
+		{
+			name: "Return leg of a recurring round trip is added on the same day",
+			templates: []*model.Request{
+				newLeg("outbound", "return", morning, model.NewRecurrenceRule(weekdays, morning, time.Time{}, nil)),
+				newLeg("return", "outbound", evening, model.NewRecurrenceRule(weekdays, morning, time.Time{}, nil)),
+			},
+			expectedIDs: []string{"outbound@2025-09-15", "return@2025-09-15"},
+		},
+		{
+			name: "Return leg of a recurring round trip matched on that day is left out",
+			templates: []*model.Request{
+				newLeg("outbound", "return", morning, model.NewRecurrenceRule(weekdays, morning, time.Time{}, nil)),
+				func() *model.Request {
+					request := newLeg("return", "outbound", evening, model.NewRecurrenceRule(weekdays, morning, time.Time{}, nil))
+					request.AddMatchedOccurrence(evening)
+					return request
+				}(),
+			},
+			expectedIDs: []string{"outbound@2025-09-15"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := reader.ExpandRecurringRequests(tc.templates, start, end)
+			if missing := reader.MissingLinkedRequestIDs(tc.templates); len(tc.linked) > 0 && (len(missing) != 1 || missing[0] != "return") {
+				t.Fatalf("Expected the return leg to be missing but got %v", missing)
+			}
+			completed := reader.CompleteRoundTrips(requests, append(tc.templates, tc.linked...))
+
+			if len(completed) != len(tc.expectedIDs) {
+				t.Fatalf("Expected %d requests but got %d", len(tc.expectedIDs), len(completed))
+			}
+			for i, request := range completed {
+				if request.ID() != tc.expectedIDs[i] {
+					t.Errorf("Expected request %s but got %s", tc.expectedIDs[i], request.ID())
+				}
+			}
+		})
+	}
+}
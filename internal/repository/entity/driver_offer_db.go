@@ -15,6 +15,10 @@ type DriverOfferDB struct {
 	SourceLatitude  float64 `gorm:"type:decimal(10,8);not null"`
 	SourceLongitude float64 `gorm:"type:decimal(11,8);not null"`
 
+	FlexibleSourceMinutes int      `gorm:"not null;default:0"`
+	StartLatitude         *float64 `gorm:"type:decimal(10,8)"`
+	StartLongitude        *float64 `gorm:"type:decimal(11,8)"`
+
 	DestinationLatitude  float64 `gorm:"type:decimal(10,8);not null"`
 	DestinationLongitude float64 `gorm:"type:decimal(11,8);not null"`
 
@@ -24,8 +28,16 @@ type DriverOfferDB struct {
 
 	DetourDurationMinutes   int `gorm:"default:0"`
 	Capacity                int `gorm:"not null;check:capacity > 0"`
+	LuggageCapacity         int `gorm:"not null;default:0"`
+	WheelchairCapacity      int `gorm:"not null;default:0"`
+	ChildSeatCapacity       int `gorm:"not null;default:0"`
 	CurrentNumberOfRequests int `gorm:"not null;default:0"`
 
+	RoutePolyline      *string `gorm:"type:text"`
+	RoutePathSignature *string `gorm:"type:varchar(16)"`
+
+	RecurrenceDB `gorm:"embedded"`
+
 	SameGender    bool          `gorm:"not null;default:false"`
 	UserGender    enums.Gender  `gorm:"type:gender_type;not null"`
 	PathPoints    []PathPointDB `gorm:"foreignKey:DriverOfferID"`
@@ -53,13 +65,38 @@ func (d *DriverOfferDB) ToDriverOffer() *model.Offer {
 	// Create a map to store requests by ID
 	requestsMap := make(map[string]*model.Request)
 
-	// Add source point
-	sourcePoint := model.NewPathPoint(*sourceCoord, enums.Source, d.DepartureTime, nil, 0)
+	// Add source point, the start chosen for a flexible source replaces it
+	startCoord := sourceCoord
+	if d.StartLatitude != nil && d.StartLongitude != nil {
+		if chosenStart, err := model.NewCoordinate(*d.StartLatitude, *d.StartLongitude); err == nil {
+			startCoord = chosenStart
+		}
+	}
+	sourcePoint := model.NewPathPoint(*startCoord, enums.Source, d.DepartureTime, nil, 0)
 	pathPoints = append(pathPoints, *sourcePoint) // Use the value, not the pointer
 
+	// The points of a recurring offer belong to its matched occurrences, not to the offer itself
+	recurrence := d.ToRecurrenceRule(d.DepartureTime)
+	occurrences := make(map[string]*occurrenceDB)
+	occurrenceDates := make([]string, 0)
+
 	// Process path points from database
 	for _, pp := range d.PathPoints {
 		pathPoint := pp.ToPathPoint()
+		if recurrence != nil {
+			if pp.OccurrenceDate == nil {
+				continue
+			}
+			date := pp.OccurrenceDate.Format("2006-01-02")
+			occurrence, ok := occurrences[date]
+			if !ok {
+				occurrence = &occurrenceDB{date: *pp.OccurrenceDate, requests: make(map[string]*model.Request)}
+				occurrences[date] = occurrence
+				occurrenceDates = append(occurrenceDates, date)
+			}
+			occurrence.add(*pathPoint)
+			continue
+		}
 		pathPoints = append(pathPoints, *pathPoint) // Use the value, not the pointer
 
 		// If the path point has a request associated with it
@@ -102,6 +139,18 @@ func (d *DriverOfferDB) ToDriverOffer() *model.Offer {
 		requests,
 	)
 
+	driverOffer.SetRecurrence(recurrence)
+	for _, date := range occurrenceDates {
+		occurrences[date].addTo(driverOffer)
+	}
+	driverOffer.SetFlexibleSourceDuration(time.Duration(d.FlexibleSourceMinutes) * time.Minute)
+	driverOffer.SetVehicleCapacity(*model.NewCapacity(d.Capacity, d.LuggageCapacity, d.WheelchairCapacity, d.ChildSeatCapacity))
+	if d.RoutePolyline != nil && d.RoutePathSignature != nil {
+		if routePolyline, err := model.NewPolyline(*d.RoutePolyline); err == nil {
+			driverOffer.RestoreRoutePolyline(routePolyline, *d.RoutePathSignature)
+		}
+	}
+
 	// Set the driver as owner of the first and last path points
 	if len(driverOffer.Path()) > 0 {
 		driverOffer.Path()[0].SetOwner(driverOffer)
@@ -110,3 +159,31 @@ func (d *DriverOfferDB) ToDriverOffer() *model.Offer {
 
 	return driverOffer
 }
+
+// occurrenceDB gathers the points and riders of one matched occurrence of a recurring offer
+type occurrenceDB struct {
+	date     time.Time
+	points   []model.PathPoint
+	requests map[string]*model.Request
+	order    []*model.Request
+}
+
+// add appends a point to the occurrence, the pickup and dropoff of a rider sharing the same request
+func (o *occurrenceDB) add(point model.PathPoint) {
+	if point.Owner() != nil {
+		if request, ok := point.Owner().AsRequest(); ok && request != nil {
+			if known, ok := o.requests[request.ID()]; ok {
+				point.SetOwner(known)
+			} else {
+				o.requests[request.ID()] = request
+				o.order = append(o.order, request)
+			}
+		}
+	}
+	o.points = append(o.points, point)
+}
+
+// addTo records the occurrence on the offer
+func (o *occurrenceDB) addTo(offer *model.Offer) {
+	offer.AddMatchedOccurrence(o.date, o.points, o.order)
+}
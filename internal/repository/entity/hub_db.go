@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"fmt"
+
+	"matching-engine/internal/model"
+)
+
+// HubDB is the database model for fixed meeting points
+type HubDB struct {
+	ID        string  `gorm:"type:varchar(50);primaryKey"`
+	Name      string  `gorm:"type:varchar(255);not null"`
+	HubType   string  `gorm:"type:varchar(50);not null"`
+	Latitude  float64 `gorm:"type:decimal(10,8);not null"`
+	Longitude float64 `gorm:"type:decimal(11,8);not null"`
+	IsActive  bool    `gorm:"not null;default:true"`
+}
+
+// TableName specifies the table name for HubDB
+func (HubDB) TableName() string {
+	return "meeting_hubs"
+}
+
+// ToHub converts a HubDB to a domain Hub
+func (h *HubDB) ToHub() (*model.Hub, error) {
+	coordinate, err := model.NewCoordinate(h.Latitude, h.Longitude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid coordinate for hub %s: %w", h.ID, err)
+	}
+	return model.NewHub(h.ID, h.Name, h.HubType, *coordinate), nil
+}
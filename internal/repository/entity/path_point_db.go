@@ -15,9 +15,12 @@ type PathPointDB struct {
 	Latitude               float64         `gorm:"type:decimal(10,8);not null"`
 	Longitude              float64         `gorm:"type:decimal(11,8);not null"`
 	WalkingDurationMinutes int             `gorm:"default:0"`
+	TransitDurationMinutes int             `gorm:"default:0"`
 	ExpectedArrivalTime    time.Time       `gorm:"type:timestamp with time zone;not null"`
 	RiderRequestID         string          `gorm:"type:varchar(50)"`          // Foreign key field
 	RiderRequest           *RiderRequestDB `gorm:"foreignKey:RiderRequestID"` // Specify the foreign key field name
+	OccurrenceDate         *time.Time      `gorm:"type:date"`                 // Occurrence of a recurring offer the point belongs to
+	RiderOccurrenceDate    *time.Time      `gorm:"type:date"`                 // Occurrence of a recurring rider request the point belongs to
 }
 
 // TableName specifies the table name for PathPointDB
@@ -31,12 +34,17 @@ func (p *PathPointDB) ToPathPoint() *model.PathPoint {
 	coordinate, _ := model.NewCoordinate(p.Latitude, p.Longitude)
 
 	var riderRequest *model.Request = p.RiderRequest.ToRiderRequest()
+	if riderRequest != nil && riderRequest.Recurrence() != nil && p.RiderOccurrenceDate != nil {
+		riderRequest = riderRequest.Instance(model.OccurrenceOn(riderRequest.EarliestDepartureTime(), *p.RiderOccurrenceDate))
+	}
 
-	return model.NewPathPoint(
+	pathPoint := model.NewPathPoint(
 		*coordinate,
 		p.PointType,
 		p.ExpectedArrivalTime,
 		riderRequest,
 		time.Duration(p.WalkingDurationMinutes)*time.Minute,
 	)
+	pathPoint.SetTransitDuration(time.Duration(p.TransitDurationMinutes) * time.Minute)
+	return pathPoint
 }
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"matching-engine/internal/model"
+)
+
+// RecurrenceDB holds the recurrence columns shared by driver offers and rider requests
+type RecurrenceDB struct {
+	RecurrenceWeekdays   *int       `gorm:"column:recurrence_weekdays"` // bit i is set when the trip repeats on time.Weekday(i), Sunday being bit 0
+	RecurrenceStartDate  *time.Time `gorm:"column:recurrence_start_date;type:date"`
+	RecurrenceEndDate    *time.Time `gorm:"column:recurrence_end_date;type:date"`
+	RecurrenceExceptions string     `gorm:"column:recurrence_exceptions;type:text"` // comma separated dates (2006-01-02) skipped by the recurrence
+}
+
+// ToRecurrenceRule converts the recurrence columns to a RecurrenceRule, returns nil for one-off trips.
+// A missing start date defaults to the date of the first trip.
+func (r *RecurrenceDB) ToRecurrenceRule(firstTrip time.Time) *model.RecurrenceRule {
+	if r.RecurrenceWeekdays == nil || *r.RecurrenceWeekdays == 0 {
+		return nil
+	}
+
+	weekdays := make([]time.Weekday, 0, 7)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if *r.RecurrenceWeekdays&(1<<weekday) != 0 {
+			weekdays = append(weekdays, weekday)
+		}
+	}
+
+	startDate := firstTrip
+	if r.RecurrenceStartDate != nil {
+		startDate = *r.RecurrenceStartDate
+	}
+	var endDate time.Time
+	if r.RecurrenceEndDate != nil {
+		endDate = *r.RecurrenceEndDate
+	}
+
+	exceptions := make([]time.Time, 0)
+	for _, value := range strings.Split(r.RecurrenceExceptions, ",") {
+		exception, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		exceptions = append(exceptions, exception)
+	}
+
+	return model.NewRecurrenceRule(weekdays, startDate, endDate, exceptions)
+}
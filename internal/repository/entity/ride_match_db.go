@@ -1,12 +1,34 @@
 package entity
 
+import (
+	"time"
+
+	"matching-engine/internal/model"
+)
+
 // RideMatchDB is the database model for ride matches
 type RideMatchDB struct {
-	DriverOfferID  string `gorm:"type:varchar(50);not null;primaryKey"`
-	RiderRequestID string `gorm:"type:varchar(50);not null;primaryKey"`
+	ID                    int64      `gorm:"primaryKey"`
+	DriverOfferID         string     `gorm:"type:varchar(50);not null"`
+	RiderRequestID        string     `gorm:"type:varchar(50);not null"`
+	OfferOccurrenceDate   *time.Time `gorm:"type:date"` // Occurrence of a recurring offer, nil for a one-off offer
+	RequestOccurrenceDate *time.Time `gorm:"type:date"` // Occurrence of a recurring rider request, nil for a one-off request
+	UpdatedAt             time.Time  `gorm:"type:timestamp with time zone"`
 }
 
 // TableName specifies the table name for RideMatchDB
 func (RideMatchDB) TableName() string {
 	return "ride_matches"
 }
+
+// SharedRideDB is the last match of a driver and a rider, aggregated from the ride matches
+type SharedRideDB struct {
+	DriverUserID string
+	RiderUserID  string
+	SharedAt     time.Time
+}
+
+// ToSharedRide converts a SharedRideDB to SharedRide domain model
+func (s *SharedRideDB) ToSharedRide() *model.SharedRide {
+	return model.NewSharedRide(s.DriverUserID, s.RiderUserID, s.SharedAt)
+}
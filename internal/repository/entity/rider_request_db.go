@@ -19,8 +19,14 @@ type RiderRequestDB struct {
 	LatestArrivalTime         time.Time     `gorm:"type:timestamp with time zone;not null"`
 	MaxWalkingDurationMinutes time.Duration `gorm:"column:max_walking_duration_minutes;default:10"`
 	NumberOfRiders            int           `gorm:"not null;default:1;check:number_of_riders > 0"`
+	LuggageCount              int           `gorm:"not null;default:0"`
+	WheelchairCount           int           `gorm:"not null;default:0"`
+	ChildSeatCount            int           `gorm:"not null;default:0"`
 	SameGender                bool          `gorm:"not null;default:false"`
 	UserGender                enums.Gender  `gorm:"type:gender_type;not null"`
+	LinkedRequestID           *string       `gorm:"type:varchar(50)"`
+	RecurrenceDB              `gorm:"embedded"`
+	MatchedOccurrences        []RideMatchDB `gorm:"foreignKey:RiderRequestID"` // Matches of the occurrences of a recurring request
 }
 
 // TableName specifies the table name for RiderRequestDB
@@ -48,5 +54,15 @@ func (r *RiderRequestDB) ToRiderRequest() *model.Request {
 		r.NumberOfRiders,
 		*preferences,
 	)
+	if r.LinkedRequestID != nil {
+		riderRequest.SetLinkedRequestID(*r.LinkedRequestID)
+	}
+	riderRequest.SetRecurrence(r.ToRecurrenceRule(r.EarliestDepartureTime))
+	for _, match := range r.MatchedOccurrences {
+		if match.RequestOccurrenceDate != nil {
+			riderRequest.AddMatchedOccurrence(*match.RequestOccurrenceDate)
+		}
+	}
+	riderRequest.SetCapacityNeeds(*model.NewCapacity(r.NumberOfRiders, r.LuggageCount, r.WheelchairCount, r.ChildSeatCount))
 	return riderRequest
 }
@@ -0,0 +1,26 @@
+package entity
+
+// UserBlockDB is the database model for blocks and reports between users.
+// A row means BlockerUserID blocked or reported BlockedUserID; the relation is enforced in both directions.
+type UserBlockDB struct {
+	BlockerUserID string `gorm:"type:varchar(50);not null;primaryKey"`
+	BlockedUserID string `gorm:"type:varchar(50);not null;primaryKey"`
+	Reason        string `gorm:"type:block_reason_type;not null"`
+}
+
+// TableName specifies the table name for UserBlockDB
+func (UserBlockDB) TableName() string {
+	return "user_blocks"
+}
+
+// UserTrustProfileDB is the database model for user ratings and rating thresholds
+type UserTrustProfileDB struct {
+	UserID             string   `gorm:"type:varchar(50);primaryKey"`
+	RiderRating        *float64 `gorm:"type:decimal(3,2)"`
+	MinimumRiderRating *float64 `gorm:"type:decimal(3,2)"`
+}
+
+// TableName specifies the table name for UserTrustProfileDB
+func (UserTrustProfileDB) TableName() string {
+	return "user_trust_profiles"
+}
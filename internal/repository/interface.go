@@ -14,6 +14,9 @@ type RiderRequestRepo interface {
 
 	// GetPendingRequests retrieves all rider requests that haven't been matched with a driver
 	GetUnmatched(ctx context.Context, start, end time.Time, datasetId string) ([]*model.Request, error)
+
+	// GetUnmatchedByIDs fetches the rider requests with the given IDs that haven't been matched, whatever their time
+	GetUnmatchedByIDs(ctx context.Context, ids []string) ([]*model.Request, error)
 }
 
 // DriverRepository defines operations for driver offer persistence
@@ -24,3 +27,27 @@ type DriverOfferRepo interface {
 	// GetAvailableDrivers gets drivers with capacity and matching time windows
 	GetAvailable(ctx context.Context, start, end time.Time, datasetId string) ([]*model.Offer, error)
 }
+
+// RideMatchRepo defines read operations for the matches already committed between drivers and riders
+type RideMatchRepo interface {
+	// GetSharedRides fetches the drivers and riders matched together since the given time, once per pair
+	GetSharedRides(ctx context.Context, since time.Time) ([]*model.SharedRide, error)
+}
+
+// TrustSafetyRepo defines read operations for trust & safety data (blocks, reports and ratings)
+type TrustSafetyRepo interface {
+	// IsBlocked checks if either user has blocked or reported the other one
+	IsBlocked(ctx context.Context, userID, otherUserID string) (bool, error)
+
+	// GetRiderRating fetches the rating of a rider, found is false if the rider has not been rated yet
+	GetRiderRating(ctx context.Context, userID string) (rating float64, found bool, err error)
+
+	// GetMinimumRiderRating fetches the minimum rider rating required by a driver, found is false if the driver has no threshold
+	GetMinimumRiderRating(ctx context.Context, driverUserID string) (rating float64, found bool, err error)
+}
+
+// HubRepo defines read operations for the catalogue of fixed meeting points
+type HubRepo interface {
+	// GetActive fetches all the meeting points that can currently be used for pickups and dropoffs
+	GetActive(ctx context.Context) ([]*model.Hub, error)
+}
@@ -58,7 +58,7 @@ func (r *PostgresDriverOfferRepo) GetAvailable(ctx context.Context, start, end t
 	err := r.db.WithContext(ctx).
 		Preload("PathPoints", orderPathPointsByPathOrder).
 		Preload("PathPoints.RiderRequest").
-		Where("departure_time BETWEEN ? AND ?", start, end).
+		Where("(departure_time BETWEEN ? AND ?) OR ("+recurrenceOverlapsWindow+")", start, end, end, start).
 		Where("current_number_of_requests < ?", constants.MaxDriverCapacity).
 		Where("dataset_id = ?", datasetId).
 		Omit("dataset_id").
@@ -71,6 +71,12 @@ func (r *PostgresDriverOfferRepo) GetAvailable(ctx context.Context, start, end t
 	return convertToDriverOffers(driverOfferDB), nil
 }
 
+// recurrenceOverlapsWindow selects recurring rows whose recurrence date range overlaps the [start, end] window,
+// it expects the end then the start of the window as arguments
+const recurrenceOverlapsWindow = "recurrence_weekdays IS NOT NULL AND recurrence_weekdays <> 0" +
+	" AND (recurrence_start_date IS NULL OR recurrence_start_date <= ?)" +
+	" AND (recurrence_end_date IS NULL OR recurrence_end_date >= ?::date)"
+
 // orderPathPointsByPathOrder returns a function that orders path points by their path_order
 func orderPathPointsByPathOrder(db *gorm.DB) *gorm.DB {
 	return db.Order("path_order ASC")
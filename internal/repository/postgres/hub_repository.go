@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"matching-engine/internal/errors"
+	"matching-engine/internal/model"
+	"matching-engine/internal/repository"
+	"matching-engine/internal/repository/entity"
+)
+
+// PostgresHubRepo implements repository.HubRepo
+type PostgresHubRepo struct {
+	db *gorm.DB
+}
+
+// NewPostgresHubRepo creates a new meeting hubs repository
+func NewPostgresHubRepo(db *Database) repository.HubRepo {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &PostgresHubRepo{db: db.DB}
+}
+
+// GetActive fetches all the active meeting hubs
+func (r *PostgresHubRepo) GetActive(ctx context.Context) ([]*model.Hub, error) {
+	var hubsDB []entity.HubDB
+	err := r.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Find(&hubsDB).Error
+	if err != nil {
+		return nil, errors.DatabaseError("fetch_active_hubs", err)
+	}
+
+	hubs := make([]*model.Hub, 0, len(hubsDB))
+	for i := range hubsDB {
+		hub, err := hubsDB[i].ToHub()
+		if err != nil {
+			return nil, err
+		}
+		hubs = append(hubs, hub)
+	}
+	return hubs, nil
+}
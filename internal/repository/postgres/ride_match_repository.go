@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"matching-engine/internal/errors"
+	"matching-engine/internal/model"
+	"matching-engine/internal/repository"
+	"matching-engine/internal/repository/entity"
+)
+
+// PostgresRideMatchRepo implements repository.RideMatchRepo
+type PostgresRideMatchRepo struct {
+	db *gorm.DB
+}
+
+// NewPostgresRideMatchRepo creates a new ride matches repository
+func NewPostgresRideMatchRepo(db *Database) repository.RideMatchRepo {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &PostgresRideMatchRepo{db: db.DB}
+}
+
+// GetSharedRides fetches the users of the offers and requests matched since the given time,
+// along with the last time every pair was matched
+func (r *PostgresRideMatchRepo) GetSharedRides(ctx context.Context, since time.Time) ([]*model.SharedRide, error) {
+	var sharedRidesDB []entity.SharedRideDB
+	err := r.db.WithContext(ctx).
+		Table("ride_matches AS m").
+		Select("o.user_id AS driver_user_id, q.user_id AS rider_user_id, MAX(m.updated_at) AS shared_at").
+		Joins("JOIN driver_offers AS o ON o.id = m.driver_offer_id").
+		Joins("JOIN rider_requests AS q ON q.id = m.rider_request_id").
+		Where("m.updated_at >= ?", since).
+		Group("o.user_id, q.user_id").
+		Scan(&sharedRidesDB).Error
+	if err != nil {
+		return nil, errors.DatabaseError("fetch_shared_rides", err)
+	}
+
+	sharedRides := make([]*model.SharedRide, 0, len(sharedRidesDB))
+	for i := range sharedRidesDB {
+		sharedRides = append(sharedRides, sharedRidesDB[i].ToSharedRide())
+	}
+	return sharedRides, nil
+}
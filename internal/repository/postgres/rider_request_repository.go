@@ -57,8 +57,9 @@ func (r *PostgresRiderRequestRepo) GetUnmatched(ctx context.Context, start, end
 
 	err := r.db.WithContext(ctx).
 		Omit("created_at, updated_at").
+		Preload("MatchedOccurrences", "request_occurrence_date IS NOT NULL").
 		Where("is_matched = false").
-		Where("earliest_departure_time BETWEEN ? AND ?", start, end).
+		Where("(earliest_departure_time BETWEEN ? AND ?) OR ("+recurrenceOverlapsWindow+")", start, end, end, start).
 		Where("dataset_id = ?", datasetId).
 		Omit("dataset_id").
 		Find(&riderRequestDB).Error
@@ -70,6 +71,28 @@ func (r *PostgresRiderRequestRepo) GetUnmatched(ctx context.Context, start, end
 	return convertToRiderRequests(riderRequestDB), nil
 }
 
+// GetUnmatchedByIDs finds the rider requests with the given IDs that haven't been matched yet
+func (r *PostgresRiderRequestRepo) GetUnmatchedByIDs(ctx context.Context, ids []string) ([]*model.Request, error) {
+	if len(ids) == 0 {
+		return make([]*model.Request, 0), nil
+	}
+
+	var riderRequestDB []entity.RiderRequestDB
+
+	err := r.db.WithContext(ctx).
+		Omit("created_at, updated_at").
+		Preload("MatchedOccurrences", "request_occurrence_date IS NOT NULL").
+		Where("is_matched = false").
+		Where("id IN ?", ids).
+		Find(&riderRequestDB).Error
+
+	if err != nil {
+		return nil, errors.DatabaseError("find_unmatched_requests_by_ids", err)
+	}
+
+	return convertToRiderRequests(riderRequestDB), nil
+}
+
 // convertToRiderRequests converts database model to domain model
 func convertToRiderRequests(riderRequestDB []entity.RiderRequestDB) []*model.Request {
 	requests := make([]*model.Request, 0, len(riderRequestDB))
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"matching-engine/internal/collections"
+	"matching-engine/internal/errors"
+	"matching-engine/internal/repository"
+	"matching-engine/internal/repository/entity"
+)
+
+// PostgresTrustSafetyRepo implements repository.TrustSafetyRepo
+// Blocks and trust profiles are loaded once per user and cached for cacheTTL, since the checker
+// asks for the same users many times while pruning candidates, and a long-lived worker must see the new blocks
+type PostgresTrustSafetyRepo struct {
+	db            *gorm.DB
+	cacheTTL      time.Duration
+	blockedUsers  *collections.SyncMap[string, cachedValue[*collections.Set[string]]]
+	trustProfiles *collections.SyncMap[string, cachedValue[*entity.UserTrustProfileDB]]
+}
+
+// cachedValue is a value loaded from the database with the time it was loaded at
+type cachedValue[T any] struct {
+	value    T
+	loadedAt time.Time
+}
+
+// NewPostgresTrustSafetyRepo creates a new trust & safety repository caching the blocks and trust profiles for cacheTTL
+func NewPostgresTrustSafetyRepo(db *Database, cacheTTL time.Duration) repository.TrustSafetyRepo {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	return &PostgresTrustSafetyRepo{
+		db:            db.DB,
+		cacheTTL:      cacheTTL,
+		blockedUsers:  collections.NewSyncMap[string, cachedValue[*collections.Set[string]]](),
+		trustProfiles: collections.NewSyncMap[string, cachedValue[*entity.UserTrustProfileDB]](),
+	}
+}
+
+// isFresh tells whether a value loaded at the given time can still be used
+func (r *PostgresTrustSafetyRepo) isFresh(loadedAt time.Time) bool {
+	return time.Since(loadedAt) < r.cacheTTL
+}
+
+// IsBlocked checks if either user has blocked or reported the other one
+func (r *PostgresTrustSafetyRepo) IsBlocked(ctx context.Context, userID, otherUserID string) (bool, error) {
+	if userID == "" || otherUserID == "" {
+		return false, errors.EmptyID("user")
+	}
+	blocked, err := r.getBlockedUsers(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return blocked.Contains(otherUserID), nil
+}
+
+// GetRiderRating fetches the rating of a rider
+func (r *PostgresTrustSafetyRepo) GetRiderRating(ctx context.Context, userID string) (float64, bool, error) {
+	profile, err := r.getTrustProfile(ctx, userID)
+	if err != nil {
+		return 0, false, err
+	}
+	if profile == nil || profile.RiderRating == nil {
+		return 0, false, nil
+	}
+	return *profile.RiderRating, true, nil
+}
+
+// GetMinimumRiderRating fetches the minimum rider rating required by a driver
+func (r *PostgresTrustSafetyRepo) GetMinimumRiderRating(ctx context.Context, driverUserID string) (float64, bool, error) {
+	profile, err := r.getTrustProfile(ctx, driverUserID)
+	if err != nil {
+		return 0, false, err
+	}
+	if profile == nil || profile.MinimumRiderRating == nil {
+		return 0, false, nil
+	}
+	return *profile.MinimumRiderRating, true, nil
+}
+
+// getBlockedUsers loads every user that blocked, reported, or was blocked or reported by the given user
+func (r *PostgresTrustSafetyRepo) getBlockedUsers(ctx context.Context, userID string) (*collections.Set[string], error) {
+	if cached, ok := r.blockedUsers.Get(userID); ok && r.isFresh(cached.loadedAt) {
+		return cached.value, nil
+	}
+
+	var userBlocksDB []entity.UserBlockDB
+	err := r.db.WithContext(ctx).
+		Where("blocker_user_id = ? OR blocked_user_id = ?", userID, userID).
+		Find(&userBlocksDB).Error
+	if err != nil {
+		return nil, errors.DatabaseError("find_user_blocks", err)
+	}
+
+	blocked := collections.NewSet[string]()
+	for _, userBlock := range userBlocksDB {
+		if userBlock.BlockerUserID == userID {
+			blocked.Add(userBlock.BlockedUserID)
+		} else {
+			blocked.Add(userBlock.BlockerUserID)
+		}
+	}
+	r.blockedUsers.Set(userID, cachedValue[*collections.Set[string]]{value: blocked, loadedAt: time.Now()})
+	return blocked, nil
+}
+
+// getTrustProfile loads the trust profile of a user, returns nil if the user has none
+func (r *PostgresTrustSafetyRepo) getTrustProfile(ctx context.Context, userID string) (*entity.UserTrustProfileDB, error) {
+	if userID == "" {
+		return nil, errors.EmptyID("user")
+	}
+	if cached, ok := r.trustProfiles.Get(userID); ok && r.isFresh(cached.loadedAt) {
+		return cached.value, nil
+	}
+
+	var userTrustProfileDB entity.UserTrustProfileDB
+	err := r.db.WithContext(ctx).
+		First(&userTrustProfileDB, "user_id = ?", userID).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, errors.DatabaseError("get_user_trust_profile", err)
+		}
+		r.trustProfiles.Set(userID, cachedValue[*entity.UserTrustProfileDB]{loadedAt: time.Now()})
+		return nil, nil
+	}
+
+	r.trustProfiles.Set(userID, cachedValue[*entity.UserTrustProfileDB]{value: &userTrustProfileDB, loadedAt: time.Now()})
+	return &userTrustProfileDB, nil
+}
@@ -0,0 +1,36 @@
+package affinity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/repository"
+)
+
+// HistoryLoader fills a tracker with the rides shared during its history, as every run starts a new process
+// that wouldn't remember the matches of the previous runs otherwise
+type HistoryLoader struct {
+	tracker     *Tracker
+	rideMatches repository.RideMatchRepo
+}
+
+// NewHistoryLoader creates a new HistoryLoader
+func NewHistoryLoader(tracker *Tracker, rideMatches repository.RideMatchRepo) *HistoryLoader {
+	return &HistoryLoader{
+		tracker:     tracker,
+		rideMatches: rideMatches,
+	}
+}
+
+// Load records in the tracker the drivers and riders matched together by the previous runs
+func (l *HistoryLoader) Load(ctx context.Context) error {
+	rides, err := l.rideMatches.GetSharedRides(ctx, l.tracker.HistoryStart())
+	if err != nil {
+		return fmt.Errorf("failed to get the shared rides: %w", err)
+	}
+	l.tracker.RecordSharedRides(rides)
+	log.Debug().Int("pairs", len(rides)).Msg("Loaded the carpool history")
+	return nil
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/affinity"
+	"testing"
+	"time"
+)
+
+func newOffer(driverID string, matchedRequests []*model.Request) *model.Offer {
+	departure := time.Date(2025, 9, 15, 8, 0, 0, 0, time.UTC)
+	return model.NewOffer(
+		"offer-"+driverID, driverID,
+		model.Coordinate{}, model.Coordinate{},
+		departure, 15*time.Minute, 3, *model.NewPreference(enums.Female, false), departure.Add(1*time.Hour), len(matchedRequests), nil, matchedRequests,
+	)
+}
+
+func newRequest(riderID string) *model.Request {
+	departure := time.Date(2025, 9, 15, 8, 0, 0, 0, time.UTC)
+	return model.NewRequest(
+		"request-"+riderID, riderID,
+		model.Coordinate{}, model.Coordinate{},
+		departure, departure.Add(1*time.Hour),
+		10*time.Minute,
+		1,
+		*model.NewPreference(enums.Female, false),
+	)
+}
+
+// sharedScore returns the score of the single edge between the driver and the rider
+func sharedScore(tracker *affinity.Tracker, offer *model.Offer, request *model.Request) int {
+	offerNode := model.NewOfferNode(offer)
+	edge := model.NewEdge(model.NewRequestNode(request), nil)
+	offerNode.AddEdge(edge)
+	return tracker.PreferenceScores([]*model.OfferNode{offerNode})[edge]
+}
+
+func TestTracker_RecordResults(t *testing.T) {
+	now := time.Date(2025, 9, 15, 6, 0, 0, 0, time.UTC)
+	tracker := affinity.NewTrackerWithHistory(24*time.Hour, func() time.Time { return now })
+
+	offer := newOffer("driver1", nil)
+	rider := newRequest("rider1")
+
+	if score := sharedScore(tracker, offer, rider); score != 0 {
+		t.Fatalf("Expected no score before the pair is committed but got %d", score)
+	}
+
+	tracker.RecordResults([]*model.MatchingResult{
+		model.NewMatchingResult(offer.UserID(), offer.ID(), []*model.Request{rider}, nil, 1),
+		model.NewWalkMatchingResult(newRequest("walker1"), 10*time.Minute),
+	})
+
+	if tracker.Size() != 1 {
+		t.Errorf("Expected only the committed ride to be recorded but got %d pairs", tracker.Size())
+	}
+	if score := sharedScore(tracker, offer, rider); score == 0 {
+		t.Errorf("Expected the committed pair to be scored")
+	}
+}
+
+func TestTracker_ForgetsExpiredPairs(t *testing.T) {
+	now := time.Date(2025, 9, 15, 6, 0, 0, 0, time.UTC)
+	tracker := affinity.NewTrackerWithHistory(24*time.Hour, func() time.Time { return now })
+
+	rider1, rider2 := newRequest("rider1"), newRequest("rider2")
+	tracker.RecordOffers([]*model.Offer{newOffer("driver1", []*model.Request{rider1})})
+
+	now = now.Add(12 * time.Hour)
+	tracker.RecordOffers([]*model.Offer{newOffer("driver2", []*model.Request{rider2})})
+	if tracker.Size() != 2 {
+		t.Fatalf("Expected 2 pairs within the history but got %d", tracker.Size())
+	}
+
+	now = now.Add(13 * time.Hour)
+	tracker.RecordOffers(nil)
+	if tracker.Size() != 1 {
+		t.Errorf("Expected the pair of driver1 to expire but got %d pairs", tracker.Size())
+	}
+	if score := sharedScore(tracker, newOffer("driver1", nil), rider1); score != 0 {
+		t.Errorf("Expected no score for the expired pair but got %d", score)
+	}
+	if score := sharedScore(tracker, newOffer("driver2", nil), rider2); score == 0 {
+		t.Errorf("Expected the pair of driver2 to be kept")
+	}
+}
+
+func TestTracker_RecordSharedRides(t *testing.T) {
+	now := time.Date(2025, 9, 15, 6, 0, 0, 0, time.UTC)
+	tracker := affinity.NewTrackerWithHistory(24*time.Hour, func() time.Time { return now })
+
+	// The rides of the previous runs, driver2 and rider2 being matched before the history
+	tracker.RecordSharedRides([]*model.SharedRide{
+		model.NewSharedRide("driver1", "rider1", now.Add(-2*time.Hour)),
+		model.NewSharedRide("driver2", "rider2", now.Add(-25*time.Hour)),
+	})
+
+	if tracker.Size() != 1 {
+		t.Fatalf("Expected only the ride within the history to be recorded but got %d pairs", tracker.Size())
+	}
+	if score := sharedScore(tracker, newOffer("driver1", nil), newRequest("rider1")); score == 0 {
+		t.Errorf("Expected the pair matched by a previous run to be scored")
+	}
+	if start := tracker.HistoryStart(); !start.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("Expected the history to start at %s but got %s", now.Add(-24*time.Hour), start)
+	}
+}
@@ -0,0 +1,161 @@
+// Package affinity keeps drivers and riders who already share a car together, so that commuters get stable carpools.
+package affinity
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/model"
+)
+
+const (
+	// historyScore is added to the score of a driver and rider who already shared a car,
+	// it is larger than any count of occurrences within a single run
+	historyScore = 1 << 16
+
+	// DefaultHistory is how long a driver and a rider who stopped sharing a car are kept together
+	DefaultHistory = 28 * 24 * time.Hour
+)
+
+// Tracker remembers which drivers and riders share a car and scores the edges keeping them together.
+// A pair is forgotten once it was not recorded during the history, so the tracker doesn't grow with the lifetime
+// of the process.
+type Tracker struct {
+	mu      sync.Mutex
+	history time.Duration
+	pairs   map[string]time.Time // Last time every pair was recorded
+	now     func() time.Time
+}
+
+// NewTracker creates a new Tracker with the history configured by AFFINITY_HISTORY
+func NewTracker() *Tracker {
+	history := DefaultHistory
+	if value, ok := os.LookupEnv("AFFINITY_HISTORY"); ok {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			history = parsed
+		} else {
+			log.Warn().Str("value", value).Msg("Invalid AFFINITY_HISTORY, using the default history")
+		}
+	}
+	return NewTrackerWithHistory(history, time.Now)
+}
+
+// NewTrackerWithHistory creates a new Tracker forgetting the pairs not recorded during the history,
+// measured with the given clock
+func NewTrackerWithHistory(history time.Duration, now func() time.Time) *Tracker {
+	return &Tracker{
+		history: history,
+		pairs:   make(map[string]time.Time),
+		now:     now,
+	}
+}
+
+// RecordOffers records the riders already matched to the given offers, and forgets the expired pairs
+func (t *Tracker) RecordOffers(offers []*model.Offer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire()
+	for _, offer := range offers {
+		for _, request := range offer.MatchedRequests() {
+			if request != nil {
+				t.record(offer.UserID(), request.UserID())
+			}
+		}
+	}
+}
+
+// RecordResults records the riders assigned to the offers by the committed results of a run
+func (t *Tracker) RecordResults(results []*model.MatchingResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, result := range results {
+		if result.IsWalk() {
+			continue
+		}
+		for _, request := range result.AssignedMatchedRequests() {
+			t.record(result.UserID(), request.UserID())
+		}
+	}
+}
+
+// RecordSharedRides records the drivers and riders matched together by the previous runs, each pair keeping its
+// latest ride, and forgets the expired pairs
+func (t *Tracker) RecordSharedRides(rides []*model.SharedRide) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, ride := range rides {
+		key := pairKey(ride.DriverUserID(), ride.RiderUserID())
+		if recordedAt, ok := t.pairs[key]; !ok || recordedAt.Before(ride.SharedAt()) {
+			t.pairs[key] = ride.SharedAt()
+		}
+	}
+	t.expire()
+}
+
+// HistoryStart returns the time before which the pairs are forgotten
+func (t *Tracker) HistoryStart() time.Time {
+	return t.now().Add(-t.history)
+}
+
+// Size returns the number of pairs remembered by the tracker
+func (t *Tracker) Size() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.pairs)
+}
+
+// PreferenceScores scores the edges between a driver and a rider who already shared a car,
+// and the edges between a driver and a rider who can share a car on several occurrences of their recurring trips.
+// The score grows with the number of occurrences, so the longest carpools are kept first.
+func (t *Tracker) PreferenceScores(offers []*model.OfferNode) map[*model.Edge]int {
+	occurrences := make(map[string]int)
+	for _, offerNode := range offers {
+		for _, edge := range offerNode.Edges() {
+			occurrences[pairKey(offerNode.Offer().UserID(), edge.RequestNode().Request().UserID())]++
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	scores := make(map[*model.Edge]int)
+	for _, offerNode := range offers {
+		for _, edge := range offerNode.Edges() {
+			key := pairKey(offerNode.Offer().UserID(), edge.RequestNode().Request().UserID())
+			score := occurrences[key]
+			if _, shared := t.pairs[key]; shared {
+				score += historyScore
+			}
+			if score > 1 {
+				scores[edge] = score
+			}
+		}
+	}
+	return scores
+}
+
+func (t *Tracker) record(driverID, riderID string) {
+	t.pairs[pairKey(driverID, riderID)] = t.now()
+}
+
+// expire forgets the pairs not recorded during the history
+func (t *Tracker) expire() {
+	oldest := t.now().Add(-t.history)
+	for key, recordedAt := range t.pairs {
+		if recordedAt.Before(oldest) {
+			delete(t.pairs, key)
+		}
+	}
+}
+
+// pairKey identifies a driver and a rider, regardless of the occurrence of their trips
+func pairKey(driverID, riderID string) string {
+	return driverID + "|" + riderID
+}
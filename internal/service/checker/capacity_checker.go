@@ -19,8 +19,8 @@ func (cc *CapacityChecker) Check(offer *model.Offer, request *model.Request) (bo
 	if offer == nil || request == nil {
 		return false, fmt.Errorf("offer or request is nil")
 	}
-	// Check if the offer has enough capacity to accommodate the request
-	if offer.Capacity() < request.NumberOfRiders() {
+	// Check if the offer has enough capacity to accommodate the request on every dimension
+	if !request.CapacityNeeds().FitsIn(offer.VehicleCapacity()) {
 		log.Debug().
 			Str("offer_id", offer.ID()).
 			Str("request_id", request.ID()).
@@ -0,0 +1,222 @@
+package checker
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/s2"
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/app/config"
+	"matching-engine/internal/collections"
+	"matching-engine/internal/geo"
+	"matching-engine/internal/geo/downsampling"
+	"matching-engine/internal/model"
+)
+
+// DefaultCorridorCheckerBufferMeters widens the corridor beyond the walking radius and the detour of the driver,
+// covering the error of the downsampling and the riders reaching the route by other means
+const DefaultCorridorCheckerBufferMeters = 200.0
+
+// RoutePlanner plans the driving route of an offer through its current path points
+type RoutePlanner interface {
+	PlanRoute(offer *model.Offer) (*model.Polyline, error)
+}
+
+// CorridorChecker rejects the pairs whose rider source or destination is out of reach of the downsampled route
+// of the driver, or whose destination is only reached before the source along the route.
+// The route is the stored route polyline of the offer, or the one planned through its path points.
+// The pairs are let through when the route of the offer can't be planned, as the straight lines between
+// the path points may leave the road followed by the driver.
+// The reach is the walking radius of the rider plus the distance the driver can leave the route during
+// half the detour, at the fixed speed, and the buffer.
+type CorridorChecker struct {
+	downSampler  downsampling.RouteDownSampler
+	planner      RoutePlanner
+	bufferMeters float64
+	speedKmh     float64
+	routes       *collections.SyncMap[string, *corridorRoute]
+}
+
+// corridorRoute is the downsampled route of an offer, with the distance from its start to every point.
+// It has no points when the route of the offer is unknown.
+type corridorRoute struct {
+	pathSignature string
+	points        []s2.Point
+	positions     []float64
+}
+
+// NewCorridorChecker creates a corridor checker configured from the environment, the buffer being at least minBufferMeters
+func NewCorridorChecker(downSampler downsampling.RouteDownSampler, planner RoutePlanner, minBufferMeters float64) Checker {
+	return NewCorridorCheckerWithConfig(
+		downSampler,
+		planner,
+		math.Max(config.GetEnvFloat("CORRIDOR_CHECKER_BUFFER_METERS", DefaultCorridorCheckerBufferMeters), minBufferMeters),
+		config.GetEnvFloat("FIXED_SPEED_KMH", 27),
+	)
+}
+
+// NewCorridorCheckerWithConfig creates a corridor checker with the given buffer and driving speed,
+// the offers without a stored route are let through when planner is nil
+func NewCorridorCheckerWithConfig(downSampler downsampling.RouteDownSampler, planner RoutePlanner, bufferMeters, speedKmh float64) Checker {
+	return &CorridorChecker{
+		downSampler:  downSampler,
+		planner:      planner,
+		bufferMeters: bufferMeters,
+		speedKmh:     speedKmh,
+		routes:       collections.NewSyncMap[string, *corridorRoute](),
+	}
+}
+
+// Check checks that the rider can be picked up and then dropped off along the route of the driver
+func (c *CorridorChecker) Check(offer *model.Offer, request *model.Request) (bool, error) {
+	if offer == nil || request == nil {
+		return false, fmt.Errorf("offer or request is nil")
+	}
+	route, err := c.route(offer)
+	if err != nil {
+		return false, err
+	}
+	if len(route.points) == 0 {
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Str("request_id", request.ID()).
+			Msg("no route for the offer, the rider is not checked against its corridor")
+		return true, nil
+	}
+
+	reachMeters := request.MaxWalkingDurationMinutes().Seconds()*geo.WalkingSpeedMPS +
+		offer.DetourDurationMinutes().Hours()*c.speedKmh*1000/2 +
+		c.bufferMeters
+
+	pickupPosition, ok := route.firstPosition(s2PointOf(request.Source()), reachMeters)
+	// The driver of a flexible source may start anywhere within its driving time from the start of the route
+	if !ok && offer.HasFlexibleSource() {
+		flexibleReachMeters := reachMeters + offer.FlexibleSourceDuration().Hours()*c.speedKmh*1000
+		ok = distanceMeters(route.points[0], s2PointOf(request.Source())) <= flexibleReachMeters
+		pickupPosition = 0
+	}
+	if !ok {
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Str("request_id", request.ID()).
+			Msg("rider source is out of the corridor of the route")
+		return false, nil
+	}
+
+	dropoffPosition, ok := route.lastPosition(s2PointOf(request.Destination()), reachMeters)
+	if !ok {
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Str("request_id", request.ID()).
+			Msg("rider destination is out of the corridor of the route")
+		return false, nil
+	}
+	if dropoffPosition < pickupPosition {
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Str("request_id", request.ID()).
+			Msg("rider travels against the direction of the route")
+		return false, nil
+	}
+	return true, nil
+}
+
+// route returns the downsampled route of the offer, it is planned and downsampled again once the path
+// of the offer changed. The route has no points when the offer has no stored route and it can't be planned.
+func (c *CorridorChecker) route(offer *model.Offer) (*corridorRoute, error) {
+	signature := model.PathSignature(offer.PathPoints())
+	if route, exists := c.routes.Get(offer.ID()); exists && route.pathSignature == signature {
+		return route, nil
+	}
+
+	routeLine := c.routeLineString(offer)
+	if len(routeLine) == 0 {
+		route := &corridorRoute{pathSignature: signature}
+		c.routes.Set(offer.ID(), route)
+		return route, nil
+	}
+	line, err := c.downSampler.DownSample(routeLine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to downsample the route of offer %s: %w", offer.ID(), err)
+	}
+	route := &corridorRoute{
+		pathSignature: signature,
+		points:        make([]s2.Point, len(line)),
+		positions:     make([]float64, len(line)),
+	}
+	for i := range line {
+		route.points[i] = s2PointOf(&line[i])
+		if i > 0 {
+			route.positions[i] = route.positions[i-1] + distanceMeters(route.points[i-1], route.points[i])
+		}
+	}
+	c.routes.Set(offer.ID(), route)
+	return route, nil
+}
+
+// firstPosition returns the distance along the route to the projection of the point on the first segment within reach
+func (r *corridorRoute) firstPosition(point s2.Point, reachMeters float64) (float64, bool) {
+	if len(r.points) == 1 {
+		return 0, distanceMeters(r.points[0], point) <= reachMeters
+	}
+	for i := 0; i < len(r.points)-1; i++ {
+		if position, ok := r.segmentPosition(i, point, reachMeters); ok {
+			return position, true
+		}
+	}
+	return 0, false
+}
+
+// lastPosition returns the distance along the route to the projection of the point on the last segment within reach
+func (r *corridorRoute) lastPosition(point s2.Point, reachMeters float64) (float64, bool) {
+	if len(r.points) == 1 {
+		return 0, distanceMeters(r.points[0], point) <= reachMeters
+	}
+	for i := len(r.points) - 2; i >= 0; i-- {
+		if position, ok := r.segmentPosition(i, point, reachMeters); ok {
+			return position, true
+		}
+	}
+	return 0, false
+}
+
+// segmentPosition returns the distance along the route to the projection of the point on the i-th segment,
+// when the point is within reach of the segment
+func (r *corridorRoute) segmentPosition(i int, point s2.Point, reachMeters float64) (float64, bool) {
+	a, b := r.points[i], r.points[i+1]
+	if s2.DistanceFromSegment(point, a, b).Radians()*geo.EarthRadiusInMeters > reachMeters {
+		return 0, false
+	}
+	return r.positions[i] + distanceMeters(a, s2.Project(point, a, b)), true
+}
+
+// routeLineString returns the stored route of the offer while it follows the path, or plans it through the path points
+func (c *CorridorChecker) routeLineString(offer *model.Offer) model.LineString {
+	polyline := offer.RoutePolyline()
+	if polyline == nil && c.planner != nil {
+		planned, err := c.planner.PlanRoute(offer)
+		if err != nil {
+			log.Warn().Err(err).Str("offer_id", offer.ID()).Msg("failed to plan the route of the corridor, its riders are let through")
+			return nil
+		}
+		polyline = planned
+	}
+	if polyline == nil {
+		return nil
+	}
+	coordinates, err := polyline.Coordinates()
+	if err != nil {
+		log.Warn().Err(err).Str("offer_id", offer.ID()).Msg("failed to decode the route of the corridor, its riders are let through")
+		return nil
+	}
+	return coordinates
+}
+
+func s2PointOf(coordinate *model.Coordinate) s2.Point {
+	return s2.PointFromLatLng(s2.LatLngFromDegrees(coordinate.Lat(), coordinate.Lng()))
+}
+
+func distanceMeters(a, b s2.Point) float64 {
+	return a.Distance(b).Radians() * geo.EarthRadiusInMeters
+}
@@ -7,6 +7,8 @@ import (
 	"matching-engine/internal/adapter/routing"
 	"matching-engine/internal/model"
 	"matching-engine/internal/service/pickupdropoffservice"
+	"matching-engine/internal/service/pickupdropoffservice/pickupdropoffcache"
+	"time"
 )
 
 type DetourTimeChecker struct {
@@ -29,8 +31,24 @@ func (dtc *DetourTimeChecker) Check(offer *model.Offer, request *model.Request)
 		return false, fmt.Errorf("failed to get pickup and dropoff points: %w", err)
 	}
 
-	waypoints := []model.Coordinate{*offer.Source(), *value.Pickup().Coordinate(), *value.Dropoff().Coordinate(), *offer.Destination()}
-	params, err := model.NewRouteParams(waypoints, offer.DepartureTime())
+	valid, err := dtc.checkFromSource(offer, request, value, tripStart(offer), offer.TripStartTime(offer.Path()))
+	if err != nil || valid {
+		return valid, err
+	}
+	// The driver of a flexible source offer may start elsewhere
+	for _, source := range value.SourceCandidates() {
+		valid, err = dtc.checkFromSource(offer, request, value, source.Coordinate(), offer.DepartureTime().Add(source.AccessDrivingDuration()))
+		if err != nil || valid {
+			return valid, err
+		}
+	}
+	return false, nil
+}
+
+// checkFromSource checks the detour and the time windows of the trip leaving the given source at start
+func (dtc *DetourTimeChecker) checkFromSource(offer *model.Offer, request *model.Request, value *pickupdropoffcache.Value, source *model.Coordinate, start time.Time) (bool, error) {
+	waypoints := []model.Coordinate{*source, *value.Pickup().Coordinate(), *value.Dropoff().Coordinate(), *offer.Destination()}
+	params, err := model.NewRouteParams(waypoints, start)
 	if err != nil {
 		return false, fmt.Errorf("failed to create route params: %w", err)
 	}
@@ -43,7 +61,7 @@ func (dtc *DetourTimeChecker) Check(offer *model.Offer, request *model.Request)
 	pickupDuration := durations[1]
 	dropoffDuration := durations[2]
 
-	if offer.DepartureTime().Add(pickupDuration).Before(request.EarliestDepartureTime().Add(value.Pickup().WalkingDuration())) {
+	if start.Add(pickupDuration).Before(request.EarliestDepartureTime().Add(value.Pickup().AccessDuration())) {
 		log.Debug().
 			Str("offer_id", offer.ID()).
 			Str("request_id", request.ID()).
@@ -52,7 +70,7 @@ func (dtc *DetourTimeChecker) Check(offer *model.Offer, request *model.Request)
 		return false, nil
 	}
 
-	if offer.DepartureTime().Add(dropoffDuration).After(request.LatestArrivalTime().Add(-value.Dropoff().WalkingDuration())) {
+	if start.Add(dropoffDuration).After(request.LatestArrivalTime().Add(-value.Dropoff().AccessDuration())) {
 		log.Debug().
 			Str("offer_id", offer.ID()).
 			Str("request_id", request.ID()).
@@ -60,8 +78,7 @@ func (dtc *DetourTimeChecker) Check(offer *model.Offer, request *model.Request)
 		return false, nil
 	}
 	// Check if the detour time is within the acceptable range
-	totalTripDuration := durations[3]
-	if totalTripDuration > offer.MaxEstimatedArrivalTime().Sub(offer.DepartureTime()) {
+	if start.Add(durations[3]).After(offer.MaxEstimatedArrivalTime()) {
 		log.Debug().
 			Str("offer_id", offer.ID()).
 			Str("request_id", request.ID()).
@@ -71,3 +88,12 @@ func (dtc *DetourTimeChecker) Check(offer *model.Offer, request *model.Request)
 
 	return true, nil
 }
+
+// tripStart returns the start of the offer path, which is the source unless the driver of a flexible source offer
+// already chose to start elsewhere
+func tripStart(offer *model.Offer) *model.Coordinate {
+	if path := offer.Path(); len(path) > 0 {
+		return path[0].Coordinate()
+	}
+	return offer.Source()
+}
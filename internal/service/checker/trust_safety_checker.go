@@ -0,0 +1,88 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/model"
+	"matching-engine/internal/repository"
+)
+
+type TrustSafetyChecker struct {
+	trustSafetyRepo repository.TrustSafetyRepo
+}
+
+// NewTrustSafetyChecker creates a new TrustSafetyChecker
+func NewTrustSafetyChecker(trustSafetyRepo repository.TrustSafetyRepo) Checker {
+	return &TrustSafetyChecker{
+		trustSafetyRepo: trustSafetyRepo,
+	}
+}
+
+// Check checks that the request does not break any block or rating threshold of the driver
+// and of the riders already matched to the offer
+func (tsc *TrustSafetyChecker) Check(offer *model.Offer, request *model.Request) (bool, error) {
+	if offer == nil || request == nil {
+		return false, fmt.Errorf("offer or request is nil")
+	}
+	ctx := context.Background()
+
+	blocked, err := tsc.trustSafetyRepo.IsBlocked(ctx, offer.UserID(), request.UserID())
+	if err != nil {
+		return false, fmt.Errorf("failed to check block between driver and rider: %w", err)
+	}
+	if blocked {
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Str("request_id", request.ID()).
+			Msg("driver and rider have blocked or reported each other")
+		return false, nil
+	}
+
+	meetsRating, err := tsc.meetsMinimumRiderRating(ctx, offer, request)
+	if err != nil {
+		return false, err
+	}
+	if !meetsRating {
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Str("request_id", request.ID()).
+			Msg("rider rating is below the minimum rating required by the driver")
+		return false, nil
+	}
+
+	// Check the request against the riders already sharing the car
+	for _, matchedRequest := range offer.MatchedRequests() {
+		if matchedRequest == nil {
+			continue
+		}
+		blocked, err := tsc.trustSafetyRepo.IsBlocked(ctx, matchedRequest.UserID(), request.UserID())
+		if err != nil {
+			return false, fmt.Errorf("failed to check block between riders: %w", err)
+		}
+		if blocked {
+			log.Debug().
+				Str("matched_request_id", matchedRequest.ID()).
+				Str("request_id", request.ID()).
+				Msg("riders have blocked or reported each other")
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// meetsMinimumRiderRating checks the rider rating against the threshold of the driver, unrated riders never meet a threshold
+func (tsc *TrustSafetyChecker) meetsMinimumRiderRating(ctx context.Context, offer *model.Offer, request *model.Request) (bool, error) {
+	minimumRating, found, err := tsc.trustSafetyRepo.GetMinimumRiderRating(ctx, offer.UserID())
+	if err != nil {
+		return false, fmt.Errorf("failed to get minimum rider rating: %w", err)
+	}
+	if !found {
+		return true, nil
+	}
+	rating, found, err := tsc.trustSafetyRepo.GetRiderRating(ctx, request.UserID())
+	if err != nil {
+		return false, fmt.Errorf("failed to get rider rating: %w", err)
+	}
+	return found && rating >= minimumRating, nil
+}
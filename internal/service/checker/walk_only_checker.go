@@ -0,0 +1,39 @@
+package checker
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice"
+)
+
+// WalkOnlyChecker rejects the pairs whose pickup and dropoff collapse to the same place,
+// as the rider would walk to the car and leave it right away
+type WalkOnlyChecker struct {
+	selector pickupdropoffservice.PickupDropoffSelectorInterface
+	detector *pickupdropoffservice.WalkOnlyDetector
+}
+
+func NewWalkOnlyChecker(selector pickupdropoffservice.PickupDropoffSelectorInterface, detector *pickupdropoffservice.WalkOnlyDetector) Checker {
+	return &WalkOnlyChecker{
+		selector: selector,
+		detector: detector,
+	}
+}
+
+// Check checks that the pickup and the dropoff selected for the pair are apart
+func (c *WalkOnlyChecker) Check(offer *model.Offer, request *model.Request) (bool, error) {
+	value, err := c.selector.GetPickupDropoffPointsAndDurations(request, offer)
+	if err != nil {
+		return false, fmt.Errorf("failed to get pickup and dropoff points: %w", err)
+	}
+
+	if c.detector.IsDegenerate(value.Pickup(), value.Dropoff()) {
+		log.Debug().
+			Str("offer_id", offer.ID()).
+			Str("request_id", request.ID()).
+			Msg("pickup and dropoff collapse to the same place")
+		return false, nil
+	}
+	return true, nil
+}
@@ -8,9 +8,10 @@ import (
 )
 
 type CandidateIterator struct {
-	offers   []*model.Offer
-	requests []*model.Request
-	checker  checker.Checker
+	offers          []*model.Offer
+	requests        []*model.Request
+	requestsByOffer [][]*model.Request // Requests to check against each offer, every request when nil
+	checker         checker.Checker
 }
 
 func NewCandidateIterator(offers []*model.Offer, requests []*model.Request, checker checker.Checker) *CandidateIterator {
@@ -21,10 +22,20 @@ func NewCandidateIterator(offers []*model.Offer, requests []*model.Request, chec
 	}
 }
 
+// NewIndexedCandidateIterator creates an iterator checking each offer against its own requests only,
+// requestsByOffer[i] being the requests to check against offers[i]
+func NewIndexedCandidateIterator(offers []*model.Offer, requestsByOffer [][]*model.Request, checker checker.Checker) *CandidateIterator {
+	return &CandidateIterator{
+		offers:          offers,
+		requestsByOffer: requestsByOffer,
+		checker:         checker,
+	}
+}
+
 func (ci *CandidateIterator) Candidates() iter.Seq2[*model.MatchCandidate, error] {
 	return func(yield func(*model.MatchCandidate, error) bool) {
-		for _, offer := range ci.offers {
-			for _, request := range ci.requests {
+		for i, offer := range ci.offers {
+			for _, request := range ci.requestsOf(i) {
 				// Check if the offer and request can be matched
 				isPotential, err := ci.checker.Check(offer, request)
 				if err != nil {
@@ -44,3 +55,11 @@ func (ci *CandidateIterator) Candidates() iter.Seq2[*model.MatchCandidate, error
 		}
 	}
 }
+
+// requestsOf returns the requests to check against the offer at the given index
+func (ci *CandidateIterator) requestsOf(offerIndex int) []*model.Request {
+	if ci.requestsByOffer == nil {
+		return ci.requests
+	}
+	return ci.requestsByOffer[offerIndex]
+}
@@ -0,0 +1,229 @@
+package earlypruning
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/app/config"
+	"matching-engine/internal/geo"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/checker"
+)
+
+const (
+	// DefaultCorridorBufferMeters widens the corridor of every offer beyond the walking radius of the riders,
+	// to keep the riders reaching the route by other means, such as a hub or transit
+	DefaultCorridorBufferMeters = 1000.0
+	// DefaultCorridorDetourSpeedMPS is the driving speed turning the detour of an offer into the distance the
+	// driver can leave the route, the detour being split between leaving and coming back
+	DefaultCorridorDetourSpeedMPS = 15.0
+
+	// corridorChunkMeters bounds the span of the boxes the corridor is split into, so a diagonal route
+	// is not indexed as one box covering the whole area
+	corridorChunkMeters = 2000.0
+	// corridorTreeDimension indexes the latitude, the longitude and the time in minutes
+	corridorTreeDimension  = 3
+	corridorMinNodeEntries = 25
+	corridorMaxNodeEntries = 50
+
+	metersPerDegree = math.Pi * geo.EarthRadiusInMeters / 180
+)
+
+// SpatialCandidateGenerator indexes the corridors of the offers in an R-tree over space and time,
+// and only checks the pairs whose rider source and destination both fall in the corridor of the offer
+// while it is on the road. The corridor is the stored route of the offer buffered by the distance
+// the driver can detour and the walking radius of the rider. The offers without a stored route are
+// paired with every request, as the straight lines between their path points may leave the road.
+type SpatialCandidateGenerator struct {
+	checker        checker.Checker
+	bufferMeters   float64
+	detourSpeedMPS float64
+}
+
+// corridorBox is a part of the corridor of an offer, on the road during a time range
+type corridorBox struct {
+	offerIndex int
+	rect       rtreego.Rect
+}
+
+// Bounds implements the rtreego.Spatial interface
+func (b *corridorBox) Bounds() rtreego.Rect {
+	return b.rect
+}
+
+// NewSpatialCandidateGenerator creates a spatial candidate generator configured from the environment,
+// the buffer being at least minBufferMeters
+func NewSpatialCandidateGenerator(checker checker.Checker, minBufferMeters float64) CandidateGenerator {
+	return NewSpatialCandidateGeneratorWithConfig(
+		checker,
+		math.Max(config.GetEnvFloat("CANDIDATE_CORRIDOR_BUFFER_METERS", DefaultCorridorBufferMeters), minBufferMeters),
+		config.GetEnvFloat("CANDIDATE_CORRIDOR_DETOUR_SPEED_MPS", DefaultCorridorDetourSpeedMPS),
+	)
+}
+
+// NewSpatialCandidateGeneratorWithConfig creates a spatial candidate generator with the given corridor buffer and detour speed
+func NewSpatialCandidateGeneratorWithConfig(checker checker.Checker, bufferMeters, detourSpeedMPS float64) CandidateGenerator {
+	return &SpatialCandidateGenerator{
+		checker:        checker,
+		bufferMeters:   bufferMeters,
+		detourSpeedMPS: detourSpeedMPS,
+	}
+}
+
+func (g *SpatialCandidateGenerator) GenerateCandidates(offers []*model.Offer, requests []*model.Request) (*CandidateIterator, error) {
+	if len(offers) == 0 || len(requests) == 0 {
+		return nil, fmt.Errorf("no offers or requests")
+	}
+
+	tree, unrouted := g.indexCorridors(offers)
+
+	requestsByOffer := make([][]*model.Request, len(offers))
+	pairs := 0
+	for _, request := range requests {
+		offerIndexes := append(g.searchOffers(tree, request), unrouted...)
+		sort.Ints(offerIndexes)
+		for _, offerIndex := range offerIndexes {
+			requestsByOffer[offerIndex] = append(requestsByOffer[offerIndex], request)
+			pairs++
+		}
+	}
+	log.Info().
+		Int("pairs", pairs).
+		Int("all_pairs", len(offers)*len(requests)).
+		Int("unrouted_offers", len(unrouted)).
+		Msg("Spatial index selected the candidate pairs to check")
+
+	return NewIndexedCandidateIterator(offers, requestsByOffer, g.checker), nil
+}
+
+// indexCorridors inserts the boxes of the corridor of every offer in a new R-tree,
+// and returns the indexes of the offers without a stored route
+func (g *SpatialCandidateGenerator) indexCorridors(offers []*model.Offer) (*rtreego.Rtree, []int) {
+	tree := rtreego.NewTree(corridorTreeDimension, corridorMinNodeEntries, corridorMaxNodeEntries)
+	unrouted := make([]int, 0)
+	for i, offer := range offers {
+		corridor := offerCorridor(offer)
+		if len(corridor) == 0 {
+			unrouted = append(unrouted, i)
+			continue
+		}
+		start, end := offerTimeRange(offer)
+		buffer := g.bufferMeters + offer.DetourDurationMinutes().Seconds()/2*g.detourSpeedMPS
+
+		for _, chunk := range chunkLineString(corridor) {
+			tree.Insert(&corridorBox{offerIndex: i, rect: newCorridorRect(chunk, buffer, start, end)})
+		}
+
+		// The driver of a flexible source may start anywhere within its driving time from the source
+		if offer.HasFlexibleSource() {
+			flexibleBuffer := buffer + offer.FlexibleSourceDuration().Seconds()*g.detourSpeedMPS
+			tree.Insert(&corridorBox{offerIndex: i, rect: newCorridorRect(corridor[:1], flexibleBuffer, start, end)})
+		}
+	}
+	return tree, unrouted
+}
+
+// searchOffers returns the indexes of the offers whose corridor holds both the source and the destination
+// of the request while the request can be served, in the order of the offers
+func (g *SpatialCandidateGenerator) searchOffers(tree *rtreego.Rtree, request *model.Request) []int {
+	walkingRadius := request.MaxWalkingDurationMinutes().Seconds() * geo.WalkingSpeedMPS
+	start, end := request.EarliestDepartureTime(), request.LatestArrivalTime()
+
+	sourceOffers := make(map[int]bool)
+	sourceRect := newCorridorRect(model.LineString{*request.Source()}, walkingRadius, start, end)
+	for _, spatial := range tree.SearchIntersect(sourceRect) {
+		sourceOffers[spatial.(*corridorBox).offerIndex] = true
+	}
+	if len(sourceOffers) == 0 {
+		return nil
+	}
+
+	destinationRect := newCorridorRect(model.LineString{*request.Destination()}, walkingRadius, start, end)
+	matched := make(map[int]bool)
+	offerIndexes := make([]int, 0)
+	for _, spatial := range tree.SearchIntersect(destinationRect) {
+		offerIndex := spatial.(*corridorBox).offerIndex
+		if sourceOffers[offerIndex] && !matched[offerIndex] {
+			matched[offerIndex] = true
+			offerIndexes = append(offerIndexes, offerIndex)
+		}
+	}
+	sort.Ints(offerIndexes)
+	return offerIndexes
+}
+
+// offerCorridor returns the stored route of the offer while it follows the path, or nothing without a stored route
+func offerCorridor(offer *model.Offer) model.LineString {
+	if polyline := offer.RoutePolyline(); polyline != nil {
+		if coordinates, err := polyline.Coordinates(); err == nil && len(coordinates) > 0 {
+			return coordinates
+		}
+	}
+	return nil
+}
+
+// offerTimeRange returns when the driver of the offer is on the road, detour included
+func offerTimeRange(offer *model.Offer) (time.Time, time.Time) {
+	start, end := offer.DepartureTime(), offer.MaxEstimatedArrivalTime()
+	if path := offer.PathPoints(); len(path) > 0 {
+		if arrival := path[len(path)-1].ExpectedArrivalTime().Add(offer.DetourDurationMinutes()); arrival.After(end) {
+			end = arrival
+		}
+	}
+	return start, end
+}
+
+// chunkLineString splits the line into consecutive parts spanning at most corridorChunkMeters,
+// the parts share their boundary points so the corridor has no gap
+func chunkLineString(line model.LineString) []model.LineString {
+	if len(line) <= 1 {
+		return []model.LineString{line}
+	}
+	chunks := make([]model.LineString, 0)
+	chunkStart := 0
+	for i := 1; i < len(line); i++ {
+		if spanMeters(line[chunkStart:i+1]) > corridorChunkMeters && i-1 > chunkStart {
+			chunks = append(chunks, line[chunkStart:i])
+			chunkStart = i - 1
+		}
+	}
+	return append(chunks, line[chunkStart:])
+}
+
+// spanMeters returns the largest side of the bounding box of the line, in meters
+func spanMeters(line model.LineString) float64 {
+	minLat, maxLat, minLng, maxLng := boundingBox(line)
+	latSpan := (maxLat - minLat) * metersPerDegree
+	lngSpan := (maxLng - minLng) * metersPerDegree * math.Cos((minLat+maxLat)/2*math.Pi/180)
+	return math.Max(latSpan, lngSpan)
+}
+
+// newCorridorRect returns the bounding box of the line widened by the buffer, during the time range
+func newCorridorRect(line model.LineString, bufferMeters float64, start, end time.Time) rtreego.Rect {
+	minLat, maxLat, minLng, maxLng := boundingBox(line)
+	latBuffer := geo.MetersToDegrees(bufferMeters)
+	lngBuffer := latBuffer / math.Max(math.Cos((minLat+maxLat)/2*math.Pi/180), 0.01)
+
+	startMinutes := float64(start.Unix()) / 60
+	endMinutes := math.Max(float64(end.Unix())/60, startMinutes+1)
+	rect, _ := rtreego.NewRectFromPoints(
+		rtreego.Point{minLat - latBuffer, minLng - lngBuffer, startMinutes},
+		rtreego.Point{maxLat + latBuffer, maxLng + lngBuffer, endMinutes},
+	)
+	return rect
+}
+
+func boundingBox(line model.LineString) (minLat, maxLat, minLng, maxLng float64) {
+	minLat, minLng = math.Inf(1), math.Inf(1)
+	maxLat, maxLng = math.Inf(-1), math.Inf(-1)
+	for _, c := range line {
+		minLat, maxLat = math.Min(minLat, c.Lat()), math.Max(maxLat, c.Lat())
+		minLng, maxLng = math.Min(minLng, c.Lng()), math.Max(maxLng, c.Lng())
+	}
+	return minLat, maxLat, minLng, maxLng
+}
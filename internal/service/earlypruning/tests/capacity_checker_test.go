@@ -68,6 +68,66 @@ func TestCapacityChecker_Check(t *testing.T) {
 			expected:    false,
 			expectError: false,
 		},
+		{
+			name: "Wheelchair needed without wheelchair space",
+			offer: model.NewOffer(
+				"offer1", "user1",
+				model.Coordinate{}, model.Coordinate{},
+				time.Now(), 30*time.Minute,
+				3, // capacity
+				*model.NewPreference(enums.Male, false),
+				time.Now().Add(1*time.Hour),
+				0,
+				nil,
+				nil,
+			),
+			request: func() *model.Request {
+				request := model.NewRequest(
+					"request1", "user2",
+					model.Coordinate{}, model.Coordinate{},
+					time.Now(), time.Now().Add(1*time.Hour),
+					10*time.Minute,
+					1, // number of riders
+					*model.NewPreference(enums.Female, false),
+				)
+				request.SetCapacityNeeds(*model.NewCapacity(1, 0, 1, 0))
+				return request
+			}(),
+			expected:    false,
+			expectError: false,
+		},
+		{
+			name: "Luggage and child seat within vehicle capacity",
+			offer: func() *model.Offer {
+				offer := model.NewOffer(
+					"offer1", "user1",
+					model.Coordinate{}, model.Coordinate{},
+					time.Now(), 30*time.Minute,
+					3, // capacity
+					*model.NewPreference(enums.Male, false),
+					time.Now().Add(1*time.Hour),
+					0,
+					nil,
+					nil,
+				)
+				offer.SetVehicleCapacity(*model.NewCapacity(3, 2, 0, 1))
+				return offer
+			}(),
+			request: func() *model.Request {
+				request := model.NewRequest(
+					"request1", "user2",
+					model.Coordinate{}, model.Coordinate{},
+					time.Now(), time.Now().Add(1*time.Hour),
+					10*time.Minute,
+					2, // number of riders
+					*model.NewPreference(enums.Female, false),
+				)
+				request.SetCapacityNeeds(*model.NewCapacity(2, 2, 0, 1))
+				return request
+			}(),
+			expected:    true,
+			expectError: false,
+		},
 		{
 			name:        "Nil offer",
 			offer:       nil,
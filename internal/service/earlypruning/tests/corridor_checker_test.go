@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"errors"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/geo/downsampling"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/checker"
+	"testing"
+	"time"
+)
+
+func corridorCoordinate(t *testing.T, lat, lng float64) model.Coordinate {
+	c, err := model.NewCoordinate(lat, lng)
+	if err != nil {
+		t.Fatalf("invalid coordinate: %v", err)
+	}
+	return *c
+}
+
+// newCorridorOffer creates an offer driving east then north, with its route stored when withRoute is set
+func newCorridorOffer(t *testing.T, departure time.Time, withRoute bool) *model.Offer {
+	source, corner, destination := corridorCoordinate(t, 30.0, 31.0), corridorCoordinate(t, 30.0, 31.1), corridorCoordinate(t, 30.1, 31.1)
+	path := []model.PathPoint{
+		*model.NewPathPoint(source, enums.Source, departure, nil, 0),
+		*model.NewPathPoint(destination, enums.Destination, departure.Add(time.Hour), nil, 0),
+	}
+	offer := model.NewOffer("offer", "driver", source, destination, departure, 0, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, path, nil)
+	if withRoute {
+		route := model.LineString{source, corridorCoordinate(t, 30.0, 31.05), corner, corridorCoordinate(t, 30.05, 31.1), destination}
+		polyline, err := model.NewPolylineFromCoordinates(route)
+		if err != nil {
+			t.Fatalf("failed to encode the route: %v", err)
+		}
+		offer.SetRoutePolyline(polyline)
+	}
+	return offer
+}
+
+// pathRoutePlanner plans the straight lines between the path points of the offers
+type pathRoutePlanner struct {
+	calls int
+	err   error
+}
+
+func (p *pathRoutePlanner) PlanRoute(offer *model.Offer) (*model.Polyline, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	line := make(model.LineString, len(offer.PathPoints()))
+	for i, point := range offer.PathPoints() {
+		line[i] = *point.Coordinate()
+	}
+	return model.NewPolylineFromCoordinates(line)
+}
+
+func newCorridorRequest(t *testing.T, departure time.Time, source, destination model.Coordinate) *model.Request {
+	return model.NewRequest("request", "rider", source, destination, departure,
+		departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+}
+
+func TestCorridorChecker(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	// 10 minutes of walking and the buffer reach about a kilometer around the route
+	corridorChecker := checker.NewCorridorCheckerWithConfig(downsampling.NewRDPDownSampler(downsampling.WithEpsilonMeters(50)), nil, 200, 27)
+
+	tests := []struct {
+		name        string
+		withRoute   bool
+		flexible    time.Duration
+		source      model.Coordinate
+		destination model.Coordinate
+		expected    bool
+	}{
+		{
+			name:        "rider along the middle of the route",
+			withRoute:   true,
+			source:      corridorCoordinate(t, 30.001, 31.05),
+			destination: corridorCoordinate(t, 30.05, 31.101),
+			expected:    true,
+		},
+		{
+			name:        "rider far from both legs of the route",
+			withRoute:   true,
+			source:      corridorCoordinate(t, 30.05, 31.05),
+			destination: corridorCoordinate(t, 30.05, 31.101),
+			expected:    false,
+		},
+		{
+			name:        "rider travelling against the route",
+			withRoute:   true,
+			source:      corridorCoordinate(t, 30.05, 31.101),
+			destination: corridorCoordinate(t, 30.001, 31.05),
+			expected:    false,
+		},
+		{
+			// the straight line between the source and the destination is far from the road followed by the driver
+			name:        "offer without a stored route lets the rider through",
+			withRoute:   false,
+			source:      corridorCoordinate(t, 30.001, 31.05),
+			destination: corridorCoordinate(t, 30.05, 31.101),
+			expected:    true,
+		},
+		{
+			name:        "rider before the start of the route",
+			withRoute:   true,
+			source:      corridorCoordinate(t, 30.0, 30.97),
+			destination: corridorCoordinate(t, 30.05, 31.101),
+			expected:    false,
+		},
+		{
+			name:        "rider before the start of the route of a flexible source",
+			withRoute:   true,
+			flexible:    10 * time.Minute,
+			source:      corridorCoordinate(t, 30.0, 30.97),
+			destination: corridorCoordinate(t, 30.05, 31.101),
+			expected:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer := newCorridorOffer(t, departure, tt.withRoute)
+			offer.SetFlexibleSourceDuration(tt.flexible)
+			result, err := corridorChecker.Check(offer, newCorridorRequest(t, departure, tt.source, tt.destination))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCorridorChecker_RouteOfPreviousPath(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	planner := &pathRoutePlanner{}
+	corridorChecker := checker.NewCorridorCheckerWithConfig(downsampling.NewTimeThresholdDownSampler(), planner, 200, 27)
+	offer := newCorridorOffer(t, departure, true)
+	request := newCorridorRequest(t, departure, corridorCoordinate(t, 30.001, 31.05), corridorCoordinate(t, 30.05, 31.101))
+
+	if ok, err := corridorChecker.Check(offer, request); err != nil || !ok {
+		t.Fatalf("expected the rider along the stored route to pass, got %v, %v", ok, err)
+	}
+
+	// A rider matched at the start changes the path, the stored route no longer applies
+	path := offer.Path()
+	offer.SetPath([]model.PathPoint{
+		path[0],
+		*model.NewPathPoint(corridorCoordinate(t, 30.0001, 31.0001), enums.Pickup, departure, nil, 0),
+		path[1],
+	})
+	if ok, err := corridorChecker.Check(offer, request); err != nil || ok {
+		t.Fatalf("expected the route to be planned again through the new path, got %v, %v", ok, err)
+	}
+	if planner.calls != 1 {
+		t.Errorf("expected the route of the new path to be planned once, got %d calls", planner.calls)
+	}
+}
+
+func TestCorridorChecker_UnplannedRouteLetsThrough(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	planner := &pathRoutePlanner{err: errors.New("routing engine unavailable")}
+	corridorChecker := checker.NewCorridorCheckerWithConfig(downsampling.NewTimeThresholdDownSampler(), planner, 200, 27)
+	offer := newCorridorOffer(t, departure, false)
+
+	for _, request := range []*model.Request{
+		newCorridorRequest(t, departure, corridorCoordinate(t, 30.05, 31.05), corridorCoordinate(t, 30.05, 31.101)),
+		newCorridorRequest(t, departure, corridorCoordinate(t, 31.0, 32.0), corridorCoordinate(t, 31.1, 32.1)),
+	} {
+		if ok, err := corridorChecker.Check(offer, request); err != nil || !ok {
+			t.Errorf("expected the rider to be let through without a route, got %v, %v", ok, err)
+		}
+	}
+	if planner.calls != 1 {
+		t.Errorf("expected the failed route to be planned once per path, got %d calls", planner.calls)
+	}
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/earlypruning"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSpatialCandidateGenerator_GenerateCandidates(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	coordinate := func(lat, lng float64) model.Coordinate {
+		c, err := model.NewCoordinate(lat, lng)
+		if err != nil {
+			t.Fatalf("invalid coordinate: %v", err)
+		}
+		return *c
+	}
+
+	// The offer drives east along the 30th parallel for about 40 km, during an hour
+	source, destination := coordinate(30.0, 31.0), coordinate(30.0, 31.4)
+	path := []model.PathPoint{
+		*model.NewPathPoint(source, enums.Source, departure, nil, 0),
+		*model.NewPathPoint(destination, enums.Destination, departure.Add(time.Hour), nil, 0),
+	}
+	offer := model.NewOffer("offer1", "driver1", source, destination, departure, 10*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, path, nil)
+	setStraightRoute(t, offer)
+
+	newRequest := func(id string, source, destination model.Coordinate, earliestDeparture time.Time) *model.Request {
+		return model.NewRequest(id, "rider_"+id, source, destination, earliestDeparture,
+			earliestDeparture.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+	}
+	requests := []*model.Request{
+		// Along the route while the driver is on the road
+		newRequest("along", coordinate(30.01, 31.1), coordinate(29.99, 31.3), departure),
+		// Along the route, a day later
+		newRequest("next_day", coordinate(30.01, 31.1), coordinate(29.99, 31.3), departure.Add(24*time.Hour)),
+		// From the route to 20 km north of it
+		newRequest("leaves_route", coordinate(30.01, 31.1), coordinate(30.2, 31.3), departure),
+		// Far from the route
+		newRequest("far", coordinate(31.0, 32.0), coordinate(31.1, 32.1), departure),
+	}
+
+	generator := earlypruning.NewSpatialCandidateGeneratorWithConfig(&testChecker{shouldMatch: true}, 1000, 15)
+	iterator, err := generator.GenerateCandidates([]*model.Offer{offer}, requests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	candidates := make([]string, 0)
+	for candidate, err := range iterator.Candidates() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		candidates = append(candidates, candidate.Request().ID())
+	}
+	sort.Strings(candidates)
+	if len(candidates) != 1 || candidates[0] != "along" {
+		t.Errorf("expected only the request along the route, got %v", candidates)
+	}
+}
+
+func TestSpatialCandidateGenerator_OfferWithoutRoute(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	source, _ := model.NewCoordinate(30.0, 31.0)
+	destination, _ := model.NewCoordinate(30.0, 31.4)
+	offer := model.NewOffer("offer1", "driver1", *source, *destination, departure, 10*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, nil, nil)
+
+	// Far from the straight line between the source and the destination, the road may still pass by
+	riderSource, _ := model.NewCoordinate(30.2, 31.1)
+	riderDestination, _ := model.NewCoordinate(30.2, 31.3)
+	request := model.NewRequest("request1", "rider1", *riderSource, *riderDestination, departure,
+		departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+
+	generator := earlypruning.NewSpatialCandidateGeneratorWithConfig(&testChecker{shouldMatch: true}, 1000, 15)
+	iterator, err := generator.GenerateCandidates([]*model.Offer{offer}, []*model.Request{request})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	count := 0
+	for _, err := range iterator.Candidates() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Errorf("expected the offer without a route to be paired with the request, got %d candidates", count)
+	}
+}
+
+// setStraightRoute stores the straight line between the source and the destination as the route of the offer
+func setStraightRoute(t *testing.T, offer *model.Offer) {
+	polyline, err := model.NewPolylineFromCoordinates(model.LineString{*offer.Source(), *offer.Destination()})
+	if err != nil {
+		t.Fatalf("failed to encode the route: %v", err)
+	}
+	offer.SetRoutePolyline(polyline)
+}
+
+func TestSpatialCandidateGenerator_FlexibleSourceWidensTheStart(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	source, _ := model.NewCoordinate(30.0, 31.0)
+	destination, _ := model.NewCoordinate(30.0, 31.4)
+	offer := model.NewOffer("offer1", "driver1", *source, *destination, departure, 0, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, nil, nil)
+	setStraightRoute(t, offer)
+
+	// The rider sets off about 8 km south of the source
+	riderSource, _ := model.NewCoordinate(29.93, 31.0)
+	riderDestination, _ := model.NewCoordinate(30.0, 31.3)
+	request := model.NewRequest("request1", "rider1", *riderSource, *riderDestination, departure,
+		departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+
+	generator := earlypruning.NewSpatialCandidateGeneratorWithConfig(&testChecker{shouldMatch: true}, 1000, 15)
+	countCandidates := func() int {
+		iterator, err := generator.GenerateCandidates([]*model.Offer{offer}, []*model.Request{request})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		count := 0
+		for _, err := range iterator.Candidates() {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			count++
+		}
+		return count
+	}
+
+	if count := countCandidates(); count != 0 {
+		t.Errorf("expected no candidate for a fixed source, got %d", count)
+	}
+	offer.SetFlexibleSourceDuration(10 * time.Minute)
+	if count := countCandidates(); count != 1 {
+		t.Errorf("expected the rider within the flexible source to be a candidate, got %d candidates", count)
+	}
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/checker"
+	"testing"
+	"time"
+)
+
+// MockTrustSafetyRepo is an in-memory implementation of the TrustSafetyRepo interface
+type MockTrustSafetyRepo struct {
+	blocks         map[string]map[string]bool
+	ratings        map[string]float64
+	minimumRatings map[string]float64
+	err            error
+}
+
+// NewMockTrustSafetyRepo creates a new MockTrustSafetyRepo
+func NewMockTrustSafetyRepo() *MockTrustSafetyRepo {
+	return &MockTrustSafetyRepo{
+		blocks:         make(map[string]map[string]bool),
+		ratings:        make(map[string]float64),
+		minimumRatings: make(map[string]float64),
+	}
+}
+
+func (m *MockTrustSafetyRepo) block(userID, otherUserID string) *MockTrustSafetyRepo {
+	if m.blocks[userID] == nil {
+		m.blocks[userID] = make(map[string]bool)
+	}
+	m.blocks[userID][otherUserID] = true
+	return m
+}
+
+// IsBlocked implements the TrustSafetyRepo interface
+func (m *MockTrustSafetyRepo) IsBlocked(ctx context.Context, userID, otherUserID string) (bool, error) {
+	return m.blocks[userID][otherUserID] || m.blocks[otherUserID][userID], m.err
+}
+
+// GetRiderRating implements the TrustSafetyRepo interface
+func (m *MockTrustSafetyRepo) GetRiderRating(ctx context.Context, userID string) (float64, bool, error) {
+	rating, found := m.ratings[userID]
+	return rating, found, m.err
+}
+
+// GetMinimumRiderRating implements the TrustSafetyRepo interface
+func (m *MockTrustSafetyRepo) GetMinimumRiderRating(ctx context.Context, driverUserID string) (float64, bool, error) {
+	rating, found := m.minimumRatings[driverUserID]
+	return rating, found, m.err
+}
+
+func TestTrustSafetyChecker_Check(t *testing.T) {
+	newRequest := func(id, userID string) *model.Request {
+		return model.NewRequest(
+			id, userID,
+			model.Coordinate{}, model.Coordinate{},
+			time.Now(), time.Now().Add(1*time.Hour),
+			10*time.Minute,
+			1,
+			*model.NewPreference(enums.Female, false),
+		)
+	}
+	newOffer := func(matchedRequests ...*model.Request) *model.Offer {
+		return model.NewOffer(
+			"offer1", "driver1",
+			model.Coordinate{}, model.Coordinate{},
+			time.Now(), 30*time.Minute,
+			3,
+			*model.NewPreference(enums.Male, false),
+			time.Now().Add(1*time.Hour),
+			len(matchedRequests),
+			nil,
+			matchedRequests,
+		)
+	}
+
+	tests := []struct {
+		name        string
+		repo        *MockTrustSafetyRepo
+		offer       *model.Offer
+		request     *model.Request
+		expected    bool
+		expectError bool
+	}{
+		{
+			name:     "No blocks and no rating threshold",
+			repo:     NewMockTrustSafetyRepo(),
+			offer:    newOffer(),
+			request:  newRequest("request1", "rider1"),
+			expected: true,
+		},
+		{
+			name:     "Rider blocked the driver",
+			repo:     NewMockTrustSafetyRepo().block("rider1", "driver1"),
+			offer:    newOffer(),
+			request:  newRequest("request1", "rider1"),
+			expected: false,
+		},
+		{
+			name:     "Driver reported the rider",
+			repo:     NewMockTrustSafetyRepo().block("driver1", "rider1"),
+			offer:    newOffer(),
+			request:  newRequest("request1", "rider1"),
+			expected: false,
+		},
+		{
+			name:     "Rider reported a matched rider",
+			repo:     NewMockTrustSafetyRepo().block("rider2", "rider1"),
+			offer:    newOffer(newRequest("request2", "rider2")),
+			request:  newRequest("request1", "rider1"),
+			expected: false,
+		},
+		{
+			name: "Rider rating meets the driver threshold",
+			repo: func() *MockTrustSafetyRepo {
+				repo := NewMockTrustSafetyRepo()
+				repo.minimumRatings["driver1"] = 4.5
+				repo.ratings["rider1"] = 4.5
+				return repo
+			}(),
+			offer:    newOffer(),
+			request:  newRequest("request1", "rider1"),
+			expected: true,
+		},
+		{
+			name: "Rider rating below the driver threshold",
+			repo: func() *MockTrustSafetyRepo {
+				repo := NewMockTrustSafetyRepo()
+				repo.minimumRatings["driver1"] = 4.5
+				repo.ratings["rider1"] = 4.2
+				return repo
+			}(),
+			offer:    newOffer(),
+			request:  newRequest("request1", "rider1"),
+			expected: false,
+		},
+		{
+			name: "Unrated rider with a driver threshold",
+			repo: func() *MockTrustSafetyRepo {
+				repo := NewMockTrustSafetyRepo()
+				repo.minimumRatings["driver1"] = 4.0
+				return repo
+			}(),
+			offer:    newOffer(),
+			request:  newRequest("request1", "rider1"),
+			expected: false,
+		},
+		{
+			name: "Repository error",
+			repo: func() *MockTrustSafetyRepo {
+				repo := NewMockTrustSafetyRepo()
+				repo.err = fmt.Errorf("database unavailable")
+				return repo
+			}(),
+			offer:       newOffer(),
+			request:     newRequest("request1", "rider1"),
+			expected:    false,
+			expectError: true,
+		},
+		{
+			name:        "Nil offer",
+			repo:        NewMockTrustSafetyRepo(),
+			offer:       nil,
+			request:     newRequest("request1", "rider1"),
+			expected:    false,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := checker.NewTrustSafetyChecker(tc.repo).Check(tc.offer, tc.request)
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error but got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected result %v but got %v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"fmt"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/checker"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"matching-engine/internal/service/pickupdropoffservice/pickupdropoffcache"
+	"testing"
+	"time"
+)
+
+func TestWalkOnlyChecker_Check(t *testing.T) {
+	now := time.Now().Add(24 * time.Hour)
+	source, _ := model.NewCoordinate(30.0, 31.0)
+	destination, _ := model.NewCoordinate(30.0, 31.1)
+	pickupCoord, _ := model.NewCoordinate(30.0, 31.05)
+	nearDropoffCoord, _ := model.NewCoordinate(30.0002, 31.05)
+	farDropoffCoord, _ := model.NewCoordinate(30.0, 31.08)
+
+	offer := model.NewOffer("offer1", "user1", *source, *destination, now, 30*time.Minute, 3,
+		*model.NewPreference(enums.Male, false), now.Add(time.Hour), 0, nil, nil)
+	request := model.NewRequest("request1", "user2", *pickupCoord, *farDropoffCoord, now, now.Add(time.Hour),
+		10*time.Minute, 1, *model.NewPreference(enums.Female, false))
+
+	tests := []struct {
+		name          string
+		selectorValue *pickupdropoffcache.Value
+		selectorErr   error
+		expected      bool
+		expectError   bool
+	}{
+		{
+			name: "Pickup and dropoff apart",
+			selectorValue: pickupdropoffcache.NewValue(
+				model.NewPathPoint(*pickupCoord, enums.Pickup, now, nil, 0),
+				model.NewPathPoint(*farDropoffCoord, enums.Dropoff, now, nil, 0),
+			),
+			expected: true,
+		},
+		{
+			name: "Pickup and dropoff collapse",
+			selectorValue: pickupdropoffcache.NewValue(
+				model.NewPathPoint(*pickupCoord, enums.Pickup, now, nil, 0),
+				model.NewPathPoint(*nearDropoffCoord, enums.Dropoff, now, nil, 0),
+			),
+			expected: false,
+		},
+		{
+			name:        "Selector error",
+			selectorErr: fmt.Errorf("selector error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := pickupdropoffservice.NewWalkOnlyDetectorWithConfig(nil, 50, 30*time.Minute)
+			walkOnlyChecker := checker.NewWalkOnlyChecker(NewMockPickupDropoffSelector(tt.selectorValue, tt.selectorErr), detector)
+
+			result, err := walkOnlyChecker.Check(offer, request)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
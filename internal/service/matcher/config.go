@@ -0,0 +1,115 @@
+package matcher
+
+import (
+	"github.com/rs/zerolog/log"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const (
+	// RoundTripModeIndependent matches both legs of a round trip independently
+	RoundTripModeIndependent = "independent"
+	// RoundTripModeBothLegs commits both legs of a round trip or neither
+	RoundTripModeBothLegs = "both_legs"
+
+	// PartitionModeNone matches the whole batch as a single problem
+	PartitionModeNone = "none"
+	// PartitionModeZone matches the offers and requests of every zone and departure time bucket in parallel,
+	// then matches the requests left over against every offer
+	PartitionModeZone = "zone"
+
+	// DefaultPartitionS2Level is the level of the S2 cells used as zones, about 80 km² at level 10
+	DefaultPartitionS2Level = 10
+	// DefaultPartitionTimeBucketMinutes is the width of the departure time buckets
+	DefaultPartitionTimeBucketMinutes = 60
+
+	// DistributionModeLocal builds the matching graph in the process
+	DistributionModeLocal = "local"
+	// DistributionModeCoordinator dispatches the building of the matching graph to the graph workers
+	// and runs the maximum matching on their edges
+	DistributionModeCoordinator = "coordinator"
+	// DistributionModeWorker builds the edges of the graph tasks dispatched by a coordinator
+	DistributionModeWorker = "worker"
+
+	// DefaultGraphTaskOffers is the number of offers sent to a graph worker in a single task
+	DefaultGraphTaskOffers = 50
+)
+
+// partitionConfig configures the partitioning of a batch into sub-batches matched in parallel
+type partitionConfig struct {
+	mode       string
+	s2Level    int
+	timeBucket time.Duration
+	workers    int
+}
+
+// distributionConfig configures the dispatching of the matching graph to the graph workers
+type distributionConfig struct {
+	offersPerTask int
+}
+
+// GetDistributionMode returns the role of the process set by MATCHING_DISTRIBUTION_MODE
+func GetDistributionMode() string {
+	if v, ok := os.LookupEnv("MATCHING_DISTRIBUTION_MODE"); ok && v != "" {
+		return v
+	}
+	return DistributionModeLocal
+}
+
+func getRoundTripMode() string {
+	roundTripMode := RoundTripModeIndependent // Default round trip mode
+	if v, ok := os.LookupEnv("ROUND_TRIP_MODE"); ok && v != "" {
+		roundTripMode = v
+	} else {
+		log.Warn().Msgf("ROUND_TRIP_MODE environment variable is not set. Using default: %s", roundTripMode)
+	}
+	return roundTripMode
+}
+
+func getPartitionConfig() partitionConfig {
+	cfg := partitionConfig{
+		mode:       PartitionModeNone,
+		s2Level:    DefaultPartitionS2Level,
+		timeBucket: DefaultPartitionTimeBucketMinutes * time.Minute,
+		workers:    runtime.NumCPU(),
+	}
+	if v, ok := os.LookupEnv("MATCHING_PARTITION_MODE"); ok && v != "" {
+		cfg.mode = v
+	}
+	if v, ok := os.LookupEnv("MATCHING_PARTITION_S2_LEVEL"); ok && v != "" {
+		if level, err := strconv.Atoi(v); err == nil && level >= 0 && level <= 30 {
+			cfg.s2Level = level
+		} else {
+			log.Warn().Msgf("Invalid MATCHING_PARTITION_S2_LEVEL value: %s. Using default: %d", v, cfg.s2Level)
+		}
+	}
+	if v, ok := os.LookupEnv("MATCHING_PARTITION_TIME_BUCKET_MINUTES"); ok && v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil && minutes > 0 {
+			cfg.timeBucket = time.Duration(minutes) * time.Minute
+		} else {
+			log.Warn().Msgf("Invalid MATCHING_PARTITION_TIME_BUCKET_MINUTES value: %s. Using default: %s", v, cfg.timeBucket)
+		}
+	}
+	if v, ok := os.LookupEnv("MATCHING_PARTITION_WORKERS"); ok && v != "" {
+		if workers, err := strconv.Atoi(v); err == nil && workers > 0 {
+			cfg.workers = workers
+		} else {
+			log.Warn().Msgf("Invalid MATCHING_PARTITION_WORKERS value: %s. Using default: %d", v, cfg.workers)
+		}
+	}
+	return cfg
+}
+
+func getDistributionConfig() distributionConfig {
+	cfg := distributionConfig{offersPerTask: DefaultGraphTaskOffers}
+	if v, ok := os.LookupEnv("MATCHING_GRAPH_TASK_OFFERS"); ok && v != "" {
+		if offers, err := strconv.Atoi(v); err == nil && offers > 0 {
+			cfg.offersPerTask = offers
+		} else {
+			log.Warn().Msgf("Invalid MATCHING_GRAPH_TASK_OFFERS value: %s. Using default: %d", v, cfg.offersPerTask)
+		}
+	}
+	return cfg
+}
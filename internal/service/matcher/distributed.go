@@ -0,0 +1,278 @@
+package matcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/collections"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+)
+
+// GraphWorkerPool builds the edges of the matching graph on remote workers, see GraphWorker.
+// It must be safe for concurrent use, the tasks of a round are dispatched in parallel.
+type GraphWorkerPool interface {
+	BuildEdges(task *GraphTask) ([]GraphEdge, error)
+}
+
+// GraphTask asks a worker for the edges between a subset of the offers and their candidate requests.
+// The worker reads the same batch as the coordinator, so the task only carries the ID of the batch and the state
+// changed by the matching: the current path and the riders of every offer.
+type GraphTask struct {
+	BatchID string
+	Offers  []GraphTaskOffer
+}
+
+// GraphTaskOffer is an offer of a task with its current path, its riders and its candidate requests
+type GraphTaskOffer struct {
+	OfferID                 string
+	Path                    []RemotePathPoint
+	MatchedRequestIDs       []string
+	NewlyAssignedRequestIDs []string
+	RequestIDs              []string
+}
+
+// GraphEdge is a feasible edge found by a worker, with the path of the offer through the request
+type GraphEdge struct {
+	OfferID   string
+	RequestID string
+	Path      []RemotePathPoint
+}
+
+// RemotePathPoint is a path point sent to or received from a worker, its owner is referenced by ID
+type RemotePathPoint struct {
+	OwnerType           enums.RoleType
+	OwnerID             string
+	Coordinate          model.Coordinate
+	PointType           enums.PointType
+	ExpectedArrivalTime time.Time
+	WalkingDuration     time.Duration
+	TransitDuration     time.Duration
+}
+
+// buildMatchingGraphRemotely splits the candidate offers into tasks dispatched in parallel to the graph workers,
+// and adds the edges they return to the graph. The maximum matching stays on the coordinator.
+func (matcher *Matcher) buildMatchingGraphRemotely(graph *model.MaximumMatchingGraph) (bool, error) {
+	offerIDs := make([]string, 0, matcher.potentialOfferRequests.Size())
+	_ = matcher.potentialOfferRequests.Range(func(offerID string, _ *collections.Set[string]) error {
+		offerIDs = append(offerIDs, offerID)
+		return nil
+	})
+	sort.Strings(offerIDs)
+
+	type candidates struct {
+		offerNode    *model.OfferNode
+		requestSet   *collections.Set[string]
+		requestNodes []*model.RequestNode
+	}
+	candidatesByOffer := make(map[string]candidates, len(offerIDs))
+	tasks := make([]*GraphTask, 0)
+	task := &GraphTask{BatchID: matcher.batchID}
+	for _, offerID := range offerIDs {
+		requestSet, exists := matcher.potentialOfferRequests.Get(offerID)
+		if !exists {
+			continue
+		}
+		offerNode, requestNodes, exists := matcher.candidateNodes(offerID, requestSet)
+		if !exists || len(requestNodes) == 0 {
+			continue
+		}
+		candidatesByOffer[offerID] = candidates{offerNode: offerNode, requestSet: requestSet, requestNodes: requestNodes}
+		task.Offers = append(task.Offers, newGraphTaskOffer(offerNode, requestNodes))
+		if len(task.Offers) == matcher.distributionConfig.offersPerTask {
+			tasks = append(tasks, task)
+			task = &GraphTask{BatchID: matcher.batchID}
+		}
+	}
+	if len(task.Offers) > 0 {
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		return false, nil
+	}
+	log.Info().Msgf("Dispatching %d offers to the graph workers in %d tasks", len(candidatesByOffer), len(tasks))
+
+	edgesByTask, err := matcher.dispatchGraphTasks(tasks)
+	if err != nil {
+		return false, err
+	}
+
+	edgesByOffer := make(map[string][]*model.Edge, len(candidatesByOffer))
+	for _, taskEdges := range edgesByTask {
+		for _, remoteEdge := range taskEdges {
+			c, exists := candidatesByOffer[remoteEdge.OfferID]
+			if !exists {
+				return false, fmt.Errorf("graph worker returned an edge for the unknown offer %s", remoteEdge.OfferID)
+			}
+			edge, err := newEdgeFromRemote(c.offerNode, c.requestNodes, remoteEdge)
+			if err != nil {
+				return false, err
+			}
+			edgesByOffer[remoteEdge.OfferID] = append(edgesByOffer[remoteEdge.OfferID], edge)
+		}
+	}
+
+	hasNewEdge := false
+	for _, offerID := range offerIDs {
+		c, exists := candidatesByOffer[offerID]
+		if !exists {
+			continue
+		}
+		edges := edgesByOffer[offerID]
+		removeRejectedCandidates(c.requestSet, c.requestNodes, edges)
+		if len(edges) > 0 {
+			hasNewEdge = true
+			addEdges(graph, c.offerNode, edges)
+		}
+	}
+	return hasNewEdge, nil
+}
+
+// dispatchGraphTasks sends the tasks to the graph workers in parallel, the edges are returned in the order of the tasks
+func (matcher *Matcher) dispatchGraphTasks(tasks []*GraphTask) ([][]GraphEdge, error) {
+	edgesByTask := make([][]GraphEdge, len(tasks))
+	errs := make([]error, len(tasks))
+
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task *GraphTask) {
+			defer wg.Done()
+			edgesByTask[i], errs[i] = matcher.graphWorkers.BuildEdges(task)
+		}(i, task)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("graph task %d of %d failed: %w", i+1, len(tasks), err)
+		}
+	}
+	return edgesByTask, nil
+}
+
+// BatchID identifies a batch by its offers, the riders already matched with them, and its requests.
+// The coordinator and the workers reading the same batch get the same ID.
+func BatchID(offers []*model.Offer, requests []*model.Request) string {
+	offerKeys := make([]string, len(offers))
+	for i, offer := range offers {
+		matchedRequestIDs := requestIDsOf(offer.MatchedRequests())
+		sort.Strings(matchedRequestIDs)
+		offerKeys[i] = offer.ID() + ":" + strings.Join(matchedRequestIDs, ",")
+	}
+	sort.Strings(offerKeys)
+	requestIDs := requestIDsOf(requests)
+	sort.Strings(requestIDs)
+
+	hash := sha256.New()
+	for _, key := range offerKeys {
+		fmt.Fprintf(hash, "offer %s;", key)
+	}
+	for _, requestID := range requestIDs {
+		fmt.Fprintf(hash, "request %s;", requestID)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+func newGraphTaskOffer(offerNode *model.OfferNode, requestNodes []*model.RequestNode) GraphTaskOffer {
+	requestIDs := make([]string, len(requestNodes))
+	for i, requestNode := range requestNodes {
+		requestIDs[i] = requestNode.Request().ID()
+	}
+	return GraphTaskOffer{
+		OfferID:                 offerNode.Offer().ID(),
+		Path:                    toRemotePath(offerNode.Offer().Path()),
+		MatchedRequestIDs:       requestIDsOf(offerNode.Offer().MatchedRequests()),
+		NewlyAssignedRequestIDs: requestIDsOf(offerNode.NewlyAssignedMatchedRequests()),
+		RequestIDs:              requestIDs,
+	}
+}
+
+// newEdgeFromRemote returns the edge between the offer and one of its candidate requests found by a worker
+func newEdgeFromRemote(offerNode *model.OfferNode, requestNodes []*model.RequestNode, remoteEdge GraphEdge) (*model.Edge, error) {
+	requests := make(map[string]*model.Request, len(requestNodes)+len(offerNode.GetAllRequests()))
+	for _, request := range offerNode.GetAllRequests() {
+		requests[request.ID()] = request
+	}
+	var requestNode *model.RequestNode
+	for _, candidate := range requestNodes {
+		requests[candidate.Request().ID()] = candidate.Request()
+		if candidate.Request().ID() == remoteEdge.RequestID {
+			requestNode = candidate
+		}
+	}
+	if requestNode == nil {
+		return nil, fmt.Errorf("graph worker returned an edge for the offer %s and the request %s which is not a candidate", remoteEdge.OfferID, remoteEdge.RequestID)
+	}
+
+	path, err := fromRemotePath(remoteEdge.Path, offerNode.Offer(), requests)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path for the offer %s and the request %s: %w", remoteEdge.OfferID, remoteEdge.RequestID, err)
+	}
+	return model.NewEdge(requestNode, path), nil
+}
+
+// toRemotePath references the owners of the path points by ID
+func toRemotePath(path []model.PathPoint) []RemotePathPoint {
+	remotePath := make([]RemotePathPoint, len(path))
+	for i := range path {
+		point := &path[i]
+		remotePath[i] = RemotePathPoint{
+			OwnerID:             point.GetOwnerID(),
+			Coordinate:          *point.Coordinate(),
+			PointType:           point.PointType(),
+			ExpectedArrivalTime: point.ExpectedArrivalTime(),
+			WalkingDuration:     point.WalkingDuration(),
+			TransitDuration:     point.TransitDuration(),
+		}
+		if point.Owner() == nil {
+			continue
+		}
+		if _, ok := point.Owner().AsOffer(); ok {
+			remotePath[i].OwnerType = enums.Offer
+		} else if _, ok := point.Owner().AsRequest(); ok {
+			remotePath[i].OwnerType = enums.Request
+		}
+	}
+	return remotePath
+}
+
+// fromRemotePath resolves the owners of the path points, which are the offer or one of the requests
+func fromRemotePath(remotePath []RemotePathPoint, offer *model.Offer, requests map[string]*model.Request) ([]model.PathPoint, error) {
+	path := make([]model.PathPoint, len(remotePath))
+	for i, remotePoint := range remotePath {
+		var owner model.Role
+		switch remotePoint.OwnerType {
+		case enums.Offer:
+			if remotePoint.OwnerID != offer.ID() {
+				return nil, fmt.Errorf("point %d belongs to the offer %s", i, remotePoint.OwnerID)
+			}
+			owner = offer
+		case enums.Request:
+			request, exists := requests[remotePoint.OwnerID]
+			if !exists {
+				return nil, fmt.Errorf("point %d belongs to the unknown request %s", i, remotePoint.OwnerID)
+			}
+			owner = request
+		}
+		point := model.NewPathPoint(remotePoint.Coordinate, remotePoint.PointType, remotePoint.ExpectedArrivalTime, owner, remotePoint.WalkingDuration)
+		point.SetTransitDuration(remotePoint.TransitDuration)
+		path[i] = *point
+	}
+	return path, nil
+}
+
+func requestIDsOf(requests []*model.Request) []string {
+	ids := make([]string, len(requests))
+	for i, request := range requests {
+		ids[i] = request.ID()
+	}
+	return ids
+}
@@ -7,57 +7,94 @@ import (
 )
 
 // buildMatchingGraph constructs the graph by finding feasible paths and connecting offers with requests.
+// The edges are built by the remote graph workers when the matcher coordinates them.
 func (matcher *Matcher) buildMatchingGraph(graph *model.MaximumMatchingGraph) (bool, error) {
+	if matcher.graphWorkers != nil {
+		return matcher.buildMatchingGraphRemotely(graph)
+	}
+
 	hasNewEdge := false
 	err := matcher.potentialOfferRequests.Range(func(offerID string, requestSet *collections.Set[string]) error {
-		offerNode, exists := matcher.availableOffers.Get(offerID)
-		if !exists || offerNode == nil {
-			matcher.potentialOfferRequests.Delete(offerID)
+		offerNode, requestNodes, exists := matcher.candidateNodes(offerID, requestSet)
+		if !exists || len(requestNodes) == 0 {
 			return nil
 		}
 
-		requestNodes := make([]*model.RequestNode, 0, requestSet.Size())
-		requestSetSlice := requestSet.ToSlice()
-		for _, requestID := range requestSetSlice {
-			if requestNode, ok := matcher.availableRequests.Get(requestID); ok && requestNode != nil {
-				requestNodes = append(requestNodes, requestNode)
-			} else {
-				requestSet.Remove(requestID)
-			}
-		}
-
-		if len(requestNodes) == 0 {
-			return nil
-		}
-
-		err := matcher.timeMatrixCachePopulator.Populate(offerNode, requestNodes)
-
+		edges, err := matcher.evaluateCandidates(offerNode, requestNodes)
 		if err != nil {
 			return err
 		}
+		removeRejectedCandidates(requestSet, requestNodes, edges)
 
-		for _, requestNode := range requestNodes {
+		if len(edges) > 0 {
+			hasNewEdge = true
+			addEdges(graph, offerNode, edges)
+		}
+		return nil
+	})
 
-			path, valid, err := matcher.matchEvaluator.Evaluate(offerNode, requestNode)
+	return hasNewEdge, err
+}
 
-			if err != nil {
-				return fmt.Errorf("error evaluating the match %v", err)
-			}
+// candidateNodes returns the node of the offer and the nodes of its candidate requests still available.
+// The offer is dropped from the candidates when it is no longer available, and so are the requests.
+func (matcher *Matcher) candidateNodes(offerID string, requestSet *collections.Set[string]) (*model.OfferNode, []*model.RequestNode, bool) {
+	offerNode, exists := matcher.availableOffers.Get(offerID)
+	if !exists || offerNode == nil {
+		matcher.potentialOfferRequests.Delete(offerID)
+		return nil, nil, false
+	}
 
-			if !valid {
-				requestSet.Remove(requestNode.Request().ID())
-				continue
-			}
+	requestNodes := make([]*model.RequestNode, 0, requestSet.Size())
+	for _, requestID := range requestSet.ToSlice() {
+		if requestNode, ok := matcher.availableRequests.Get(requestID); ok && requestNode != nil {
+			requestNodes = append(requestNodes, requestNode)
+		} else {
+			requestSet.Remove(requestID)
+		}
+	}
+	return offerNode, requestNodes, true
+}
 
-			hasNewEdge = true
-			edge := model.NewEdge(requestNode, path)
-			graph.AddOfferNode(offerNode)
-			graph.AddRequestNode(requestNode)
-			graph.AddEdge(offerNode, requestNode, edge)
+// evaluateCandidates finds a feasible path through the offer for every candidate request,
+// and returns the edges of the feasible ones in the order of the requests.
+func (matcher *Matcher) evaluateCandidates(offerNode *model.OfferNode, requestNodes []*model.RequestNode) ([]*model.Edge, error) {
+	// The evaluations fall back to a matrix per request when the one of the offer was evicted in between
+	if _, err := matcher.timeMatrixCachePopulator.Populate(offerNode, requestNodes); err != nil {
+		return nil, err
+	}
 
+	edges := make([]*model.Edge, 0, len(requestNodes))
+	for _, requestNode := range requestNodes {
+		path, valid, err := matcher.matchEvaluator.Evaluate(offerNode, requestNode)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating the match %v", err)
 		}
-		return nil
-	})
+		if valid {
+			edges = append(edges, model.NewEdge(requestNode, path))
+		}
+	}
+	return edges, nil
+}
 
-	return hasNewEdge, err
+// removeRejectedCandidates removes the requests without an edge from the candidates of the offer,
+// they won't become feasible in a later round
+func removeRejectedCandidates(requestSet *collections.Set[string], requestNodes []*model.RequestNode, edges []*model.Edge) {
+	feasible := collections.NewSet[string]()
+	for _, edge := range edges {
+		feasible.Add(edge.RequestNode().Request().ID())
+	}
+	for _, requestNode := range requestNodes {
+		if !feasible.Contains(requestNode.Request().ID()) {
+			requestSet.Remove(requestNode.Request().ID())
+		}
+	}
+}
+
+func addEdges(graph *model.MaximumMatchingGraph, offerNode *model.OfferNode, edges []*model.Edge) {
+	for _, edge := range edges {
+		graph.AddOfferNode(offerNode)
+		graph.AddRequestNode(edge.RequestNode())
+		graph.AddEdge(offerNode, edge.RequestNode(), edge)
+	}
 }
@@ -0,0 +1,169 @@
+package matcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/model"
+)
+
+// ErrUnknownBatch is returned for the graph tasks of a batch other than the one loaded by the worker
+var ErrUnknownBatch = errors.New("the graph task belongs to a batch the worker didn't load")
+
+// BatchLoader reads the batch of offers and requests of a graph worker, the riders already matched with the offers included
+type BatchLoader func(ctx context.Context) ([]*model.Offer, []*model.Request, error)
+
+// GraphWorker builds the edges of the graph tasks dispatched by a coordinator, with the evaluator and caches of its matcher.
+// The worker loads the same batch of offers and requests as the coordinator, and applies the path and the riders
+// sent with every offer of a task before evaluating its candidate requests.
+// The tasks of another batch are rejected with ErrUnknownBatch, the batch must then be loaded again.
+type GraphWorker struct {
+	matcher  *Matcher
+	mu       sync.Mutex
+	batchID  string
+	offers   map[string]*model.Offer
+	requests map[string]*model.Request
+	pathKeys map[string]string
+}
+
+// NewGraphWorker creates a graph worker evaluating the candidates with the services of the matcher
+func NewGraphWorker(matcher *Matcher) *GraphWorker {
+	return &GraphWorker{
+		matcher:  matcher,
+		offers:   make(map[string]*model.Offer),
+		requests: make(map[string]*model.Request),
+		pathKeys: make(map[string]string),
+	}
+}
+
+// Load replaces the batch the tasks refer to, the riders already matched with the offers included.
+// The values cached for the offers of the previous batch are dropped, their paths may have changed since.
+func (w *GraphWorker) Load(offers []*model.Offer, requests []*model.Request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for offerID := range w.offers {
+		w.matcher.offerCaches.invalidateOffer(offerID)
+	}
+	w.batchID = BatchID(offers, requests)
+	w.offers = make(map[string]*model.Offer, len(offers))
+	w.requests = make(map[string]*model.Request, len(requests))
+	w.pathKeys = make(map[string]string, len(offers))
+	for _, offer := range offers {
+		w.offers[offer.ID()] = offer
+		w.pathKeys[offer.ID()] = remotePathKey(toRemotePath(offer.Path()))
+		for _, request := range offer.MatchedRequests() {
+			w.requests[request.ID()] = request
+		}
+	}
+	for _, request := range requests {
+		w.requests[request.ID()] = request
+	}
+	log.Info().Str("batch", w.batchID).Int("offers", len(offers)).Int("requests", len(requests)).Msg("Graph worker loaded the batch")
+}
+
+// BuildEdges evaluates the candidate requests of every offer of the task and returns the feasible edges.
+// The tasks are evaluated one at a time, as they update the offers of the batch.
+func (w *GraphWorker) BuildEdges(task *GraphTask) ([]GraphEdge, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if task.BatchID != w.batchID {
+		return nil, fmt.Errorf("%w: got batch %s, loaded batch %s", ErrUnknownBatch, task.BatchID, w.batchID)
+	}
+
+	edges := make([]GraphEdge, 0)
+	for _, taskOffer := range task.Offers {
+		offerNode, err := w.applyTaskOffer(taskOffer)
+		if err != nil {
+			return nil, err
+		}
+
+		requestNodes := make([]*model.RequestNode, len(taskOffer.RequestIDs))
+		for i, requestID := range taskOffer.RequestIDs {
+			request, exists := w.requests[requestID]
+			if !exists {
+				return nil, fmt.Errorf("unknown request %s, the worker must read the same batch as the coordinator", requestID)
+			}
+			requestNodes[i] = model.NewRequestNode(request)
+		}
+
+		offerEdges, err := w.matcher.evaluateCandidates(offerNode, requestNodes)
+		if err != nil {
+			return nil, err
+		}
+		for _, edge := range offerEdges {
+			edges = append(edges, GraphEdge{
+				OfferID:   taskOffer.OfferID,
+				RequestID: edge.RequestNode().Request().ID(),
+				Path:      toRemotePath(edge.NewPath()),
+			})
+		}
+	}
+	return edges, nil
+}
+
+// applyTaskOffer sets the path and the riders of the task on the offer of the batch, and returns its node.
+// The caches of the offer are only dropped when its path changed, the path points of an unchanged path are kept
+// as the cached time matrices refer to them.
+func (w *GraphWorker) applyTaskOffer(taskOffer GraphTaskOffer) (*model.OfferNode, error) {
+	offer, exists := w.offers[taskOffer.OfferID]
+	if !exists {
+		return nil, fmt.Errorf("unknown offer %s, the worker must read the same batch as the coordinator", taskOffer.OfferID)
+	}
+	matchedRequests, err := w.lookupRequests(taskOffer.MatchedRequestIDs)
+	if err != nil {
+		return nil, err
+	}
+	newlyAssignedRequests, err := w.lookupRequests(taskOffer.NewlyAssignedRequestIDs)
+	if err != nil {
+		return nil, err
+	}
+	offer.SetMatchedRequests(matchedRequests)
+
+	if pathKey := remotePathKey(taskOffer.Path); pathKey != w.pathKeys[offer.ID()] {
+		pathRequests := make(map[string]*model.Request, len(matchedRequests)+len(newlyAssignedRequests))
+		for _, requests := range [][]*model.Request{matchedRequests, newlyAssignedRequests} {
+			for _, request := range requests {
+				pathRequests[request.ID()] = request
+			}
+		}
+		path, err := fromRemotePath(taskOffer.Path, offer, pathRequests)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path for the offer %s: %w", offer.ID(), err)
+		}
+		offer.SetPath(path)
+		w.pathKeys[offer.ID()] = pathKey
+		w.matcher.offerCaches.invalidateOffer(offer.ID())
+	}
+
+	offerNode := model.NewOfferNode(offer)
+	offerNode.SetNewlyAssignedMatchedRequests(newlyAssignedRequests)
+	return offerNode, nil
+}
+
+func (w *GraphWorker) lookupRequests(requestIDs []string) ([]*model.Request, error) {
+	requests := make([]*model.Request, len(requestIDs))
+	for i, requestID := range requestIDs {
+		request, exists := w.requests[requestID]
+		if !exists {
+			return nil, fmt.Errorf("unknown request %s, the worker must read the same batch as the coordinator", requestID)
+		}
+		requests[i] = request
+	}
+	return requests, nil
+}
+
+// remotePathKey identifies a path by its coordinates and the owners of its points
+func remotePathKey(path []RemotePathPoint) string {
+	var key strings.Builder
+	for _, point := range path {
+		fmt.Fprintf(&key, "%.6f,%.6f,%s,%s,%d;", point.Coordinate.Lat(), point.Coordinate.Lng(), point.PointType, point.OwnerID, point.ExpectedArrivalTime.Unix())
+	}
+	return key.String()
+}
@@ -6,6 +6,7 @@ import (
 	"matching-engine/internal/collections"
 	"matching-engine/internal/errors"
 	"matching-engine/internal/model"
+	"matching-engine/internal/service/affinity"
 	"matching-engine/internal/service/earlypruning"
 	"matching-engine/internal/service/matchevaluator"
 	"matching-engine/internal/service/maximummatching"
@@ -22,15 +23,27 @@ type Matcher struct {
 	availableRequests        *collections.SyncMap[string, *model.RequestNode]
 	potentialOfferRequests   *collections.SyncMap[string, *collections.Set[string]]
 	results                  []*model.MatchingResult
+	roundTripDrivers         map[string]string // Driver of every leg of a round trip matched in the run, by request ID
 	matchEvaluator           matchevaluator.Evaluator
 	candidateGenerator       earlypruning.CandidateGenerator
 	maximumMatching          maximummatching.MaximumMatching
 	timeMatrixCachePopulator *timematrix.CacheWithOfferIdPopulator
+	affinityTracker          *affinity.Tracker
+	offerCaches              OfferCaches
+	trafficProfile           TrafficProfile
+	walkOnlyDetector         WalkOnlyDetector
+	routePlanner             RoutePlanner
+	runRecorder              RunRecorder
+	graphWorkers             GraphWorkerPool
+	batchID                  string // ID of the batch sent with the graph tasks, see BatchID
 	limit                    int
+	roundTripMode            string
+	partitionConfig          partitionConfig
+	distributionConfig       distributionConfig
 }
 
 // NewMatcher creates and initializes a new Matcher instance.
-func NewMatcher(evaluator matchevaluator.Evaluator, generator earlypruning.CandidateGenerator, matching maximummatching.MaximumMatching, cachePopulator *timematrix.CacheWithOfferIdPopulator) *Matcher {
+func NewMatcher(evaluator matchevaluator.Evaluator, generator earlypruning.CandidateGenerator, matching maximummatching.MaximumMatching, cachePopulator *timematrix.CacheWithOfferIdPopulator, affinityTracker *affinity.Tracker, offerCaches OfferCaches, trafficProfile TrafficProfile, walkOnlyDetector WalkOnlyDetector, routePlanner RoutePlanner, runRecorder RunRecorder, graphWorkers GraphWorkerPool) *Matcher {
 	if evaluator == nil {
 		log.Error().Msg("Matcher: Evaluator is nil")
 		panic("Matcher: Evaluator is nil")
@@ -41,11 +54,22 @@ func NewMatcher(evaluator matchevaluator.Evaluator, generator earlypruning.Candi
 		availableRequests:        collections.NewSyncMap[string, *model.RequestNode](),
 		potentialOfferRequests:   collections.NewSyncMap[string, *collections.Set[string]](),
 		results:                  make([]*model.MatchingResult, 0),
+		roundTripDrivers:         make(map[string]string),
 		matchEvaluator:           evaluator,
 		candidateGenerator:       generator,
 		maximumMatching:          matching,
 		limit:                    DefaultLimit,
 		timeMatrixCachePopulator: cachePopulator,
+		affinityTracker:          affinityTracker,
+		offerCaches:              offerCaches,
+		trafficProfile:           trafficProfile,
+		walkOnlyDetector:         walkOnlyDetector,
+		routePlanner:             routePlanner,
+		runRecorder:              runRecorder,
+		graphWorkers:             graphWorkers,
+		roundTripMode:            getRoundTripMode(),
+		partitionConfig:          getPartitionConfig(),
+		distributionConfig:       getDistributionConfig(),
 	}
 }
 
@@ -55,6 +79,62 @@ func (matcher *Matcher) Match(offers []*model.Offer, requests []*model.Request)
 		return nil, fmt.Errorf(errors.ErrNoOffersOrRequests)
 	}
 
+	if matcher.runRecorder != nil {
+		matcher.runRecorder.RecordOffers(offers)
+	}
+	if matcher.graphWorkers != nil {
+		matcher.batchID = BatchID(offers, requests)
+	}
+
+	// Riders who can walk the whole way don't take a seat
+	requests, walkResults := matcher.splitWalkableRequests(requests)
+	if len(requests) == 0 {
+		matcher.recordResults(walkResults)
+		return walkResults, nil
+	}
+
+	var results []*model.MatchingResult
+	var err error
+	if matcher.partitionConfig.mode == PartitionModeZone {
+		results, err = matcher.matchPartitioned(offers, requests)
+	} else {
+		results, err = matcher.matchBatch(offers, requests)
+	}
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, walkResults...)
+	// Only the committed carpools are kept together in the next runs
+	matcher.affinityTracker.RecordResults(results)
+	matcher.recordResults(results)
+	return results, nil
+}
+
+// matchBatch matches the offers and requests as a single problem.
+func (matcher *Matcher) matchBatch(offers []*model.Offer, requests []*model.Request) ([]*model.MatchingResult, error) {
+	if matcher.roundTripMode == RoundTripModeBothLegs {
+		return matcher.matchRoundTrips(offers, requests)
+	}
+	return matcher.matchOnce(offers, requests)
+}
+
+// recordResults ends the recorded run with its results, the matching goes on when they can't be recorded.
+func (matcher *Matcher) recordResults(results []*model.MatchingResult) {
+	if matcher.runRecorder == nil {
+		return
+	}
+	if err := matcher.runRecorder.RecordResults(results); err != nil {
+		log.Warn().Err(err).Msg("failed to record the matching run")
+	}
+}
+
+// matchOnce runs the matching process from a clean state.
+func (matcher *Matcher) matchOnce(offers []*model.Offer, requests []*model.Request) ([]*model.MatchingResult, error) {
+	matcher.reset()
+
+	// Remember the existing carpools so the maximum matching keeps them together
+	matcher.affinityTracker.RecordOffers(offers)
+
 	// Generate Candidates
 	if err := matcher.buildCandidateMatches(offers, requests); err != nil {
 		return nil, fmt.Errorf("failed to build candidate matches: %w", err)
@@ -62,7 +142,7 @@ func (matcher *Matcher) Match(offers []*model.Offer, requests []*model.Request)
 
 	graph := model.NewMaximumMatchingGraph()
 
-	for matcher.availableOffers.Size() > 0 && matcher.availableRequests.Size() > 0 {
+	for round := 1; matcher.availableOffers.Size() > 0 && matcher.availableRequests.Size() > 0; round++ {
 		// Build Matching Graph
 		log.Info().Msg("Building matching graph")
 		// Build the matching graph with potential edges between offers and requests
@@ -75,6 +155,9 @@ func (matcher *Matcher) Match(offers []*model.Offer, requests []*model.Request)
 			log.Info().Msg("No new edges found, stopping matching process")
 			break
 		}
+		if matcher.runRecorder != nil {
+			matcher.runRecorder.RecordCandidateGraph(round, graph)
+		}
 
 		// Process unmatched offers
 		matcher.processUnmatchedOffers(graph)
@@ -99,5 +182,15 @@ func (matcher *Matcher) Match(offers []*model.Offer, requests []*model.Request)
 		return nil, fmt.Errorf("failed to process remaining offers: %w", err)
 	}
 
+	matcher.offerCaches.logStats()
 	return matcher.results, nil
 }
+
+// reset clears the state left by a previous matching process.
+func (matcher *Matcher) reset() {
+	matcher.availableOffers = collections.NewSyncMap[string, *model.OfferNode]()
+	matcher.availableRequests = collections.NewSyncMap[string, *model.RequestNode]()
+	matcher.potentialOfferRequests = collections.NewSyncMap[string, *collections.Set[string]]()
+	matcher.results = make([]*model.MatchingResult, 0)
+	matcher.roundTripDrivers = make(map[string]string)
+}
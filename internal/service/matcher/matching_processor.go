@@ -18,17 +18,24 @@ func (matcher *Matcher) processMaximumMatching(graph *model.MaximumMatchingGraph
 		return nil
 	}
 
+	maxPairs = matcher.alignRoundTripDrivers(graph, maxPairs)
+
 	for _, pair := range maxPairs {
 		offerNode := pair.First
 		edge := pair.Second
 		requestNode := edge.RequestNode()
 		offerNode.SetMatched(true)
 		offerNode.AddNewlyMatchedRequest(requestNode.Request())
+		if requestNode.Request().LinkedRequestID() != "" {
+			matcher.roundTripDrivers[requestNode.Request().ID()] = offerNode.Offer().UserID()
+		}
 		newPath := edge.NewPath()
 		if newPath == nil {
 			return fmt.Errorf("edge with nil path encountered for offer %s and request %s", offerNode.Offer().ID(), requestNode.Request().ID())
 		}
 		offerNode.Offer().SetPath(newPath)
+		// The matrices, routes and pickup points of the offer were computed from the previous path
+		matcher.offerCaches.invalidateOffer(offerNode.Offer().ID())
 
 		requestSet, exists := matcher.potentialOfferRequests.Get(offerNode.Offer().ID())
 		if exists {
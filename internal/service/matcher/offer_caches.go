@@ -0,0 +1,46 @@
+package matcher
+
+import (
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/collections"
+	"sort"
+)
+
+// OfferCache holds values computed from the path of an offer, they are stale once the path changes.
+type OfferCache interface {
+	InvalidateOffer(offerID string)
+	Stats() collections.CacheStats
+}
+
+// OfferCaches are the in-run caches invalidated by the matcher, keyed by a name used in the logs.
+type OfferCaches map[string]OfferCache
+
+// invalidateOffer drops the values cached for an offer whose path was just changed.
+func (caches OfferCaches) invalidateOffer(offerID string) {
+	for _, cache := range caches {
+		cache.InvalidateOffer(offerID)
+	}
+}
+
+// logStats logs the hit rate and size of every cache.
+func (caches OfferCaches) logStats() {
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		stats := caches[name].Stats()
+		log.Info().
+			Str("cache", name).
+			Float64("hit_rate", stats.HitRate()).
+			Int64("hits", stats.Hits).
+			Int64("misses", stats.Misses).
+			Int64("evictions", stats.Evictions).
+			Int("entries", stats.Entries).
+			Int64("cost", stats.Cost).
+			Int64("capacity", stats.Capacity).
+			Msg("Cache statistics")
+	}
+}
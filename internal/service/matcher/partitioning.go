@@ -0,0 +1,206 @@
+package matcher
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/rs/zerolog/log"
+
+	"matching-engine/internal/collections"
+	"matching-engine/internal/model"
+)
+
+// partitionKey identifies a zone and a departure time bucket
+type partitionKey struct {
+	cell   s2.CellID
+	bucket int64
+}
+
+// partition is a sub-batch of offers and requests starting in the same zone during the same time bucket
+type partition struct {
+	key      partitionKey
+	offers   []*model.Offer
+	requests []*model.Request
+}
+
+// matchPartitioned matches every partition of the batch in parallel, then runs a reconciliation pass
+// matching the requests left over, such as the ones picked up across a zone boundary, against every offer.
+// The riders matched in the partitions are added to their offers, so the reconciliation pass only
+// inserts new riders into the paths found by the partitions.
+func (matcher *Matcher) matchPartitioned(offers []*model.Offer, requests []*model.Request) ([]*model.MatchingResult, error) {
+	partitions := partitionBatch(offers, requests, matcher.partitionConfig)
+	if len(partitions) <= 1 {
+		return matcher.matchBatch(offers, requests)
+	}
+	log.Info().Msgf("Matching %d partitions with %d workers", len(partitions), matcher.partitionConfig.workers)
+
+	partitionResults, err := matcher.matchPartitions(partitions)
+	if err != nil {
+		return nil, err
+	}
+	commitResults(offers, partitionResults)
+
+	assigned := collections.NewSet[string]()
+	for _, result := range partitionResults {
+		for _, request := range result.AssignedMatchedRequests() {
+			assigned.Add(request.ID())
+		}
+	}
+	leftoverRequests := make([]*model.Request, 0, len(requests))
+	for _, request := range requests {
+		if !assigned.Contains(request.ID()) {
+			leftoverRequests = append(leftoverRequests, request)
+		}
+	}
+	if len(leftoverRequests) == 0 {
+		return partitionResults, nil
+	}
+
+	log.Info().Msgf("Reconciling %d requests left over by the partitions", len(leftoverRequests))
+	reconciledResults, err := matcher.matchBatch(offers, leftoverRequests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile the partitions: %w", err)
+	}
+	return mergeResults(partitionResults, reconciledResults), nil
+}
+
+// matchPartitions matches the partitions with both offers and requests in parallel, each with its own matcher.
+// The results are returned in the order of the partitions.
+func (matcher *Matcher) matchPartitions(partitions []*partition) ([]*model.MatchingResult, error) {
+	resultsByPartition := make([][]*model.MatchingResult, len(partitions))
+	errs := make([]error, len(partitions))
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, matcher.partitionConfig.workers)
+	for i, p := range partitions {
+		if len(p.offers) == 0 || len(p.requests) == 0 {
+			continue
+		}
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, p *partition) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			resultsByPartition[i], errs[i] = matcher.newPartitionMatcher().matchBatch(p.offers, p.requests)
+		}(i, p)
+	}
+	wg.Wait()
+
+	results := make([]*model.MatchingResult, 0)
+	for i, p := range partitions {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to match partition %s/%d: %w", p.key.cell.ToToken(), p.key.bucket, errs[i])
+		}
+		results = append(results, resultsByPartition[i]...)
+	}
+	return results, nil
+}
+
+// newPartitionMatcher returns a matcher sharing the services of this matcher with a state of its own.
+// The partitions aren't recorded: they run concurrently and would record the same round numbers,
+// the recorded graphs are those of the reconciliation.
+func (matcher *Matcher) newPartitionMatcher() *Matcher {
+	partitionMatcher := *matcher
+	partitionMatcher.runRecorder = nil
+	partitionMatcher.reset()
+	return &partitionMatcher
+}
+
+// partitionBatch groups the offers and requests by the S2 cell of their source and the bucket of their departure time.
+// Both legs of a round trip are kept in the partition of the first leg, so they can be committed together.
+// The partitions are sorted by key so the matching is deterministic.
+func partitionBatch(offers []*model.Offer, requests []*model.Request, cfg partitionConfig) []*partition {
+	partitions := make(map[partitionKey]*partition)
+	get := func(key partitionKey) *partition {
+		p, exists := partitions[key]
+		if !exists {
+			p = &partition{key: key}
+			partitions[key] = p
+		}
+		return p
+	}
+
+	for _, offer := range offers {
+		key := newPartitionKey(offer.Source(), offer.DepartureTime(), cfg)
+		get(key).offers = append(get(key).offers, offer)
+	}
+
+	requestKeys := make(map[string]partitionKey, len(requests))
+	for _, request := range requests {
+		key, linked := requestKeys[request.LinkedRequestID()]
+		if request.LinkedRequestID() == "" || !linked {
+			key = newPartitionKey(request.Source(), request.EarliestDepartureTime(), cfg)
+		}
+		requestKeys[request.ID()] = key
+		get(key).requests = append(get(key).requests, request)
+	}
+
+	sorted := make([]*partition, 0, len(partitions))
+	for _, p := range partitions {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].key.cell != sorted[j].key.cell {
+			return sorted[i].key.cell < sorted[j].key.cell
+		}
+		return sorted[i].key.bucket < sorted[j].key.bucket
+	})
+	return sorted
+}
+
+func newPartitionKey(coordinate *model.Coordinate, departure time.Time, cfg partitionConfig) partitionKey {
+	cell := s2.CellIDFromLatLng(s2.LatLngFromDegrees(coordinate.Lat(), coordinate.Lng())).Parent(cfg.s2Level)
+	return partitionKey{cell: cell, bucket: departure.Unix() / int64(cfg.timeBucket.Seconds())}
+}
+
+// commitResults adds the riders assigned by the results to the matched requests of their offers,
+// the paths of the offers being already set by the matching
+func commitResults(offers []*model.Offer, results []*model.MatchingResult) {
+	offersByID := make(map[string]*model.Offer, len(offers))
+	for _, offer := range offers {
+		offersByID[offer.ID()] = offer
+	}
+	for _, result := range results {
+		offer, exists := offersByID[result.OfferID()]
+		if !exists {
+			continue
+		}
+		matchedRequests := make([]*model.Request, 0, len(offer.MatchedRequests())+len(result.AssignedMatchedRequests()))
+		matchedRequests = append(matchedRequests, offer.MatchedRequests()...)
+		matchedRequests = append(matchedRequests, result.AssignedMatchedRequests()...)
+		offer.SetMatchedRequests(matchedRequests)
+	}
+}
+
+// mergeResults merges the results of the reconciliation pass into the results of the partitions.
+// The reconciled result of an offer matched by a partition holds its final path, and assigns the riders of both passes.
+func mergeResults(partitionResults, reconciledResults []*model.MatchingResult) []*model.MatchingResult {
+	reconciledByOffer := make(map[string]*model.MatchingResult, len(reconciledResults))
+	for _, result := range reconciledResults {
+		reconciledByOffer[result.OfferID()] = result
+	}
+
+	merged := make([]*model.MatchingResult, 0, len(partitionResults)+len(reconciledResults))
+	for _, result := range partitionResults {
+		reconciled, exists := reconciledByOffer[result.OfferID()]
+		if !exists {
+			merged = append(merged, result)
+			continue
+		}
+		assigned := make([]*model.Request, 0, len(result.AssignedMatchedRequests())+len(reconciled.AssignedMatchedRequests()))
+		assigned = append(assigned, result.AssignedMatchedRequests()...)
+		assigned = append(assigned, reconciled.AssignedMatchedRequests()...)
+		reconciled.SetAssignedMatchedRequests(assigned)
+		merged = append(merged, reconciled)
+		delete(reconciledByOffer, result.OfferID())
+	}
+	for _, result := range reconciledResults {
+		if _, pending := reconciledByOffer[result.OfferID()]; pending {
+			merged = append(merged, result)
+		}
+	}
+	return merged
+}
@@ -11,5 +11,23 @@ func (matcher *Matcher) updateResults(offerNode *model.OfferNode) {
 		log.Error().Err(err).Msgf("failed to create matching result for offer %s", offerNode.Offer().ID())
 		return // continue
 	}
+	if matcher.trafficProfile != nil {
+		matchingResult.SetTrafficProfile(matcher.trafficProfile.ID())
+	}
+	if matcher.routePlanner != nil {
+		matcher.setRoutePolyline(offerNode.Offer(), matchingResult)
+	}
 	matcher.results = append(matcher.results, matchingResult)
 }
+
+// setRoutePolyline plans the driving route through the new path of the offer for the driver app's map,
+// the route is kept on the offer so it is planned once per path
+func (matcher *Matcher) setRoutePolyline(offer *model.Offer, matchingResult *model.MatchingResult) {
+	polyline, err := matcher.routePlanner.PlanRoute(offer)
+	if err != nil {
+		log.Warn().Err(err).Str("offer_id", offer.ID()).Msg("failed to plan the route of the matching result")
+		return
+	}
+	offer.SetRoutePolyline(polyline)
+	matchingResult.SetRoutePolyline(polyline)
+}
@@ -0,0 +1,138 @@
+package matcher
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/collections"
+	"matching-engine/internal/model"
+)
+
+// matchRoundTrips runs the matching until every matched leg of a round trip has its other leg matched too.
+// The legs of the broken round trips are dropped, and the offers are restored before running the matching again.
+// A leg whose other leg is not part of the input is matched on its own, as the reader adds the other legs still waiting
+// for a driver, so the other leg was matched by a previous run.
+func (matcher *Matcher) matchRoundTrips(offers []*model.Offer, requests []*model.Request) ([]*model.MatchingResult, error) {
+	originalPaths := make([][]model.PathPoint, len(offers))
+	originalSignatures := make([]string, len(offers))
+	for i, offer := range offers {
+		originalPaths[i] = offer.Path()
+		originalSignatures[i] = model.PathSignature(offer.Path())
+	}
+
+	for {
+		results, err := matcher.matchOnce(offers, requests)
+		if err != nil {
+			return nil, err
+		}
+
+		brokenRoundTrips := findBrokenRoundTrips(results, requests)
+		if brokenRoundTrips.Size() == 0 {
+			return results, nil
+		}
+		log.Info().Msgf("Dropping %d legs of round trips matched without their other leg", brokenRoundTrips.Size())
+
+		remainingRequests := make([]*model.Request, 0, len(requests))
+		for _, request := range requests {
+			if !brokenRoundTrips.Contains(request.ID()) {
+				remainingRequests = append(remainingRequests, request)
+			}
+		}
+		requests = remainingRequests
+
+		for i, offer := range offers {
+			if model.PathSignature(offer.Path()) == originalSignatures[i] {
+				continue
+			}
+			offer.SetPath(originalPaths[i])
+			// The matrices, routes and pickup points of the offer were computed from the dropped path
+			matcher.offerCaches.invalidateOffer(offer.ID())
+		}
+		if len(requests) == 0 {
+			return make([]*model.MatchingResult, 0), nil
+		}
+	}
+}
+
+// findBrokenRoundTrips returns the IDs of both legs of every round trip with only one matched leg
+func findBrokenRoundTrips(results []*model.MatchingResult, requests []*model.Request) *collections.Set[string] {
+	inputRequests := collections.NewSet[string]()
+	for _, request := range requests {
+		inputRequests.Add(request.ID())
+	}
+
+	matchedRequests := collections.NewSet[string]()
+	for _, result := range results {
+		for _, request := range result.AssignedMatchedRequests() {
+			matchedRequests.Add(request.ID())
+		}
+	}
+
+	brokenRoundTrips := collections.NewSet[string]()
+	for _, request := range requests {
+		linkedRequestID := request.LinkedRequestID()
+		if linkedRequestID == "" || !inputRequests.Contains(linkedRequestID) {
+			continue
+		}
+		if matchedRequests.Contains(request.ID()) && !matchedRequests.Contains(linkedRequestID) {
+			brokenRoundTrips.Add(request.ID())
+			brokenRoundTrips.Add(linkedRequestID)
+		}
+	}
+	return brokenRoundTrips
+}
+
+// alignRoundTripDrivers moves a leg of a round trip matched in this round to an offer of the driver of its other leg,
+// so the same driver takes both legs when possible. The leg is only moved to an offer with an edge to it which is not
+// matched in this round, so the size of the matching is unchanged.
+func (matcher *Matcher) alignRoundTripDrivers(graph *model.MaximumMatchingGraph, pairs []collections.Tuple2[*model.OfferNode, *model.Edge]) []collections.Tuple2[*model.OfferNode, *model.Edge] {
+	legDrivers := make(map[string]string, len(matcher.roundTripDrivers)+len(pairs))
+	for requestID, driverID := range matcher.roundTripDrivers {
+		legDrivers[requestID] = driverID
+	}
+	matchedOffers := collections.NewSet[string]()
+	for _, pair := range pairs {
+		legDrivers[pair.Second.RequestNode().Request().ID()] = pair.First.Offer().UserID()
+		matchedOffers.Add(pair.First.Offer().ID())
+	}
+
+	var offerNodes []*model.OfferNode
+	for i, pair := range pairs {
+		request := pair.Second.RequestNode().Request()
+		driverID, linked := legDrivers[request.LinkedRequestID()]
+		if request.LinkedRequestID() == "" || !linked || driverID == pair.First.Offer().UserID() {
+			continue
+		}
+		if offerNodes == nil {
+			offerNodes = sortedOfferNodes(graph)
+		}
+		for _, offerNode := range offerNodes {
+			if offerNode.Offer().UserID() != driverID || matchedOffers.Contains(offerNode.Offer().ID()) {
+				continue
+			}
+			edge, exists := graph.GetEdge(offerNode.Offer(), request)
+			if !exists {
+				continue
+			}
+			matchedOffers.Remove(pair.First.Offer().ID())
+			matchedOffers.Add(offerNode.Offer().ID())
+			pairs[i] = collections.NewTuple2(offerNode, edge)
+			legDrivers[request.ID()] = driverID
+			break
+		}
+	}
+	return pairs
+}
+
+// sortedOfferNodes returns the offer nodes of the graph sorted by offer ID, so the alignment is deterministic
+func sortedOfferNodes(graph *model.MaximumMatchingGraph) []*model.OfferNode {
+	offerNodes := make([]*model.OfferNode, 0, graph.OfferNodes().Size())
+	_ = graph.OfferNodes().Range(func(_ string, offerNode *model.OfferNode) error {
+		offerNodes = append(offerNodes, offerNode)
+		return nil
+	})
+	sort.Slice(offerNodes, func(i, j int) bool {
+		return offerNodes[i].Offer().ID() < offerNodes[j].Offer().ID()
+	})
+	return offerNodes
+}
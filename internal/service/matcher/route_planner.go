@@ -0,0 +1,9 @@
+package matcher
+
+import "matching-engine/internal/model"
+
+// RoutePlanner plans the driving route through the path of an offer, reported in the results.
+// It is nil when the results are sent without a route.
+type RoutePlanner interface {
+	PlanRoute(offer *model.Offer) (*model.Polyline, error)
+}
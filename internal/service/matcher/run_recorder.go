@@ -0,0 +1,11 @@
+package matcher
+
+import "matching-engine/internal/model"
+
+// RunRecorder records the geometry of a matching run to debug it on a map.
+// It is nil when the runs are not recorded.
+type RunRecorder interface {
+	RecordOffers(offers []*model.Offer)
+	RecordCandidateGraph(round int, graph *model.MaximumMatchingGraph)
+	RecordResults(results []*model.MatchingResult) error
+}
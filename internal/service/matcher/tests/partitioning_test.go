@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/affinity"
+	"matching-engine/internal/service/earlypruning"
+	"matching-engine/internal/service/matcher"
+	"matching-engine/internal/service/matchevaluator"
+	"matching-engine/internal/service/maximummatching"
+	"matching-engine/internal/service/timematrix"
+	"matching-engine/internal/service/timematrix/cache"
+	"sort"
+	"testing"
+	"time"
+)
+
+// allPairsChecker lets every pair through, the evaluator decides which pairs are feasible
+type allPairsChecker struct{}
+
+func (c *allPairsChecker) Check(offer *model.Offer, request *model.Request) (bool, error) {
+	return true, nil
+}
+
+// noMatrixGenerator skips the time matrices, the evaluator doesn't need them
+type noMatrixGenerator struct{}
+
+func (g *noMatrixGenerator) Generate(offer *model.OfferNode, requestNodes []*model.RequestNode) (*cache.PathPointMappedTimeMatrix, error) {
+	return nil, nil
+}
+
+// pairEvaluator accepts the listed pairs, inserting the pickup and the dropoff before the destination of the offer
+type pairEvaluator struct {
+	feasible map[string]string // request ID to offer ID
+}
+
+func (e *pairEvaluator) Evaluate(offerNode *model.OfferNode, requestNode *model.RequestNode) ([]model.PathPoint, bool, error) {
+	offer, request := offerNode.Offer(), requestNode.Request()
+	if e.feasible[request.ID()] != offer.ID() {
+		return nil, false, nil
+	}
+	path := offer.Path()
+	newPath := make([]model.PathPoint, 0, len(path)+2)
+	newPath = append(newPath, path[:len(path)-1]...)
+	newPath = append(newPath,
+		*model.NewPathPoint(*request.Source(), enums.Pickup, request.EarliestDepartureTime(), request, 0),
+		*model.NewPathPoint(*request.Destination(), enums.Dropoff, request.LatestArrivalTime(), request, 0),
+		path[len(path)-1],
+	)
+	return newPath, true, nil
+}
+
+// roundRecorder keeps the rounds of the recorded candidate graphs
+type roundRecorder struct {
+	rounds []int
+}
+
+func (r *roundRecorder) RecordOffers(offers []*model.Offer) {}
+
+func (r *roundRecorder) RecordCandidateGraph(round int, graph *model.MaximumMatchingGraph) {
+	r.rounds = append(r.rounds, round)
+}
+
+func (r *roundRecorder) RecordResults(results []*model.MatchingResult) error {
+	return nil
+}
+
+func newTestMatcher(evaluator matchevaluator.Evaluator) *matcher.Matcher {
+	return newRecordedTestMatcher(evaluator, nil)
+}
+
+func newRecordedTestMatcher(evaluator matchevaluator.Evaluator, recorder matcher.RunRecorder) *matcher.Matcher {
+	return matcher.NewMatcher(
+		evaluator,
+		earlypruning.NewPreChecksCandidateGenerator(&allPairsChecker{}),
+		maximummatching.NewHopcroftKarp(),
+		timematrix.NewCacheWithOfferIdPopulator(&noMatrixGenerator{}, cache.NewTimeMatrixCacheWithOfferId()),
+		affinity.NewTracker(),
+		matcher.OfferCaches{},
+		nil, nil, nil, recorder, nil,
+	)
+}
+
+func TestMatch_ZonePartitions(t *testing.T) {
+	t.Setenv("MATCHING_PARTITION_MODE", matcher.PartitionModeZone)
+	t.Setenv("MATCHING_PARTITION_WORKERS", "2")
+
+	departure := time.Now().Add(time.Hour)
+	coordinate := func(lat, lng float64) model.Coordinate {
+		c, err := model.NewCoordinate(lat, lng)
+		if err != nil {
+			t.Fatalf("invalid coordinate: %v", err)
+		}
+		return *c
+	}
+	newOffer := func(id string, source, destination model.Coordinate) *model.Offer {
+		path := []model.PathPoint{
+			*model.NewPathPoint(source, enums.Source, departure, nil, 0),
+			*model.NewPathPoint(destination, enums.Destination, departure.Add(time.Hour), nil, 0),
+		}
+		return model.NewOffer(id, "driver_"+id, source, destination, departure, 10*time.Minute, 3,
+			model.Preference{}, departure.Add(time.Hour), 0, path, nil)
+	}
+	newRequest := func(id string, source, destination model.Coordinate) *model.Request {
+		return model.NewRequest(id, "rider_"+id, source, destination, departure,
+			departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+	}
+
+	// Two cities far apart, and a rider starting in a third zone on the way of the driver of the first city
+	offers := []*model.Offer{
+		newOffer("cairo", coordinate(30.0, 31.2), coordinate(30.1, 31.3)),
+		newOffer("alexandria", coordinate(31.2, 29.9), coordinate(31.3, 30.0)),
+	}
+	requests := []*model.Request{
+		newRequest("cairo_rider", coordinate(30.01, 31.21), coordinate(30.09, 31.29)),
+		newRequest("alexandria_rider", coordinate(31.21, 29.91), coordinate(31.29, 29.99)),
+		newRequest("boundary_rider", coordinate(30.5, 31.25), coordinate(30.09, 31.29)),
+	}
+	evaluator := &pairEvaluator{feasible: map[string]string{
+		"cairo_rider":      "cairo",
+		"alexandria_rider": "alexandria",
+		"boundary_rider":   "cairo",
+	}}
+
+	recorder := &roundRecorder{}
+	results, err := newRecordedTestMatcher(evaluator, recorder).Match(offers, requests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assigned := make(map[string][]string)
+	for _, result := range results {
+		for _, request := range result.AssignedMatchedRequests() {
+			assigned[result.OfferID()] = append(assigned[result.OfferID()], request.ID())
+		}
+		sort.Strings(assigned[result.OfferID()])
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected one result per offer, got %d results: %v", len(results), assigned)
+	}
+	if got := assigned["cairo"]; len(got) != 2 || got[0] != "boundary_rider" || got[1] != "cairo_rider" {
+		t.Errorf("expected the cairo offer to pick up its rider and the boundary rider, got %v", got)
+	}
+	if got := assigned["alexandria"]; len(got) != 1 || got[0] != "alexandria_rider" {
+		t.Errorf("expected the alexandria offer to pick up its rider, got %v", got)
+	}
+
+	for _, result := range results {
+		if result.OfferID() != "cairo" {
+			continue
+		}
+		if result.CurrentNumberOfRequests() != 2 {
+			t.Errorf("expected 2 requests on the cairo offer, got %d", result.CurrentNumberOfRequests())
+		}
+		// The reconciliation inserted the boundary rider into the path found by the partition
+		if len(result.NewPath()) != 6 {
+			t.Errorf("expected a path through both riders, got %d points", len(result.NewPath()))
+		}
+	}
+
+	// Only the reconciliation is recorded, the partitions would repeat its round numbers
+	if len(recorder.rounds) == 0 {
+		t.Error("expected the reconciliation to be recorded")
+	}
+	for i, round := range recorder.rounds {
+		if round != i+1 {
+			t.Errorf("expected the recorded rounds to follow each other, got %v", recorder.rounds)
+			break
+		}
+	}
+}
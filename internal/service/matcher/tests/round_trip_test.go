@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"matching-engine/internal/collections"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/affinity"
+	"matching-engine/internal/service/earlypruning"
+	"matching-engine/internal/service/matcher"
+	"matching-engine/internal/service/maximummatching"
+	"matching-engine/internal/service/timematrix"
+	"matching-engine/internal/service/timematrix/cache"
+	"testing"
+	"time"
+)
+
+// multiPairEvaluator accepts the listed pairs, inserting the pickup and the dropoff before the destination of the offer
+type multiPairEvaluator struct {
+	feasible map[string][]string // request ID to offer IDs
+}
+
+func (e *multiPairEvaluator) Evaluate(offerNode *model.OfferNode, requestNode *model.RequestNode) ([]model.PathPoint, bool, error) {
+	for _, offerID := range e.feasible[requestNode.Request().ID()] {
+		if offerID == offerNode.Offer().ID() {
+			return (&pairEvaluator{feasible: map[string]string{requestNode.Request().ID(): offerID}}).Evaluate(offerNode, requestNode)
+		}
+	}
+	return nil, false, nil
+}
+
+// pathRecordingCache records the signature of the path of the offers when they are invalidated
+type pathRecordingCache struct {
+	offers        map[string]*model.Offer
+	invalidations map[string][]string
+}
+
+func (c *pathRecordingCache) InvalidateOffer(offerID string) {
+	c.invalidations[offerID] = append(c.invalidations[offerID], model.PathSignature(c.offers[offerID].Path()))
+}
+
+func (c *pathRecordingCache) Stats() collections.CacheStats {
+	return collections.CacheStats{}
+}
+
+func newRoundTripOffer(id, driverID string, departure time.Time) *model.Offer {
+	source, destination := model.Coordinate{}, model.Coordinate{}
+	path := []model.PathPoint{
+		*model.NewPathPoint(source, enums.Source, departure, nil, 0),
+		*model.NewPathPoint(destination, enums.Destination, departure.Add(time.Hour), nil, 0),
+	}
+	return model.NewOffer(id, driverID, source, destination, departure, 10*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, path, nil)
+}
+
+func newRoundTripRequest(id, riderID, linkedRequestID string, departure time.Time) *model.Request {
+	request := model.NewRequest(id, riderID, model.Coordinate{}, model.Coordinate{}, departure,
+		departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+	request.SetLinkedRequestID(linkedRequestID)
+	return request
+}
+
+func assignedOffers(results []*model.MatchingResult) map[string]string {
+	assigned := make(map[string]string)
+	for _, result := range results {
+		for _, request := range result.AssignedMatchedRequests() {
+			assigned[request.ID()] = result.OfferID()
+		}
+	}
+	return assigned
+}
+
+func TestMatch_RoundTripBothLegsWithTheSameDriver(t *testing.T) {
+	t.Setenv("ROUND_TRIP_MODE", matcher.RoundTripModeBothLegs)
+
+	morning := time.Now().Add(time.Hour)
+	evening := morning.Add(9 * time.Hour)
+	// The maximum matching alone may give the return leg to either driver
+	offers := []*model.Offer{
+		newRoundTripOffer("b_morning", "driver1", morning),
+		newRoundTripOffer("a_evening", "driver2", evening),
+		newRoundTripOffer("b_evening", "driver1", evening),
+	}
+	requests := []*model.Request{
+		newRoundTripRequest("outbound", "rider1", "return", morning),
+		newRoundTripRequest("return", "rider1", "outbound", evening),
+	}
+	evaluator := &multiPairEvaluator{feasible: map[string][]string{
+		"outbound": {"b_morning"},
+		"return":   {"a_evening", "b_evening"},
+	}}
+
+	results, err := newTestMatcher(evaluator).Match(offers, requests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assigned := assignedOffers(results)
+	if assigned["outbound"] != "b_morning" {
+		t.Errorf("expected the outbound leg on b_morning, got %q", assigned["outbound"])
+	}
+	if assigned["return"] != "b_evening" {
+		t.Errorf("expected the return leg with the driver of the outbound leg on b_evening, got %q", assigned["return"])
+	}
+}
+
+func TestMatch_RoundTripRollback(t *testing.T) {
+	t.Setenv("ROUND_TRIP_MODE", matcher.RoundTripModeBothLegs)
+
+	morning := time.Now().Add(time.Hour)
+	offer := newRoundTripOffer("morning", "driver1", morning)
+	originalSignature := model.PathSignature(offer.Path())
+	requests := []*model.Request{
+		newRoundTripRequest("outbound", "rider1", "return", morning),
+		newRoundTripRequest("return", "rider1", "outbound", morning.Add(9*time.Hour)),
+		newRoundTripRequest("one_way", "rider2", "", morning),
+	}
+	// The return leg has no driver, so the outbound leg must be dropped
+	evaluator := &multiPairEvaluator{feasible: map[string][]string{
+		"outbound": {"morning"},
+		"one_way":  {"morning"},
+	}}
+
+	pathCache := &pathRecordingCache{
+		offers:        map[string]*model.Offer{"morning": offer},
+		invalidations: make(map[string][]string),
+	}
+	tracker := affinity.NewTracker()
+	m := matcher.NewMatcher(
+		evaluator,
+		earlypruning.NewPreChecksCandidateGenerator(&allPairsChecker{}),
+		maximummatching.NewHopcroftKarp(),
+		timematrix.NewCacheWithOfferIdPopulator(&noMatrixGenerator{}, cache.NewTimeMatrixCacheWithOfferId()),
+		tracker,
+		matcher.OfferCaches{"path": pathCache},
+		nil, nil, nil, nil, nil,
+	)
+
+	results, err := m.Match([]*model.Offer{offer}, requests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assigned := assignedOffers(results)
+	if len(assigned) != 1 || assigned["one_way"] != "morning" {
+		t.Fatalf("expected only the one way rider on the offer, got %v", assigned)
+	}
+	if len(results[0].NewPath()) != 4 {
+		t.Errorf("expected the path of the one way rider only, got %d points", len(results[0].NewPath()))
+	}
+
+	restored := false
+	for _, signature := range pathCache.invalidations["morning"] {
+		restored = restored || signature == originalSignature
+	}
+	if !restored {
+		t.Errorf("expected the caches of the offer to be invalidated when its path was restored")
+	}
+	if tracker.Size() != 1 {
+		t.Errorf("expected only the committed one way rider to be recorded, got %d pairs", tracker.Size())
+	}
+}
@@ -0,0 +1,7 @@
+package matcher
+
+// TrafficProfile identifies the traffic profile adjusting the travel times behind the ETAs of the results.
+// It is nil when the routing engine times are used as is.
+type TrafficProfile interface {
+	ID() string
+}
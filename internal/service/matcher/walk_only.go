@@ -0,0 +1,42 @@
+package matcher
+
+import (
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/model"
+	"time"
+)
+
+// WalkOnlyDetector detects the requests whose riders are better off walking the whole way.
+// It is nil when the detection is disabled.
+type WalkOnlyDetector interface {
+	IsWalkable(request *model.Request) (bool, time.Duration, error)
+}
+
+// splitWalkableRequests separates the walkable requests from the ones to match, and returns a walk result for each of them.
+// A request whose walk cannot be computed is kept for matching.
+func (matcher *Matcher) splitWalkableRequests(requests []*model.Request) ([]*model.Request, []*model.MatchingResult) {
+	if matcher.walkOnlyDetector == nil {
+		return requests, nil
+	}
+
+	remainingRequests := make([]*model.Request, 0, len(requests))
+	walkResults := make([]*model.MatchingResult, 0)
+	for _, request := range requests {
+		walkable, walkingDuration, err := matcher.walkOnlyDetector.IsWalkable(request)
+		if err != nil {
+			log.Warn().Err(err).Msgf("failed to check whether request %s is walkable, matching it", request.ID())
+			remainingRequests = append(remainingRequests, request)
+			continue
+		}
+		if !walkable {
+			remainingRequests = append(remainingRequests, request)
+			continue
+		}
+		walkResults = append(walkResults, model.NewWalkMatchingResult(request, walkingDuration))
+	}
+
+	if len(walkResults) > 0 {
+		log.Info().Msgf("%d requests are walkable and will not be matched", len(walkResults))
+	}
+	return remainingRequests, walkResults
+}
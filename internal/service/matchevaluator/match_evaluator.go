@@ -27,7 +27,8 @@ func (m *MatchEvaluator) Evaluate(offerNode *model.OfferNode, requestNode *model
 	offer := offerNode.Offer()
 	request := requestNode.Request()
 
-	valid, err := m.preferenceChecker.Check(offerNode.Offer(), requestNode.Request())
+	// Check against a copy of the offer that includes the requests assigned earlier in this run
+	valid, err := m.preferenceChecker.Check(offerNode.OfferWithAllRequests(), requestNode.Request())
 	if err != nil {
 		return nil, false, fmt.Errorf("preference check failed for offer %s and request %s: %w", offer.ID(), request.ID(), err)
 	}
@@ -35,11 +36,12 @@ func (m *MatchEvaluator) Evaluate(offerNode *model.OfferNode, requestNode *model
 		return nil, false, nil
 	}
 
-	// Populate the time matrix cache with offer ID and request ID
-	err = m.timeMatrixCacheWithDriverOfferIdAndRequestIdPopulator.Populate(offerNode, []*model.RequestNode{requestNode})
+	// Populate the time matrix cache with offer ID and request ID, the path planner reads the matrix pinned for this evaluation
+	_, err = m.timeMatrixCacheWithDriverOfferIdAndRequestIdPopulator.Populate(offerNode, []*model.RequestNode{requestNode})
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to populate time matrix cache for offer %s and request %s: %w", offer.ID(), request.ID(), err)
 	}
+	defer m.timeMatrixCacheWithDriverOfferIdAndRequestIdPopulator.Unpin(offerNode, requestNode)
 
 	// Find the first feasible path using the path planner
 	path, isFeasible, err := m.pathPlanner.FindFirstFeasiblePath(offerNode, requestNode)
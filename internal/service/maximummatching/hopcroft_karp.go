@@ -6,6 +6,7 @@ import (
 	"matching-engine/internal/collections"
 	"matching-engine/internal/model"
 	"math"
+	"sort"
 )
 
 const (
@@ -19,6 +20,7 @@ type HopcroftKarp struct {
 	offerMatches   []int
 	requestMatches []int
 	distances      []int
+	preference     EdgePreference
 }
 
 // NewHopcroftKarp returns a hopcroftKarp using the Hopcroft–Karp algorithm.
@@ -26,6 +28,12 @@ func NewHopcroftKarp() MaximumMatching {
 	return &HopcroftKarp{}
 }
 
+// NewHopcroftKarpWithPreference returns a hopcroftKarp that starts from the preferred edges.
+// Augmenting paths never unmatch a vertex, so the matching stays maximum while keeping as many preferred pairs as possible.
+func NewHopcroftKarpWithPreference(preference EdgePreference) MaximumMatching {
+	return &HopcroftKarp{preference: preference}
+}
+
 // FindMaximumMatching finds maximum bipartite matching between offer and request nodes.
 func (hk *HopcroftKarp) FindMaximumMatching(
 	graph *model.MaximumMatchingGraph,
@@ -46,6 +54,9 @@ func (hk *HopcroftKarp) FindMaximumMatching(
 	adj := buildAdjacencyList(offers, requestIndex, offerCount)
 
 	matchingCount := 0
+	if hk.preference != nil {
+		matchingCount = hk.seedPreferredEdges(offers, requestIndex, adj)
+	}
 
 	// Main matching loop
 	for hk.computeLayerDistances(adj, offerCount) {
@@ -102,6 +113,62 @@ func buildAdjacencyList(offers []*model.OfferNode, requestIndexMap *collections.
 	return adj
 }
 
+// seedPreferredEdges greedily matches the preferred edges by decreasing score, and moves them first in the adjacency list
+// so that augmenting paths try them first. Returns the number of seeded matches.
+func (hk *HopcroftKarp) seedPreferredEdges(offers []*model.OfferNode, requestIndexMap *collections.SyncMap[*model.RequestNode, int], adj [][]int) int {
+	scores := hk.preference.PreferenceScores(offers)
+	if len(scores) == 0 {
+		return 0
+	}
+
+	type preferredEdge struct {
+		offerIndex   int
+		requestIndex int
+		score        int
+	}
+	preferredEdges := make([]preferredEdge, 0, len(scores))
+	for idx, offer := range offers {
+		for _, edge := range offer.Edges() {
+			score, preferred := scores[edge]
+			requestIdx, exists := requestIndexMap.Get(edge.RequestNode())
+			if !preferred || !exists {
+				continue
+			}
+			preferredEdges = append(preferredEdges, preferredEdge{offerIndex: idx + 1, requestIndex: requestIdx, score: score})
+		}
+	}
+	sort.SliceStable(preferredEdges, func(i, j int) bool {
+		return preferredEdges[i].score > preferredEdges[j].score
+	})
+
+	// Moving the lowest scores first leaves the highest score at the front
+	for i := len(preferredEdges) - 1; i >= 0; i-- {
+		moveToFront(adj[preferredEdges[i].offerIndex], preferredEdges[i].requestIndex)
+	}
+
+	seeded := 0
+	for _, edge := range preferredEdges {
+		if hk.offerMatches[edge.offerIndex] != NIL || hk.requestMatches[edge.requestIndex] != NIL {
+			continue
+		}
+		hk.offerMatches[edge.offerIndex] = edge.requestIndex
+		hk.requestMatches[edge.requestIndex] = edge.offerIndex
+		seeded++
+	}
+	return seeded
+}
+
+// moveToFront moves the request to the front of the adjacency list of an offer
+func moveToFront(requests []int, request int) {
+	for i, candidate := range requests {
+		if candidate == request {
+			copy(requests[1:i+1], requests[:i])
+			requests[0] = request
+			return
+		}
+	}
+}
+
 func (hk *HopcroftKarp) computeLayerDistances(adj [][]int, offerCount int) bool {
 	// Reset distances
 	distNIL := INF
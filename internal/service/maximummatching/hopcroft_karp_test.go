@@ -421,3 +421,45 @@ func TestHopcroftKarp_LargeCase(t *testing.T) {
 	fmt.Printf("\nAverage matches over %d runs: %.2f\n", nRuns, float64(totalMatches)/float64(nRuns))
 	fmt.Printf("Average time over %d runs: %v\n", nRuns, totalTime/time.Duration(nRuns))
 }
+
+// preferredEdges is an EdgePreference giving the same score to a fixed set of edges
+type preferredEdges []*model.Edge
+
+func (p preferredEdges) PreferenceScores(_ []*model.OfferNode) map[*model.Edge]int {
+	scores := make(map[*model.Edge]int, len(p))
+	for _, edge := range p {
+		scores[edge] = 2
+	}
+	return scores
+}
+
+func TestHopcroftKarp_FindMaximumMatching_WithPreference(t *testing.T) {
+	g := model.NewMaximumMatchingGraph()
+	offerNode1 := model.NewOfferNode(minimalOffer("offer1"))
+	offerNode2 := model.NewOfferNode(minimalOffer("offer2"))
+	requestNode1 := model.NewRequestNode(minimalRequest("request1"))
+	requestNode2 := model.NewRequestNode(minimalRequest("request2"))
+	g.AddOfferNode(offerNode1)
+	g.AddOfferNode(offerNode2)
+	g.AddRequestNode(requestNode1)
+	g.AddRequestNode(requestNode2)
+	preferred := minimalEdge(requestNode2)
+	g.AddEdge(offerNode1, requestNode1, minimalEdge(requestNode1))
+	g.AddEdge(offerNode1, requestNode2, preferred)
+	g.AddEdge(offerNode2, requestNode1, minimalEdge(requestNode1))
+	g.AddEdge(offerNode2, requestNode2, minimalEdge(requestNode2))
+
+	hk := NewHopcroftKarpWithPreference(preferredEdges{preferred})
+	got, err := hk.FindMaximumMatching(g)
+	if err != nil {
+		t.Fatalf("HopcroftKarp.FindMaximumMatching() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("HopcroftKarp.FindMaximumMatching() got size = %v, want 2", len(got))
+	}
+	for _, pair := range got {
+		if pair.First == offerNode1 && pair.Second != preferred {
+			t.Errorf("offer1 matched with request %s, want preferred request2", pair.Second.RequestNode().Request().ID())
+		}
+	}
+}
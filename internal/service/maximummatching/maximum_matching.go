@@ -9,3 +9,9 @@ type MaximumMatching interface {
 	// FindMaximumMatching finds the maximum matching in a bipartite graph.
 	FindMaximumMatching(graph *model.MaximumMatchingGraph) ([]collections.Tuple2[*model.OfferNode, *model.Edge], error)
 }
+
+// EdgePreference scores the edges that should be kept in the matching whenever possible.
+// Edges missing from the scores have no preference, higher scores are kept first.
+type EdgePreference interface {
+	PreferenceScores(offers []*model.OfferNode) map[*model.Edge]int
+}
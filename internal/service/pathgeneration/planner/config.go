@@ -3,8 +3,12 @@ package planner
 import (
 	"github.com/rs/zerolog/log"
 	"os"
+	"strconv"
 )
 
+// DefaultCandidateWalkingWeight values a minute of rider walking as much as a minute of driver trip
+const DefaultCandidateWalkingWeight = 1.0
+
 func getPathPlannerType() string {
 	pathPlannerType := "default" // Default path planner type
 	if v, ok := os.LookupEnv("PATH_PLANNER_TYPE"); ok && v != "" {
@@ -14,3 +18,18 @@ func getPathPlannerType() string {
 	}
 	return pathPlannerType
 }
+
+// getCandidateWalkingWeight returns how much a minute of walking costs relative to a minute of driver trip
+// when choosing among the pickup and dropoff candidates
+func getCandidateWalkingWeight() float64 {
+	walkingWeight := DefaultCandidateWalkingWeight
+	if v, ok := os.LookupEnv("CANDIDATE_WALKING_WEIGHT"); ok && v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 {
+			log.Warn().Msgf("Invalid CANDIDATE_WALKING_WEIGHT value %q, using default: %f", v, DefaultCandidateWalkingWeight)
+		} else {
+			walkingWeight = parsed
+		}
+	}
+	return walkingWeight
+}
@@ -7,6 +7,7 @@ import (
 	"matching-engine/internal/service/pathgeneration/generator"
 	"matching-engine/internal/service/pathgeneration/validator"
 	"matching-engine/internal/service/pickupdropoffservice"
+	"time"
 )
 
 // TODO: Revisit error handling
@@ -14,6 +15,8 @@ type DefaultPathPlanner struct {
 	pathGenerator         generator.PathGenerator
 	pathValidator         validator.PathValidator
 	pickupDropoffSelector pickupdropoffservice.PickupDropoffSelectorInterface
+	// walkingWeight is the weight of the rider walking duration against the driver trip duration, set by CANDIDATE_WALKING_WEIGHT
+	walkingWeight float64
 }
 
 func NewDefaultPathPlanner(pathGenerator generator.PathGenerator, pathValidator validator.PathValidator, selector pickupdropoffservice.PickupDropoffSelectorInterface) PathPlanner {
@@ -21,6 +24,7 @@ func NewDefaultPathPlanner(pathGenerator generator.PathGenerator, pathValidator
 		pathGenerator:         pathGenerator,
 		pathValidator:         pathValidator,
 		pickupDropoffSelector: selector,
+		walkingWeight:         getCandidateWalkingWeight(),
 	}
 }
 func (planner *DefaultPathPlanner) FindFirstFeasiblePath(offerNode *model.OfferNode, requestNode *model.RequestNode) ([]model.PathPoint, bool, error) {
@@ -30,10 +34,95 @@ func (planner *DefaultPathPlanner) FindFirstFeasiblePath(offerNode *model.OfferN
 		return nil, false, fmt.Errorf("FindFirstFeasiblePath: error getting pickup & dropoff points: %w", err)
 	}
 
+	pickups := pickupAndDropOffs.PickupCandidates()
+	dropoffs := pickupAndDropOffs.DropoffCandidates()
+	path, found, err := planner.findPath(offerNode, requestNode, offerNode.Offer().Path(), pickups, dropoffs)
+	if err != nil || found {
+		return path, found, err
+	}
+
+	// The driver of a flexible source offer starts elsewhere only when the trip can't start at the source
+	for _, source := range pickupAndDropOffs.SourceCandidates() {
+		path, found, err = planner.findPath(offerNode, requestNode, withSource(offerNode.Offer().Path(), source), pickups, dropoffs)
+		if err != nil || found {
+			return path, found, err
+		}
+	}
+	return nil, false, nil
+}
+
+// findPath searches a path inserting the request into the offer path, through the best pickup and dropoff candidates
+func (planner *DefaultPathPlanner) findPath(
+	offerNode *model.OfferNode,
+	requestNode *model.RequestNode,
+	offerPath []model.PathPoint,
+	pickups, dropoffs []*model.PathPoint,
+) ([]model.PathPoint, bool, error) {
+	if len(pickups) == 1 && len(dropoffs) == 1 {
+		return planner.findFirstFeasiblePath(offerNode, requestNode, offerPath, pickups[0], dropoffs[0])
+	}
+	return planner.findBestCandidatesPath(offerNode, requestNode, offerPath, pickups, dropoffs)
+}
+
+// withSource returns a copy of the offer path starting at the given source
+func withSource(offerPath []model.PathPoint, source *model.PathPoint) []model.PathPoint {
+	path := make([]model.PathPoint, len(offerPath))
+	copy(path, offerPath)
+	path[0] = *source
+	return path
+}
+
+// findBestCandidatesPath searches the combinations of ranked pickup and dropoff candidates.
+// The first feasible path of each combination is scored by the driver trip duration plus the rider walking
+// duration weighted by CANDIDATE_WALKING_WEIGHT, the path with the lowest score is returned.
+func (planner *DefaultPathPlanner) findBestCandidatesPath(
+	offerNode *model.OfferNode,
+	requestNode *model.RequestNode,
+	offerPath []model.PathPoint,
+	pickups, dropoffs []*model.PathPoint,
+) ([]model.PathPoint, bool, error) {
+	maxWalkingDuration := requestNode.Request().MaxWalkingDurationMinutes()
+	var bestPath []model.PathPoint
+	var bestCost time.Duration
+	for _, pickup := range pickups {
+		for _, dropoff := range dropoffs {
+			// trade walking against detour only within the rider's walking limit
+			if pickup.WalkingDuration() > maxWalkingDuration || dropoff.WalkingDuration() > maxWalkingDuration {
+				continue
+			}
+			path, found, err := planner.findFirstFeasiblePath(offerNode, requestNode, offerPath, pickup, dropoff)
+			if err != nil {
+				return nil, false, err
+			}
+			if !found {
+				continue
+			}
+
+			tripDuration := path[len(path)-1].ExpectedArrivalTime().Sub(offerNode.Offer().DepartureTime())
+			walkingDuration := pickup.WalkingDuration() + dropoff.WalkingDuration()
+			cost := tripDuration + time.Duration(planner.walkingWeight*float64(walkingDuration))
+			if bestPath == nil || cost < bestCost {
+				bestPath, bestCost = path, cost
+			}
+		}
+	}
+
+	if bestPath == nil {
+		return nil, false, nil
+	}
+	return bestPath, true, nil
+}
+
+func (planner *DefaultPathPlanner) findFirstFeasiblePath(
+	offerNode *model.OfferNode,
+	requestNode *model.RequestNode,
+	offerPath []model.PathPoint,
+	pickup, dropoff *model.PathPoint,
+) ([]model.PathPoint, bool, error) {
 	pathIter, err := planner.pathGenerator.GeneratePaths(
-		offerNode.Offer().Path(),
-		pickupAndDropOffs.Pickup(),
-		pickupAndDropOffs.Dropoff(),
+		offerPath,
+		pickup,
+		dropoff,
 	)
 
 	if err != nil {
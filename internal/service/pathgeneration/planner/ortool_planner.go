@@ -75,6 +75,7 @@ func (p *ORToolPlanner) FindFirstFeasiblePath(
 	subMatrix := make([][]int, pathLen)
 	timeWindows := make([][2]int, pathLen)
 	capacities := make([]int, pathLen)
+	capacityDemands := make([][]int, pathLen)
 	pickupDropoffMap := map[string][2]int{}
 	departureTime := offerNode.Offer().DepartureTime()
 
@@ -86,7 +87,8 @@ func (p *ORToolPlanner) FindFirstFeasiblePath(
 
 		timeWindow, capacity := calculateTimeWindow(fromPoint, departureTime, pickupDropoffMap, i)
 		timeWindows[i] = timeWindow
-		capacities[i] = capacity
+		capacities[i] = capacity.Seats()
+		capacityDemands[i] = capacity.Dimensions()
 
 		row := make([]int, pathLen)
 		for j, toPoint := range path {
@@ -111,6 +113,7 @@ func (p *ORToolPlanner) FindFirstFeasiblePath(
 		scaleDownDuration(offerNode.Offer().MaxEstimatedArrivalTime().Sub(departureTime)),
 	)
 
+	data.SetCapacityDimensions(capacityDemands, offerNode.Offer().VehicleCapacity().Dimensions())
 	data.SetMethod(p.cfg.Method)
 	data.SetTimeout(p.cfg.Timeout)
 	data.SetEnableGuidedLocalSearch(p.cfg.EnableGuidedLocalSearch)
@@ -145,23 +148,23 @@ func (p *ORToolPlanner) FindFirstFeasiblePath(
 	return result, true, nil
 }
 
-func calculateTimeWindow(point model.PathPoint, departure time.Time, pickupDropoffMap map[string][2]int, idx int) ([2]int, int) {
+func calculateTimeWindow(point model.PathPoint, departure time.Time, pickupDropoffMap map[string][2]int, idx int) ([2]int, model.Capacity) {
 	window := make([]int, 2)
-	capacity := 0
+	capacity := model.Capacity{}
 	if request, ok := point.Owner().AsRequest(); ok {
 		window[0] = scaleDownDuration(request.EarliestDepartureTime().Sub(departure))
 		window[1] = scaleDownDuration(request.LatestArrivalTime().Sub(departure))
 		switch point.PointType() {
 		case enums.Pickup:
 			{
-				capacity = request.NumberOfRiders()
+				capacity = request.CapacityNeeds()
 				pickupDropoff := pickupDropoffMap[request.ID()]
 				pickupDropoff[0] = idx
 			}
 
 		case enums.Dropoff:
 			{
-				capacity = -request.NumberOfRiders()
+				capacity = request.CapacityNeeds().Negate()
 				pickupDropoff := pickupDropoffMap[request.ID()]
 				pickupDropoff[1] = idx
 			}
@@ -5,10 +5,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"iter"
+	"matching-engine/internal/enums"
 	"matching-engine/internal/model"
 	"matching-engine/internal/service/pathgeneration/planner"
+	"matching-engine/internal/service/pathgeneration/validator"
 	"matching-engine/internal/service/pickupdropoffservice/pickupdropoffcache"
 	"testing"
+	"time"
 )
 
 // MockPathGenerator implements the PathGenerator interface for testing
@@ -300,3 +303,191 @@ func TestFindFirstFeasiblePath_MultiplePathsFirstInvalid(t *testing.T) {
 	mockValidator.AssertExpectations(t)
 	mockSelector.AssertExpectations(t)
 }
+
+// TestFindFirstFeasiblePath_CandidatesTradeWalkingAgainstDetour tests that a longer walk is chosen when it saves more driving
+func TestFindFirstFeasiblePath_CandidatesTradeWalkingAgainstDetour(t *testing.T) {
+	mockGenerator := new(MockPathGenerator)
+	mockValidator := new(MockPathValidator)
+	mockSelector := new(MockPickupDropoffSelector)
+
+	offer := createDefaultOffer()
+	offerNode := model.NewOfferNode(offer)
+
+	request := createDefaultRequest()
+	requestNode := model.NewRequestNode(request)
+
+	departure := offer.DepartureTime()
+	nearPickup := model.NewPathPoint(*createDefaultCoordinate(), enums.Pickup, departure, request, 2*time.Minute)
+	onRoutePickup := model.NewPathPoint(*createDefaultCoordinate(), enums.Pickup, departure, request, 8*time.Minute)
+	// beyond the 10 minutes walking limit of the request, never explored
+	farPickup := model.NewPathPoint(*createDefaultCoordinate(), enums.Pickup, departure, request, 12*time.Minute)
+	dropoff := model.NewPathPoint(*createDefaultCoordinate(), enums.Dropoff, departure, request, 5*time.Minute)
+
+	pathEndingAt := func(pickup *model.PathPoint, tripDuration time.Duration) []model.PathPoint {
+		destination := model.NewPathPoint(*createDefaultCoordinate(), enums.Destination, departure.Add(tripDuration), nil, 0)
+		return []model.PathPoint{*pickup, *dropoff, *destination}
+	}
+	// the near pickup costs 40 + 2 + 5 minutes, the on-route one 30 + 8 + 5 minutes
+	nearPath := pathEndingAt(nearPickup, 40*time.Minute)
+	onRoutePath := pathEndingAt(onRoutePickup, 30*time.Minute)
+
+	pickupDropoff := pickupdropoffcache.NewValueWithCandidates(
+		[]*model.PathPoint{nearPickup, onRoutePickup, farPickup},
+		[]*model.PathPoint{dropoff},
+	)
+	mockSelector.On("GetPickupDropoffPointsAndDurations", request, offer).Return(pickupDropoff, nil)
+	mockGenerator.On("GeneratePaths", offer.Path(), nearPickup, dropoff).Return([][]model.PathPoint{nearPath}, nil)
+	mockGenerator.On("GeneratePaths", offer.Path(), onRoutePickup, dropoff).Return([][]model.PathPoint{onRoutePath}, nil)
+	mockValidator.On("ValidatePath", offerNode, requestNode, mock.Anything).Return(true, nil)
+
+	planner := planner.NewDefaultPathPlanner(mockGenerator, mockValidator, mockSelector)
+	resultPath, found, err := planner.FindFirstFeasiblePath(offerNode, requestNode)
+
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, onRoutePath, resultPath)
+
+	mockGenerator.AssertExpectations(t)
+	mockValidator.AssertNumberOfCalls(t, "ValidatePath", 2)
+}
+
+// TestFindFirstFeasiblePath_FlexibleSource tests that the driver starts elsewhere only when the trip can't start at the source
+func TestFindFirstFeasiblePath_FlexibleSource(t *testing.T) {
+	mockGenerator := new(MockPathGenerator)
+	mockValidator := new(MockPathValidator)
+	mockSelector := new(MockPickupDropoffSelector)
+
+	departure := time.Now()
+	source := model.NewPathPoint(*createDefaultCoordinate(), enums.Source, departure, nil, 0)
+	destination := model.NewPathPoint(*createDefaultCoordinate(), enums.Destination, departure.Add(time.Hour), nil, 0)
+	offer := model.NewOffer(
+		"flexibleOffer", "defaultDriver",
+		*createDefaultCoordinate(), *createDefaultCoordinate(),
+		departure, 0, 4,
+		model.Preference{}, departure.Add(time.Hour),
+		0, []model.PathPoint{*source, *destination}, nil,
+	)
+	offer.SetFlexibleSourceDuration(10 * time.Minute)
+	offerNode := model.NewOfferNode(offer)
+
+	request := createDefaultRequest()
+	requestNode := model.NewRequestNode(request)
+	pickup, dropoff := createDefaultPickupDropoff(request)
+
+	// The driver can meet the rider at the pickup, or start from a park-and-ride
+	pickupStart := model.NewPathPoint(*pickup.Coordinate(), enums.Source, departure, offer, 0)
+	parkAndRideStart := model.NewPathPoint(*createDefaultCoordinate(), enums.Source, departure, offer, 0)
+	pickupDropoff := pickupdropoffcache.NewValue(pickup, dropoff)
+	pickupDropoff.SetSourceCandidates([]*model.PathPoint{pickupStart, parkAndRideStart})
+
+	startingAt := func(start *model.PathPoint) interface{} {
+		return mock.MatchedBy(func(path []model.PathPoint) bool { return path[0].ID() == start.ID() })
+	}
+	homePath := []model.PathPoint{*source, *pickup, *dropoff, *destination}
+	pickupStartPath := []model.PathPoint{*pickupStart, *pickup, *dropoff, *destination}
+
+	mockSelector.On("GetPickupDropoffPointsAndDurations", request, offer).Return(pickupDropoff, nil)
+	mockGenerator.On("GeneratePaths", startingAt(source), pickup, dropoff).Return([][]model.PathPoint{homePath}, nil)
+	mockGenerator.On("GeneratePaths", startingAt(pickupStart), pickup, dropoff).Return([][]model.PathPoint{pickupStartPath}, nil)
+	mockValidator.On("ValidatePath", offerNode, requestNode, homePath).Return(false, nil)
+	mockValidator.On("ValidatePath", offerNode, requestNode, pickupStartPath).Return(true, nil)
+
+	planner := planner.NewDefaultPathPlanner(mockGenerator, mockValidator, mockSelector)
+	resultPath, found, err := planner.FindFirstFeasiblePath(offerNode, requestNode)
+
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, pickupStartPath, resultPath)
+	// The offer path is left untouched until the match is committed
+	assert.Equal(t, source.ID(), offer.Path()[0].ID())
+
+	// The park-and-ride is not tried once the trip can start at the pickup
+	mockGenerator.AssertNumberOfCalls(t, "GeneratePaths", 2)
+	mockValidator.AssertExpectations(t)
+}
+
+// TestFindFirstFeasiblePath_FlexibleSourceAccessDrive tests that the times of a trip from a flexible start
+// count from the moment the driver reaches it, not from the departure at the source
+func TestFindFirstFeasiblePath_FlexibleSourceAccessDrive(t *testing.T) {
+	departure := time.Now().Truncate(time.Minute)
+
+	tests := []struct {
+		name              string
+		latestArrival     time.Time
+		expectedFound     bool
+		expectedPickupAt  time.Time
+		expectedArrivalAt time.Time
+	}{
+		{
+			name:              "rider dropped off in time after the access drive",
+			latestArrival:     departure.Add(45 * time.Minute),
+			expectedFound:     true,
+			expectedPickupAt:  departure.Add(18 * time.Minute),
+			expectedArrivalAt: departure.Add(48 * time.Minute),
+		},
+		{
+			// the dropoff would be in time if the trip started at the departure time
+			name:          "rider dropped off too late after the access drive",
+			latestArrival: departure.Add(35 * time.Minute),
+			expectedFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGenerator := new(MockPathGenerator)
+			mockSelector := new(MockPickupDropoffSelector)
+			mockTimeMatrixService := new(MockTimeMatrixService)
+
+			source := model.NewPathPoint(*createDefaultCoordinate(), enums.Source, departure, nil, 0)
+			destination := model.NewPathPoint(*createDefaultCoordinate(), enums.Destination, departure.Add(time.Hour), nil, 0)
+			offer := model.NewOffer(
+				"flexibleOffer", "defaultDriver",
+				*createDefaultCoordinate(), *createDefaultCoordinate(),
+				departure, 10*time.Minute, 4,
+				model.Preference{}, departure.Add(time.Hour),
+				0, []model.PathPoint{*source, *destination}, nil,
+			)
+			offer.SetFlexibleSourceDuration(10 * time.Minute)
+			offerNode := model.NewOfferNode(offer)
+
+			request := model.NewRequest(
+				"defaultRequest", "defaultRider",
+				*createDefaultCoordinate(), *createDefaultCoordinate(),
+				departure, tt.latestArrival,
+				10*time.Minute, 1, model.Preference{},
+			)
+			requestNode := model.NewRequestNode(request)
+			pickup := model.NewPathPoint(*createDefaultCoordinate(), enums.Pickup, departure, request, 5*time.Minute)
+			dropoff := model.NewPathPoint(*createDefaultCoordinate(), enums.Dropoff, tt.latestArrival, request, 5*time.Minute)
+
+			// the driver needs 8 minutes to reach the flexible start from the source
+			start := model.NewPathPoint(*createDefaultCoordinate(), enums.Source, departure.Add(8*time.Minute), offer, 0)
+			start.SetAccessDrivingDuration(8 * time.Minute)
+			pickupDropoff := pickupdropoffcache.NewValue(pickup, dropoff)
+			pickupDropoff.SetSourceCandidates([]*model.PathPoint{start})
+
+			startingAt := func(point *model.PathPoint) interface{} {
+				return mock.MatchedBy(func(path []model.PathPoint) bool { return path[0].ID() == point.ID() })
+			}
+			startPath := []model.PathPoint{*start, *pickup, *dropoff, *destination}
+
+			mockSelector.On("GetPickupDropoffPointsAndDurations", request, offer).Return(pickupDropoff, nil)
+			mockGenerator.On("GeneratePaths", startingAt(source), pickup, dropoff).Return([][]model.PathPoint{}, nil)
+			mockGenerator.On("GeneratePaths", startingAt(start), pickup, dropoff).Return([][]model.PathPoint{startPath}, nil)
+			mockTimeMatrixService.On("GetCumulativeTravelDurations", offerNode, requestNode, mock.Anything).
+				Return([]time.Duration{0, 10 * time.Minute, 25 * time.Minute, 40 * time.Minute}, nil)
+			mockTimeMatrixService.On("GetTravelDuration", offerNode, requestNode, start.ID(), destination.ID()).Return(40*time.Minute, nil)
+
+			planner := planner.NewDefaultPathPlanner(mockGenerator, validator.NewDefaultPathValidator(mockTimeMatrixService), mockSelector)
+			resultPath, found, err := planner.FindFirstFeasiblePath(offerNode, requestNode)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFound, found)
+			if tt.expectedFound {
+				assert.Equal(t, tt.expectedPickupAt, resultPath[1].ExpectedArrivalTime())
+				assert.Equal(t, tt.expectedArrivalAt, resultPath[3].ExpectedArrivalTime())
+			}
+		})
+	}
+}
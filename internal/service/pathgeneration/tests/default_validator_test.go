@@ -257,6 +257,35 @@ func TestDefaultPathValidator_ValidatePath(t *testing.T) {
 		mockTimeMatrix.AssertExpectations(t)
 	})
 
+	t.Run("Invalid - luggage exceeds vehicle capacity", func(t *testing.T) {
+
+		offerNode := createTestOfferNode(timeNow, 15*time.Minute)
+		offerNode.Offer().SetVehicleCapacity(*model.NewCapacity(1, 1, 0, 0))
+		sourcePoint := createPathPoint(offerNode.Offer(), enums.Source, timeNow, 0)
+		destinationPoint := createPathPoint(offerNode.Offer(), enums.Destination, timeNow.Add(1*time.Hour), 0)
+
+		requestNode := model.NewRequestNode(createTestRequest(timeNow, timeNow.Add(25*time.Minute), 1)) // dummy values
+
+		r1 := createTestRequest(timeNow, timeNow.Add(25*time.Minute), 1)
+		r2 := createTestRequest(timeNow, timeNow.Add(25*time.Minute), 1)
+		r2.SetCapacityNeeds(*model.NewCapacity(1, 2, 0, 0))
+		p1 := createPathPoint(r1, enums.Pickup, timeNow, 0)
+		d1 := createPathPoint(r1, enums.Dropoff, timeNow.Add(25*time.Minute), 0)
+		p2 := createPathPoint(r2, enums.Pickup, timeNow, 0)
+		d2 := createPathPoint(r2, enums.Dropoff, timeNow.Add(25*time.Minute), 0)
+
+		path := []model.PathPoint{*sourcePoint, *p1, *d1, *p2, *d2, *destinationPoint}
+
+		mockTimeMatrix.On("GetCumulativeTravelDurations", offerNode, requestNode, path).Return(createCumulativeTravelDurations(), nil)
+		mockTimeMatrix.On("GetTravelDuration", offerNode, requestNode, path[0].ID(), path[5].ID()).Return(15*time.Minute, nil)
+
+		valid, err := pathValidator.ValidatePath(offerNode, requestNode, path)
+
+		assert.NoError(t, err)
+		assert.False(t, valid)
+		mockTimeMatrix.AssertExpectations(t)
+	})
+
 	t.Run("Error - System error from time matrix service, GetCumulativeTravelDurations", func(t *testing.T) {
 
 		offerNode := createTestOfferNode(timeNow, 5*time.Minute)
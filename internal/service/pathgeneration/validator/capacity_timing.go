@@ -14,8 +14,10 @@ func (validator *DefaultPathValidator) validateCapacityAndTiming(
 	cumulativeDurations []time.Duration,
 	availableExtraDetour *time.Duration,
 ) (bool, error) {
-	currentCapacity := 0
+	currentCapacity := model.Capacity{}
 	extraAccumulatedDuration := time.Duration(0)
+	// the cumulative durations count from the start of the path, a flexible start is reached after the departure
+	tripStart := offer.TripStartTime(path)
 
 	for i := range path {
 
@@ -30,6 +32,7 @@ func (validator *DefaultPathValidator) validateCapacityAndTiming(
 			valid, err := validator.handlePickupPoint(
 				offer,
 				point, // point.expectedArrivalTime IS BEING MODIFIED BY THE HANDLER
+				tripStart,
 				cumulativeDurations[i],
 				&currentCapacity, // THIS VALUE IS BEING MODIFIED BY THE HANDLER
 			)
@@ -41,6 +44,7 @@ func (validator *DefaultPathValidator) validateCapacityAndTiming(
 			valid, err := validator.handleDropoffPoint(
 				offer,
 				point, // point.expectedArrivalTime IS BEING MODIFIED BY THE HANDLER
+				tripStart,
 				cumulativeDurations[i],
 				&currentCapacity, // THIS VALUE IS BEING MODIFIED BY THE HANDLER
 			)
@@ -49,7 +53,7 @@ func (validator *DefaultPathValidator) validateCapacityAndTiming(
 			}
 
 		case enums.Destination:
-			point.SetExpectedArrivalTime(offer.DepartureTime().Add(cumulativeDurations[i]))
+			point.SetExpectedArrivalTime(tripStart.Add(cumulativeDurations[i]))
 		}
 	}
 
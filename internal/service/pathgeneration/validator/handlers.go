@@ -11,23 +11,24 @@ import (
 func (validator *DefaultPathValidator) handlePickupPoint(
 	offer *model.Offer,
 	point *model.PathPoint,
+	tripStart time.Time,
 	cumulativeDuration time.Duration,
-	currentCapacity *int,
+	currentCapacity *model.Capacity,
 ) (bool, error) {
 	request, ok := point.Owner().AsRequest()
 	if !ok {
 		return false, fmt.Errorf("PathPoint is a pickup and Owner isn't a rider")
 	}
 
-	// Check capacity constraint
-	*currentCapacity += request.NumberOfRiders()
-	if *currentCapacity > offer.Capacity() {
+	// Check capacity constraint on every dimension
+	*currentCapacity = currentCapacity.Add(request.CapacityNeeds())
+	if !currentCapacity.FitsIn(offer.VehicleCapacity()) {
 		return false, nil
 	}
 
 	// Check timing constraints
-	driverArrivalTime := offer.DepartureTime().Add(cumulativeDuration)
-	riderEarliestPickupTime := request.EarliestDepartureTime().Add(point.WalkingDuration()) // also equivalent to point.ExpectedArrivalTime()
+	driverArrivalTime := tripStart.Add(cumulativeDuration)
+	riderEarliestPickupTime := request.EarliestDepartureTime().Add(point.AccessDuration()) // also equivalent to point.ExpectedArrivalTime()
 
 	if driverArrivalTime.Before(riderEarliestPickupTime) {
 		return false, nil
@@ -43,8 +44,9 @@ func (validator *DefaultPathValidator) handlePickupPoint(
 func (validator *DefaultPathValidator) handleDropoffPoint(
 	offer *model.Offer,
 	point *model.PathPoint,
+	tripStart time.Time,
 	cumulativeDuration time.Duration,
-	currentCapacity *int,
+	currentCapacity *model.Capacity,
 ) (bool, error) {
 	request, ok := point.Owner().AsRequest()
 	if !ok {
@@ -52,8 +54,8 @@ func (validator *DefaultPathValidator) handleDropoffPoint(
 	}
 
 	// Check timing constraints
-	driverArrivalTime := offer.DepartureTime().Add(cumulativeDuration)
-	riderLatestDropoffTime := request.LatestArrivalTime().Add(-point.WalkingDuration())
+	driverArrivalTime := tripStart.Add(cumulativeDuration)
+	riderLatestDropoffTime := request.LatestArrivalTime().Add(-point.AccessDuration())
 
 	if driverArrivalTime.After(riderLatestDropoffTime) {
 		// Driver would arrive too late
@@ -61,7 +63,7 @@ func (validator *DefaultPathValidator) handleDropoffPoint(
 	}
 
 	// Update capacity
-	*currentCapacity -= request.NumberOfRiders()
+	*currentCapacity = currentCapacity.Sub(request.CapacityNeeds())
 
 	// Set expected arrival time for dropoff
 	point.SetExpectedArrivalTime(driverArrivalTime)
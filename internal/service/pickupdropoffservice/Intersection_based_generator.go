@@ -14,19 +14,28 @@ import (
 var _ PickupDropoffGenerator = (*IntersectionBasedGenerator)(nil)
 
 type IntersectionBasedGenerator struct {
-	offerProcessorCache *collections.SyncMap[string, processor.GeospatialProcessor]
+	offerProcessorCache *collections.LRUCache[string, processor.GeospatialProcessor]
 	processorFactory    processor.ProcessorFactory
 	routingEngine       routing.Engine
 }
 
 func NewIntersectionBasedGenerator(factory processor.ProcessorFactory, engine routing.Engine) PickupDropoffGenerator {
 	return &IntersectionBasedGenerator{
-		offerProcessorCache: collections.NewSyncMap[string, processor.GeospatialProcessor](),
+		offerProcessorCache: collections.NewLRUCache[string, processor.GeospatialProcessor](getOfferProcessorCacheMaxEntries(), nil),
 		processorFactory:    factory,
 		routingEngine:       engine,
 	}
 }
 
+// InvalidateOffer drops the processor built from the previous route of the offer
+func (g *IntersectionBasedGenerator) InvalidateOffer(offerID string) {
+	g.offerProcessorCache.Delete(offerID)
+}
+
+func (g *IntersectionBasedGenerator) Stats() collections.CacheStats {
+	return g.offerProcessorCache.Stats()
+}
+
 func (g *IntersectionBasedGenerator) getPickupDropoffPoint(
 	geospatialProcessor processor.GeospatialProcessor,
 	coord *model.Coordinate,
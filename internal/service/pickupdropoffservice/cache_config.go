@@ -0,0 +1,24 @@
+package pickupdropoffservice
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultOfferProcessorCacheMaxEntries bounds the number of offers whose route is kept by a generator
+const DefaultOfferProcessorCacheMaxEntries = 10_000
+
+func getOfferProcessorCacheMaxEntries() int64 {
+	v := os.Getenv("OFFER_PROCESSOR_CACHE_MAX_ENTRIES")
+	if v == "" {
+		return DefaultOfferProcessorCacheMaxEntries
+	}
+	maxEntries, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || maxEntries < 0 {
+		log.Warn().Msgf("Invalid OFFER_PROCESSOR_CACHE_MAX_ENTRIES value %q, using default: %d", v, DefaultOfferProcessorCacheMaxEntries)
+		return DefaultOfferProcessorCacheMaxEntries
+	}
+	return maxEntries
+}
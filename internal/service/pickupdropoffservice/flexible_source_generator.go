@@ -0,0 +1,89 @@
+package pickupdropoffservice
+
+import (
+	"context"
+	"fmt"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice/hubcatalogue"
+	"sort"
+)
+
+var _ SourceCandidatesGenerator = (*FlexibleSourceGenerator)(nil)
+
+// maxDrivingSpeedMetersPerSecond bounds the straight-line distance driven within the flexible source duration
+const maxDrivingSpeedMetersPerSecond = 35.0
+
+// FlexibleSourceGenerator proposes the starts the driver reaches from the source within the flexible source duration
+// of the offer: the pickups of the rider, so that the driver meets them halfway, and the park-and-rides of the catalogue.
+type FlexibleSourceGenerator struct {
+	routingEngine routing.Engine
+	parkAndRides  *hubcatalogue.Catalogue
+}
+
+// NewFlexibleSourceGenerator creates a flexible source generator, parkAndRides may be nil
+func NewFlexibleSourceGenerator(engine routing.Engine, parkAndRides *hubcatalogue.Catalogue) *FlexibleSourceGenerator {
+	return &FlexibleSourceGenerator{
+		routingEngine: engine,
+		parkAndRides:  parkAndRides,
+	}
+}
+
+func (g *FlexibleSourceGenerator) GenerateSourceCandidates(request *model.Request, offer *model.Offer, pickups []*model.PathPoint) ([]*model.PathPoint, error) {
+	if request == nil || offer == nil {
+		return nil, fmt.Errorf("request or offer is nil")
+	}
+	maxDuration := offer.FlexibleSourceDuration()
+	if maxDuration <= 0 {
+		return nil, nil
+	}
+
+	candidates := make([]model.Coordinate, 0, len(pickups))
+	for _, pickup := range pickups {
+		candidates = append(candidates, *pickup.Coordinate())
+	}
+	if g.parkAndRides != nil {
+		radiusMeters := maxDuration.Seconds() * maxDrivingSpeedMetersPerSecond
+		for _, parkAndRide := range g.parkAndRides.Within(offer.Source(), radiusMeters) {
+			candidates = append(candidates, *parkAndRide.Coordinate())
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	params, err := model.NewDistanceTimeMatrixParams(
+		[]model.Coordinate{*offer.Source()},
+		model.ProfileAuto,
+		model.WithTargets(candidates),
+		model.WithDepartureTime(offer.DepartureTime()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flexible source matrix params: %w", err)
+	}
+	matrix, err := g.routingEngine.ComputeDistanceTimeMatrix(context.Background(), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute driving times to the source candidates: %w", err)
+	}
+	drivingTimes := matrix.Times()[0]
+
+	reachable := make([]int, 0, len(candidates))
+	for i, duration := range drivingTimes {
+		// the source itself is already the start of the trip
+		if duration > 0 && duration <= maxDuration {
+			reachable = append(reachable, i)
+		}
+	}
+	sort.SliceStable(reachable, func(a, b int) bool {
+		return drivingTimes[reachable[a]] < drivingTimes[reachable[b]]
+	})
+
+	// the driver leaves the source at the departure time, the trip starts once the flexible start is reached
+	sources := make([]*model.PathPoint, len(reachable))
+	for i, index := range reachable {
+		sources[i] = model.NewPathPoint(candidates[index], enums.Source, offer.DepartureTime().Add(drivingTimes[index]), offer, 0)
+		sources[i].SetAccessDrivingDuration(drivingTimes[index])
+	}
+	return sources, nil
+}
@@ -0,0 +1,155 @@
+package pickupdropoffservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/geo/s2"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/geo"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice/hubcatalogue"
+	"sort"
+	"time"
+)
+
+var _ PickupDropoffGenerator = (*HubBasedGenerator)(nil)
+var _ PickupDropoffCandidatesGenerator = (*HubBasedGenerator)(nil)
+
+// DefaultMaxHubRouteDistanceMeters is how far a hub can be from the driver's route to be used as a meeting point
+const DefaultMaxHubRouteDistanceMeters = 150.0
+
+// HubBasedGenerator picks pickup and dropoff points among the fixed meeting points of the catalogue.
+// A hub is a candidate when it is near the driver's route and the rider can walk to it within MaxWalkingDurationMinutes,
+// the closest one by walking time is chosen. The fallback generator is used when no hub is reachable.
+type HubBasedGenerator struct {
+	*offerRouteCache
+	catalogue                 *hubcatalogue.Catalogue
+	routingEngine             routing.Engine
+	fallback                  PickupDropoffGenerator
+	maxHubRouteDistanceMeters float64
+}
+
+func NewHubBasedGenerator(
+	catalogue *hubcatalogue.Catalogue,
+	engine routing.Engine,
+	fallback PickupDropoffGenerator,
+	maxHubRouteDistanceMeters float64,
+) PickupDropoffGenerator {
+	return &HubBasedGenerator{
+		catalogue:                 catalogue,
+		offerRouteCache:           newOfferRouteCache(engine),
+		routingEngine:             engine,
+		fallback:                  fallback,
+		maxHubRouteDistanceMeters: maxHubRouteDistanceMeters,
+	}
+}
+
+func (g *HubBasedGenerator) GeneratePickupDropoffPoints(request *model.Request, offer *model.Offer) (pickup, dropoff *model.PathPoint, err error) {
+	pickups, dropoffs, err := g.GeneratePickupDropoffCandidates(request, offer, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pickups[0], dropoffs[0], nil
+}
+
+// GeneratePickupDropoffCandidates returns the reachable hubs near the route ranked by walking time,
+// the fallback generator provides a single point when no hub is reachable
+func (g *HubBasedGenerator) GeneratePickupDropoffCandidates(request *model.Request, offer *model.Offer, maxCandidates int) (pickups, dropoffs []*model.PathPoint, err error) {
+	if request == nil || offer == nil {
+		return nil, nil, fmt.Errorf("request or offer is nil")
+	}
+	route, err := g.getOfferRoute(offer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pickups, err = g.getHubPoints(route, request.Source(), enums.Pickup, request.EarliestDepartureTime(), request, maxCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	dropoffs, err = g.getHubPoints(route, request.Destination(), enums.Dropoff, request.LatestArrivalTime(), request, maxCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pickups) > 0 && len(dropoffs) > 0 {
+		return pickups, dropoffs, nil
+	}
+
+	fallbackPickup, fallbackDropoff, err := g.fallback.GeneratePickupDropoffPoints(request, offer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pickups) == 0 {
+		pickups = []*model.PathPoint{fallbackPickup}
+	}
+	if len(dropoffs) == 0 {
+		dropoffs = []*model.PathPoint{fallbackDropoff}
+	}
+	return pickups, dropoffs, nil
+}
+
+// InvalidateOffer drops the route built from the previous path of the offer, and the values cached by the fallback
+func (g *HubBasedGenerator) InvalidateOffer(offerID string) {
+	g.offerRouteCache.InvalidateOffer(offerID)
+	if invalidator, ok := g.fallback.(OfferInvalidator); ok {
+		invalidator.InvalidateOffer(offerID)
+	}
+}
+
+// getHubPoints returns up to maxCandidates hubs near the route ranked by walking time from the point,
+// or none if no hub is reachable
+func (g *HubBasedGenerator) getHubPoints(
+	route *s2.Polyline,
+	coord *model.Coordinate,
+	pointType enums.PointType,
+	timeValue time.Time,
+	request *model.Request,
+	maxCandidates int,
+) ([]*model.PathPoint, error) {
+	maxWalkingDuration := request.MaxWalkingDurationMinutes()
+	walkingRadiusMeters := maxWalkingDuration.Seconds() * geo.WalkingSpeedMPS
+
+	candidates := make([]model.Coordinate, 0)
+	for _, hub := range g.catalogue.Within(coord, walkingRadiusMeters) {
+		if distanceToRoute(route, hub.Coordinate()) <= g.maxHubRouteDistanceMeters {
+			candidates = append(candidates, *hub.Coordinate())
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	params, err := model.NewDistanceTimeMatrixParams(
+		[]model.Coordinate{*coord},
+		model.ProfilePedestrian,
+		model.WithTargets(candidates),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hub walking matrix params: %w", err)
+	}
+	matrix, err := g.routingEngine.ComputeDistanceTimeMatrix(context.Background(), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute walking times to hubs: %w", err)
+	}
+	walkingTimes := matrix.Times()[0]
+
+	reachable := make([]int, 0, len(candidates))
+	for i, duration := range walkingTimes {
+		if duration <= maxWalkingDuration {
+			reachable = append(reachable, i)
+		}
+	}
+	sort.SliceStable(reachable, func(a, b int) bool {
+		return walkingTimes[reachable[a]] < walkingTimes[reachable[b]]
+	})
+	if len(reachable) > maxCandidates {
+		reachable = reachable[:maxCandidates]
+	}
+
+	points := make([]*model.PathPoint, len(reachable))
+	for i, index := range reachable {
+		points[i] = model.NewPathPoint(candidates[index], pointType, timeValue, request, walkingTimes[index])
+	}
+	return points, nil
+}
@@ -0,0 +1,40 @@
+package hubcatalogue
+
+import (
+	"github.com/umahmood/haversine"
+	"matching-engine/internal/model"
+)
+
+// Catalogue holds the fixed meeting points allowed for pickups and dropoffs
+type Catalogue struct {
+	hubs []*model.Hub
+}
+
+func NewCatalogue(hubs []*model.Hub) *Catalogue {
+	if hubs == nil {
+		hubs = make([]*model.Hub, 0)
+	}
+	return &Catalogue{hubs: hubs}
+}
+
+func (c *Catalogue) Hubs() []*model.Hub {
+	return c.hubs
+}
+
+func (c *Catalogue) Size() int {
+	return len(c.hubs)
+}
+
+// Within returns the hubs whose great-circle distance to the point is at most radiusMeters
+func (c *Catalogue) Within(point *model.Coordinate, radiusMeters float64) []*model.Hub {
+	from := haversine.Coord{Lat: point.Lat(), Lon: point.Lng()}
+	hubs := make([]*model.Hub, 0)
+	for _, hub := range c.hubs {
+		to := haversine.Coord{Lat: hub.Coordinate().Lat(), Lon: hub.Coordinate().Lng()}
+		_, km := haversine.Distance(from, to)
+		if km*1000 <= radiusMeters {
+			hubs = append(hubs, hub)
+		}
+	}
+	return hubs
+}
@@ -0,0 +1,58 @@
+package hubcatalogue
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/paulmach/go.geojson"
+	"matching-engine/internal/model"
+	"matching-engine/internal/repository"
+)
+
+// LoadFromRepo loads the catalogue from the active hubs in the database
+func LoadFromRepo(ctx context.Context, repo repository.HubRepo) (*Catalogue, error) {
+	hubs, err := repo.GetActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load hubs: %w", err)
+	}
+	return NewCatalogue(hubs), nil
+}
+
+// LoadFromGeoJSON loads the catalogue from a GeoJSON feature collection of points.
+// Each feature may set the "id", "name" and "type" properties, the feature index is used when "id" is missing
+func LoadFromGeoJSON(path string) (*Catalogue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hubs file %s: %w", path, err)
+	}
+	return ParseGeoJSON(data)
+}
+
+// ParseGeoJSON parses a GeoJSON feature collection of points into a catalogue
+func ParseGeoJSON(data []byte) (*Catalogue, error) {
+	fc, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse hubs geojson: %w", err)
+	}
+
+	hubs := make([]*model.Hub, 0, len(fc.Features))
+	for i, feature := range fc.Features {
+		if feature.Geometry == nil || !feature.Geometry.IsPoint() {
+			return nil, fmt.Errorf("hub feature %d is not a point", i)
+		}
+		coordinate, err := model.NewCoordinate(feature.Geometry.Point[1], feature.Geometry.Point[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinate for hub feature %d: %w", i, err)
+		}
+
+		id := feature.PropertyMustString("id", fmt.Sprintf("hub-%d", i))
+		if feature.ID != nil {
+			id = fmt.Sprint(feature.ID)
+		}
+		name := feature.PropertyMustString("name", id)
+		hubType := feature.PropertyMustString("type", "")
+		hubs = append(hubs, model.NewHub(id, name, hubType, *coordinate))
+	}
+	return NewCatalogue(hubs), nil
+}
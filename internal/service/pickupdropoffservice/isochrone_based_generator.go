@@ -0,0 +1,305 @@
+package pickupdropoffservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/geo/s2"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/geo"
+	"matching-engine/internal/model"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+var _ PickupDropoffGenerator = (*IsochroneBasedGenerator)(nil)
+var _ PickupDropoffCandidatesGenerator = (*IsochroneBasedGenerator)(nil)
+
+const (
+	// detourTolerance is the difference under which two candidate detours are considered equal,
+	// the routing engines round travel times and snap candidates to slightly different road positions
+	detourTolerance = 5 * time.Second
+	// minCandidateSpacingMeters keeps the ranked candidates from being the same stop
+	minCandidateSpacingMeters = 25.0
+)
+
+// IsochroneBasedGenerator picks pickup and dropoff points where the driver's route crosses the rider's walking isochrone.
+// Unlike a straight-line walking radius, the isochrone follows the pedestrian network, so a point across a river
+// or a highway is not offered to a rider who cannot walk there. The candidates are the boundary crossings of the
+// route and the route point closest to the rider; the one adding the least driving time to the offer is chosen,
+// ties are broken by walking time. The fallback generator is used when the route does not enter the isochrone,
+// or when the routing engine has no isochrone service, any other failure of the engine is returned.
+type IsochroneBasedGenerator struct {
+	*offerRouteCache
+	routingEngine routing.Engine
+	fallback      PickupDropoffGenerator
+	// isochronesUnsupported is set once the engine reported it has no isochrone service, the fallback is used from then on
+	isochronesUnsupported atomic.Bool
+}
+
+func NewIsochroneBasedGenerator(engine routing.Engine, fallback PickupDropoffGenerator) PickupDropoffGenerator {
+	return &IsochroneBasedGenerator{
+		offerRouteCache: newOfferRouteCache(engine),
+		routingEngine:   engine,
+		fallback:        fallback,
+	}
+}
+
+func (g *IsochroneBasedGenerator) GeneratePickupDropoffPoints(request *model.Request, offer *model.Offer) (pickup, dropoff *model.PathPoint, err error) {
+	pickups, dropoffs, err := g.GeneratePickupDropoffCandidates(request, offer, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pickups[0], dropoffs[0], nil
+}
+
+// GeneratePickupDropoffCandidates returns the route points within walking reach of the rider ranked by driver detour,
+// the fallback generator provides a single point when the route does not enter an isochrone
+func (g *IsochroneBasedGenerator) GeneratePickupDropoffCandidates(request *model.Request, offer *model.Offer, maxCandidates int) (pickups, dropoffs []*model.PathPoint, err error) {
+	if request == nil || offer == nil {
+		return nil, nil, fmt.Errorf("request or offer is nil")
+	}
+	route, err := g.getOfferRoute(offer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pickups, err = g.getIsochronePoints(route, offer, request.Source(), enums.Pickup, request.EarliestDepartureTime(), request, maxCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	dropoffs, err = g.getIsochronePoints(route, offer, request.Destination(), enums.Dropoff, request.LatestArrivalTime(), request, maxCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pickups) > 0 && len(dropoffs) > 0 {
+		return pickups, dropoffs, nil
+	}
+
+	fallbackPickup, fallbackDropoff, err := g.fallback.GeneratePickupDropoffPoints(request, offer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pickups) == 0 {
+		pickups = []*model.PathPoint{fallbackPickup}
+	}
+	if len(dropoffs) == 0 {
+		dropoffs = []*model.PathPoint{fallbackDropoff}
+	}
+	return pickups, dropoffs, nil
+}
+
+// InvalidateOffer drops the route built from the previous path of the offer, and the values cached by the fallback
+func (g *IsochroneBasedGenerator) InvalidateOffer(offerID string) {
+	g.offerRouteCache.InvalidateOffer(offerID)
+	if invalidator, ok := g.fallback.(OfferInvalidator); ok {
+		invalidator.InvalidateOffer(offerID)
+	}
+}
+
+// getIsochronePoints returns up to maxCandidates route points within walking reach of coord, ranked by driver detour
+// and then by walking time, or none if the route does not enter the walking isochrone
+func (g *IsochroneBasedGenerator) getIsochronePoints(
+	route *s2.Polyline,
+	offer *model.Offer,
+	coord *model.Coordinate,
+	pointType enums.PointType,
+	timeValue time.Time,
+	request *model.Request,
+	maxCandidates int,
+) ([]*model.PathPoint, error) {
+	maxWalkingDuration := request.MaxWalkingDurationMinutes()
+	if maxWalkingDuration <= 0 || len(*route) < 2 || g.isochronesUnsupported.Load() {
+		return nil, nil
+	}
+
+	contour, err := model.NewContour(float32(maxWalkingDuration.Minutes()), model.ContourMetricTimeInMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create walking contour: %w", err)
+	}
+	isochroneParams, err := model.NewIsochroneParams(coord, contour, model.ProfilePedestrian)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create isochrone params: %w", err)
+	}
+	isochrone, err := g.routingEngine.ComputeIsochrone(context.Background(), isochroneParams)
+	if errors.Is(err, routing.ErrIsochroneNotSupported) {
+		if !g.isochronesUnsupported.Swap(true) {
+			log.Warn().Err(err).Msg("the routing engine has no isochrone service, pickup and dropoff points use the fallback generator")
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute walking isochrone: %w", err)
+	}
+
+	candidates, positions := routeIsochroneCandidates(route, isochrone, coord)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	walkingTimes, err := g.walkingTimes(coord, candidates, pointType)
+	if err != nil {
+		return nil, err
+	}
+	detours, err := g.detours(route, offer, candidates, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]int, 0, len(candidates))
+	for i := range candidates {
+		if walkingTimes[i] <= maxWalkingDuration {
+			ranked = append(ranked, i)
+		}
+	}
+	// detours are compared in steps of detourTolerance so that engine noise does not outweigh walking time
+	sort.SliceStable(ranked, func(a, b int) bool {
+		stepA, stepB := detours[ranked[a]]/detourTolerance, detours[ranked[b]]/detourTolerance
+		if stepA != stepB {
+			return stepA < stepB
+		}
+		return walkingTimes[ranked[a]] < walkingTimes[ranked[b]]
+	})
+
+	points := make([]*model.PathPoint, 0, maxCandidates)
+	for _, i := range ranked {
+		if len(points) >= maxCandidates {
+			break
+		}
+		if isNearCandidate(points, &candidates[i]) {
+			continue
+		}
+		points = append(points, model.NewPathPoint(candidates[i], pointType, timeValue, request, walkingTimes[i]))
+	}
+	return points, nil
+}
+
+// walkingTimes returns the walking time between the rider and each candidate, towards the candidate for a pickup
+// and from the candidate for a dropoff
+func (g *IsochroneBasedGenerator) walkingTimes(coord *model.Coordinate, candidates []model.Coordinate, pointType enums.PointType) ([]time.Duration, error) {
+	sources, targets := []model.Coordinate{*coord}, candidates
+	if pointType == enums.Dropoff {
+		sources, targets = candidates, []model.Coordinate{*coord}
+	}
+	params, err := model.NewDistanceTimeMatrixParams(sources, model.ProfilePedestrian, model.WithTargets(targets))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create candidates walking matrix params: %w", err)
+	}
+	matrix, err := g.routingEngine.ComputeDistanceTimeMatrix(context.Background(), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute walking times to candidates: %w", err)
+	}
+
+	walkingTimes := make([]time.Duration, len(candidates))
+	for i := range candidates {
+		if pointType == enums.Dropoff {
+			walkingTimes[i] = matrix.Times()[i][0]
+		} else {
+			walkingTimes[i] = matrix.Times()[0][i]
+		}
+	}
+	return walkingTimes, nil
+}
+
+// detours returns the driving time each candidate adds between the offer path points surrounding it on the route
+func (g *IsochroneBasedGenerator) detours(route *s2.Polyline, offer *model.Offer, candidates []model.Coordinate, positions []float64) ([]time.Duration, error) {
+	pathPoints := offer.PathPoints()
+	pathPositions := make([]float64, len(pathPoints))
+	points := make([]model.Coordinate, 0, len(pathPoints)+len(candidates))
+	for i, point := range pathPoints {
+		pathPositions[i] = routePosition(route, toS2Point(point.Coordinate()))
+		points = append(points, *point.Coordinate())
+	}
+	points = append(points, candidates...)
+
+	params, err := model.NewDistanceTimeMatrixParams(points, model.ProfileAuto, model.WithDepartureTime(offer.DepartureTime()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create detour matrix params: %w", err)
+	}
+	matrix, err := g.routingEngine.ComputeDistanceTimeMatrix(context.Background(), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute detours to candidates: %w", err)
+	}
+	times := matrix.Times()
+
+	detours := make([]time.Duration, len(candidates))
+	for i, position := range positions {
+		// the candidate is driven through between the last path point before it and the next one
+		prev := 0
+		for j := 1; j < len(pathPoints)-1; j++ {
+			if pathPositions[j] <= position {
+				prev = j
+			}
+		}
+		next := prev + 1
+		candidate := len(pathPoints) + i
+		detours[i] = times[prev][candidate] + times[candidate][next] - times[prev][next]
+	}
+	return detours, nil
+}
+
+// routeIsochroneCandidates returns the points where the route crosses the isochrone boundary and, when it lies
+// inside the isochrone, the route point closest to the rider, with the position of each of them along the route
+func routeIsochroneCandidates(route *s2.Polyline, isochrone *model.Isochrone, coord *model.Coordinate) ([]model.Coordinate, []float64) {
+	ring := *isochrone.Geometry()
+	// a closed ring needs three distinct vertices and the repeated first one
+	if len(ring) < 4 {
+		return nil, nil
+	}
+	// the ring repeats its first vertex at the end, a loop does not
+	loopPoints := make([]s2.Point, 0, len(ring)-1)
+	for i := 0; i < len(ring)-1; i++ {
+		loopPoints = append(loopPoints, toS2Point(&ring[i]))
+	}
+	loop := s2.LoopFromPoints(loopPoints)
+	loop.Normalize()
+
+	var candidates []model.Coordinate
+	var positions []float64
+	add := func(point s2.Point) {
+		coordinate, err := fromS2Point(point)
+		if err != nil {
+			return
+		}
+		candidates = append(candidates, *coordinate)
+		positions = append(positions, routePosition(route, point))
+	}
+
+	for i := 0; i+1 < len(*route); i++ {
+		a, b := (*route)[i], (*route)[i+1]
+		for j := range loopPoints {
+			c, d := loopPoints[j], loopPoints[(j+1)%len(loopPoints)]
+			if s2.CrossingSign(a, b, c, d) == s2.Cross {
+				add(s2.Intersection(a, b, c, d))
+			}
+		}
+	}
+
+	if closest, _ := route.Project(toS2Point(coord)); loop.ContainsPoint(closest) {
+		add(closest)
+	}
+	return candidates, positions
+}
+
+// routePosition returns the length of the route, in radians, up to the projection of the point
+func routePosition(route *s2.Polyline, point s2.Point) float64 {
+	projected, next := route.Project(point)
+	var position float64
+	for i := 1; i < next; i++ {
+		position += (*route)[i-1].Distance((*route)[i]).Radians()
+	}
+	return position + (*route)[next-1].Distance(projected).Radians()
+}
+
+// isNearCandidate reports whether coord is within minCandidateSpacingMeters of an already selected point
+func isNearCandidate(points []*model.PathPoint, coord *model.Coordinate) bool {
+	for _, point := range points {
+		if toS2Point(point.Coordinate()).Distance(toS2Point(coord)).Radians()*geo.EarthRadiusInMeters < minCandidateSpacingMeters {
+			return true
+		}
+	}
+	return false
+}
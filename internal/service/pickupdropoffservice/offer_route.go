@@ -0,0 +1,118 @@
+package pickupdropoffservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/geo/s2"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/collections"
+	"matching-engine/internal/geo"
+	"matching-engine/internal/model"
+	"math"
+)
+
+// OfferRoutePlanner plans the driving route of an offer through its current path points.
+// The route stored on the offer is reused as long as the path it was planned for is unchanged.
+type OfferRoutePlanner struct {
+	engine routing.Engine
+}
+
+func NewOfferRoutePlanner(engine routing.Engine) *OfferRoutePlanner {
+	return &OfferRoutePlanner{engine: engine}
+}
+
+// PlanRoute returns the encoded driving route of the offer through its current path points
+func (p *OfferRoutePlanner) PlanRoute(offer *model.Offer) (*model.Polyline, error) {
+	if polyline := offer.RoutePolyline(); polyline != nil {
+		return polyline, nil
+	}
+	coords := make([]model.Coordinate, len(offer.PathPoints()))
+	for i, point := range offer.PathPoints() {
+		coords[i] = *point.Coordinate()
+	}
+	routeParams, err := model.NewRouteParams(coords, offer.DepartureTime())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create route params: %w", err)
+	}
+	plannedRoute, err := p.engine.PlanDrivingRoute(context.Background(), routeParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan route: %w", err)
+	}
+	return plannedRoute.Polyline(), nil
+}
+
+// offerRouteCache keeps the driving route of each offer through its current path points.
+// The generators placing their points along the driver's route embed it.
+type offerRouteCache struct {
+	routes *collections.LRUCache[string, *s2.Polyline]
+	engine routing.Engine
+}
+
+func newOfferRouteCache(engine routing.Engine) *offerRouteCache {
+	return &offerRouteCache{
+		routes: collections.NewLRUCache[string, *s2.Polyline](getOfferProcessorCacheMaxEntries(), nil),
+		engine: engine,
+	}
+}
+
+// getOfferRoute returns the driving route of the offer through its current path points
+func (c *offerRouteCache) getOfferRoute(offer *model.Offer) (*s2.Polyline, error) {
+	if route, exists := c.routes.Get(offer.ID()); exists {
+		return route, nil
+	}
+	route, err := planOfferRoute(c.engine, offer)
+	if err != nil {
+		return nil, err
+	}
+	c.routes.Set(offer.ID(), route)
+	return route, nil
+}
+
+// InvalidateOffer drops the route built from the previous path of the offer
+func (c *offerRouteCache) InvalidateOffer(offerID string) {
+	c.routes.Delete(offerID)
+}
+
+func (c *offerRouteCache) Stats() collections.CacheStats {
+	return c.routes.Stats()
+}
+
+// planOfferRoute returns the driving route of the offer through its current path points
+func planOfferRoute(engine routing.Engine, offer *model.Offer) (*s2.Polyline, error) {
+	polyline, err := NewOfferRoutePlanner(engine).PlanRoute(offer)
+	if err != nil {
+		return nil, err
+	}
+	routeCoords, err := polyline.Coordinates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode route polyline: %w", err)
+	}
+
+	route := s2.Polyline(make([]s2.Point, len(routeCoords)))
+	for i, c := range routeCoords {
+		route[i] = toS2Point(&c)
+	}
+	return &route, nil
+}
+
+// distanceToRoute returns the distance in meters between the coordinate and the closest point of the route
+func distanceToRoute(route *s2.Polyline, coord *model.Coordinate) float64 {
+	point := toS2Point(coord)
+	if len(*route) == 0 {
+		return math.Inf(1)
+	}
+	if len(*route) == 1 {
+		return (*route)[0].Distance(point).Radians() * geo.EarthRadiusInMeters
+	}
+	projected, _ := route.Project(point)
+	return projected.Distance(point).Radians() * geo.EarthRadiusInMeters
+}
+
+func toS2Point(coord *model.Coordinate) s2.Point {
+	return s2.PointFromLatLng(s2.LatLngFromDegrees(coord.Lat(), coord.Lng()))
+}
+
+func fromS2Point(point s2.Point) (*model.Coordinate, error) {
+	latLng := s2.LatLngFromPoint(point)
+	return model.NewCoordinate(latLng.Lat.Degrees(), latLng.Lng.Degrees())
+}
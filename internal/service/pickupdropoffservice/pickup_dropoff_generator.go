@@ -6,3 +6,23 @@ type PickupDropoffGenerator interface {
 	// GeneratePickupDropoffPoints generates the best pickup and dropoff points for a given request and offer
 	GeneratePickupDropoffPoints(request *model.Request, offer *model.Offer) (*model.PathPoint, *model.PathPoint, error)
 }
+
+// OfferInvalidator is implemented by the generators caching values computed from the route of an offer
+type OfferInvalidator interface {
+	// InvalidateOffer drops the cached values of the offer after its path changed
+	InvalidateOffer(offerID string)
+}
+
+// PickupDropoffCandidatesGenerator is implemented by the generators able to propose several meeting points per request
+type PickupDropoffCandidatesGenerator interface {
+	// GeneratePickupDropoffCandidates returns up to maxCandidates pickup and dropoff points each, best first.
+	// Both slices are non-empty when no error is returned.
+	GeneratePickupDropoffCandidates(request *model.Request, offer *model.Offer, maxCandidates int) (pickups, dropoffs []*model.PathPoint, err error)
+}
+
+// SourceCandidatesGenerator proposes the starts the driver of a flexible source offer can choose instead of the source
+type SourceCandidatesGenerator interface {
+	// GenerateSourceCandidates returns the starts within the flexible source duration of the offer, closest first.
+	// The pickups of the request are the meeting points the driver can start from.
+	GenerateSourceCandidates(request *model.Request, offer *model.Offer, pickups []*model.PathPoint) ([]*model.PathPoint, error)
+}
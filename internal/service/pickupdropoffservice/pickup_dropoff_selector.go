@@ -4,19 +4,57 @@ import (
 	"fmt"
 	"matching-engine/internal/model"
 	"matching-engine/internal/service/pickupdropoffservice/pickupdropoffcache"
+	"os"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
 )
 
+// DefaultMaxCandidates keeps a single pickup and dropoff point per offer and request pair
+const DefaultMaxCandidates = 1
+
 type PickupDropoffSelector struct {
 	// generator is the underlying generator that will be used to get the pickup and dropoff points
 	generator PickupDropoffGenerator
 	// cache is a map that will store the cached pickup and dropoff points
 	cache *pickupdropoffcache.PickupDropoffCache
+	// maxCandidates is the number of ranked pickup and dropoff points kept when the generator can propose several
+	maxCandidates int
+	// sourceGenerator proposes the starts of the flexible source offers, nil when the drivers always start at the source
+	sourceGenerator SourceCandidatesGenerator
 }
 
 func NewPickupDropoffSelector(generator PickupDropoffGenerator, cache *pickupdropoffcache.PickupDropoffCache) PickupDropoffSelectorInterface {
+	return NewPickupDropoffSelectorWithCandidates(generator, cache, getMaxCandidates())
+}
+
+// NewPickupDropoffSelectorWithCandidates creates a selector keeping up to maxCandidates pickup and dropoff points
+func NewPickupDropoffSelectorWithCandidates(generator PickupDropoffGenerator, cache *pickupdropoffcache.PickupDropoffCache, maxCandidates int) PickupDropoffSelectorInterface {
+	return NewPickupDropoffSelectorWithSources(generator, nil, cache, maxCandidates)
+}
+
+// NewFlexibleSourcePickupDropoffSelector creates a selector that also proposes starts to the flexible source offers
+func NewFlexibleSourcePickupDropoffSelector(
+	generator PickupDropoffGenerator,
+	sourceGenerator SourceCandidatesGenerator,
+	cache *pickupdropoffcache.PickupDropoffCache,
+) PickupDropoffSelectorInterface {
+	return NewPickupDropoffSelectorWithSources(generator, sourceGenerator, cache, getMaxCandidates())
+}
+
+// NewPickupDropoffSelectorWithSources creates a selector keeping up to maxCandidates pickup and dropoff points,
+// and proposing starts to the flexible source offers when sourceGenerator is not nil
+func NewPickupDropoffSelectorWithSources(
+	generator PickupDropoffGenerator,
+	sourceGenerator SourceCandidatesGenerator,
+	cache *pickupdropoffcache.PickupDropoffCache,
+	maxCandidates int,
+) PickupDropoffSelectorInterface {
 	return &PickupDropoffSelector{
-		generator: generator,
-		cache:     cache,
+		generator:       generator,
+		cache:           cache,
+		maxCandidates:   maxCandidates,
+		sourceGenerator: sourceGenerator,
 	}
 }
 
@@ -30,22 +68,70 @@ func (selector *PickupDropoffSelector) GetPickupDropoffPointsAndDurations(reques
 	}
 
 	// Call the underlying generator to get the pickup and dropoff points
-	pickup, dropoff, err := selector.generator.GeneratePickupDropoffPoints(request, offer)
+	pickups, dropoffs, err := selector.generateCandidates(request, offer)
 	if err != nil {
 		return nil, fmt.Errorf("pickup dropoff generator error: %v", err)
 	}
 
 	// Set the expected arrival times:
-	// - For the pickup point: earliest pickup time (departure time + walking duration)
-	// - For the dropoff point: latest dropoff time (latest arrival time - walking duration)
+	// - For the pickup point: earliest pickup time (departure time + walking and transit duration)
+	// - For the dropoff point: latest dropoff time (latest arrival time - walking and transit duration)
 	// NOTE: Be careful when changing these as some path generation logic depends on it
-	pickup.SetExpectedArrivalTime(request.EarliestDepartureTime().Add(pickup.WalkingDuration()))
-	dropoff.SetExpectedArrivalTime(request.LatestArrivalTime().Add(-dropoff.WalkingDuration()))
+	for _, pickup := range pickups {
+		pickup.SetExpectedArrivalTime(request.EarliestDepartureTime().Add(pickup.AccessDuration()))
+	}
+	for _, dropoff := range dropoffs {
+		dropoff.SetExpectedArrivalTime(request.LatestArrivalTime().Add(-dropoff.AccessDuration()))
+	}
 
 	// Store the pickup and dropoff points in the cache
-	cacheValue := pickupdropoffcache.NewValue(pickup, dropoff)
+	cacheValue := pickupdropoffcache.NewValueWithCandidates(pickups, dropoffs)
+	cacheValue.SetSourceCandidates(selector.generateSourceCandidates(request, offer, pickups))
 	selector.cache.Set(cacheKey, cacheValue)
 
 	// Return the pickup and dropoff points
 	return cacheValue, nil
 }
+
+// generateCandidates asks the generator for ranked candidates when several are wanted and it can propose them
+func (selector *PickupDropoffSelector) generateCandidates(request *model.Request, offer *model.Offer) (pickups, dropoffs []*model.PathPoint, err error) {
+	if candidatesGenerator, ok := selector.generator.(PickupDropoffCandidatesGenerator); ok && selector.maxCandidates > 1 {
+		return candidatesGenerator.GeneratePickupDropoffCandidates(request, offer, selector.maxCandidates)
+	}
+	pickup, dropoff, err := selector.generator.GeneratePickupDropoffPoints(request, offer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []*model.PathPoint{pickup}, []*model.PathPoint{dropoff}, nil
+}
+
+// generateSourceCandidates proposes starts to the flexible source offers whose trip still starts at the source.
+// Once the driver starts elsewhere to pick up a rider, the start is kept for the next riders.
+func (selector *PickupDropoffSelector) generateSourceCandidates(request *model.Request, offer *model.Offer, pickups []*model.PathPoint) []*model.PathPoint {
+	if selector.sourceGenerator == nil || !offer.HasFlexibleSource() {
+		return nil
+	}
+	path := offer.Path()
+	if len(path) == 0 || *path[0].Coordinate() != *offer.Source() {
+		return nil
+	}
+	sources, err := selector.sourceGenerator.GenerateSourceCandidates(request, offer, pickups)
+	if err != nil {
+		log.Warn().Err(err).Str("offer_id", offer.ID()).Str("request_id", request.ID()).Msg("failed to generate source candidates, the trip starts at the source")
+		return nil
+	}
+	return sources
+}
+
+func getMaxCandidates() int {
+	maxCandidates := DefaultMaxCandidates
+	if v, ok := os.LookupEnv("PICKUP_DROPOFF_MAX_CANDIDATES"); ok && v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			log.Warn().Msgf("Invalid PICKUP_DROPOFF_MAX_CANDIDATES value %q, using default: %d", v, DefaultMaxCandidates)
+		} else {
+			maxCandidates = parsed
+		}
+	}
+	return maxCandidates
+}
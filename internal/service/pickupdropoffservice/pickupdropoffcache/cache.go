@@ -3,15 +3,29 @@ package pickupdropoffcache
 import (
 	"matching-engine/internal/collections"
 	"matching-engine/internal/model"
+	"os"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
 )
 
+// DefaultMaxEntries bounds the number of offer and request pairs kept in the cache
+const DefaultMaxEntries = 500_000
+
+// PickupDropoffCache keeps the pickup and dropoff points of offer and request pairs.
+// It holds at most PICKUP_DROPOFF_CACHE_MAX_ENTRIES pairs and evicts the least recently used first.
 type PickupDropoffCache struct {
-	store *collections.SyncMap[model.OfferRequestKey, *Value]
+	store *collections.LRUCache[model.OfferRequestKey, *Value]
 }
 
 func NewPickupDropoffCache() *PickupDropoffCache {
+	return NewPickupDropoffCacheWithLimit(getMaxEntries())
+}
+
+// NewPickupDropoffCacheWithLimit creates a cache holding up to maxEntries pairs, 0 means unbounded
+func NewPickupDropoffCacheWithLimit(maxEntries int64) *PickupDropoffCache {
 	return &PickupDropoffCache{
-		store: collections.NewSyncMap[model.OfferRequestKey, *Value](),
+		store: collections.NewLRUCache[model.OfferRequestKey, *Value](maxEntries, nil),
 	}
 }
 
@@ -26,3 +40,27 @@ func (c *PickupDropoffCache) Get(key model.OfferRequestKey) (*Value, bool) {
 func (c *PickupDropoffCache) Delete(key model.OfferRequestKey) {
 	c.store.Delete(key)
 }
+
+// InvalidateOffer removes the points computed against the previous route of the offer
+func (c *PickupDropoffCache) InvalidateOffer(offerID string) {
+	c.store.DeleteFunc(func(key model.OfferRequestKey, _ *Value) bool {
+		return key.OfferID() == offerID
+	})
+}
+
+func (c *PickupDropoffCache) Stats() collections.CacheStats {
+	return c.store.Stats()
+}
+
+func getMaxEntries() int64 {
+	v := os.Getenv("PICKUP_DROPOFF_CACHE_MAX_ENTRIES")
+	if v == "" {
+		return DefaultMaxEntries
+	}
+	maxEntries, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || maxEntries < 0 {
+		log.Warn().Msgf("Invalid PICKUP_DROPOFF_CACHE_MAX_ENTRIES value %q, using default: %d", v, DefaultMaxEntries)
+		return DefaultMaxEntries
+	}
+	return maxEntries
+}
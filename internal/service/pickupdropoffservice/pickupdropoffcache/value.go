@@ -7,6 +7,11 @@ import (
 type Value struct {
 	pickup  *model.PathPoint
 	dropoff *model.PathPoint
+	// alternative points ranked after pickup and dropoff, explored by the path planner
+	alternativePickups  []*model.PathPoint
+	alternativeDropoffs []*model.PathPoint
+	// starts the driver of a flexible source offer can choose instead of the source, explored by the path planner
+	sourceCandidates []*model.PathPoint
 }
 
 func NewValue(pickup, dropoff *model.PathPoint) *Value {
@@ -16,6 +21,17 @@ func NewValue(pickup, dropoff *model.PathPoint) *Value {
 	}
 }
 
+// NewValueWithCandidates creates a value from ranked candidates, the first ones being the pickup and the dropoff.
+// Both slices must be non-empty.
+func NewValueWithCandidates(pickups, dropoffs []*model.PathPoint) *Value {
+	return &Value{
+		pickup:              pickups[0],
+		dropoff:             dropoffs[0],
+		alternativePickups:  pickups[1:],
+		alternativeDropoffs: dropoffs[1:],
+	}
+}
+
 func (v *Value) Pickup() *model.PathPoint {
 	return v.pickup
 }
@@ -24,6 +40,16 @@ func (v *Value) Dropoff() *model.PathPoint {
 	return v.dropoff
 }
 
+// PickupCandidates returns the pickup followed by its ranked alternatives
+func (v *Value) PickupCandidates() []*model.PathPoint {
+	return append([]*model.PathPoint{v.pickup}, v.alternativePickups...)
+}
+
+// DropoffCandidates returns the dropoff followed by its ranked alternatives
+func (v *Value) DropoffCandidates() []*model.PathPoint {
+	return append([]*model.PathPoint{v.dropoff}, v.alternativeDropoffs...)
+}
+
 func (v *Value) SetPickup(pickup *model.PathPoint) {
 	v.pickup = pickup
 }
@@ -31,3 +57,12 @@ func (v *Value) SetPickup(pickup *model.PathPoint) {
 func (v *Value) SetDropoff(dropoff *model.PathPoint) {
 	v.dropoff = dropoff
 }
+
+// SourceCandidates returns the starts the driver can choose instead of the source, closest to the source first
+func (v *Value) SourceCandidates() []*model.PathPoint {
+	return v.sourceCandidates
+}
+
+func (v *Value) SetSourceCandidates(sourceCandidates []*model.PathPoint) {
+	v.sourceCandidates = sourceCandidates
+}
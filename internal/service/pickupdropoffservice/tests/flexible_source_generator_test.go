@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"matching-engine/internal/service/pickupdropoffservice/hubcatalogue"
+	"testing"
+	"time"
+)
+
+func TestFlexibleSourceGenerator_GenerateSourceCandidates(t *testing.T) {
+	home := mustCoordinate(t, 30.0, 31.0)
+	destination := mustCoordinate(t, 30.0, 31.2)
+	departure := time.Now().Add(time.Hour)
+	offer := model.NewOffer("offer1", "driver1", *home, *destination, departure, 0, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, nil, nil)
+	request := model.NewRequest("request1", "rider1", *mustCoordinate(t, 30.03, 31.0), *destination, departure,
+		departure.Add(2*time.Hour), 10*time.Minute, 1, model.Preference{})
+
+	// Driving at 10 m/s, the pickup is 5.5 minutes away, the park-and-rides 2 and 18.5 minutes away
+	pickup := model.NewPathPoint(*mustCoordinate(t, 30.03, 31.0), enums.Pickup, departure, request, 0)
+	nearParkAndRide := mustCoordinate(t, 30.011, 31.0)
+	farParkAndRide := mustCoordinate(t, 30.1, 31.0)
+	parkAndRides := hubcatalogue.NewCatalogue([]*model.Hub{
+		model.NewHub("near", "Near park-and-ride", "park_and_ride", *nearParkAndRide),
+		model.NewHub("far", "Far park-and-ride", "park_and_ride", *farParkAndRide),
+	})
+	engine := &hubTestRoutingEngine{walkingSpeedMPS: 10}
+	generator := pickupdropoffservice.NewFlexibleSourceGenerator(engine, parkAndRides)
+
+	t.Run("Fixed source", func(t *testing.T) {
+		sources, err := generator.GenerateSourceCandidates(request, offer, []*model.PathPoint{pickup})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sources) != 0 || engine.matrixCalls != 0 {
+			t.Errorf("expected no source candidates nor matrix for a fixed source, got %d candidates", len(sources))
+		}
+	})
+
+	t.Run("Starts within the flexible source duration", func(t *testing.T) {
+		offer.SetFlexibleSourceDuration(10 * time.Minute)
+		sources, err := generator.GenerateSourceCandidates(request, offer, []*model.PathPoint{pickup})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sources) != 2 {
+			t.Fatalf("expected 2 source candidates, got %d", len(sources))
+		}
+		if *sources[0].Coordinate() != *nearParkAndRide || *sources[1].Coordinate() != *pickup.Coordinate() {
+			t.Errorf("expected the near park-and-ride then the pickup, got %s and %s", sources[0].Coordinate(), sources[1].Coordinate())
+		}
+		for _, source := range sources {
+			if source.PointType() != enums.Source || source.GetOwnerID() != offer.ID() {
+				t.Errorf("expected a source of the offer, got a %s of %q", source.PointType(), source.GetOwnerID())
+			}
+		}
+		// the driver reaches each start after driving from home
+		for i, expected := range []time.Duration{2 * time.Minute, 5*time.Minute + 30*time.Second} {
+			access := sources[i].AccessDrivingDuration()
+			if access < expected-5*time.Second || access > expected+5*time.Second {
+				t.Errorf("expected an access drive of about %v to start %d, got %v", expected, i, access)
+			}
+			if !sources[i].ExpectedArrivalTime().Equal(departure.Add(access)) {
+				t.Errorf("expected start %d to be reached at %v, got %v", i, departure.Add(access), sources[i].ExpectedArrivalTime())
+			}
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"matching-engine/internal/service/pickupdropoffservice/hubcatalogue"
+	"math"
+	"testing"
+	"time"
+)
+
+// hubTestRoutingEngine plans a straight route between the path points and walks at a fixed speed
+type hubTestRoutingEngine struct {
+	walkingSpeedMPS float64
+	matrixCalls     int
+}
+
+func (e *hubTestRoutingEngine) PlanDrivingRoute(ctx context.Context, routeParams *model.RouteParams) (*model.Route, error) {
+	polyline, err := model.NewPolylineFromCoordinates(routeParams.Waypoints())
+	if err != nil {
+		return nil, err
+	}
+	distance, _ := model.NewDistance(0, model.DistanceUnitKilometer)
+	return model.NewRoute(polyline, distance, 0)
+}
+
+func (e *hubTestRoutingEngine) ComputeDrivingTime(ctx context.Context, routeParams *model.RouteParams) ([]time.Duration, error) {
+	return nil, errors.New("ComputeDrivingTime should not be called in this test")
+}
+
+func (e *hubTestRoutingEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	return 0, errors.New("ComputeWalkingTime should not be called in this test")
+}
+
+func (e *hubTestRoutingEngine) ComputeIsochrone(ctx context.Context, req *model.IsochroneParams) (*model.Isochrone, error) {
+	return nil, errors.New("ComputeIsochrone should not be called in this test")
+}
+
+func (e *hubTestRoutingEngine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	return point, nil
+}
+
+func (e *hubTestRoutingEngine) ComputeDistanceTimeMatrix(ctx context.Context, req *model.DistanceTimeMatrixParams) (*model.DistanceTimeMatrix, error) {
+	e.matrixCalls++
+	distances := make([][]model.Distance, len(req.Sources()))
+	times := make([][]time.Duration, len(req.Sources()))
+	for i, source := range req.Sources() {
+		distances[i] = make([]model.Distance, len(req.Targets()))
+		times[i] = make([]time.Duration, len(req.Targets()))
+		for j, target := range req.Targets() {
+			meters := approxMeters(source, target)
+			distance, _ := model.NewDistance(float32(meters/1000), model.DistanceUnitKilometer)
+			distances[i][j] = *distance
+			times[i][j] = time.Duration(meters / e.walkingSpeedMPS * float64(time.Second))
+		}
+	}
+	return model.NewDistanceTimeMatrix(distances, times)
+}
+
+// approxMeters returns an equirectangular approximation of the distance between two coordinates
+func approxMeters(a, b model.Coordinate) float64 {
+	const metersPerDegree = 111320.0
+	dLat := (a.Lat() - b.Lat()) * metersPerDegree
+	dLng := (a.Lng() - b.Lng()) * metersPerDegree * math.Cos(a.Lat()*math.Pi/180)
+	return math.Sqrt(dLat*dLat + dLng*dLng)
+}
+
+func mustCoordinate(t *testing.T, lat, lng float64) *model.Coordinate {
+	t.Helper()
+	c, err := model.NewCoordinate(lat, lng)
+	if err != nil {
+		t.Fatalf("invalid coordinate: %v", err)
+	}
+	return c
+}
+
+func TestHubBasedGenerator_GeneratePickupDropoffPoints(t *testing.T) {
+	// The driver goes east along latitude 30.0
+	driverSource := mustCoordinate(t, 30.0, 31.0)
+	driverDestination := mustCoordinate(t, 30.0, 31.1)
+	departure := time.Now().Add(time.Hour)
+	offer := model.NewOffer("offer1", "driver1", *driverSource, *driverDestination, departure, 15*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, []model.PathPoint{
+			*model.NewPathPoint(*driverSource, enums.Source, departure, nil, 0),
+			*model.NewPathPoint(*driverDestination, enums.Destination, departure.Add(30*time.Minute), nil, 0),
+		}, nil)
+
+	// Riders are about 220m north of the route
+	riderSource := mustCoordinate(t, 30.002, 31.02)
+	riderDestination := mustCoordinate(t, 30.002, 31.08)
+
+	tests := []struct {
+		name            string
+		hubs            []*model.Hub
+		expectedPickup  *model.Coordinate
+		expectedDropoff *model.Coordinate
+	}{
+		{
+			name: "closest hubs near the route are chosen",
+			hubs: []*model.Hub{
+				model.NewHub("metro", "Metro station", "metro_station", *mustCoordinate(t, 30.0005, 31.021)),
+				model.NewHub("parking", "Parking lot", "parking_lot", *mustCoordinate(t, 30.0005, 31.025)),
+				model.NewHub("gate", "Campus gate", "campus_gate", *mustCoordinate(t, 30.0, 31.079)),
+			},
+			expectedPickup:  mustCoordinate(t, 30.0005, 31.021),
+			expectedDropoff: mustCoordinate(t, 30.0, 31.079),
+		},
+		{
+			name: "hubs far from the route are ignored",
+			hubs: []*model.Hub{
+				// Closest to the rider but about 330m off the route
+				model.NewHub("mall", "Mall", "parking_lot", *mustCoordinate(t, 30.003, 31.02)),
+				model.NewHub("metro", "Metro station", "metro_station", *mustCoordinate(t, 30.0, 31.022)),
+			},
+			expectedPickup:  mustCoordinate(t, 30.0, 31.022),
+			expectedDropoff: riderDestination,
+		},
+		{
+			name: "fallback is used when no hub is within walking distance",
+			hubs: []*model.Hub{
+				model.NewHub("far", "Far station", "metro_station", *mustCoordinate(t, 30.0, 31.05)),
+			},
+			expectedPickup:  riderSource,
+			expectedDropoff: riderDestination,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &hubTestRoutingEngine{walkingSpeedMPS: 1.4}
+			fallback := pickupdropoffservice.NewSnappedSourceDestinationGenerator(engine)
+			generator := pickupdropoffservice.NewHubBasedGenerator(hubcatalogue.NewCatalogue(tt.hubs), engine, fallback, 150)
+
+			request := model.NewRequest("request1", "rider1", *riderSource, *riderDestination,
+				departure, departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+
+			pickup, dropoff, err := generator.GeneratePickupDropoffPoints(request, offer)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !pickup.Coordinate().Equal(tt.expectedPickup) {
+				t.Errorf("expected pickup %v, got %v", tt.expectedPickup, pickup.Coordinate())
+			}
+			if !dropoff.Coordinate().Equal(tt.expectedDropoff) {
+				t.Errorf("expected dropoff %v, got %v", tt.expectedDropoff, dropoff.Coordinate())
+			}
+			if pickup.PointType() != enums.Pickup || dropoff.PointType() != enums.Dropoff {
+				t.Errorf("unexpected point types %v and %v", pickup.PointType(), dropoff.PointType())
+			}
+		})
+	}
+}
+
+func TestHubCatalogue_ParseGeoJSON(t *testing.T) {
+	data := []byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[31.2357,30.0444]},"properties":{"id":"sadat","name":"Sadat station","type":"metro_station"}},
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[31.3,30.1]},"properties":{}}
+	]}`)
+
+	catalogue, err := hubcatalogue.ParseGeoJSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if catalogue.Size() != 2 {
+		t.Fatalf("expected 2 hubs, got %d", catalogue.Size())
+	}
+	hub := catalogue.Hubs()[0]
+	if hub.ID() != "sadat" || hub.Name() != "Sadat station" || hub.Type() != "metro_station" {
+		t.Errorf("unexpected hub %v", hub)
+	}
+	if hub.Coordinate().Lat() != 30.0444 || hub.Coordinate().Lng() != 31.2357 {
+		t.Errorf("unexpected hub coordinate %v", hub.Coordinate())
+	}
+	if catalogue.Hubs()[1].ID() != "hub-1" {
+		t.Errorf("expected generated id hub-1, got %s", catalogue.Hubs()[1].ID())
+	}
+
+	if _, err := hubcatalogue.ParseGeoJSON([]byte(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"LineString","coordinates":[[31,30],[31.1,30]]}}]}`)); err == nil {
+		t.Error("expected an error for non point features")
+	}
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"math"
+	"testing"
+	"time"
+)
+
+// isochroneTestRoutingEngine plans straight routes, returns circular walking isochrones and drives at a fixed speed.
+// Driving to or from a point west of penaltyBeforeLng costs an extra penalty, like a U-turn on a divided road.
+type isochroneTestRoutingEngine struct {
+	isochroneRadiusMeters float64
+	penaltyBeforeLng      float64
+	penalty               time.Duration
+	isochroneErr          error
+	isochroneCalls        int
+}
+
+func (e *isochroneTestRoutingEngine) PlanDrivingRoute(ctx context.Context, routeParams *model.RouteParams) (*model.Route, error) {
+	polyline, err := model.NewPolylineFromCoordinates(routeParams.Waypoints())
+	if err != nil {
+		return nil, err
+	}
+	distance, _ := model.NewDistance(0, model.DistanceUnitKilometer)
+	return model.NewRoute(polyline, distance, 0)
+}
+
+func (e *isochroneTestRoutingEngine) ComputeDrivingTime(ctx context.Context, routeParams *model.RouteParams) ([]time.Duration, error) {
+	return nil, errors.New("ComputeDrivingTime should not be called in this test")
+}
+
+func (e *isochroneTestRoutingEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	return 0, errors.New("ComputeWalkingTime should not be called in this test")
+}
+
+func (e *isochroneTestRoutingEngine) ComputeIsochrone(ctx context.Context, req *model.IsochroneParams) (*model.Isochrone, error) {
+	e.isochroneCalls++
+	if e.isochroneErr != nil {
+		return nil, e.isochroneErr
+	}
+	const metersPerDegree = 111320.0
+	origin := req.Origin()
+	ring := make(model.LineString, 0, 33)
+	for i := 0; i < 32; i++ {
+		angle := 2 * math.Pi * float64(i) / 32
+		lat := origin.Lat() + e.isochroneRadiusMeters*math.Sin(angle)/metersPerDegree
+		lng := origin.Lng() + e.isochroneRadiusMeters*math.Cos(angle)/(metersPerDegree*math.Cos(origin.Lat()*math.Pi/180))
+		c, err := model.NewCoordinate(lat, lng)
+		if err != nil {
+			return nil, err
+		}
+		ring = append(ring, *c)
+	}
+	ring = append(ring, ring[0])
+	return model.NewIsochrone(req.Contour(), &ring)
+}
+
+func (e *isochroneTestRoutingEngine) SnapPointToRoad(ctx context.Context, point *model.Coordinate) (*model.Coordinate, error) {
+	return point, nil
+}
+
+func (e *isochroneTestRoutingEngine) ComputeDistanceTimeMatrix(ctx context.Context, req *model.DistanceTimeMatrixParams) (*model.DistanceTimeMatrix, error) {
+	speed := 10.0
+	if req.Profile() == model.ProfilePedestrian {
+		speed = 1.4
+	}
+	distances := make([][]model.Distance, len(req.Sources()))
+	times := make([][]time.Duration, len(req.Sources()))
+	for i, source := range req.Sources() {
+		distances[i] = make([]model.Distance, len(req.Targets()))
+		times[i] = make([]time.Duration, len(req.Targets()))
+		for j, target := range req.Targets() {
+			meters := approxMeters(source, target)
+			distance, _ := model.NewDistance(float32(meters/1000), model.DistanceUnitKilometer)
+			distances[i][j] = *distance
+			times[i][j] = time.Duration(meters / speed * float64(time.Second))
+			if req.Profile() == model.ProfileAuto && i != j && e.penalized(source, target) {
+				times[i][j] += e.penalty
+			}
+		}
+	}
+	return model.NewDistanceTimeMatrix(distances, times)
+}
+
+func (e *isochroneTestRoutingEngine) penalized(a, b model.Coordinate) bool {
+	isCandidate := func(c model.Coordinate) bool { return c.Lng() > 31.0 && c.Lng() < 31.1 }
+	return (isCandidate(a) && a.Lng() < e.penaltyBeforeLng) || (isCandidate(b) && b.Lng() < e.penaltyBeforeLng)
+}
+
+func TestIsochroneBasedGenerator_GeneratePickupDropoffPoints(t *testing.T) {
+	// The driver goes east along latitude 30.0
+	driverSource := mustCoordinate(t, 30.0, 31.0)
+	driverDestination := mustCoordinate(t, 30.0, 31.1)
+	departure := time.Now().Add(time.Hour)
+	offer := model.NewOffer("offer1", "driver1", *driverSource, *driverDestination, departure, 15*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, []model.PathPoint{
+			*model.NewPathPoint(*driverSource, enums.Source, departure, nil, 0),
+			*model.NewPathPoint(*driverDestination, enums.Destination, departure.Add(30*time.Minute), nil, 0),
+		}, nil)
+
+	// Riders are about 220m north of the route, a 400m isochrone crosses the route about 330m east and west of them
+	riderSource := mustCoordinate(t, 30.002, 31.02)
+	riderDestination := mustCoordinate(t, 30.002, 31.08)
+
+	tests := []struct {
+		name               string
+		engine             *isochroneTestRoutingEngine
+		expectedPickupLng  float64
+		expectedDropoffLng float64
+	}{
+		{
+			name:               "route point closest to the rider when no candidate adds a detour",
+			engine:             &isochroneTestRoutingEngine{isochroneRadiusMeters: 400},
+			expectedPickupLng:  31.02,
+			expectedDropoffLng: 31.08,
+		},
+		{
+			name: "boundary crossing minimizing the driver detour",
+			engine: &isochroneTestRoutingEngine{
+				isochroneRadiusMeters: 400,
+				penaltyBeforeLng:      31.0201,
+				penalty:               3 * time.Minute,
+			},
+			expectedPickupLng:  31.02345,
+			expectedDropoffLng: 31.08,
+		},
+		{
+			name:               "fallback when the isochrone does not reach the route",
+			engine:             &isochroneTestRoutingEngine{isochroneRadiusMeters: 100},
+			expectedPickupLng:  31.02,
+			expectedDropoffLng: 31.08,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := pickupdropoffservice.NewSnappedSourceDestinationGenerator(tt.engine)
+			generator := pickupdropoffservice.NewIsochroneBasedGenerator(tt.engine, fallback)
+
+			request := model.NewRequest("request1", "rider1", *riderSource, *riderDestination,
+				departure, departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+
+			pickup, dropoff, err := generator.GeneratePickupDropoffPoints(request, offer)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.engine.isochroneCalls != 2 {
+				t.Errorf("expected 2 isochrone calls, got %d", tt.engine.isochroneCalls)
+			}
+			if math.Abs(pickup.Coordinate().Lng()-tt.expectedPickupLng) > 1e-4 {
+				t.Errorf("expected pickup at longitude %v, got %v", tt.expectedPickupLng, pickup.Coordinate())
+			}
+			if math.Abs(dropoff.Coordinate().Lng()-tt.expectedDropoffLng) > 1e-4 {
+				t.Errorf("expected dropoff at longitude %v, got %v", tt.expectedDropoffLng, dropoff.Coordinate())
+			}
+			if pickup.WalkingDuration() > request.MaxWalkingDurationMinutes() {
+				t.Errorf("pickup walking duration %v exceeds the limit", pickup.WalkingDuration())
+			}
+			if pickup.PointType() != enums.Pickup || dropoff.PointType() != enums.Dropoff {
+				t.Errorf("unexpected point types %v and %v", pickup.PointType(), dropoff.PointType())
+			}
+		})
+	}
+}
+
+func TestIsochroneBasedGenerator_FallsBackWithoutIsochrones(t *testing.T) {
+	driverSource := mustCoordinate(t, 30.0, 31.0)
+	driverDestination := mustCoordinate(t, 30.0, 31.1)
+	departure := time.Now().Add(time.Hour)
+	offer := model.NewOffer("offer1", "driver1", *driverSource, *driverDestination, departure, 15*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, []model.PathPoint{
+			*model.NewPathPoint(*driverSource, enums.Source, departure, nil, 0),
+			*model.NewPathPoint(*driverDestination, enums.Destination, departure.Add(30*time.Minute), nil, 0),
+		}, nil)
+	riderSource := mustCoordinate(t, 30.002, 31.02)
+	riderDestination := mustCoordinate(t, 30.002, 31.08)
+
+	tests := []struct {
+		name          string
+		isochroneErr  error
+		expectedCalls int
+		expectErr     bool
+	}{
+		{
+			name:          "engine without an isochrone service is not asked again",
+			isochroneErr:  fmt.Errorf("%w by OSRM", routing.ErrIsochroneNotSupported),
+			expectedCalls: 1,
+		},
+		{
+			name:          "failed isochrone is returned",
+			isochroneErr:  errors.New("valhalla is unavailable"),
+			expectedCalls: 2,
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &isochroneTestRoutingEngine{isochroneRadiusMeters: 400, isochroneErr: tt.isochroneErr}
+			fallback := pickupdropoffservice.NewSnappedSourceDestinationGenerator(engine)
+			generator := pickupdropoffservice.NewIsochroneBasedGenerator(engine, fallback)
+
+			for _, id := range []string{"request1", "request2"} {
+				request := model.NewRequest(id, "rider1", *riderSource, *riderDestination,
+					departure, departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+
+				pickup, dropoff, err := generator.GeneratePickupDropoffPoints(request, offer)
+				if tt.expectErr {
+					if !errors.Is(err, tt.isochroneErr) {
+						t.Errorf("expected the isochrone error, got %v", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if math.Abs(pickup.Coordinate().Lng()-31.02) > 1e-4 || math.Abs(dropoff.Coordinate().Lng()-31.08) > 1e-4 {
+					t.Errorf("expected the fallback points, got %v and %v", pickup.Coordinate(), dropoff.Coordinate())
+				}
+			}
+			if engine.isochroneCalls != tt.expectedCalls {
+				t.Errorf("expected %d isochrone calls, got %d", tt.expectedCalls, engine.isochroneCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"testing"
+	"time"
+)
+
+func TestOfferRoutePlanner_PlanRoute(t *testing.T) {
+	source := mustCoordinate(t, 30.0, 31.0)
+	destination := mustCoordinate(t, 30.0, 31.2)
+	departure := time.Now().Add(time.Hour)
+	path := []model.PathPoint{
+		*model.NewPathPoint(*source, enums.Source, departure, nil, 0),
+		*model.NewPathPoint(*destination, enums.Destination, departure.Add(time.Hour), nil, 0),
+	}
+	offer := model.NewOffer("offer1", "driver1", *source, *destination, departure, 0, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, path, nil)
+
+	// A route stored by a previous run, following the road rather than the straight line planned by the engine
+	storedRoute, err := model.NewPolylineFromCoordinates(model.LineString{*source, *mustCoordinate(t, 30.05, 31.1), *destination})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	planner := pickupdropoffservice.NewOfferRoutePlanner(&hubTestRoutingEngine{walkingSpeedMPS: 1.4})
+
+	t.Run("Reuses the stored route of an unchanged path", func(t *testing.T) {
+		offer.RestoreRoutePolyline(storedRoute, model.PathSignature(path))
+		route, err := planner.PlanRoute(offer)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if route.Encoded() != storedRoute.Encoded() {
+			t.Errorf("expected the stored route %s, got %s", storedRoute.Encoded(), route.Encoded())
+		}
+	})
+
+	t.Run("Replans the route once the path changes", func(t *testing.T) {
+		offer.RestoreRoutePolyline(storedRoute, model.PathSignature(path))
+		pickup := model.NewPathPoint(*mustCoordinate(t, 30.0, 31.1), enums.Pickup, departure, nil, 0)
+		offer.SetPath([]model.PathPoint{path[0], *pickup, path[1]})
+		if offer.RoutePolyline() != nil {
+			t.Fatalf("expected the stored route to be stale after the path changed")
+		}
+
+		route, err := planner.PlanRoute(offer)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		coordinates, err := route.Coordinates()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(coordinates) != 3 || !coordinates[1].Equal(pickup.Coordinate()) {
+			t.Errorf("expected a route through the pickup, got %v", coordinates)
+		}
+	})
+}
+
+func TestPathSignature_SurvivesStoredCoordinatesRounding(t *testing.T) {
+	departure := time.Now()
+	path := []model.PathPoint{
+		*model.NewPathPoint(*mustCoordinate(t, 30.0123456789, 31.9876543219), enums.Source, departure, nil, 0),
+		*model.NewPathPoint(*mustCoordinate(t, 30.1, 31.2), enums.Destination, departure, nil, 0),
+	}
+	// The database keeps 8 decimals
+	storedPath := []model.PathPoint{
+		*model.NewPathPoint(*mustCoordinate(t, 30.01234568, 31.98765432), enums.Source, departure, nil, 0),
+		path[1],
+	}
+	reversedPath := []model.PathPoint{path[1], path[0]}
+
+	if model.PathSignature(path) != model.PathSignature(storedPath) {
+		t.Errorf("expected the stored path to keep the signature")
+	}
+	if model.PathSignature(path) == model.PathSignature(reversedPath) {
+		t.Errorf("expected the order of the points to change the signature")
+	}
+}
@@ -286,3 +286,71 @@ func TestPickupDropoffSelector_GetPickupDropoffPointsAndDurations_CacheUsage(t *
 		t.Errorf("Expected same result object from cache, but got different objects")
 	}
 }
+
+// mockCandidatesGenerator proposes ranked candidates and records the number it was asked for
+type mockCandidatesGenerator struct {
+	*MockPickupDropoffGenerator
+	pickups          []*model.PathPoint
+	dropoffs         []*model.PathPoint
+	requestedMaximum int
+}
+
+func (m *mockCandidatesGenerator) GeneratePickupDropoffCandidates(request *model.Request, offer *model.Offer, maxCandidates int) ([]*model.PathPoint, []*model.PathPoint, error) {
+	m.requestedMaximum = maxCandidates
+	return m.pickups, m.dropoffs, nil
+}
+
+func TestPickupDropoffSelector_KeepsRankedCandidates(t *testing.T) {
+	sourceCoord, _ := model.NewCoordinate(1.0, 1.0)
+	destCoord, _ := model.NewCoordinate(2.0, 2.0)
+	now := time.Now()
+	later := now.Add(1 * time.Hour)
+
+	request := model.NewRequest("request1", "user1", *sourceCoord, *destCoord, now, later, 15*time.Minute, 1, model.Preference{})
+	offer := model.NewOffer("offer1", "user2", *sourceCoord, *destCoord, now, 30*time.Minute, 4, model.Preference{}, later, 0, nil, nil)
+
+	pickups := []*model.PathPoint{
+		model.NewPathPoint(*sourceCoord, enums.Pickup, now, request, 3*time.Minute),
+		model.NewPathPoint(*sourceCoord, enums.Pickup, now, request, 7*time.Minute),
+	}
+	dropoffs := []*model.PathPoint{
+		model.NewPathPoint(*destCoord, enums.Dropoff, later, request, 4*time.Minute),
+		model.NewPathPoint(*destCoord, enums.Dropoff, later, request, 6*time.Minute),
+	}
+	generator := &mockCandidatesGenerator{
+		MockPickupDropoffGenerator: NewMockPickupDropoffGenerator(pickups[0], dropoffs[0], nil),
+		pickups:                    pickups,
+		dropoffs:                   dropoffs,
+	}
+
+	selector := pickupdropoffservice.NewPickupDropoffSelectorWithCandidates(generator, pickupdropoffcache.NewPickupDropoffCacheWithLimit(0), 3)
+	value, err := selector.GetPickupDropoffPointsAndDurations(request, offer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if generator.requestedMaximum != 3 || generator.callCount != 0 {
+		t.Errorf("Expected the candidates to be requested once with a maximum of 3, got %d and %d single calls", generator.requestedMaximum, generator.callCount)
+	}
+	if value.Pickup() != pickups[0] || value.Dropoff() != dropoffs[0] {
+		t.Errorf("Expected the best candidates as pickup and dropoff")
+	}
+	if len(value.PickupCandidates()) != 2 || len(value.DropoffCandidates()) != 2 {
+		t.Fatalf("Expected 2 candidates each, got %d and %d", len(value.PickupCandidates()), len(value.DropoffCandidates()))
+	}
+	// every candidate gets its own expected arrival time
+	if !value.PickupCandidates()[1].ExpectedArrivalTime().Equal(now.Add(7 * time.Minute)) {
+		t.Errorf("Unexpected pickup candidate arrival time %v", value.PickupCandidates()[1].ExpectedArrivalTime())
+	}
+	if !value.DropoffCandidates()[1].ExpectedArrivalTime().Equal(later.Add(-6 * time.Minute)) {
+		t.Errorf("Unexpected dropoff candidate arrival time %v", value.DropoffCandidates()[1].ExpectedArrivalTime())
+	}
+
+	// a single candidate selector keeps using the single point generation
+	single := pickupdropoffservice.NewPickupDropoffSelectorWithCandidates(generator, pickupdropoffcache.NewPickupDropoffCacheWithLimit(0), 1)
+	if _, err := single.GetPickupDropoffPointsAndDurations(request, offer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if generator.callCount != 1 {
+		t.Errorf("Expected a single point generation, got %d", generator.callCount)
+	}
+}
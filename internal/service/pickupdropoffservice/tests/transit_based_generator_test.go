@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"matching-engine/internal/service/pickupdropoffservice/hubcatalogue"
+	"testing"
+	"time"
+)
+
+// transitTestEngine walks 5 minutes to and from the stops and rides the rest in a straight line at a fixed speed
+type transitTestEngine struct {
+	transitSpeedMPS float64
+	failingStop     *model.Coordinate
+	calls           []*model.TransitParams
+}
+
+func (e *transitTestEngine) ComputeTransitJourney(ctx context.Context, params *model.TransitParams) (*model.TransitJourney, error) {
+	e.calls = append(e.calls, params)
+	if e.failingStop != nil && (*params.Origin() == *e.failingStop || *params.Destination() == *e.failingStop) {
+		return nil, errors.New("no transit route found")
+	}
+	meters := approxMeters(*params.Origin(), *params.Destination())
+	return model.NewTransitJourney(5*time.Minute, time.Duration(meters/e.transitSpeedMPS*float64(time.Second))), nil
+}
+
+func TestTransitBasedGenerator_GeneratePickupDropoffPoints(t *testing.T) {
+	// The driver goes east along latitude 30.0
+	driverSource := mustCoordinate(t, 30.0, 31.0)
+	driverDestination := mustCoordinate(t, 30.0, 31.1)
+	departure := time.Now().Add(time.Hour)
+	offer := model.NewOffer("offer1", "driver1", *driverSource, *driverDestination, departure, 15*time.Minute, 3,
+		model.Preference{}, departure.Add(time.Hour), 0, []model.PathPoint{
+			*model.NewPathPoint(*driverSource, enums.Source, departure, nil, 0),
+			*model.NewPathPoint(*driverDestination, enums.Destination, departure.Add(30*time.Minute), nil, 0),
+		}, nil)
+
+	nearStop := mustCoordinate(t, 30.0, 31.02)
+	farStop := mustCoordinate(t, 30.0, 31.08)
+	offRouteStop := mustCoordinate(t, 30.05, 31.02)
+	stops := hubcatalogue.NewCatalogue([]*model.Hub{
+		model.NewHub("near", "Near station", "transit_stop", *nearStop),
+		model.NewHub("far", "Far station", "transit_stop", *farStop),
+		model.NewHub("off_route", "Off route station", "transit_stop", *offRouteStop),
+	})
+
+	// The rider lives 6.7km north of the route, and works next to it
+	riderSource := mustCoordinate(t, 30.06, 31.02)
+	riderDestination := mustCoordinate(t, 30.0004, 31.09)
+	request := model.NewRequest("request1", "rider1", *riderSource, *riderDestination, departure, departure.Add(2*time.Hour),
+		10*time.Minute, 1, model.Preference{})
+
+	transitConfig := pickupdropoffservice.TransitConfig{
+		StopSearchRadiusMeters:     15_000,
+		MaxStopRouteDistanceMeters: 150,
+		MaxTransitDuration:         45 * time.Minute,
+		MaxStopCandidates:          5,
+	}
+
+	tests := []struct {
+		name               string
+		failingStop        *model.Coordinate
+		maxTransitDuration time.Duration
+		expectedPickup     *model.Coordinate
+		expectTransit      bool
+	}{
+		{
+			name:           "Closest stop near the route",
+			expectedPickup: nearStop,
+			expectTransit:  true,
+		},
+		{
+			name:           "Failing journey to the closest stop",
+			failingStop:    nearStop,
+			expectedPickup: farStop,
+			expectTransit:  true,
+		},
+		{
+			name:               "Transit longer than allowed falls back",
+			maxTransitDuration: time.Minute,
+			expectedPickup:     riderSource,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := NewMockPickupDropoffGenerator(
+				model.NewPathPoint(*riderSource, enums.Pickup, departure, request, 0),
+				model.NewPathPoint(*mustCoordinate(t, 30.0, 31.09), enums.Dropoff, departure.Add(2*time.Hour), request, time.Minute),
+				nil,
+			)
+			transitEngine := &transitTestEngine{transitSpeedMPS: 10, failingStop: tt.failingStop}
+			cfg := transitConfig
+			if tt.maxTransitDuration > 0 {
+				cfg.MaxTransitDuration = tt.maxTransitDuration
+			}
+			generator := pickupdropoffservice.NewTransitBasedGenerator(stops, &hubTestRoutingEngine{walkingSpeedMPS: 1.4}, transitEngine, fallback, cfg)
+
+			pickup, dropoff, err := generator.GeneratePickupDropoffPoints(request, offer)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *pickup.Coordinate() != *tt.expectedPickup {
+				t.Errorf("expected pickup at %s, got %s", tt.expectedPickup, pickup.Coordinate())
+			}
+			if tt.expectTransit {
+				if pickup.TransitDuration() <= 0 || pickup.WalkingDuration() != 5*time.Minute {
+					t.Errorf("expected a transit pickup with a 5 minutes walk, got transit %v and walk %v", pickup.TransitDuration(), pickup.WalkingDuration())
+				}
+				if pickup.AccessDuration() != pickup.WalkingDuration()+pickup.TransitDuration() {
+					t.Errorf("expected the access duration to add the walk and the transit, got %v", pickup.AccessDuration())
+				}
+			} else if pickup.TransitDuration() != 0 {
+				t.Errorf("expected no transit on the fallback pickup, got %v", pickup.TransitDuration())
+			}
+
+			// The destination is within walking distance of the route, no transit is planned for the dropoff
+			if dropoff.TransitDuration() != 0 || dropoff.WalkingDuration() != time.Minute {
+				t.Errorf("expected the fallback dropoff, got transit %v and walk %v", dropoff.TransitDuration(), dropoff.WalkingDuration())
+			}
+			for _, params := range transitEngine.calls {
+				if params.ArriveBy() || *params.Origin() != *riderSource {
+					t.Errorf("expected only journeys departing from the rider source, got %s", params)
+				}
+				if *params.Destination() == *offRouteStop {
+					t.Errorf("expected the stop away from the route to be ignored")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice"
+	"testing"
+	"time"
+)
+
+// walkTestRoutingEngine walks in a straight line at a fixed speed
+type walkTestRoutingEngine struct {
+	hubTestRoutingEngine
+	walkCalls int
+	err       error
+}
+
+func (e *walkTestRoutingEngine) ComputeWalkingTime(ctx context.Context, walkParams *model.WalkParams) (time.Duration, error) {
+	e.walkCalls++
+	if e.err != nil {
+		return 0, e.err
+	}
+	meters := approxMeters(*walkParams.Origin(), *walkParams.Destination())
+	return time.Duration(meters / e.walkingSpeedMPS * float64(time.Second)), nil
+}
+
+func TestWalkOnlyDetector_IsWalkable(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	source := mustCoordinate(t, 30.0, 31.0)
+
+	tests := []struct {
+		name            string
+		destination     *model.Coordinate
+		latestArrival   time.Time
+		engineErr       error
+		expected        bool
+		expectError     bool
+		expectEngineUse bool
+	}{
+		{
+			name:            "Source and destination collapse",
+			destination:     mustCoordinate(t, 30.0002, 31.0),
+			latestArrival:   departure,
+			expected:        true,
+			expectEngineUse: true,
+		},
+		{
+			name:            "Walk fits before the latest arrival time",
+			destination:     mustCoordinate(t, 30.009, 31.0),
+			latestArrival:   departure.Add(time.Hour),
+			expected:        true,
+			expectEngineUse: true,
+		},
+		{
+			name:            "Walk arrives after the latest arrival time",
+			destination:     mustCoordinate(t, 30.009, 31.0),
+			latestArrival:   departure.Add(5 * time.Minute),
+			expected:        false,
+			expectEngineUse: true,
+		},
+		{
+			name:          "Far destination is ruled out without the engine",
+			destination:   mustCoordinate(t, 30.2, 31.0),
+			latestArrival: departure.Add(10 * time.Hour),
+			expected:      false,
+		},
+		{
+			name:            "Engine error",
+			destination:     mustCoordinate(t, 30.009, 31.0),
+			latestArrival:   departure.Add(time.Hour),
+			engineErr:       errors.New("engine down"),
+			expectError:     true,
+			expectEngineUse: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &walkTestRoutingEngine{hubTestRoutingEngine: hubTestRoutingEngine{walkingSpeedMPS: 1.4}, err: tt.engineErr}
+			detector := pickupdropoffservice.NewWalkOnlyDetectorWithConfig(engine, 50, 30*time.Minute)
+			request := model.NewRequest("request1", "rider1", *source, *tt.destination, departure, tt.latestArrival,
+				10*time.Minute, 1, model.Preference{})
+
+			walkable, walkingDuration, err := detector.IsWalkable(request)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if walkable != tt.expected {
+				t.Errorf("expected walkable %v, got %v", tt.expected, walkable)
+			}
+			if walkable && walkingDuration <= 0 {
+				t.Errorf("expected a positive walking duration, got %v", walkingDuration)
+			}
+			if (engine.walkCalls > 0) != tt.expectEngineUse {
+				t.Errorf("expected engine use %v, got %d walking time calls", tt.expectEngineUse, engine.walkCalls)
+			}
+		})
+	}
+}
+
+func TestWalkOnlyDetector_IsDegenerate(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	detector := pickupdropoffservice.NewWalkOnlyDetectorWithConfig(nil, 50, 30*time.Minute)
+	pickup := model.NewPathPoint(*mustCoordinate(t, 30.0, 31.0), enums.Pickup, departure, nil, 0)
+	nearDropoff := model.NewPathPoint(*mustCoordinate(t, 30.0003, 31.0), enums.Dropoff, departure, nil, 0)
+	farDropoff := model.NewPathPoint(*mustCoordinate(t, 30.01, 31.0), enums.Dropoff, departure, nil, 0)
+
+	if !detector.IsDegenerate(pickup, nearDropoff) {
+		t.Errorf("expected a pickup and dropoff 33m apart to be degenerate")
+	}
+	if detector.IsDegenerate(pickup, farDropoff) {
+		t.Errorf("expected a pickup and dropoff 1km apart not to be degenerate")
+	}
+}
+
+func TestNewWalkMatchingResult(t *testing.T) {
+	departure := time.Now().Add(time.Hour)
+	request := model.NewRequest("request1", "rider1", *mustCoordinate(t, 30.0, 31.0), *mustCoordinate(t, 30.009, 31.0),
+		departure, departure.Add(time.Hour), 10*time.Minute, 1, model.Preference{})
+
+	result := model.NewWalkMatchingResult(request, 12*time.Minute)
+	if !result.IsWalk() || result.Mode() != model.ResultModeWalk {
+		t.Fatalf("expected a walk result, got mode %q", result.Mode())
+	}
+	if result.OfferID() != "" || result.UserID() != "rider1" {
+		t.Errorf("expected no offer and the rider as user, got offer %q and user %q", result.OfferID(), result.UserID())
+	}
+	path := result.NewPath()
+	if len(path) != 2 || !path[1].ExpectedArrivalTime().Equal(departure.Add(12*time.Minute)) {
+		t.Errorf("expected a source and destination path arriving after the walk, got %d points", len(path))
+	}
+}
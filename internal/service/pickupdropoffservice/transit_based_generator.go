@@ -0,0 +1,166 @@
+package pickupdropoffservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/geo/s2"
+	"github.com/rs/zerolog/log"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/geo"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice/hubcatalogue"
+	"sort"
+	"time"
+)
+
+var _ PickupDropoffGenerator = (*TransitBasedGenerator)(nil)
+
+const (
+	// DefaultTransitStopSearchRadiusMeters is how far from the rider a transit stop can be used as a meeting point
+	DefaultTransitStopSearchRadiusMeters = 15_000.0
+	// DefaultMaxTransitDurationMinutes bounds the transit part of the rider's first or last mile
+	DefaultMaxTransitDurationMinutes = 45.0
+	// DefaultMaxTransitStopCandidates bounds the transit journeys planned for each pickup or dropoff
+	DefaultMaxTransitStopCandidates = 5
+)
+
+// TransitBasedGenerator lets the riders far from the driver's route reach the pickup point, or finish the trip
+// from the dropoff point, with public transit. The pickup or dropoff is a transit stop near the route,
+// and the rider's transit journey to or from it is set as the transit duration of the point.
+// The fallback generator is used for the riders within walking distance of the route, and when no stop is reachable.
+type TransitBasedGenerator struct {
+	*offerRouteCache
+	stops         *hubcatalogue.Catalogue
+	transitEngine routing.TransitEngine
+	fallback      PickupDropoffGenerator
+	config        TransitConfig
+}
+
+// TransitConfig bounds the transit journeys considered for the first and last mile
+type TransitConfig struct {
+	StopSearchRadiusMeters     float64
+	MaxStopRouteDistanceMeters float64
+	MaxTransitDuration         time.Duration
+	MaxStopCandidates          int
+}
+
+func NewTransitBasedGenerator(
+	stops *hubcatalogue.Catalogue,
+	engine routing.Engine,
+	transitEngine routing.TransitEngine,
+	fallback PickupDropoffGenerator,
+	config TransitConfig,
+) PickupDropoffGenerator {
+	return &TransitBasedGenerator{
+		stops:           stops,
+		offerRouteCache: newOfferRouteCache(engine),
+		transitEngine:   transitEngine,
+		fallback:        fallback,
+		config:          config,
+	}
+}
+
+func (g *TransitBasedGenerator) GeneratePickupDropoffPoints(request *model.Request, offer *model.Offer) (pickup, dropoff *model.PathPoint, err error) {
+	if request == nil || offer == nil {
+		return nil, nil, fmt.Errorf("request or offer is nil")
+	}
+	pickup, dropoff, err = g.fallback.GeneratePickupDropoffPoints(request, offer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	route, err := g.getOfferRoute(offer)
+	if err != nil {
+		return nil, nil, err
+	}
+	walkingRadiusMeters := request.MaxWalkingDurationMinutes().Seconds() * geo.WalkingSpeedMPS
+
+	if distanceToRoute(route, request.Source()) > walkingRadiusMeters {
+		if transitPickup := g.getTransitPoint(route, request, enums.Pickup); transitPickup != nil {
+			pickup = transitPickup
+		}
+	}
+	if distanceToRoute(route, request.Destination()) > walkingRadiusMeters {
+		if transitDropoff := g.getTransitPoint(route, request, enums.Dropoff); transitDropoff != nil {
+			dropoff = transitDropoff
+		}
+	}
+	return pickup, dropoff, nil
+}
+
+// InvalidateOffer drops the route built from the previous path of the offer, and the values cached by the fallback
+func (g *TransitBasedGenerator) InvalidateOffer(offerID string) {
+	g.offerRouteCache.InvalidateOffer(offerID)
+	if invalidator, ok := g.fallback.(OfferInvalidator); ok {
+		invalidator.InvalidateOffer(offerID)
+	}
+}
+
+// getTransitPoint returns the stop near the route the rider reaches the fastest from the source, or leaves the fastest
+// to the destination, or nil if no stop is reachable within the walking and transit limits.
+// The journey to a pickup departs at the earliest departure time of the rider, and the journey from a dropoff
+// arrives by the latest arrival time, as the time the driver reaches the stop is not known yet.
+func (g *TransitBasedGenerator) getTransitPoint(route *s2.Polyline, request *model.Request, pointType enums.PointType) *model.PathPoint {
+	riderPoint := request.Source()
+	timeValue := request.EarliestDepartureTime()
+	if pointType == enums.Dropoff {
+		riderPoint = request.Destination()
+		timeValue = request.LatestArrivalTime()
+	}
+
+	stops := g.getStopsNearRoute(route, riderPoint)
+	var best *model.Hub
+	var bestJourney *model.TransitJourney
+	for _, stop := range stops {
+		journey, err := g.computeJourney(riderPoint, stop.Coordinate(), pointType, timeValue)
+		if err != nil {
+			log.Warn().Err(err).Str("stop_id", stop.ID()).Str("request_id", request.ID()).Msg("failed to compute transit journey")
+			continue
+		}
+		if journey.WalkingDuration() > request.MaxWalkingDurationMinutes() || journey.TransitDuration() > g.config.MaxTransitDuration {
+			continue
+		}
+		if bestJourney == nil || journey.Duration() < bestJourney.Duration() {
+			best, bestJourney = stop, journey
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	point := model.NewPathPoint(*best.Coordinate(), pointType, timeValue, request, bestJourney.WalkingDuration())
+	point.SetTransitDuration(bestJourney.TransitDuration())
+	return point
+}
+
+// getStopsNearRoute returns the stops near the route within the search radius of the rider, closest to the rider first
+func (g *TransitBasedGenerator) getStopsNearRoute(route *s2.Polyline, riderPoint *model.Coordinate) []*model.Hub {
+	stops := make([]*model.Hub, 0)
+	for _, stop := range g.stops.Within(riderPoint, g.config.StopSearchRadiusMeters) {
+		if distanceToRoute(route, stop.Coordinate()) <= g.config.MaxStopRouteDistanceMeters {
+			stops = append(stops, stop)
+		}
+	}
+	sort.SliceStable(stops, func(a, b int) bool {
+		return DistanceMeters(riderPoint, stops[a].Coordinate()) < DistanceMeters(riderPoint, stops[b].Coordinate())
+	})
+	if len(stops) > g.config.MaxStopCandidates {
+		stops = stops[:g.config.MaxStopCandidates]
+	}
+	return stops
+}
+
+func (g *TransitBasedGenerator) computeJourney(riderPoint, stop *model.Coordinate, pointType enums.PointType, timeValue time.Time) (*model.TransitJourney, error) {
+	var params *model.TransitParams
+	var err error
+	if pointType == enums.Pickup {
+		params, err = model.NewDepartAtTransitParams(riderPoint, stop, timeValue)
+	} else {
+		params, err = model.NewArriveByTransitParams(stop, riderPoint, timeValue)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transit params: %w", err)
+	}
+	return g.transitEngine.ComputeTransitJourney(context.Background(), params)
+}
@@ -0,0 +1,91 @@
+package pickupdropoffservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/umahmood/haversine"
+	"matching-engine/internal/adapter/routing"
+	"matching-engine/internal/app/config"
+	"matching-engine/internal/model"
+	"time"
+)
+
+const (
+	// DefaultWalkOnlyCollapseMeters is the distance under which two points are considered the same place
+	DefaultWalkOnlyCollapseMeters = 50.0
+	// DefaultWalkOnlyMaxMinutes bounds the walk a rider is told to take instead of riding
+	DefaultWalkOnlyMaxMinutes = 30.0
+
+	// maxWalkingSpeedMetersPerSecond is a fast walking pace, so a straight line walked at this speed is a lower bound
+	// of the walking time and rules out the far requests without querying the routing engine
+	maxWalkingSpeedMetersPerSecond = 2.0
+)
+
+// WalkOnlyDetector detects the requests whose riders are better off walking the whole way than being matched
+type WalkOnlyDetector struct {
+	engine         routing.Engine
+	collapseMeters float64
+	maxWalk        time.Duration
+}
+
+// NewWalkOnlyDetector creates a walk only detector configured from the environment
+func NewWalkOnlyDetector(engine routing.Engine) *WalkOnlyDetector {
+	collapseMeters := config.GetEnvFloat("WALK_ONLY_COLLAPSE_METERS", DefaultWalkOnlyCollapseMeters)
+	maxMinutes := config.GetEnvFloat("WALK_ONLY_MAX_MINUTES", DefaultWalkOnlyMaxMinutes)
+	return NewWalkOnlyDetectorWithConfig(engine, collapseMeters, time.Duration(maxMinutes*float64(time.Minute)))
+}
+
+// NewWalkOnlyDetectorWithConfig creates a walk only detector with the given collapse distance and maximum walk
+func NewWalkOnlyDetectorWithConfig(engine routing.Engine, collapseMeters float64, maxWalk time.Duration) *WalkOnlyDetector {
+	return &WalkOnlyDetector{
+		engine:         engine,
+		collapseMeters: collapseMeters,
+		maxWalk:        maxWalk,
+	}
+}
+
+// IsWalkable reports whether the rider of the request can walk from the source to the destination,
+// and returns the walking duration when they can.
+// A request is walkable when its source and destination collapse to the same place, or when the walk
+// is within the maximum walk and arrives before the latest arrival time of the request.
+func (d *WalkOnlyDetector) IsWalkable(request *model.Request) (bool, time.Duration, error) {
+	distance := DistanceMeters(request.Source(), request.Destination())
+	collapsed := distance <= d.collapseMeters
+	if !collapsed && time.Duration(distance/maxWalkingSpeedMetersPerSecond*float64(time.Second)) > d.maxWalk {
+		return false, 0, nil
+	}
+
+	params, err := model.NewWalkParams(request.Source(), request.Destination())
+	if err != nil {
+		return false, 0, fmt.Errorf("walk params: %w", err)
+	}
+	walkingDuration, err := d.engine.ComputeWalkingTime(context.Background(), params)
+	if err != nil {
+		return false, 0, fmt.Errorf("walking time: %w", err)
+	}
+
+	if collapsed {
+		return true, walkingDuration, nil
+	}
+	if walkingDuration > d.maxWalk {
+		return false, 0, nil
+	}
+	if request.EarliestDepartureTime().Add(walkingDuration).After(request.LatestArrivalTime()) {
+		return false, 0, nil
+	}
+	return true, walkingDuration, nil
+}
+
+// IsDegenerate reports whether the pickup and the dropoff collapse to the same place, leaving nothing to ride
+func (d *WalkOnlyDetector) IsDegenerate(pickup, dropoff *model.PathPoint) bool {
+	return DistanceMeters(pickup.Coordinate(), dropoff.Coordinate()) <= d.collapseMeters
+}
+
+// DistanceMeters returns the great circle distance between two coordinates in meters
+func DistanceMeters(from, to *model.Coordinate) float64 {
+	_, km := haversine.Distance(
+		haversine.Coord{Lat: from.Lat(), Lon: from.Lng()},
+		haversine.Coord{Lat: to.Lat(), Lon: to.Lng()},
+	)
+	return km * 1000
+}
@@ -4,15 +4,23 @@ import (
 	"matching-engine/internal/collections"
 )
 
-// TimeMatrixCacheWithOfferId is a specialized cache for route data
+// TimeMatrixCacheWithOfferId is a specialized cache for route data.
+// It is bounded by TIME_MATRIX_CACHE_MAX_MB and evicts the least recently used matrices first.
 type TimeMatrixCacheWithOfferId struct {
-	cache *collections.SyncMap[string, *PathPointMappedTimeMatrix]
+	cache *collections.LRUCache[string, *PathPointMappedTimeMatrix]
 }
 
-// NewTimeMatrixCacheWithOfferId creates a new TimeMatrixCacheWithOfferIdAndRequestId instance
+// NewTimeMatrixCacheWithOfferId creates a new TimeMatrixCacheWithOfferId instance
 func NewTimeMatrixCacheWithOfferId() *TimeMatrixCacheWithOfferId {
+	return NewTimeMatrixCacheWithOfferIdWithLimit(getMaxBytes("TIME_MATRIX_CACHE_MAX_MB", DefaultOfferCacheMaxMB))
+}
+
+// NewTimeMatrixCacheWithOfferIdWithLimit creates a cache holding up to maxBytes of matrices, 0 means unbounded
+func NewTimeMatrixCacheWithOfferIdWithLimit(maxBytes int64) *TimeMatrixCacheWithOfferId {
 	return &TimeMatrixCacheWithOfferId{
-		cache: collections.NewSyncMap[string, *PathPointMappedTimeMatrix](),
+		cache: collections.NewLRUCache(maxBytes, func(_ string, m *PathPointMappedTimeMatrix) int64 {
+			return matrixBytes(m)
+		}),
 	}
 }
 
@@ -31,7 +39,17 @@ func (c *TimeMatrixCacheWithOfferId) Delete(offerID string) {
 	c.cache.Delete(offerID)
 }
 
+// InvalidateOffer removes the matrix built from the previous path of the offer
+func (c *TimeMatrixCacheWithOfferId) InvalidateOffer(offerID string) {
+	c.cache.Delete(offerID)
+}
+
 // Clear removes all items from the cache
 func (c *TimeMatrixCacheWithOfferId) Clear() {
 	c.cache.Clear()
 }
+
+// Stats returns the hit rate and size counters of the cache
+func (c *TimeMatrixCacheWithOfferId) Stats() collections.CacheStats {
+	return c.cache.Stats()
+}
@@ -12,14 +12,24 @@ type cacheKey struct {
 
 // TimeMatrixCacheWithOfferIdAndRequestId provides a thread-safe cache for PathPointMappedTimeMatrix
 // entries keyed by both OfferID and RequestID.
+// It is bounded by TIME_MATRIX_REQUEST_CACHE_MAX_MB and evicts the least recently used matrices first.
 type TimeMatrixCacheWithOfferIdAndRequestId struct {
-	cache *collections.SyncMap[cacheKey, *PathPointMappedTimeMatrix]
+	cache *collections.LRUCache[cacheKey, *PathPointMappedTimeMatrix]
 }
 
 // NewTimeMatrixCacheWithOfferIdAndRequestId initializes a new TimeMatrixCacheWithOfferIdAndRequestId.
 func NewTimeMatrixCacheWithOfferIdAndRequestId() *TimeMatrixCacheWithOfferIdAndRequestId {
+	return NewTimeMatrixCacheWithOfferIdAndRequestIdWithLimit(
+		getMaxBytes("TIME_MATRIX_REQUEST_CACHE_MAX_MB", DefaultOfferRequestCacheMaxMB),
+	)
+}
+
+// NewTimeMatrixCacheWithOfferIdAndRequestIdWithLimit initializes a cache holding up to maxBytes of matrices, 0 means unbounded.
+func NewTimeMatrixCacheWithOfferIdAndRequestIdWithLimit(maxBytes int64) *TimeMatrixCacheWithOfferIdAndRequestId {
 	return &TimeMatrixCacheWithOfferIdAndRequestId{
-		cache: collections.NewSyncMap[cacheKey, *PathPointMappedTimeMatrix](),
+		cache: collections.NewLRUCache(maxBytes, func(_ cacheKey, m *PathPointMappedTimeMatrix) int64 {
+			return matrixBytes(m)
+		}),
 	}
 }
 
@@ -41,7 +51,19 @@ func (c *TimeMatrixCacheWithOfferIdAndRequestId) Delete(offerID, requestID strin
 	c.cache.Delete(key)
 }
 
+// InvalidateOffer removes the entries of every request for the given offerID.
+func (c *TimeMatrixCacheWithOfferIdAndRequestId) InvalidateOffer(offerID string) {
+	c.cache.DeleteFunc(func(key cacheKey, _ *PathPointMappedTimeMatrix) bool {
+		return key.OfferID == offerID
+	})
+}
+
 // Clear evicts all entries from the cache.
 func (c *TimeMatrixCacheWithOfferIdAndRequestId) Clear() {
 	c.cache.Clear()
 }
+
+// Stats returns the hit rate and size counters of the cache.
+func (c *TimeMatrixCacheWithOfferIdAndRequestId) Stats() collections.CacheStats {
+	return c.cache.Stats()
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultOfferCacheMaxMB bounds the matrices cached per offer
+	DefaultOfferCacheMaxMB = 512
+	// DefaultOfferRequestCacheMaxMB bounds the matrices cached per offer and request
+	DefaultOfferRequestCacheMaxMB = 256
+)
+
+// getMaxBytes reads a limit in megabytes from the environment, 0 disables the limit
+func getMaxBytes(key string, defaultMB int64) int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultMB << 20
+	}
+	mb, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || mb < 0 {
+		log.Warn().Msgf("Invalid %s value %q, using default: %d", key, v, defaultMB)
+		return defaultMB << 20
+	}
+	return mb << 20
+}
+
+// matrixBytes estimates the memory held by a cached matrix: the cells of every bucket, the row headers and the point index
+func matrixBytes(m *PathPointMappedTimeMatrix) int64 {
+	if m == nil {
+		return 0
+	}
+	layers := m.bucketMatrices
+	if len(layers) == 0 {
+		layers = [][][]time.Duration{m.timeMatrix}
+	}
+	var rows, cells int64
+	for _, layer := range layers {
+		rows += int64(len(layer))
+		for _, row := range layer {
+			cells += int64(len(row))
+		}
+	}
+	return cells*8 + rows*24 + int64(len(m.pointIdToIndex))*48
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"matching-engine/internal/collections"
+)
+
+// PinnedTimeMatrices holds the matrices used by the evaluations in progress, keyed by OfferID and RequestID.
+// Unlike the caches it never evicts, so the matrix ensured for an evaluation can't be evicted before it is read.
+type PinnedTimeMatrices struct {
+	matrices *collections.SyncMap[cacheKey, *PathPointMappedTimeMatrix]
+}
+
+// NewPinnedTimeMatrices creates an empty PinnedTimeMatrices
+func NewPinnedTimeMatrices() *PinnedTimeMatrices {
+	return &PinnedTimeMatrices{
+		matrices: collections.NewSyncMap[cacheKey, *PathPointMappedTimeMatrix](),
+	}
+}
+
+// Get returns the matrix pinned for the given offerID and requestID
+func (p *PinnedTimeMatrices) Get(offerID, requestID string) (*PathPointMappedTimeMatrix, bool) {
+	return p.matrices.Get(cacheKey{OfferID: offerID, RequestID: requestID})
+}
+
+// Pin keeps the matrix for the given offerID and requestID until it is unpinned
+func (p *PinnedTimeMatrices) Pin(offerID, requestID string, matrix *PathPointMappedTimeMatrix) {
+	p.matrices.Set(cacheKey{OfferID: offerID, RequestID: requestID}, matrix)
+}
+
+// Unpin releases the matrix of the given offerID and requestID
+func (p *PinnedTimeMatrices) Unpin(offerID, requestID string) {
+	p.matrices.Delete(cacheKey{OfferID: offerID, RequestID: requestID})
+}
@@ -8,6 +8,12 @@ import (
 type PathPointMappedTimeMatrix struct {
 	timeMatrix     [][]time.Duration
 	pointIdToIndex map[model.PathPointID]int
+
+	// Time-dependent layers: bucketMatrices[i] holds the travel times of legs
+	// starting in [bucketStart + i*bucketSize, bucketStart + (i+1)*bucketSize)
+	bucketStart    time.Time
+	bucketSize     time.Duration
+	bucketMatrices [][][]time.Duration
 }
 
 func NewPathPointMappedTimeMatrix(
@@ -20,10 +26,62 @@ func NewPathPointMappedTimeMatrix(
 	}
 }
 
+// NewTimeDependentPathPointMappedTimeMatrix builds a matrix holding one layer per departure time bucket.
+// The first layer is also exposed through TimeMatrix for consumers that are not time aware.
+func NewTimeDependentPathPointMappedTimeMatrix(
+	bucketMatrices [][][]time.Duration,
+	bucketStart time.Time,
+	bucketSize time.Duration,
+	pointIdToIndex map[model.PathPointID]int,
+) *PathPointMappedTimeMatrix {
+	if len(bucketMatrices) == 0 {
+		return NewPathPointMappedTimeMatrix(nil, pointIdToIndex)
+	}
+	if len(bucketMatrices) == 1 || bucketSize <= 0 {
+		return NewPathPointMappedTimeMatrix(bucketMatrices[0], pointIdToIndex)
+	}
+	return &PathPointMappedTimeMatrix{
+		timeMatrix:     bucketMatrices[0],
+		pointIdToIndex: pointIdToIndex,
+		bucketStart:    bucketStart,
+		bucketSize:     bucketSize,
+		bucketMatrices: bucketMatrices,
+	}
+}
+
 func (m *PathPointMappedTimeMatrix) TimeMatrix() [][]time.Duration {
 	return m.timeMatrix
 }
 
+// TimeMatrixAt returns the travel times of legs departing at t.
+// Times before the first bucket or after the last one use the closest bucket.
+func (m *PathPointMappedTimeMatrix) TimeMatrixAt(t time.Time) [][]time.Duration {
+	if !m.IsTimeDependent() {
+		return m.timeMatrix
+	}
+	idx := int(t.Sub(m.bucketStart) / m.bucketSize)
+	if t.Before(m.bucketStart) {
+		idx = 0
+	}
+	if idx >= len(m.bucketMatrices) {
+		idx = len(m.bucketMatrices) - 1
+	}
+	return m.bucketMatrices[idx]
+}
+
+// IsTimeDependent reports whether the matrix holds more than one departure time bucket
+func (m *PathPointMappedTimeMatrix) IsTimeDependent() bool {
+	return len(m.bucketMatrices) > 1
+}
+
+// BucketCount returns the number of departure time buckets held by the matrix
+func (m *PathPointMappedTimeMatrix) BucketCount() int {
+	if !m.IsTimeDependent() {
+		return 1
+	}
+	return len(m.bucketMatrices)
+}
+
 func (m *PathPointMappedTimeMatrix) PointIdToIndex() map[model.PathPointID]int {
 	return m.pointIdToIndex
 }
@@ -10,44 +10,49 @@ type CacheWithOfferIdRequestIdPopulator struct {
 	generator                    Generator
 	cacheWithOfferIdAndRequestId *cache.TimeMatrixCacheWithOfferIdAndRequestId
 	CacheWithOfferId             *cache.TimeMatrixCacheWithOfferId
+	pinned                       *cache.PinnedTimeMatrices
 }
 
-func NewCacheWithOfferIdRequestIdPopulator(generator Generator, cacheWithOfferIdAndRequestId *cache.TimeMatrixCacheWithOfferIdAndRequestId, CacheWithOfferId *cache.TimeMatrixCacheWithOfferId) *CacheWithOfferIdRequestIdPopulator {
+func NewCacheWithOfferIdRequestIdPopulator(generator Generator, cacheWithOfferIdAndRequestId *cache.TimeMatrixCacheWithOfferIdAndRequestId, CacheWithOfferId *cache.TimeMatrixCacheWithOfferId, pinned *cache.PinnedTimeMatrices) *CacheWithOfferIdRequestIdPopulator {
 	return &CacheWithOfferIdRequestIdPopulator{
 		generator:                    generator,
 		cacheWithOfferIdAndRequestId: cacheWithOfferIdAndRequestId,
 		CacheWithOfferId:             CacheWithOfferId,
+		pinned:                       pinned,
 	}
 }
 
-func (p *CacheWithOfferIdRequestIdPopulator) Populate(offer *model.OfferNode, requestNodes []*model.RequestNode) error {
-
-	_, exists := p.CacheWithOfferId.Get(offer.Offer().ID())
-	if exists {
-		return nil
-	}
-
+// Populate ensures a time matrix covering the offer and the request node is available, and returns it.
+// The matrix is the one of the offer when it is cached, otherwise the one of the offer and the request.
+// It stays pinned for the selector until RemoveEntry, so the caches can't evict it during the evaluation.
+func (p *CacheWithOfferIdRequestIdPopulator) Populate(offer *model.OfferNode, requestNodes []*model.RequestNode) (*cache.PathPointMappedTimeMatrix, error) {
 	if len(requestNodes) != 1 {
-		return fmt.Errorf("requestNodes should be contain 1 node, got %d request nodes", len(requestNodes))
-	}
-	// Check if the time matrix is already cached
-	_, exists = p.cacheWithOfferIdAndRequestId.Get(offer.Offer().ID(), requestNodes[0].Request().ID())
-	if exists {
-		return nil
+		return nil, fmt.Errorf("requestNodes should be contain 1 node, got %d request nodes", len(requestNodes))
 	}
+	offerID, requestID := offer.Offer().ID(), requestNodes[0].Request().ID()
 
-	// Create a new time matrix
-	timeMatrix, err := p.generator.Generate(offer, requestNodes)
+	timeMatrix, exists := p.CacheWithOfferId.Get(offerID)
+	if !exists {
+		// Check if the time matrix is already cached
+		timeMatrix, exists = p.cacheWithOfferIdAndRequestId.Get(offerID, requestID)
+	}
+	if !exists {
+		// Create a new time matrix
+		var err error
+		timeMatrix, err = p.generator.Generate(offer, requestNodes)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate time matrix for offer %s: %w", offerID, err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("could not generate time matrix for offer %s: %w", offer.Offer().ID(), err)
+		// Store the time matrix in the cacheWithOfferIdAndRequestId
+		p.cacheWithOfferIdAndRequestId.Set(offerID, requestID, timeMatrix)
 	}
 
-	// Store the time matrix in the cacheWithOfferIdAndRequestId
-	p.cacheWithOfferIdAndRequestId.Set(offer.Offer().ID(), requestNodes[0].Request().ID(), timeMatrix)
-	return nil
+	p.pinned.Pin(offerID, requestID, timeMatrix)
+	return timeMatrix, nil
 }
 
+// RemoveEntry removes the time matrix of the offer and the request node, and unpins the matrix of the evaluation
 func (p *CacheWithOfferIdRequestIdPopulator) RemoveEntry(offer *model.OfferNode, requestNodes []*model.RequestNode) error {
 	if len(requestNodes) != 1 {
 		return fmt.Errorf("requestNodes should contain exactly 1 node, got %d request nodes", len(requestNodes))
@@ -55,5 +60,11 @@ func (p *CacheWithOfferIdRequestIdPopulator) RemoveEntry(offer *model.OfferNode,
 
 	// Remove the time matrix from the cacheWithOfferIdAndRequestId
 	p.cacheWithOfferIdAndRequestId.Delete(offer.Offer().ID(), requestNodes[0].Request().ID())
+	p.pinned.Unpin(offer.Offer().ID(), requestNodes[0].Request().ID())
 	return nil
 }
+
+// Unpin releases the matrix pinned by Populate, the cached matrices are kept for the next evaluations
+func (p *CacheWithOfferIdRequestIdPopulator) Unpin(offer *model.OfferNode, requestNode *model.RequestNode) {
+	p.pinned.Unpin(offer.Offer().ID(), requestNode.Request().ID())
+}
@@ -20,27 +20,29 @@ func NewCacheWithOfferIdPopulator(generator Generator, cacheWithOfferId *cache.T
 	}
 }
 
-func (p *CacheWithOfferIdPopulator) Populate(offer *model.OfferNode, requestNodes []*model.RequestNode) error {
+// Populate ensures the time matrix of the offer and all the request nodes is cached, and returns it.
+// No matrix is returned when the number of request nodes exceeds the caching bound.
+func (p *CacheWithOfferIdPopulator) Populate(offer *model.OfferNode, requestNodes []*model.RequestNode) (*cache.PathPointMappedTimeMatrix, error) {
 
 	// early return if the number of request nodes exceeds the caching bound
 	if len(requestNodes) > p.cachingBound {
-		return nil
+		return nil, nil
 	}
 	// Check if the time matrix is already cached
-	_, exists := p.cacheWithOfferId.Get(offer.Offer().ID())
+	timeMatrix, exists := p.cacheWithOfferId.Get(offer.Offer().ID())
 	if exists {
-		return nil
+		return timeMatrix, nil
 	}
 
 	// Create a new time matrix
 	timeMatrix, err := p.generator.Generate(offer, requestNodes)
 	if err != nil {
-		return fmt.Errorf("could not generate time matrix for offer %s: %w", offer.Offer().ID(), err)
+		return nil, fmt.Errorf("could not generate time matrix for offer %s: %w", offer.Offer().ID(), err)
 	}
 
 	// Store the time matrix in the cacheWithOfferIdAndRequestId
 	p.cacheWithOfferId.Set(offer.Offer().ID(), timeMatrix)
-	return nil
+	return timeMatrix, nil
 }
 
 func (p *CacheWithOfferIdPopulator) RemoveEntry(offer *model.OfferNode, requestNodes []*model.RequestNode) error {
@@ -1,24 +1,38 @@
 package timematrix
 
 import (
+	"context"
 	"fmt"
 	"matching-engine/internal/adapter/routing"
 	"matching-engine/internal/model"
 	"matching-engine/internal/service/pickupdropoffservice"
 	"matching-engine/internal/service/timematrix/cache"
+	"time"
 )
 
 type DefaultGenerator struct {
 	engine                routing.Engine
 	pickupDropoffSelector pickupdropoffservice.PickupDropoffSelectorInterface
+	timeDependent         TimeDependentConfig
 }
 
 func NewDefaultGenerator(engine routing.Engine, pickupDropoffSelector pickupdropoffservice.PickupDropoffSelectorInterface) Generator {
+	return NewDefaultGeneratorWithConfig(engine, pickupDropoffSelector, LoadTimeDependentConfig())
+}
+
+// NewDefaultGeneratorWithConfig creates a generator that evaluates the departure time buckets described by timeDependent
+func NewDefaultGeneratorWithConfig(
+	engine routing.Engine,
+	pickupDropoffSelector pickupdropoffservice.PickupDropoffSelectorInterface,
+	timeDependent TimeDependentConfig,
+) Generator {
 	return &DefaultGenerator{
 		engine:                engine,
 		pickupDropoffSelector: pickupDropoffSelector,
+		timeDependent:         timeDependent,
 	}
 }
+
 func (ds *DefaultGenerator) Generate(offerNode *model.OfferNode, requestNodes []*model.RequestNode) (*cache.PathPointMappedTimeMatrix, error) {
 	if requestNodes == nil || len(requestNodes) == 0 {
 		return nil, fmt.Errorf("requestNodes cannot be nil or empty")
@@ -42,38 +56,95 @@ func (ds *DefaultGenerator) Generate(offerNode *model.OfferNode, requestNodes []
 			// TODO: check if error is related to API calls before returning an error from the generator
 			return nil, fmt.Errorf("failed to get pickup/dropoff points for requestNode %s: %w", requestNode.Request().ID(), err)
 		}
-		pickup := pickupDropoff.Pickup()
-		dropoff := pickupDropoff.Dropoff()
-
-		matrixPoints = append(matrixPoints, *pickup.Coordinate())
-		pointToIdMap[pickup.ID()] = len(matrixPoints) - 1
-		idToPoint[pickup.ID()] = *pickup
+		// every candidate is added, so that the planner can try the alternatives of the best pickup and dropoff
+		for _, pickup := range pickupDropoff.PickupCandidates() {
+			matrixPoints = append(matrixPoints, *pickup.Coordinate())
+			pointToIdMap[pickup.ID()] = len(matrixPoints) - 1
+			idToPoint[pickup.ID()] = *pickup
+		}
 
-		matrixPoints = append(matrixPoints, *dropoff.Coordinate())
-		pointToIdMap[dropoff.ID()] = len(matrixPoints) - 1
-		idToPoint[dropoff.ID()] = *dropoff
+		for _, dropoff := range pickupDropoff.DropoffCandidates() {
+			matrixPoints = append(matrixPoints, *dropoff.Coordinate())
+			pointToIdMap[dropoff.ID()] = len(matrixPoints) - 1
+			idToPoint[dropoff.ID()] = *dropoff
+		}
 
+		// the starts of a flexible source offer replace its source in the paths tried by the planner
+		for _, source := range pickupDropoff.SourceCandidates() {
+			matrixPoints = append(matrixPoints, *source.Coordinate())
+			pointToIdMap[source.ID()] = len(matrixPoints) - 1
+			idToPoint[source.ID()] = *source
+		}
 	}
 
 	if len(matrixPoints) <= 2 {
 		return nil, fmt.Errorf("not enough points to generate a distance/time matrix")
 	}
 
-	// Call the routing engine to get the distance and time matrix
+	if !ds.timeDependent.Enabled {
+		times, err := ds.computeTimes(offerNode.Offer(), matrixPoints, offerNode.Offer().DepartureTime())
+		if err != nil {
+			return nil, err
+		}
+		return cache.NewPathPointMappedTimeMatrix(times, pointToIdMap), nil
+	}
+
+	bucketStart, departures := ds.departureBuckets(offerNode.Offer())
+	bucketMatrices := make([][][]time.Duration, len(departures))
+	for i, departure := range departures {
+		times, err := ds.computeTimes(offerNode.Offer(), matrixPoints, departure)
+		if err != nil {
+			return nil, err
+		}
+		bucketMatrices[i] = times
+	}
+
+	return cache.NewTimeDependentPathPointMappedTimeMatrix(bucketMatrices, bucketStart, ds.timeDependent.BucketSize, pointToIdMap), nil
+}
+
+// computeTimes asks the routing engine for the travel times between all points when departing at departure
+func (ds *DefaultGenerator) computeTimes(offer *model.Offer, matrixPoints []model.Coordinate, departure time.Time) ([][]time.Duration, error) {
 	params, err := model.NewDistanceTimeMatrixParams(
 		matrixPoints,
 		model.ProfileAuto,
-		model.WithDepartureTime(offerNode.Offer().DepartureTime()),
+		model.WithDepartureTime(departure),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create distance time matrix params: %w", err)
 	}
 
-	distanceTimeMatrix, err := ds.engine.ComputeDistanceTimeMatrix(nil, params)
+	distanceTimeMatrix, err := ds.engine.ComputeDistanceTimeMatrix(context.Background(), params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute distance time matrix for offer %s with %d matrixPoints: %w",
-			offerNode.Offer().ID(), len(matrixPoints), err)
+			offer.ID(), len(matrixPoints), err)
+	}
+
+	return distanceTimeMatrix.Times(), nil
+}
+
+// departureBuckets returns the start of the bucket holding the offer departure and the departure time
+// used to evaluate each bucket the trip may span, up to the offer's maximum estimated arrival time.
+// The first bucket is evaluated at the offer departure itself, later ones at their start.
+func (ds *DefaultGenerator) departureBuckets(offer *model.Offer) (time.Time, []time.Time) {
+	size := ds.timeDependent.BucketSize
+	departure := offer.DepartureTime()
+	if size <= 0 {
+		return departure, []time.Time{departure}
+	}
+	bucketStart := departure.Truncate(size)
+
+	count := 1
+	if end := offer.MaxEstimatedArrivalTime(); end.After(departure) {
+		count = int(end.Sub(bucketStart)/size) + 1
+	}
+	if ds.timeDependent.MaxBuckets > 0 && count > ds.timeDependent.MaxBuckets {
+		count = ds.timeDependent.MaxBuckets
 	}
 
-	return cache.NewPathPointMappedTimeMatrix(distanceTimeMatrix.Times(), pointToIdMap), nil
+	departures := make([]time.Time, count)
+	departures[0] = departure
+	for i := 1; i < count; i++ {
+		departures[i] = bucketStart.Add(time.Duration(i) * size)
+	}
+	return bucketStart, departures
 }
@@ -10,16 +10,22 @@ import (
 type DefaultSelector struct {
 	cacheWithOfferId             *cache.TimeMatrixCacheWithOfferId
 	cacheWithOfferIdAndRequestId *cache.TimeMatrixCacheWithOfferIdAndRequestId
+	pinned                       *cache.PinnedTimeMatrices
 }
 
-func NewDefaultSelector(cacheWithOfferId *cache.TimeMatrixCacheWithOfferId, cacheWithOfferIdAndRequestId *cache.TimeMatrixCacheWithOfferIdAndRequestId) Selector {
+func NewDefaultSelector(cacheWithOfferId *cache.TimeMatrixCacheWithOfferId, cacheWithOfferIdAndRequestId *cache.TimeMatrixCacheWithOfferIdAndRequestId, pinned *cache.PinnedTimeMatrices) Selector {
 	return &DefaultSelector{
 		cacheWithOfferId:             cacheWithOfferId,
 		cacheWithOfferIdAndRequestId: cacheWithOfferIdAndRequestId,
+		pinned:                       pinned,
 	}
 }
 
+// GetTimeMatrix returns the matrix pinned for the evaluation of the offer and the request, or a cached one
 func (selector *DefaultSelector) GetTimeMatrix(offer *model.OfferNode, requestNode *model.RequestNode) (*cache.PathPointMappedTimeMatrix, error) {
+	if pinnedValue, exists := selector.pinned.Get(offer.Offer().ID(), requestNode.Request().ID()); exists {
+		return pinnedValue, nil
+	}
 
 	// Check if the time matrix is already cached
 	cachedValue, exists := selector.cacheWithOfferId.Get(offer.Offer().ID())
@@ -17,7 +17,8 @@ func NewService(selector Selector) Service {
 	}
 }
 
-func (s *DefaultService) getTravelDuration(matrix *cache.PathPointMappedTimeMatrix, from, to model.PathPointID) (time.Duration, error) {
+// getTravelDuration returns the travel time of the leg from -> to when it starts at departure
+func (s *DefaultService) getTravelDuration(matrix *cache.PathPointMappedTimeMatrix, departure time.Time, from, to model.PathPointID) (time.Duration, error) {
 	fromIdx, fromExist := matrix.PointIdToIndex()[from]
 	toIdx, toExist := matrix.PointIdToIndex()[to]
 
@@ -25,11 +26,12 @@ func (s *DefaultService) getTravelDuration(matrix *cache.PathPointMappedTimeMatr
 		return 0, fmt.Errorf("invalid path point ID: from=%v, to=%v", from, to)
 	}
 
-	if fromIdx >= len(matrix.TimeMatrix()) || toIdx >= len(matrix.TimeMatrix()[fromIdx]) {
+	times := matrix.TimeMatrixAt(departure)
+	if fromIdx >= len(times) || toIdx >= len(times[fromIdx]) {
 		return 0, fmt.Errorf("index out of bounds: fromIdx=%d, toIdx=%d", fromIdx, toIdx)
 	}
 
-	return matrix.TimeMatrix()[fromIdx][toIdx], nil
+	return times[fromIdx][toIdx], nil
 }
 
 func (s *DefaultService) GetCumulativeTravelDurations(offer *model.OfferNode, requestNode *model.RequestNode, pathPoints []model.PathPoint) ([]time.Duration, error) {
@@ -42,10 +44,12 @@ func (s *DefaultService) GetCumulativeTravelDurations(offer *model.OfferNode, re
 		return nil, err
 	}
 
+	// each leg is evaluated in the departure time bucket in which it starts
+	departure := offer.Offer().TripStartTime(pathPoints)
 	cumulativeDuration := make([]time.Duration, len(pathPoints))
 	cumulativeDuration[0] = 0
 	for i := 0; i < len(pathPoints)-1; i++ {
-		duration, err := s.getTravelDuration(matrix, pathPoints[i].ID(), pathPoints[i+1].ID())
+		duration, err := s.getTravelDuration(matrix, departure.Add(cumulativeDuration[i]), pathPoints[i].ID(), pathPoints[i+1].ID())
 		if err != nil {
 			return nil, err
 		}
@@ -66,9 +70,9 @@ func (s *DefaultService) GetCumulativeTravelTimes(offer *model.OfferNode, reques
 	}
 
 	cumulativeTimes := make([]time.Time, len(pathPoints))
-	cumulativeTimes[0] = offer.Offer().DepartureTime()
+	cumulativeTimes[0] = offer.Offer().TripStartTime(pathPoints)
 	for i := 0; i < len(pathPoints)-1; i++ {
-		duration, err := s.getTravelDuration(matrix, pathPoints[i].ID(), pathPoints[i+1].ID())
+		duration, err := s.getTravelDuration(matrix, cumulativeTimes[i], pathPoints[i].ID(), pathPoints[i+1].ID())
 		if err != nil {
 			return nil, err
 		}
@@ -85,5 +89,5 @@ func (s *DefaultService) GetTravelDuration(offer *model.OfferNode, requestNode *
 		return 0, err
 	}
 
-	return s.getTravelDuration(matrix, from, to)
+	return s.getTravelDuration(matrix, offer.Offer().DepartureTime(), from, to)
 }
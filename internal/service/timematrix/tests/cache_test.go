@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/timematrix/cache"
+	"testing"
+	"time"
+)
+
+func squareMatrix(size int) *cache.PathPointMappedTimeMatrix {
+	times := make([][]time.Duration, size)
+	index := make(map[model.PathPointID]int, size)
+	for i := range times {
+		times[i] = make([]time.Duration, size)
+		index[model.PathPointID(i)] = i
+	}
+	return cache.NewPathPointMappedTimeMatrix(times, index)
+}
+
+func TestTimeMatrixCacheWithOfferIdAndRequestId_InvalidateOffer(t *testing.T) {
+	c := cache.NewTimeMatrixCacheWithOfferIdAndRequestIdWithLimit(0)
+	c.Set("offer1", "request1", squareMatrix(2))
+	c.Set("offer1", "request2", squareMatrix(2))
+	c.Set("offer2", "request1", squareMatrix(2))
+
+	c.InvalidateOffer("offer1")
+
+	if _, ok := c.Get("offer1", "request1"); ok {
+		t.Error("expected offer1 entries to be invalidated")
+	}
+	if _, ok := c.Get("offer1", "request2"); ok {
+		t.Error("expected offer1 entries to be invalidated")
+	}
+	if _, ok := c.Get("offer2", "request1"); !ok {
+		t.Error("expected offer2 entry to be kept")
+	}
+}
+
+func TestTimeMatrixCacheWithOfferId_BoundedByMemory(t *testing.T) {
+	// A 10x10 matrix weighs about 1.5KB with its index, only one fits in 2000 bytes
+	c := cache.NewTimeMatrixCacheWithOfferIdWithLimit(2000)
+	c.Set("offer1", squareMatrix(10))
+	c.Set("offer2", squareMatrix(10))
+	c.Set("offer3", squareMatrix(10))
+
+	if _, ok := c.Get("offer1"); ok {
+		t.Error("expected the least recently used matrix to be evicted")
+	}
+	if _, ok := c.Get("offer3"); !ok {
+		t.Error("expected the latest matrix to be kept")
+	}
+	if stats := c.Stats(); stats.Evictions == 0 || stats.Cost > 2000 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/timematrix"
+	"matching-engine/internal/service/timematrix/cache"
+	"testing"
+)
+
+type squareMatrixGenerator struct{}
+
+func (g squareMatrixGenerator) Generate(offer *model.OfferNode, requestNodes []*model.RequestNode) (*cache.PathPointMappedTimeMatrix, error) {
+	return squareMatrix(10), nil
+}
+
+func TestCacheWithOfferIdRequestIdPopulator_MatrixSurvivesEviction(t *testing.T) {
+	// Only one 10x10 matrix fits in 2000 bytes, the next one evicts it
+	offerIdCache := cache.NewTimeMatrixCacheWithOfferIdWithLimit(2000)
+	requestIdCache := cache.NewTimeMatrixCacheWithOfferIdAndRequestIdWithLimit(2000)
+	pinned := cache.NewPinnedTimeMatrices()
+	populator := timematrix.NewCacheWithOfferIdRequestIdPopulator(squareMatrixGenerator{}, requestIdCache, offerIdCache, pinned)
+	selector := timematrix.NewDefaultSelector(offerIdCache, requestIdCache, pinned)
+
+	offerNode := model.NewOfferNode(createTestOffer())
+	requestNode := model.NewRequestNode(createTestRequest())
+	populated, err := populator.Populate(offerNode, []*model.RequestNode{requestNode})
+	if err != nil || populated == nil {
+		t.Fatalf("expected a matrix, got %v, %v", populated, err)
+	}
+
+	requestIdCache.Set("offer-other", "request-other", squareMatrix(10))
+	if _, ok := requestIdCache.Get(offerNode.Offer().ID(), requestNode.Request().ID()); ok {
+		t.Fatal("expected the populated matrix to be evicted from the cache")
+	}
+
+	selected, err := selector.GetTimeMatrix(offerNode, requestNode)
+	if err != nil {
+		t.Fatalf("expected the pinned matrix, got error %v", err)
+	}
+	if selected != populated {
+		t.Error("expected the selector to return the populated matrix")
+	}
+
+	populator.Unpin(offerNode, requestNode)
+	if _, err := selector.GetTimeMatrix(offerNode, requestNode); err == nil {
+		t.Error("expected no matrix once unpinned")
+	}
+}
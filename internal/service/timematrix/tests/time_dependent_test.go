@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"matching-engine/internal/adapter/traffic"
+	"matching-engine/internal/enums"
+	"matching-engine/internal/model"
+	"matching-engine/internal/service/pickupdropoffservice/pickupdropoffcache"
+	"matching-engine/internal/service/timematrix"
+	"matching-engine/internal/service/timematrix/cache"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeMatrixService_GetCumulativeTravelDurations_UsesBucketOfEachLeg(t *testing.T) {
+	resetPathPointIDCounter()
+
+	offer := createTestOffer()
+	offerNode := model.NewOfferNode(offer)
+	requestNode := model.NewRequestNode(createTestRequest())
+
+	offPeak := [][]time.Duration{
+		{0, 20 * time.Minute, 30 * time.Minute},
+		{20 * time.Minute, 0, 10 * time.Minute},
+		{30 * time.Minute, 10 * time.Minute, 0},
+	}
+	rushHour := [][]time.Duration{
+		{0, 25 * time.Minute, 45 * time.Minute},
+		{25 * time.Minute, 0, 18 * time.Minute},
+		{45 * time.Minute, 18 * time.Minute, 0},
+	}
+	pointIdToIndex := map[model.PathPointID]int{1: 0, 2: 1, 3: 2}
+
+	// the trip departs at the start of the off-peak bucket, rush hour starts 15 minutes later
+	mappedMatrix := cache.NewTimeDependentPathPointMappedTimeMatrix(
+		[][][]time.Duration{offPeak, rushHour}, offer.DepartureTime(), 15*time.Minute, pointIdToIndex,
+	)
+	mockSelector := new(MockTimeMatrixSelector)
+	mockSelector.On("GetTimeMatrix", offerNode).Return(mappedMatrix, nil)
+
+	source, _ := model.NewCoordinate(1.0, 1.0)
+	pickup, _ := model.NewCoordinate(1.5, 1.5)
+	dest, _ := model.NewCoordinate(2.0, 2.0)
+	pathPoints := []model.PathPoint{
+		*createPathPoint(source, enums.Source, offer),
+		*createPathPoint(pickup, enums.Pickup, offer),
+		*createPathPoint(dest, enums.Destination, offer),
+	}
+
+	service := timematrix.NewService(mockSelector)
+
+	cumulativeDurations, err := service.GetCumulativeTravelDurations(offerNode, requestNode, pathPoints)
+	require.NoError(t, err)
+	// first leg departs off-peak, the second one 20 minutes later during rush hour
+	assert.Equal(t, []time.Duration{0, 20 * time.Minute, 38 * time.Minute}, cumulativeDurations)
+
+	cumulativeTimes, err := service.GetCumulativeTravelTimes(offerNode, requestNode, pathPoints)
+	require.NoError(t, err)
+	assert.Equal(t, offer.DepartureTime().Add(38*time.Minute), cumulativeTimes[2])
+
+	// the direct trip departs with the offer
+	direct, err := service.GetTravelDuration(offerNode, requestNode, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, direct)
+}
+
+func TestDefaultGenerator_Generate_TimeDependentEngineBuckets(t *testing.T) {
+	offer := createTestOffer()
+	request := createTestRequest()
+	offer.SetPath([]model.PathPoint{
+		*createPathPoint(offer.Source(), enums.Source, offer),
+		*createPathPoint(offer.Destination(), enums.Destination, offer),
+	})
+
+	pickupCoord, _ := model.NewCoordinate(1.4, 1.4)
+	dropoffCoord, _ := model.NewCoordinate(2.4, 2.4)
+	pdValue := pickupdropoffcache.NewValue(
+		createPathPoint(pickupCoord, enums.Pickup, request),
+		createPathPoint(dropoffCoord, enums.Dropoff, request),
+	)
+	mockPickupDropoffSelector := new(MockPickupDropoffSelector)
+	mockPickupDropoffSelector.On("GetPickupDropoffPointsAndDurations", request, offer).Return(pdValue, nil)
+
+	timeMatrix, distanceMatrix := generateRandomTimeDistanceMatrices(4)
+	distanceTimeMatrix, _ := model.NewDistanceTimeMatrix(distanceMatrix, timeMatrix)
+
+	var departures []time.Time
+	mockEngine := new(MockRoutingEngine)
+	mockEngine.On("ComputeDistanceTimeMatrix", mock.Anything, mock.AnythingOfType("*model.DistanceTimeMatrixParams")).
+		Run(func(args mock.Arguments) {
+			departures = append(departures, args.Get(1).(*model.DistanceTimeMatrixParams).DepartureTime())
+		}).
+		Return(distanceTimeMatrix, nil)
+
+	generator := timematrix.NewDefaultGeneratorWithConfig(mockEngine, mockPickupDropoffSelector, timematrix.TimeDependentConfig{
+		Enabled:    true,
+		BucketSize: 20 * time.Minute,
+		MaxBuckets: 10,
+	})
+
+	result, err := generator.Generate(model.NewOfferNode(offer), []*model.RequestNode{model.NewRequestNode(request)})
+	require.NoError(t, err)
+
+	// the trip may last an hour, so it spans three or four 20-minute buckets depending on alignment
+	require.True(t, result.IsTimeDependent())
+	assert.Contains(t, []int{3, 4}, result.BucketCount())
+	require.Len(t, departures, result.BucketCount())
+	assert.Equal(t, offer.DepartureTime(), departures[0])
+	for i := 1; i < len(departures); i++ {
+		assert.Equal(t, time.Duration(0), departures[i].Sub(offer.DepartureTime().Truncate(20*time.Minute))%(20*time.Minute))
+		assert.True(t, departures[i].After(departures[i-1]))
+	}
+}
+
+func TestDefaultGenerator_Generate_TimeDependentTrafficProfile(t *testing.T) {
+	offer := createTestOffer()
+	request := createTestRequest()
+	offer.SetPath([]model.PathPoint{
+		*createPathPoint(offer.Source(), enums.Source, offer),
+		*createPathPoint(offer.Destination(), enums.Destination, offer),
+	})
+
+	pickupCoord, _ := model.NewCoordinate(1.4, 1.4)
+	dropoffCoord, _ := model.NewCoordinate(2.4, 2.4)
+	pdValue := pickupdropoffcache.NewValue(
+		createPathPoint(pickupCoord, enums.Pickup, request),
+		createPathPoint(dropoffCoord, enums.Dropoff, request),
+	)
+	mockPickupDropoffSelector := new(MockPickupDropoffSelector)
+	mockPickupDropoffSelector.On("GetPickupDropoffPointsAndDurations", request, offer).Return(pdValue, nil)
+
+	timeMatrix, distanceMatrix := generateRandomTimeDistanceMatrices(4)
+	distanceTimeMatrix, _ := model.NewDistanceTimeMatrix(distanceMatrix, timeMatrix)
+
+	mockEngine := new(MockRoutingEngine)
+	mockEngine.On("ComputeDistanceTimeMatrix", mock.Anything, mock.AnythingOfType("*model.DistanceTimeMatrixParams")).
+		Return(distanceTimeMatrix, nil)
+
+	// the face cell "1" covers every point of the trip, its times are doubled at all hours
+	profile, err := traffic.ReadProfile(strings.NewReader("zone,hour_of_week,multiplier\n1,*,2\n"), "double", time.UTC, traffic.DefaultSegmentLevel)
+	require.NoError(t, err)
+	generator := timematrix.NewDefaultGeneratorWithConfig(traffic.NewEngine(mockEngine, profile), mockPickupDropoffSelector, timematrix.TimeDependentConfig{
+		Enabled:    true,
+		BucketSize: 30 * time.Minute,
+		MaxBuckets: 4,
+	})
+
+	result, err := generator.Generate(model.NewOfferNode(offer), []*model.RequestNode{model.NewRequestNode(request)})
+	require.NoError(t, err)
+
+	// the profile scales every bucket exactly once
+	assert.Equal(t, 2*timeMatrix[0][1], result.TimeMatrix()[0][1])
+	assert.Equal(t, 2*timeMatrix[2][3], result.TimeMatrixAt(offer.MaxEstimatedArrivalTime())[2][3])
+}
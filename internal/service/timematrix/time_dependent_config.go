@@ -0,0 +1,63 @@
+package timematrix
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultTimeDependentBucketSize = 30 * time.Minute
+	DefaultTimeDependentMaxBuckets = 6
+)
+
+// TimeDependentConfig controls how many departure time buckets the generator evaluates per offer.
+// The routing engine computes each bucket at its departure time, so the traffic profile wrapping it
+// (see TRAFFIC_PROFILE_PATH) scales every bucket once.
+type TimeDependentConfig struct {
+	Enabled    bool
+	BucketSize time.Duration
+	MaxBuckets int
+}
+
+// LoadTimeDependentConfig reads the TIME_DEPENDENT_* environment variables.
+// Time-dependent matrices are disabled unless TIME_DEPENDENT_MATRIX_ENABLED is true.
+func LoadTimeDependentConfig() TimeDependentConfig {
+	cfg := TimeDependentConfig{
+		BucketSize: DefaultTimeDependentBucketSize,
+		MaxBuckets: DefaultTimeDependentMaxBuckets,
+	}
+
+	if v := os.Getenv("TIME_DEPENDENT_MATRIX_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Warn().Msgf("Invalid TIME_DEPENDENT_MATRIX_ENABLED value %q, time-dependent matrices disabled", v)
+		}
+		cfg.Enabled = enabled
+	}
+	if v := os.Getenv("TIME_DEPENDENT_BUCKET_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			log.Warn().Msgf("Invalid TIME_DEPENDENT_BUCKET_MINUTES value %q, using default: %s", v, DefaultTimeDependentBucketSize)
+		} else {
+			cfg.BucketSize = time.Duration(minutes) * time.Minute
+		}
+	}
+	if v := os.Getenv("TIME_DEPENDENT_MAX_BUCKETS"); v != "" {
+		maxBuckets, err := strconv.Atoi(v)
+		if err != nil || maxBuckets <= 0 {
+			log.Warn().Msgf("Invalid TIME_DEPENDENT_MAX_BUCKETS value %q, using default: %d", v, DefaultTimeDependentMaxBuckets)
+		} else {
+			cfg.MaxBuckets = maxBuckets
+		}
+	}
+	for _, key := range []string{"TIME_DEPENDENT_MATRIX_SOURCE", "TIME_DEPENDENT_TRAFFIC_PROFILE"} {
+		if os.Getenv(key) != "" {
+			log.Warn().Msgf("Ignoring %s, the buckets are scaled by the traffic profile of TRAFFIC_PROFILE_PATH", key)
+		}
+	}
+
+	return cfg
+}
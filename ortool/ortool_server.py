@@ -1,6 +1,6 @@
 from fastapi import FastAPI, HTTPException
 from pydantic import BaseModel
-from typing import List, Tuple
+from typing import List, Optional, Tuple
 from ortools.constraint_solver import routing_enums_pb2, pywrapcp
 import logging
 
@@ -18,6 +18,10 @@ class VRPData(BaseModel):
     vehicle_capacity: int
     pickup_and_dropoffs: List[Tuple[int, int]]
     max_route_duration: int
+    # Demand of every node and vehicle capacity on every capacity dimension (seats, luggage, wheelchairs, child seats).
+    # When missing, only no_of_riders_per_request and vehicle_capacity are enforced
+    capacity_demands: Optional[List[List[int]]] = None
+    vehicle_capacities: Optional[List[int]] = None
     timeout : int = 100  
     method : str = "parallel_cheapest_insertion"
     enable_guided_local_search: bool = False
@@ -113,6 +117,11 @@ def add_constraints(routing, manager, data: VRPData, time_callback_idx: int):
         "Capacity"
     )
 
+    # Other capacity dimensions, the first one (seats) is already enforced by the "Capacity" dimension
+    if data.capacity_demands and data.vehicle_capacities:
+        for dimension in range(1, len(data.vehicle_capacities)):
+            add_capacity_dimension(routing, manager, data, dimension)
+
     # Pickups & dropoffs
     for pickup_node, dropoff_node in data.pickup_and_dropoffs:
         pickup_idx = manager.NodeToIndex(pickup_node)
@@ -128,6 +137,20 @@ def add_constraints(routing, manager, data: VRPData, time_callback_idx: int):
 
 
 
+def add_capacity_dimension(routing, manager, data: VRPData, dimension: int):
+    dimension_callback_idx = routing.RegisterUnaryTransitCallback(
+        lambda idx: data.capacity_demands[manager.IndexToNode(idx)][dimension]
+    )
+    routing.AddDimensionWithVehicleCapacity(
+        dimension_callback_idx,
+        0,  # no slack
+        [data.vehicle_capacities[dimension]],
+        True,
+        f"Capacity_{dimension}"
+    )
+
+
+
 def extract_solution(data: VRPData, manager, routing, solution):
     vehicle_id = 0
     time_dim = routing.GetDimensionOrDie("Time")